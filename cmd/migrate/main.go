@@ -94,9 +94,27 @@ func dropAllTables(db *gorm.DB) {
 
 	tables := []interface{}{
 		&logging.ActivityLog{},
+		&shortlink.ShortCodeSettings{},
+		&shortlink.ShortCodeSequence{},
+		&shortlink.ReservedCode{},
+		&shortlink.LinkImportRow{},
+		&shortlink.LinkImport{},
+		&shortlink.LinkVariant{},
+		&shortlink.RedirectRule{},
+		&shortlink.CustomDomain{},
+		&shortlink.ClickPrivacySalt{},
+		&shortlink.ClickRollupState{},
+		&shortlink.ClickRollupDimensionDaily{},
+		&shortlink.ClickRollupDimensionHourly{},
+		&shortlink.ClickRollupDaily{},
+		&shortlink.ClickRollupHourly{},
 		&shortlink.ViewLinkDetail{},
 		&shortlink.ShortLinkDetail{},
+		"short_link_tags",
+		&shortlink.ShortLinkRevision{},
 		&shortlink.ShortLink{},
+		&shortlink.LinkTag{},
+		&shortlink.Collection{},
 		&user.APIKey{},
 		&user.LoginEvent{},
 		&user.LoginAttempt{},
@@ -114,9 +132,9 @@ func dropAllTables(db *gorm.DB) {
 
 	for _, table := range tables {
 		if err := db.Migrator().DropTable(table); err != nil {
-			log.Printf("⚠️  Warning: Failed to drop %T: %v", table, err)
+			log.Printf("⚠️  Warning: Failed to drop %s: %v", tableLabel(table), err)
 		} else {
-			fmt.Printf("🗑️  Dropped: %T\n", table)
+			fmt.Printf("🗑️  Dropped: %s\n", tableLabel(table))
 		}
 	}
 
@@ -141,9 +159,27 @@ func showMigrationStatus(db *gorm.DB) {
 		&user.LoginAttempt{},
 		&user.AuthMethod{},
 		&user.APIKey{},
+		&shortlink.LinkTag{},
+		&shortlink.Collection{},
 		&shortlink.ShortLink{},
+		"short_link_tags",
 		&shortlink.ShortLinkDetail{},
 		&shortlink.ViewLinkDetail{},
+		&shortlink.ClickRollupHourly{},
+		&shortlink.ClickRollupDaily{},
+		&shortlink.ClickRollupDimensionHourly{},
+		&shortlink.ClickRollupDimensionDaily{},
+		&shortlink.ClickRollupState{},
+		&shortlink.ClickPrivacySalt{},
+		&shortlink.CustomDomain{},
+		&shortlink.RedirectRule{},
+		&shortlink.LinkVariant{},
+		&shortlink.ShortCodeSequence{},
+		&shortlink.ShortCodeSettings{},
+		&shortlink.ReservedCode{},
+		&shortlink.LinkImport{},
+		&shortlink.LinkImportRow{},
+		&shortlink.ShortLinkRevision{},
 		&logging.ActivityLog{},
 		&user.PremiumKey{},
 		&user.PremiumKeyUsage{},
@@ -160,6 +196,14 @@ func showMigrationStatus(db *gorm.DB) {
 		if exists {
 			status = "✅ Exists"
 		}
-		fmt.Printf("%s %T\n", status, model)
+		fmt.Printf("%s %s\n", status, tableLabel(model))
 	}
 }
+
+// tableLabel names a model, or a join table given by its name
+func tableLabel(table interface{}) string {
+	if name, ok := table.(string); ok {
+		return name
+	}
+	return fmt.Sprintf("%T", table)
+}
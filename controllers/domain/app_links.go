@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateAppLinks sets the apps whose association files the domain serves
+func (c *Controller) UpdateAppLinks(ctx *gin.Context) {
+	var idReq dto.CustomDomainIDRequest
+	if err := ctx.ShouldBindUri(&idReq); err != nil {
+		validator.SendValidationError(ctx, err, &idReq)
+		return
+	}
+
+	var req dto.UpdateDomainAppLinksRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	domain, err := c.repo.UpdateAppLinks(idReq.ID, userID, ctx.GetString("role"), &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, toCustomDomainResponse(domain), "Custom domain app links updated successfully")
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"github.com/adehusnim37/lihatin-go/controllers"
+	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/domains"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/adehusnim37/lihatin-go/repositories/domainrepo"
+)
+
+// Controller menyediakan handler untuk domain kustom milik pengguna
+type Controller struct {
+	*controllers.BaseController
+	repo    *domainrepo.CustomDomainRepository
+	service *domains.Service
+}
+
+// NewController membuat instance baru controller domain kustom
+func NewController(base *controllers.BaseController) *Controller {
+	if base == nil || base.GormDB == nil {
+		panic("GormDB is required for DomainController")
+	}
+
+	return &Controller{
+		BaseController: base,
+		repo:           domainrepo.NewCustomDomainRepository(base.GormDB),
+		service:        domains.NewService(base.GormDB, nil),
+	}
+}
+
+func toCustomDomainResponse(domain *shortlink.CustomDomain) dto.CustomDomainResponse {
+	response := dto.CustomDomainResponse{
+		ID:                 domain.ID,
+		Domain:             domain.Domain,
+		VerificationMethod: domain.VerificationMethod,
+		Status:             domain.Status,
+		VerifiedAt:         domain.VerifiedAt,
+		LastCheckedAt:      domain.LastCheckedAt,
+		LastError:          domain.LastError,
+		FailedChecks:       domain.FailedChecks,
+		FallbackURL:        domain.FallbackURL,
+		Verification: dto.DomainVerificationInstructions{
+			TXTName:  domains.TXTRecordName(domain.Domain),
+			TXTValue: domains.TXTRecordValue(domain.VerificationToken),
+		},
+		CreatedAt: domain.CreatedAt,
+		UpdatedAt: domain.UpdatedAt,
+	}
+	if domain.IOSAppIDs != "" || domain.AndroidPackage != "" {
+		response.AppLinks = &dto.DomainAppLinks{
+			IOSAppIDs:               domain.IOSAppIDList(),
+			AndroidPackage:          domain.AndroidPackage,
+			AndroidCertFingerprints: domain.AndroidCertFingerprintList(),
+		}
+	}
+	return response
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// Create claims a custom domain; it routes short links once verified
+func (c *Controller) Create(ctx *gin.Context) {
+	var req dto.CreateCustomDomainRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	domain, err := c.repo.Create(userID, req.Domain, req.VerificationMethod, req.FallbackURL)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendCreatedResponse(ctx, toCustomDomainResponse(domain), "Custom domain added, verify ownership to start using it")
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// Delete releases a custom domain that no longer has short links
+func (c *Controller) Delete(ctx *gin.Context) {
+	var req dto.CustomDomainIDRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	if err := c.repo.Delete(req.ID, userID, ctx.GetString("role")); err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, nil, "Custom domain deleted successfully")
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// Get returns one custom domain with its verification instructions
+func (c *Controller) Get(ctx *gin.Context) {
+	var req dto.CustomDomainIDRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	domain, err := c.repo.GetByID(req.ID, userID, ctx.GetString("role"))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, toCustomDomainResponse(domain), "Custom domain retrieved successfully")
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/gin-gonic/gin"
+)
+
+// List returns the current user's custom domains
+func (c *Controller) List(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	list, err := c.repo.ListByUser(userID)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	response := make([]dto.CustomDomainResponse, 0, len(list))
+	for i := range list {
+		response = append(response, toCustomDomainResponse(&list[i]))
+	}
+
+	httputil.SendOKResponse(ctx, response, "Custom domains retrieved successfully")
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// Update changes the fallback URL unknown codes on the domain redirect to
+func (c *Controller) Update(ctx *gin.Context) {
+	var idReq dto.CustomDomainIDRequest
+	if err := ctx.ShouldBindUri(&idReq); err != nil {
+		validator.SendValidationError(ctx, err, &idReq)
+		return
+	}
+
+	var req dto.UpdateCustomDomainRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	domain, err := c.repo.UpdateFallback(idReq.ID, userID, ctx.GetString("role"), *req.FallbackURL)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, toCustomDomainResponse(domain), "Custom domain updated successfully")
+}
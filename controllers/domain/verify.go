@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// Verify checks domain ownership now instead of waiting for the scheduled
+// re-check
+func (c *Controller) Verify(ctx *gin.Context) {
+	var req dto.CustomDomainIDRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	domain, err := c.repo.GetByID(req.ID, userID, ctx.GetString("role"))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	verified, err := c.service.Check(ctx.Request.Context(), domain)
+	if err != nil {
+		httputil.HandleError(ctx, apperrors.ErrCustomDomainUpdateFailed.WithError(err), userID)
+		return
+	}
+	if !verified {
+		httputil.SendErrorResponse(ctx, apperrors.ErrCustomDomainVerificationFailed.StatusCode,
+			apperrors.ErrCustomDomainVerificationFailed.Code, domain.LastError, "domain")
+		return
+	}
+
+	httputil.SendOKResponse(ctx, toCustomDomainResponse(domain), "Custom domain verified successfully")
+}
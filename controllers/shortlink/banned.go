@@ -23,7 +23,8 @@ func (c *Controller) AdminBannedShortLink(ctx *gin.Context) {
 
 	// Set data dari param dan JWT
 
-	if err := c.repo.BannedShortByAdmin(&banData, ctx.GetString("user_id"), &codeData); err != nil {
+	codeData.Code = linkCode(ctx, codeData.Code)
+	if err := c.repo.BannedShortByAdmin(&banData, revisionActor(ctx), &codeData); err != nil {
 		httputil.HandleError(ctx, err, ctx.GetString("user_id"))
 		return
 	}
@@ -2,8 +2,17 @@ package shortlink
 
 import (
 	"github.com/adehusnim37/lihatin-go/controllers"
-	shortlinkrepo "github.com/adehusnim37/lihatin-go/repositories/shortlink"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/clicks"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/linkmeta"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/mail"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/qrcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/shortcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/storage"
+	"github.com/adehusnim37/lihatin-go/middleware"
+	shortlinkrepo "github.com/adehusnim37/lihatin-go/repositories/shortlink"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 )
 
 // Controller menyediakan semua handler untuk operasi short link
@@ -11,15 +20,47 @@ type Controller struct {
 	*controllers.BaseController
 	repo         *shortlinkrepo.ShortLinkRepository
 	emailService *mail.EmailService
+
+	socialCardImageStore *storage.S3SocialCardImageStorage
+
+	qrCache     *qrcode.Cache
+	qrLogoStore *storage.S3QRLogoStorage
 }
 
 // NewController membuat instance baru controller short link
 func NewController(base *controllers.BaseController) *Controller {
-	shortLinkRepo := shortlinkrepo.NewShortLinkRepository(base.GormDB)
+	// Redirect cache dan click tracker opsional; tanpa Redis redirect tetap jalan via MySQL
+	var redisClient *redis.Client
+	if manager := middleware.GetSessionManager(); manager != nil {
+		redisClient = manager.GetRedisClient()
+	}
+	shortLinkRepo := shortlinkrepo.NewShortLinkRepository(base.GormDB).
+		WithRedirectCache(shortlinkrepo.NewRedirectCache(redisClient)).
+		WithClickTracker(clicks.Global()).
+		WithMetadataFetcher(linkmeta.Global()).
+		WithCodeAllocator(shortcode.Global())
 	emailService := mail.NewEmailService()
+	socialCardImageStore, socialCardImageStoreErr := storage.NewS3SocialCardImageStorageFromEnv()
+	if socialCardImageStoreErr != nil {
+		logger.Logger.Warn("Social card image storage is not configured", "error", socialCardImageStoreErr.Error())
+	}
+	qrLogoStore, qrLogoStoreErr := storage.NewS3QRLogoStorageFromEnv()
+	if qrLogoStoreErr != nil {
+		logger.Logger.Warn("QR code logo storage is not configured", "error", qrLogoStoreErr.Error())
+	}
 	return &Controller{
-		BaseController: base,
-		repo:           shortLinkRepo,
-		emailService:   emailService,
+		BaseController:       base,
+		repo:                 shortLinkRepo,
+		emailService:         emailService,
+		socialCardImageStore: socialCardImageStore,
+		qrCache:              qrcode.NewCache(redisClient),
+		qrLogoStore:          qrLogoStore,
 	}
 }
+
+// linkCode is the code a management request targets. Codes are only unique
+// per domain, so ?domain= picks the link on a custom domain when the main host
+// has one with the same code.
+func linkCode(ctx *gin.Context, code string) string {
+	return shortlinkrepo.QualifiedCode(code, ctx.Query("domain"))
+}
@@ -15,7 +15,7 @@ func (c *Controller) AdminBulkDeleteShortLinks(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.repo.DeleteShortsLink(reqs); err != nil {
+	if err := c.repo.DeleteShortsLink(reqs, revisionActor(ctx)); err != nil {
 		userID := ctx.GetString("user_id")
 		httpPkg.HandleError(ctx, err, userID)
 		return
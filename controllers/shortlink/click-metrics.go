@@ -0,0 +1,26 @@
+package shortlink
+
+import (
+	"net/http"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/clicks"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/gin-gonic/gin"
+)
+
+// GetClickTrackerMetrics returns queue depth and drop/spill counters of the click ingestion pipeline
+func (c *Controller) GetClickTrackerMetrics(ctx *gin.Context) {
+	tracker := clicks.Global()
+	if tracker == nil {
+		httputil.SendErrorResponse(
+			ctx,
+			http.StatusServiceUnavailable,
+			"CLICK_TRACKER_UNAVAILABLE",
+			"Click tracker is not running",
+			"click_tracker",
+		)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, tracker.Metrics(), "Click tracker metrics retrieved successfully")
+}
@@ -0,0 +1,150 @@
+package shortlink
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// ListLinkTags returns the user's tags with the number of links carrying each
+func (c *Controller) ListLinkTags(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	tags, err := c.repo.ListLinkTags(userID)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, tags, "Tags retrieved successfully")
+}
+
+// CreateLinkTag adds a tag the user can put on their links
+func (c *Controller) CreateLinkTag(ctx *gin.Context) {
+	var req dto.LinkTagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	tag, err := c.repo.CreateLinkTag(userID, &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendCreatedResponse(ctx, tag, "Tag created successfully")
+}
+
+// UpdateLinkTag renames a tag
+func (c *Controller) UpdateLinkTag(ctx *gin.Context) {
+	var uriReq dto.LinkTagURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	var req dto.LinkTagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	tag, err := c.repo.UpdateLinkTag(uriReq.TagID, userID, &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, tag, "Tag updated successfully")
+}
+
+// DeleteLinkTag deletes a tag and takes it off the user's links
+func (c *Controller) DeleteLinkTag(ctx *gin.Context) {
+	var uriReq dto.LinkTagURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	actor := revisionActor(ctx)
+	if err := c.repo.DeleteLinkTag(uriReq.TagID, actor); err != nil {
+		httputil.HandleError(ctx, err, actor.UserID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, nil, "Tag deleted successfully")
+}
+
+// ListCollections returns the user's collections with the number of links in each
+func (c *Controller) ListCollections(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	collections, err := c.repo.ListCollections(userID)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, collections, "Collections retrieved successfully")
+}
+
+// CreateCollection adds a collection the user can file links in
+func (c *Controller) CreateCollection(ctx *gin.Context) {
+	var req dto.CollectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	collection, err := c.repo.CreateCollection(userID, &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendCreatedResponse(ctx, collection, "Collection created successfully")
+}
+
+// UpdateCollection replaces a collection's name and description
+func (c *Controller) UpdateCollection(ctx *gin.Context) {
+	var uriReq dto.CollectionURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	var req dto.CollectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	collection, err := c.repo.UpdateCollection(uriReq.CollectionID, userID, &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, collection, "Collection updated successfully")
+}
+
+// DeleteCollection deletes a collection; its links are kept
+func (c *Controller) DeleteCollection(ctx *gin.Context) {
+	var uriReq dto.CollectionURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	actor := revisionActor(ctx)
+	if err := c.repo.DeleteCollection(uriReq.CollectionID, actor); err != nil {
+		httputil.HandleError(ctx, err, actor.UserID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, nil, "Collection deleted successfully")
+}
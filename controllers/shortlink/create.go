@@ -65,15 +65,10 @@ func (c *Controller) Create(ctx *gin.Context) {
 
 // Handle single short link creation
 func (c *Controller) handleSingleCreation(ctx *gin.Context, req dto.CreateShortLinkRequest, userID, userEmail, userName string) {
-	link := dto.CreateShortLinkRequest{
-		UserID:      userID,
-		OriginalURL: req.OriginalURL,
-		Title:       req.Title,
-		Description: req.Description,
-		CustomCode:  req.CustomCode,
-		Passcode:    req.Passcode,
-		ExpiresAt:   req.ExpiresAt,
-	}
+	// Pass every requested setting on, like bulk creation does; the owner
+	// always comes from the session
+	link := req
+	link.UserID = userID
 
 	// Call repository to create short link
 	createdLink, createdDetail, err := c.repo.CreateShortLink(&link)
@@ -90,6 +85,7 @@ func (c *Controller) handleSingleCreation(ctx *gin.Context, req dto.CreateShortL
 	response := dto.ShortLinkResponse{
 		ID:          createdLink.ID,
 		UserID:      createdLink.UserID,
+		Domain:      createdLink.Domain,
 		ShortCode:   createdLink.ShortCode,
 		OriginalURL: createdLink.OriginalURL,
 		Title:       createdLink.Title,
@@ -98,6 +94,11 @@ func (c *Controller) handleSingleCreation(ctx *gin.Context, req dto.CreateShortL
 		ExpiresAt:   createdLink.ExpiresAt,
 		CreatedAt:   createdLink.CreatedAt,
 		UpdatedAt:   createdLink.UpdatedAt,
+		SocialCard:  dto.NewSocialCardResponse(createdLink),
+		DeepLinks:   dto.NewDeepLinksResponse(createdLink),
+
+		CollectionID: createdLink.CollectionID,
+		Tags:         dto.NewLinkTagResponses(createdLink.Tags),
 	}
 
 	logger.Logger.Info("Short link created successfully",
@@ -121,14 +122,12 @@ func (c *Controller) handleSingleCreation(ctx *gin.Context, req dto.CreateShortL
 				expiresAt = "Never"
 			}
 
-			// Format passcode
+			// Format passcode; it is entered on the link's form, never put in the URL
 			var passcode string = "-"
 			if createdDetail.Passcode != 0 {
 				passcode = fmt.Sprintf("%d", createdDetail.Passcode)
-				fullShortURL = fmt.Sprintf("%s/%s?passcode=%d", frontendURL, createdLink.ShortCode, createdDetail.Passcode)
-			} else {
-				fullShortURL = fmt.Sprintf("%s/%s", frontendURL, createdLink.ShortCode)
 			}
+			fullShortURL = fmt.Sprintf("%s/%s", frontendURL, createdLink.ShortCode)
 
 			if createdLink.Title == "" {
 				createdLink.Title = "No Title Provided For This Link."
@@ -185,6 +184,7 @@ func (c *Controller) handleBulkCreation(ctx *gin.Context, links []dto.CreateShor
 		responses[i] = dto.ShortLinkResponse{
 			ID:          link.ID,
 			UserID:      link.UserID,
+			Domain:      link.Domain,
 			ShortCode:   link.ShortCode,
 			OriginalURL: link.OriginalURL,
 			Title:       link.Title,
@@ -193,6 +193,11 @@ func (c *Controller) handleBulkCreation(ctx *gin.Context, links []dto.CreateShor
 			ExpiresAt:   link.ExpiresAt,
 			CreatedAt:   link.CreatedAt,
 			UpdatedAt:   link.UpdatedAt,
+			SocialCard:  dto.NewSocialCardResponse(&link),
+			DeepLinks:   dto.NewDeepLinksResponse(&link),
+
+			CollectionID: link.CollectionID,
+			Tags:         dto.NewLinkTagResponses(link.Tags),
 		}
 	}
 
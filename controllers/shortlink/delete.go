@@ -39,7 +39,7 @@ func (c *Controller) DeleteShortLink(ctx *gin.Context) {
 		}
 	}
 
-	if err := c.repo.DeleteShortLink(shortCode, ctx.GetString("user_id"), passcode, ctx.GetString("role")); err != nil {
+	if err := c.repo.DeleteShortLink(linkCode(ctx, shortCode), revisionActor(ctx), passcode); err != nil {
 		httpPkg.HandleError(ctx, err, ctx.GetString("user_id"))
 		return
 	}
@@ -1,17 +1,27 @@
 package shortlink
 
 import (
+	"github.com/adehusnim37/lihatin-go/dto"
 	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
 	"github.com/gin-gonic/gin"
 )
 
+// GetAllStatsShorts returns the dashboard stats, optionally totalled over the
+// links of one tag or collection
 func (c *Controller) GetAllStatsShorts(ctx *gin.Context) {
 	userId := ctx.GetString("user_id")
 	userRole := ctx.GetString("role")
 	startDate := ctx.Query("start_date")
 	endDate := ctx.Query("end_date")
 
-	stats, err := c.repo.GetDashboardStats(userId, userRole, startDate, endDate)
+	var filter dto.ShortLinkFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		validator.SendValidationError(ctx, err, &filter)
+		return
+	}
+
+	stats, err := c.repo.GetDashboardStats(userId, userRole, startDate, endDate, filter)
 	if err != nil {
 		httputil.HandleError(ctx, err, userId)
 		return
@@ -3,8 +3,10 @@ package shortlink
 import (
 	"net/http"
 
+	"github.com/adehusnim37/lihatin-go/dto"
 	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
 	"github.com/adehusnim37/lihatin-go/models/common"
 	"github.com/gin-gonic/gin"
 )
@@ -27,6 +29,13 @@ func (c *Controller) ListShortLinks(ctx *gin.Context) {
 	orderBy := ctx.DefaultQuery("order_by", "desc")
 	search := ctx.Query("search")
 
+	// Optional tag_id and collection_id filters
+	var filter dto.ShortLinkFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		validator.SendValidationError(ctx, err, &filter)
+		return
+	}
+
 	// Validate and convert pagination parameters
 	page, limit, sort, orderBy, vErrs := httputil.PaginateValidate(pageStr, limitStr, sort, orderBy, httputil.Role(userRole))
 	if vErrs != nil {
@@ -47,6 +56,8 @@ func (c *Controller) ListShortLinks(ctx *gin.Context) {
 		"sort", sort,
 		"order_by", orderBy,
 		"search", search,
+		"tag_id", filter.TagID,
+		"collection_id", filter.CollectionID,
 	)
 
 	// ✅ SMART FILTERING: Choose repository method based on role
@@ -58,16 +69,16 @@ func (c *Controller) ListShortLinks(ctx *gin.Context) {
 		if targetUserID != "" && !detail {
 			// ✅ Admin: Get specific user's short links without details
 			logger.Logger.Info("Admin accessing specific user short links without details", "admin_user", userID, "target_user", targetUserID)
-			paginatedResponse, repositoryErr = c.repo.GetShortsByUserIDWithPagination(targetUserID, page, limit, sort, orderBy, search)
+			paginatedResponse, repositoryErr = c.repo.GetShortsByUserIDWithPagination(targetUserID, page, limit, sort, orderBy, search, filter)
 		} else {
 			// ✅ Admin: Get all short links (with or without target user filter, but WITH details)
 			logger.Logger.Info("Admin accessing short links with details", "admin_user", userID, "target_user", targetUserID)
-			paginatedResponse, repositoryErr = c.repo.ListAllShortLinks(targetUserID, page, limit, sort, orderBy, search)
+			paginatedResponse, repositoryErr = c.repo.ListAllShortLinks(targetUserID, page, limit, sort, orderBy, search, filter)
 		}
 	} else {
 		// ✅ User: Get only user's short links (filtered by user_id)
 		logger.Logger.Info("User accessing own short links", "user_id", userID)
-		paginatedResponse, repositoryErr = c.repo.GetShortsByUserIDWithPagination(userID, page, limit, sort, orderBy, search)
+		paginatedResponse, repositoryErr = c.repo.GetShortsByUserIDWithPagination(userID, page, limit, sort, orderBy, search, filter)
 	}
 
 	if repositoryErr != nil {
@@ -67,7 +67,7 @@ func (c *Controller) GetShortLinkViewsPaginated(ctx *gin.Context) {
 	)
 
 	// Get paginated views with complete data
-	paginatedData, err := c.repo.GetShortLinkViewsPaginated(req.Code, userIDStr, page, limit, sort, orderBy, userRoleStr)
+	paginatedData, err := c.repo.GetShortLinkViewsPaginated(linkCode(ctx, req.Code), userIDStr, page, limit, sort, orderBy, userRoleStr)
 	if err != nil {
 		httputil.HandleError(ctx, err, userIDStr)
 		return
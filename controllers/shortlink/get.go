@@ -31,7 +31,7 @@ func (c *Controller) GetShortLink(ctx *gin.Context) {
 	userIDStr := userID.(string)
 
 	// Get paginated views with complete data
-	paginatedData, err := c.repo.GetShortLink(req.Code, userIDStr, userRoleStr)
+	paginatedData, err := c.repo.GetShortLink(linkCode(ctx, req.Code), userIDStr, userRoleStr)
 	if err != nil {
 		http.HandleError(ctx, err, userID)
 		return
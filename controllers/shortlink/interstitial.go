@@ -0,0 +1,94 @@
+package shortlink
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/interstitial"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminFlagShortLink forces the interstitial warning on a suspicious link
+// instead of banning it
+func (c *Controller) AdminFlagShortLink(ctx *gin.Context) {
+	var codeData dto.CodeRequest
+	if err := ctx.ShouldBindUri(&codeData); err != nil {
+		validator.SendValidationError(ctx, err, &codeData)
+		return
+	}
+
+	var flagData dto.FlagRequest
+	if err := ctx.ShouldBindJSON(&flagData); err != nil {
+		validator.SendValidationError(ctx, err, &flagData)
+		return
+	}
+
+	codeData.Code = linkCode(ctx, codeData.Code)
+	if err := c.repo.FlagShortByAdmin(&flagData, ctx.GetString("user_id"), &codeData); err != nil {
+		httputil.HandleError(ctx, err, ctx.GetString("user_id"))
+		return
+	}
+
+	httputil.SendOKResponse(ctx, flagData, "Short link flagged successfully")
+}
+
+// AdminUnflagShortLink lifts the forced interstitial warning from a link
+func (c *Controller) AdminUnflagShortLink(ctx *gin.Context) {
+	var codeData dto.CodeRequest
+	if err := ctx.ShouldBindUri(&codeData); err != nil {
+		validator.SendValidationError(ctx, err, &codeData)
+		return
+	}
+
+	if err := c.repo.UnflagShortByAdmin(linkCode(ctx, codeData.Code)); err != nil {
+		httputil.HandleError(ctx, err, ctx.GetString("user_id"))
+		return
+	}
+
+	httputil.SendOKResponse(ctx, codeData, "Short link unflagged successfully")
+}
+
+// GetInterstitialPolicy returns the admin-global interstitial setting
+func (c *Controller) GetInterstitialPolicy(ctx *gin.Context) {
+	policy := interstitial.Global()
+	if policy == nil {
+		httputil.HandleError(ctx, apperrors.ErrInterstitialPolicyFailed.WithError(errors.New("interstitial policy not initialized")), ctx.GetString("user_id"))
+		return
+	}
+
+	httputil.SendOKResponse(ctx, dto.InterstitialPolicyResponse{Enabled: policy.Enabled()}, "Interstitial policy retrieved successfully")
+}
+
+// UpdateInterstitialPolicy switches the interstitial on or off for every link
+// that inherits the global setting
+func (c *Controller) UpdateInterstitialPolicy(ctx *gin.Context) {
+	var req dto.UpdateInterstitialPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	adminID := strings.TrimSpace(ctx.GetString("user_id"))
+	policy := interstitial.Global()
+	if policy == nil {
+		httputil.HandleError(ctx, apperrors.ErrInterstitialPolicyFailed.WithError(errors.New("interstitial policy not initialized")), adminID)
+		return
+	}
+
+	if err := policy.SetEnabled(*req.Enabled, adminID); err != nil {
+		logger.Logger.Error("Failed to update interstitial policy",
+			"admin_id", adminID,
+			"enabled", *req.Enabled,
+			"error", err.Error(),
+		)
+		httputil.HandleError(ctx, apperrors.ErrInterstitialPolicyFailed.WithError(err), adminID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, dto.InterstitialPolicyResponse{Enabled: *req.Enabled}, "Interstitial policy updated successfully")
+}
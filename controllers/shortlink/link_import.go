@@ -0,0 +1,301 @@
+package shortlink
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/linkfile"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	shortlinkrepo "github.com/adehusnim37/lihatin-go/repositories/shortlink"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	// maxOpenLinkImports is how many imports a user may have waiting or
+	// running at once
+	maxOpenLinkImports = 3
+	// maxImportTags matches the tag limit of a created link
+	maxImportTags = 20
+	// linkExportBatchSize is how many links are loaded per query on export
+	linkExportBatchSize = 500
+)
+
+// ImportLinks queues a CSV or NDJSON file of links for creation in the
+// background. Every row is validated now; invalid rows are reported in the
+// import's results without holding up the rest.
+func (c *Controller) ImportLinks(ctx *gin.Context) {
+	var req dto.LinkImportRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	maxFileMB := config.GetEnvAsInt(config.EnvLinkImportMaxFileMB, 10)
+	maxRows := config.GetEnvAsInt(config.EnvLinkImportMaxRows, 10000)
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil || fileHeader == nil {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"file": "Import file is required"})
+		return
+	}
+	if fileHeader.Size <= 0 {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"file": "Import file is empty"})
+		return
+	}
+	maxFileBytes := int64(maxFileMB) * 1024 * 1024
+	if fileHeader.Size > maxFileBytes {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"file": fmt.Sprintf("Import file must be less than or equal to %dMB", maxFileMB)})
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = linkfile.FormatOf(fileHeader.Filename)
+	}
+	if format == "" {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"format": "Format must be csv or ndjson when the file name does not end in .csv, .ndjson or .jsonl"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		httputil.SendErrorResponse(ctx, http.StatusInternalServerError, "LINK_IMPORT_READ_FAILED", "Failed to read import file", "file")
+		return
+	}
+	defer file.Close()
+
+	rows, err := linkfile.Read(io.LimitReader(file, maxFileBytes), format, maxRows)
+	if err != nil {
+		message := "Import file could not be read: " + err.Error()
+		switch {
+		case errors.Is(err, linkfile.ErrNoRows):
+			message = "Import file has no rows"
+		case errors.Is(err, linkfile.ErrNoURLColumn):
+			message = "The CSV header must have an original_url column"
+		case errors.Is(err, linkfile.ErrTooManyRows):
+			message = fmt.Sprintf("Import file must have at most %d rows", maxRows)
+		}
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"file": message})
+		return
+	}
+
+	inputs := make([]shortlinkrepo.LinkImportRowInput, 0, len(rows))
+	for _, row := range rows {
+		inputs = append(inputs, importRowInput(row))
+	}
+
+	linkImport, err := c.repo.CreateLinkImport(userID, format, filepath.Base(fileHeader.Filename), inputs, maxOpenLinkImports)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendSuccessResponse(ctx, http.StatusAccepted, linkImport, "Link import queued successfully")
+}
+
+// ListLinkImports returns the user's latest imports
+func (c *Controller) ListLinkImports(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	imports, err := c.repo.ListLinkImports(userID)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, imports, "Link imports retrieved successfully")
+}
+
+// GetLinkImport returns an import's status and row counters, for polling
+func (c *Controller) GetLinkImport(ctx *gin.Context) {
+	var uriReq dto.LinkImportURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	linkImport, err := c.repo.GetLinkImport(uriReq.ImportID, userID)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, linkImport, "Link import retrieved successfully")
+}
+
+// ListLinkImportRows returns a page of an import's row results
+func (c *Controller) ListLinkImportRows(ctx *gin.Context) {
+	var uriReq dto.LinkImportURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	var req dto.LinkImportRowsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	rows, err := c.repo.ListLinkImportRows(uriReq.ImportID, userID, req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, rows, "Link import rows retrieved successfully")
+}
+
+// GetLinkImportErrors downloads the failed rows of an import as an error
+// report in the import's format
+func (c *Controller) GetLinkImportErrors(ctx *gin.Context) {
+	var uriReq dto.LinkImportURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	linkImport, failures, err := c.repo.LinkImportFailures(uriReq.ImportID, userID)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	ctx.Header("Content-Type", linkfile.ContentType(linkImport.Format))
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%s-errors.%s"`, linkImport.ID, linkImport.Format))
+	ctx.Status(http.StatusOK)
+	if err := linkfile.WriteFailures(ctx.Writer, linkImport.Format, failures); err != nil {
+		logger.Logger.Warn("Failed writing link import error report", "import_id", linkImport.ID, "error", err.Error())
+	}
+}
+
+// ExportLinks streams the user's links with their settings and counters as
+// CSV or NDJSON. The file can be imported again.
+func (c *Controller) ExportLinks(ctx *gin.Context) {
+	var req dto.LinkExportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+	format := req.Format
+	if format == "" {
+		format = linkfile.FormatCSV
+	}
+
+	userID := ctx.GetString("user_id")
+
+	// The response starts with the first record, so errors before it can
+	// still be reported as JSON
+	var writer *linkfile.Writer
+	start := func() error {
+		if writer != nil {
+			return nil
+		}
+		ctx.Header("Content-Type", linkfile.ContentType(format))
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="links-%s.%s"`, time.Now().UTC().Format("20060102"), format))
+		ctx.Status(http.StatusOK)
+		var err error
+		writer, err = linkfile.NewWriter(ctx.Writer, format)
+		return err
+	}
+
+	err := c.repo.ExportShortLinks(ctx.Request.Context(), userID, req.ShortLinkFilter, linkExportBatchSize, func(record linkfile.Record) error {
+		if err := start(); err != nil {
+			return err
+		}
+		return writer.Write(record)
+	})
+	if err == nil {
+		if err = start(); err == nil {
+			err = writer.Flush()
+		}
+	}
+	if err != nil {
+		if writer == nil {
+			httputil.HandleError(ctx, err, userID)
+			return
+		}
+		// Part of the file is out; the client sees it cut short
+		logger.Logger.Warn("Link export stopped early", "user_id", userID, "error", err.Error())
+	}
+}
+
+// importRowInput validates a row read from an import file like a created link.
+func importRowInput(row linkfile.Row) shortlinkrepo.LinkImportRowInput {
+	input := shortlinkrepo.LinkImportRowInput{
+		Line:    row.Line,
+		Payload: importPayload(row.Record),
+	}
+	if row.Err != nil {
+		input.Invalid = row.Err.Error()
+		return input
+	}
+	if len(input.Payload.TagNames) > maxImportTags {
+		input.Invalid = fmt.Sprintf("tags: a link can have at most %d tags", maxImportTags)
+		return input
+	}
+
+	if err := binding.Validator.ValidateStruct(&input.Payload.Link); err != nil {
+		details := validator.HandleValidationError(err, &input.Payload.Link).Details
+		messages := make([]string, 0, len(details))
+		for _, detail := range details {
+			messages = append(messages, detail.Message)
+		}
+		if len(messages) == 0 {
+			messages = append(messages, err.Error())
+		}
+		input.Invalid = strings.Join(messages, "; ")
+	}
+	return input
+}
+
+// importPayload maps a file record onto the request that creates its link.
+// Export-only fields are dropped.
+func importPayload(record linkfile.Record) dto.LinkImportPayload {
+	link := dto.CreateShortLinkRequest{
+		OriginalURL: record.OriginalURL,
+		CustomCode:  record.ShortCode,
+		Domain:      record.Domain,
+		Title:       record.Title,
+		Description: record.Description,
+		Passcode:    record.Passcode,
+		ExpiresAt:   record.ExpiresAt,
+		Limit:       record.ClickLimit,
+		EnableStats: record.EnableStats,
+	}
+	if record.UTMSource != "" || record.UTMMedium != "" || record.UTMCampaign != "" || record.UTMTerm != "" || record.UTMContent != "" {
+		link.Tags = &dto.Tags{
+			UTMSource:   optionalString(record.UTMSource),
+			UTMMedium:   optionalString(record.UTMMedium),
+			UTMCampaign: optionalString(record.UTMCampaign),
+			UTMTerm:     optionalString(record.UTMTerm),
+			UTMContent:  optionalString(record.UTMContent),
+		}
+	}
+
+	return dto.LinkImportPayload{
+		Link:       link,
+		TagNames:   record.Tags,
+		Collection: record.Collection,
+	}
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,276 @@
+package shortlink
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/qrcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxQRLogoSizeBytes int64 = 1024 * 1024
+	// qrBrowserCacheSeconds lets clients keep a code; the ETag changes with
+	// the link's URL, the style and the logo
+	qrBrowserCacheSeconds = 24 * 60 * 60
+)
+
+var allowedQRLogoContentTypes = map[string]struct{}{
+	"image/jpeg": {},
+	"image/png":  {},
+	"image/gif":  {},
+}
+
+// GetShortLinkQR renders the QR code of a short link as PNG or SVG. The code
+// encodes the short URL with a marker that records scans as QR clicks.
+func (c *Controller) GetShortLinkQR(ctx *gin.Context) {
+	var req dto.CodeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	var query dto.QRCodeRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		validator.SendValidationError(ctx, err, &query)
+		return
+	}
+
+	opts, fieldErrs := qrOptions(&query)
+	if len(fieldErrs) > 0 {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", fieldErrs)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	link, err := c.repo.OwnedShortLink(linkCode(ctx, req.Code), userID, ctx.GetString("role"))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	logoKey := link.QRLogoKey
+	if query.Logo != nil && !*query.Logo {
+		logoKey = ""
+	}
+	if logoKey != "" && c.qrLogoStore == nil {
+		logger.Logger.Warn("Rendering QR code without its logo, storage is not configured", "short_code", link.ShortCode)
+		logoKey = ""
+	}
+
+	content := qrContent(link)
+	key := qrcode.CacheKey(content, opts, logoKey)
+	etag := `"` + key + `"`
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", qrBrowserCacheSeconds))
+	if query.Download {
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-qr.%s"`, link.ShortCode, opts.Format))
+	}
+	if strings.Contains(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	contentType := qrcode.ContentType(opts.Format)
+	if data, ok := c.qrCache.Get(ctx.Request.Context(), key); ok {
+		ctx.Data(http.StatusOK, contentType, data)
+		return
+	}
+
+	if logoKey != "" {
+		raw, err := c.qrLogoStore.DownloadLogo(ctx.Request.Context(), logoKey, maxQRLogoSizeBytes)
+		if err != nil {
+			logger.Logger.Error("Failed loading QR code logo", "short_code", link.ShortCode, "error", err.Error())
+			httputil.HandleError(ctx, apperrors.ErrQRLogoUnavailable.WithError(err), userID)
+			return
+		}
+		if opts.Logo, err = qrcode.DecodeLogo(raw); err != nil {
+			httputil.HandleError(ctx, apperrors.ErrQRLogoUnavailable.WithError(err), userID)
+			return
+		}
+	}
+
+	data, err := qrcode.Render(content, opts)
+	if err != nil {
+		if errors.Is(err, qrcode.ErrTooSmall) || errors.Is(err, qrcode.ErrSameColors) {
+			httputil.HandleError(ctx, apperrors.ErrQRCodeInvalid.WithError(err), userID)
+			return
+		}
+		logger.Logger.Error("Failed rendering QR code", "short_code", link.ShortCode, "error", err.Error())
+		httputil.HandleError(ctx, apperrors.ErrQRCodeRenderFailed.WithError(err), userID)
+		return
+	}
+
+	c.qrCache.Set(ctx.Request.Context(), key, data)
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+// UploadQRLogo stores an image to draw in the centre of a short link's QR code
+func (c *Controller) UploadQRLogo(ctx *gin.Context) {
+	var req dto.CodeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	if c.qrLogoStore == nil {
+		httputil.SendErrorResponse(ctx, http.StatusServiceUnavailable, "QR_LOGO_STORAGE_NOT_CONFIGURED", "QR code logo storage is not configured on server", "logo")
+		return
+	}
+
+	link, err := c.repo.OwnedShortLink(linkCode(ctx, req.Code), userID, ctx.GetString("role"))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("logo")
+	if err != nil || fileHeader == nil {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"logo": "Logo file is required"})
+		return
+	}
+	if fileHeader.Size <= 0 {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"logo": "Logo file is empty"})
+		return
+	}
+	if fileHeader.Size > maxQRLogoSizeBytes {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"logo": "Logo file must be less than or equal to 1MB"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		httputil.SendErrorResponse(ctx, http.StatusInternalServerError, "QR_LOGO_READ_FAILED", "Failed to read QR code logo", "logo")
+		return
+	}
+	defer file.Close()
+
+	contentType, err := detectImageContentType(file)
+	if err != nil {
+		httputil.SendErrorResponse(ctx, http.StatusBadRequest, "QR_LOGO_INVALID", "Invalid QR code logo", "logo")
+		return
+	}
+	if _, ok := allowedQRLogoContentTypes[contentType]; !ok {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"logo": "Only JPG, PNG, or GIF images are allowed"})
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxQRLogoSizeBytes))
+	if err != nil {
+		httputil.SendErrorResponse(ctx, http.StatusInternalServerError, "QR_LOGO_READ_FAILED", "Failed to read QR code logo", "logo")
+		return
+	}
+	if _, err := qrcode.DecodeLogo(data); err != nil {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"logo": "Logo must be a readable image of at most 4096x4096 pixels"})
+		return
+	}
+
+	objectKey, err := c.qrLogoStore.UploadLogo(ctx.Request.Context(), link.ID, data, contentType)
+	if err != nil {
+		var responseErr *smithyhttp.ResponseError
+		if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusRequestEntityTooLarge {
+			httputil.SendErrorResponse(ctx, http.StatusRequestEntityTooLarge, "QR_LOGO_TOO_LARGE_FOR_STORAGE", "Logo size exceeds the upstream storage gateway limit", "logo")
+			return
+		}
+		logger.Logger.Error("Failed uploading QR code logo", "user_id", userID, "short_code", link.ShortCode, "error", err.Error())
+		httputil.SendErrorResponse(ctx, http.StatusInternalServerError, "QR_LOGO_UPLOAD_FAILED", "Failed to upload QR code logo", "logo")
+		return
+	}
+
+	previousKey := link.QRLogoKey
+	if err := c.repo.SetQRLogo(link, objectKey); err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+	c.deleteQRLogo(ctx, link, previousKey)
+
+	httputil.SendCreatedResponse(ctx, gin.H{
+		"qr_logo_key": objectKey,
+	}, "QR code logo uploaded successfully")
+}
+
+// DeleteQRLogo removes the logo from a short link's QR code
+func (c *Controller) DeleteQRLogo(ctx *gin.Context) {
+	var req dto.CodeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	link, err := c.repo.OwnedShortLink(linkCode(ctx, req.Code), userID, ctx.GetString("role"))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	previousKey := link.QRLogoKey
+	if err := c.repo.SetQRLogo(link, ""); err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+	c.deleteQRLogo(ctx, link, previousKey)
+
+	httputil.SendOKResponse(ctx, nil, "QR code logo removed successfully")
+}
+
+// deleteQRLogo removes a replaced logo from storage. Failures only leave an
+// orphaned object behind, so they are logged and not reported.
+func (c *Controller) deleteQRLogo(ctx *gin.Context, link *shortlink.ShortLink, objectKey string) {
+	if objectKey == "" || c.qrLogoStore == nil {
+		return
+	}
+	if err := c.qrLogoStore.DeleteLogo(ctx.Request.Context(), objectKey); err != nil {
+		logger.Logger.Warn("Failed deleting replaced QR code logo", "short_code", link.ShortCode, "error", err.Error())
+	}
+}
+
+// qrOptions turns the query into render options, reporting bad colours by
+// query field.
+func qrOptions(query *dto.QRCodeRequest) (qrcode.Options, map[string]string) {
+	opts := qrcode.Options{
+		Format: query.Format,
+		Size:   query.Size,
+		Level:  query.Level,
+		Margin: query.Margin,
+	}
+	if opts.Format == "" {
+		opts.Format = qrcode.FormatPNG
+	}
+
+	fieldErrs := map[string]string{}
+	var err error
+	if query.Foreground != "" {
+		if opts.Foreground, err = qrcode.ParseColor(query.Foreground); err != nil {
+			fieldErrs["foreground"] = "Foreground must be a #rrggbb colour"
+		}
+	}
+	if query.Background != "" {
+		if opts.Background, err = qrcode.ParseColor(query.Background); err != nil {
+			fieldErrs["background"] = "Background must be a #rrggbb colour"
+		}
+	}
+	return opts, fieldErrs
+}
+
+// qrContent is the URL a link's QR code encodes: the short URL on the link's
+// custom domain or the main host, with the QR source marker.
+func qrContent(link *shortlink.ShortLink) string {
+	base := strings.TrimRight(config.GetEnvOrDefault(config.EnvFrontendURL, "http://localhost:3000"), "/")
+	if link.Domain != "" {
+		base = "https://" + link.Domain
+	}
+	return base + "/" + link.ShortCode + "?" + qrcode.SourceParam + "=1"
+}
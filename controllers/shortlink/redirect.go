@@ -1,17 +1,45 @@
 package shortlink
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/csrf"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/deeplink"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/destination"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
 	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/interstitial"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/passcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/privacy"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/qrcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/socialcard"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/useragent"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
 	"github.com/adehusnim37/lihatin-go/middleware"
 	"github.com/gin-gonic/gin"
 )
 
+// variantCookieName remembers the A/B variant a visitor was served. It is
+// scoped to the short link's path, so every link keeps its own.
+const (
+	variantCookieName   = "lihatin_variant"
+	variantCookieMaxAge = 30 * 24 * 60 * 60
+)
+
+// forwardExcludedParams are meant for the short link itself and never
+// forwarded to the destination
+var forwardExcludedParams = []string{interstitial.ContinueParam, qrcode.SourceParam}
+
 // Redirect handles short link redirection and tracking
 func (c *Controller) Redirect(ctx *gin.Context) {
+	// A code ending in "+" asks for the preview page instead of the redirect
+	preview := trimPreviewSuffix(ctx)
+
 	// 1. Bind URI parameter untuk code (required)
 	var codeData dto.CodeRequest
 	if err := ctx.ShouldBindUri(&codeData); err != nil {
@@ -19,11 +47,9 @@ func (c *Controller) Redirect(ctx *gin.Context) {
 		return
 	}
 
-	// 2. Bind query parameter untuk passcode (optional)
-	var passcodeData dto.PasscodeRequest
-	if err := ctx.ShouldBindQuery(&passcodeData); err != nil {
-		passcodeData.Passcode = 0
-		validator.SendValidationError(ctx, err, &passcodeData)
+	// Passcode form submissions unlock the link and come back as a GET
+	if ctx.Request.Method == http.MethodPost {
+		c.unlockPasscode(ctx, codeData.Code)
 		return
 	}
 
@@ -36,17 +62,243 @@ func (c *Controller) Redirect(ctx *gin.Context) {
 	device := middleware.GetDevice(userAgent)
 	browser := middleware.GetBrowser(userAgent)
 	os := middleware.GetOS(userAgent)
+	doNotTrack := privacy.DoNotTrack(ctx.Request.Header)
+	bot := useragent.DetectBotRequest(ctx.Request)
+
+	// Requests routed from a custom domain resolve codes scoped to it
+	domain := ctx.GetString(middleware.CustomDomainKey)
+
+	// Show the interstitial page first unless the visitor already went through
+	// it or is a link preview crawler the link lets skip it
+	page, err := c.repo.Interstitial(ctx.Request.Context(), domain, codeData.Code, preview, ctx.Query(interstitial.ContinueParam), bot.IsBot)
+	if err != nil {
+		c.handleRedirectError(ctx, err)
+		return
+	}
+	if page != nil {
+		page.ContinueURL = interstitial.ContinueURL(ctx.Request.URL, page.ContinueToken)
+		body, err := interstitial.Render(*page)
+		if err != nil {
+			httputil.HandleError(ctx, apperrors.ErrInterstitialRenderFailed.WithError(err), nil)
+			return
+		}
+		writeHTMLPage(ctx, http.StatusOK, body)
+		return
+	}
+
+	variantCookie, _ := ctx.Cookie(variantCookieName)
+	passcodeGrant, _ := ctx.Cookie(passcode.CookieName)
 
 	// Get short link and track the view
-	link, err := c.repo.RedirectByShortCode(codeData.Code, ipAddress, userAgent, referer, device, browser, os, passcodeData.Passcode)
+	link, stickVariant, err := c.repo.RedirectByShortCode(ctx.Request.Context(), domain, codeData.Code, ipAddress, userAgent, referer, device, browser, os, passcodeGrant, doNotTrack, bot, ctx.GetHeader("Accept-Language"), ctx.Request.URL.Query(), variantCookie)
+	if err != nil {
+		// Browsers get the passcode form instead of the JSON error
+		if errors.Is(err, apperrors.ErrPasscodeRequired) && acceptsHTML(ctx) {
+			c.renderPasscodeForm(ctx, codeData.Code, http.StatusOK, "")
+			return
+		}
+		c.handleRedirectError(ctx, err)
+		return
+	}
+
+	if stickVariant != "" {
+		ctx.SetSameSite(http.SameSiteLaxMode)
+		ctx.SetCookie(variantCookieName, stickVariant, variantCookieMaxAge, ctx.Request.URL.Path, "", isHTTPS(ctx), true)
+	}
+
+	// Link preview crawlers get the link's social card instead of the destination's
+	if bot.IsBot && link.HasSocialCard() {
+		body, err := socialcard.Render(socialcard.New(link, shortURL(ctx)))
+		if err != nil {
+			httputil.HandleError(ctx, apperrors.ErrSocialCardRenderFailed.WithError(err), nil)
+			return
+		}
+		writeHTMLPage(ctx, http.StatusOK, body)
+		return
+	}
+
+	// The destination carries the link's UTM tags and, when the link
+	// forwards it, the visitor's query string
+	target, err := destination.Build(link.OriginalURL, destination.Options{
+		UTM:      link.Detail.UTMParams(),
+		Incoming: ctx.Request.URL.Query(),
+		Forward:  link.Detail.ForwardQuery,
+		Exclude:  forwardExcludedParams,
+		Conflict: link.Detail.QueryConflictMode,
+	})
+	if err != nil {
+		logger.Logger.Warn("Failed to build destination URL, using the original URL",
+			"short_code", link.ShortCode,
+			"error", err.Error(),
+		)
+		target = link.OriginalURL
+	}
+
+	// Mobile visitors go to the app when the link has a deep link for their platform
+	if platform := deeplink.PlatformFor(os, device); platform != "" && !bot.IsBot {
+		if appTarget := deeplink.Choose(platform, link.IOSDeepLink, link.AndroidDeepLink); appTarget != "" {
+			fallback := link.DeepLinkFallbackURL
+			if fallback == "" {
+				fallback = target
+			}
+			openDeepLink(ctx, platform, appTarget, fallback)
+			return
+		}
+	}
+
+	writeRedirect(ctx, link.Detail.RedirectStatus, link.Detail.RedirectCacheSeconds, target)
+}
+
+// writeRedirect sends the visitor to target with the link's status code,
+// 307 by default. Redirects are not cached unless the link allows it:
+// browsers that cache one skip the server, and the click, on the next visit.
+func writeRedirect(ctx *gin.Context, status, cacheSeconds int, target string) {
+	if status == 0 {
+		status = http.StatusTemporaryRedirect
+	}
+	if cacheSeconds > 0 {
+		ctx.Header("Cache-Control", "private, max-age="+strconv.Itoa(cacheSeconds))
+	} else {
+		ctx.Header("Cache-Control", "no-store")
+	}
+	ctx.Redirect(status, target)
+}
+
+// openDeepLink sends the visitor to target. App links are plain redirects the
+// OS hands to the app; custom schemes fall back to the web without the app,
+// through an intent: URL on Android and a page that tries the app on iOS.
+func openDeepLink(ctx *gin.Context, platform, target, fallback string) {
+	if deeplink.IsAppLink(target) {
+		ctx.Redirect(http.StatusTemporaryRedirect, target)
+		return
+	}
+	if platform == deeplink.PlatformAndroid {
+		ctx.Redirect(http.StatusTemporaryRedirect, deeplink.AndroidIntentURL(target, fallback))
+		return
+	}
+
+	nonce, err := deeplink.NewNonce()
+	if err != nil {
+		httputil.HandleError(ctx, apperrors.ErrDeepLinkRenderFailed.WithError(err), nil)
+		return
+	}
+	body, err := deeplink.RenderOpenPage(deeplink.OpenPage{AppURL: target, FallbackURL: fallback, Nonce: nonce})
+	if err != nil {
+		httputil.HandleError(ctx, apperrors.ErrDeepLinkRenderFailed.WithError(err), nil)
+		return
+	}
+	writeHTMLPage(ctx, http.StatusOK, body, "script-src 'nonce-"+nonce+"'")
+}
+
+// handleRedirectError answers a failed redirect. Unknown codes on a custom
+// domain go to the domain's fallback URL.
+func (c *Controller) handleRedirectError(ctx *gin.Context, err error) {
+	if fallback := ctx.GetString(middleware.CustomDomainFallbackKey); fallback != "" && errors.Is(err, apperrors.ErrShortLinkNotFound) {
+		ctx.Redirect(http.StatusFound, fallback)
+		return
+	}
+	httputil.HandleError(ctx, err, nil)
+}
+
+// unlockPasscode checks a passcode posted from the form. A match stores a
+// grant cookie scoped to the link and sends the visitor back to the link;
+// otherwise the form is shown again with the reason.
+func (c *Controller) unlockPasscode(ctx *gin.Context, code string) {
+	submitted, ok := passcode.ParseCode(ctx.PostForm(passcode.FormField))
+	if !ok {
+		c.renderPasscodeForm(ctx, code, http.StatusUnprocessableEntity, "Enter the 6 digit passcode.")
+		return
+	}
+
+	domain := ctx.GetString(middleware.CustomDomainKey)
+	grant, err := c.repo.UnlockPasscode(ctx.Request.Context(), domain, code, submitted, ctx.ClientIP())
+	switch {
+	case errors.Is(err, apperrors.ErrPasscodeIncorrect):
+		c.renderPasscodeForm(ctx, code, http.StatusUnauthorized, "Incorrect passcode, please try again.")
+		return
+	case errors.Is(err, apperrors.ErrPasscodeTooManyAttempts):
+		c.renderPasscodeForm(ctx, code, http.StatusTooManyRequests, "Too many incorrect attempts. Please wait a few minutes and try again.")
+		return
+	case err != nil:
+		c.handleRedirectError(ctx, err)
+		return
+	}
+
+	if grant != "" {
+		ctx.SetSameSite(http.SameSiteLaxMode)
+		ctx.SetCookie(passcode.CookieName, grant, passcode.Global().UnlockMaxAge(), ctx.Request.URL.Path, "", isHTTPS(ctx), true)
+	}
+	ctx.Redirect(http.StatusSeeOther, passcode.FormAction(ctx.Request.URL))
+}
+
+// renderPasscodeForm answers with the passcode form for code, showing
+// message when set.
+func (c *Controller) renderPasscodeForm(ctx *gin.Context, code string, status int, message string) {
+	page, err := c.repo.PasscodeForm(ctx.Request.Context(), ctx.GetString(middleware.CustomDomainKey), code)
+	if err != nil {
+		c.handleRedirectError(ctx, err)
+		return
+	}
+
+	page.Action = passcode.FormAction(ctx.Request.URL)
+	page.CSRFField = csrf.DefaultFormField
+	page.CSRFToken = csrf.GetMaskedToken(ctx)
+	page.Error = message
+	body, err := passcode.RenderForm(*page)
 	if err != nil {
-		httputil.HandleError(ctx, err, nil)
+		httputil.HandleError(ctx, apperrors.ErrPasscodeProcessFailed.WithError(err), nil)
 		return
 	}
+	writeHTMLPage(ctx, status, body)
+}
 
-	// This should only be reached if no error occurred
-	// Redirect to original URL
-	// Use StatusTemporaryRedirect (307) or StatusFound (302) to prevent browser caching
-	// StatusMovedPermanently (301) causes the browser to skip the server on subsequent clicks!
-	ctx.Redirect(http.StatusTemporaryRedirect, link.OriginalURL)
+// writeHTMLPage sends a server-rendered page. Its CSP replaces the API's
+// default-src 'self', which would block the page's inline styles; extraCSP
+// adds directives such as a script nonce.
+func writeHTMLPage(ctx *gin.Context, status int, body []byte, extraCSP ...string) {
+	csp := "default-src 'none'; style-src 'unsafe-inline'; base-uri 'none'; frame-ancestors 'none'"
+	for _, directive := range extraCSP {
+		csp += "; " + directive
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("X-Robots-Tag", "noindex, nofollow")
+	ctx.Header("Content-Security-Policy", csp)
+	ctx.Data(status, "text/html; charset=utf-8", body)
+}
+
+// isHTTPS reports whether the client reached us over HTTPS, directly or
+// through the proxy.
+func isHTTPS(ctx *gin.Context) bool {
+	return ctx.Request.TLS != nil || strings.EqualFold(ctx.GetHeader("X-Forwarded-Proto"), "https")
+}
+
+// shortURL returns the address the client requested, without the query.
+func shortURL(ctx *gin.Context) string {
+	scheme := "http"
+	if isHTTPS(ctx) {
+		scheme = "https"
+	}
+	return scheme + "://" + ctx.Request.Host + ctx.Request.URL.Path
+}
+
+// acceptsHTML reports whether the client is a browser asking for a page.
+func acceptsHTML(ctx *gin.Context) bool {
+	return strings.Contains(ctx.GetHeader("Accept"), "text/html")
+}
+
+// trimPreviewSuffix strips the preview suffix from the code parameter and
+// reports whether it was there.
+func trimPreviewSuffix(ctx *gin.Context) bool {
+	for i, param := range ctx.Params {
+		if param.Key != "code" {
+			continue
+		}
+		code, ok := strings.CutSuffix(param.Value, interstitial.PreviewSuffix)
+		if !ok || code == "" {
+			return false
+		}
+		ctx.Params[i].Value = code
+		return true
+	}
+	return false
 }
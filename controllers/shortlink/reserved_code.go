@@ -0,0 +1,60 @@
+package shortlink
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// ListReservedCodes returns the words short codes may not use
+func (c *Controller) ListReservedCodes(ctx *gin.Context) {
+	var req dto.ListReservedCodesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	items, err := c.repo.ListReservedCodes(req.Kind)
+	if err != nil {
+		httputil.HandleError(ctx, err, ctx.GetString("user_id"))
+		return
+	}
+
+	httputil.SendOKResponse(ctx, items, "Reserved codes retrieved successfully")
+}
+
+// CreateReservedCode reserves a word or blocks a term for short codes
+func (c *Controller) CreateReservedCode(ctx *gin.Context) {
+	var req dto.CreateReservedCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	adminID := ctx.GetString("user_id")
+	item, err := c.repo.CreateReservedCode(&req, adminID)
+	if err != nil {
+		httputil.HandleError(ctx, err, adminID)
+		return
+	}
+
+	httputil.SendCreatedResponse(ctx, item, "Reserved code created successfully")
+}
+
+// DeleteReservedCode frees a word for short codes again
+func (c *Controller) DeleteReservedCode(ctx *gin.Context) {
+	var req dto.ReservedCodeIDRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	adminID := ctx.GetString("user_id")
+	if err := c.repo.DeleteReservedCode(req.ID, adminID); err != nil {
+		httputil.HandleError(ctx, err, adminID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, nil, "Reserved code deleted successfully")
+}
@@ -0,0 +1,89 @@
+package shortlink
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	shortlinkrepo "github.com/adehusnim37/lihatin-go/repositories/shortlink"
+	"github.com/gin-gonic/gin"
+)
+
+// revisionActor is who the request changes a link as, for its revision
+// history
+func revisionActor(ctx *gin.Context) shortlinkrepo.RevisionActor {
+	return shortlinkrepo.RevisionActor{
+		UserID:   ctx.GetString("user_id"),
+		Role:     ctx.GetString("role"),
+		APIKeyID: ctx.GetString("api_key_id"),
+	}
+}
+
+// ListLinkRevisions returns a page of the short link's change history, newest
+// first. Changes to redirect rules and variants are not in it, except a
+// variant promote.
+func (c *Controller) ListLinkRevisions(ctx *gin.Context) {
+	var codeReq dto.CodeRequest
+	if err := ctx.ShouldBindUri(&codeReq); err != nil {
+		validator.SendValidationError(ctx, err, &codeReq)
+		return
+	}
+
+	var req dto.LinkRevisionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	revisions, err := c.repo.ListLinkRevisions(linkCode(ctx, codeReq.Code), userID, ctx.GetString("role"), req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, revisions, "Short link revisions retrieved successfully")
+}
+
+// GetLinkRevision returns a revision with the state it recorded and what
+// changed since the revision before it, or since compare_to
+func (c *Controller) GetLinkRevision(ctx *gin.Context) {
+	var uriReq dto.LinkRevisionURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	var req dto.LinkRevisionDiffRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	revision, err := c.repo.GetLinkRevision(linkCode(ctx, uriReq.Code), uriReq.RevisionID, req.CompareTo, userID, ctx.GetString("role"))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, revision, "Short link revision retrieved successfully")
+}
+
+// RollbackShortLink restores the short link to a prior revision. Redirect
+// rules and variants are left as they are.
+func (c *Controller) RollbackShortLink(ctx *gin.Context) {
+	var uriReq dto.LinkRevisionURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	actor := revisionActor(ctx)
+	revision, err := c.repo.RollbackShortLink(linkCode(ctx, uriReq.Code), uriReq.RevisionID, actor)
+	if err != nil {
+		httputil.HandleError(ctx, err, actor.UserID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, revision, "Short link rolled back successfully")
+}
@@ -15,7 +15,7 @@ func (c *Controller) ReviveShortLink(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.repo.RestoreDeletedShortByAdmin(codeData.Code); err != nil {
+	if err := c.repo.RestoreDeletedShortByAdmin(linkCode(ctx, codeData.Code), revisionActor(ctx)); err != nil {
 		httputil.HandleError(ctx, err, nil)
 		return
 	}
@@ -0,0 +1,115 @@
+package shortlink
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// ListRedirectRules returns the redirect rules of a short link in evaluation order
+func (c *Controller) ListRedirectRules(ctx *gin.Context) {
+	var req dto.CodeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	rules, err := c.repo.ListRedirectRules(linkCode(ctx, req.Code), userID, ctx.GetString("role"))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, rules, "Redirect rules retrieved successfully")
+}
+
+// CreateRedirectRule appends a redirect rule to a short link
+func (c *Controller) CreateRedirectRule(ctx *gin.Context) {
+	var codeReq dto.CodeRequest
+	if err := ctx.ShouldBindUri(&codeReq); err != nil {
+		validator.SendValidationError(ctx, err, &codeReq)
+		return
+	}
+
+	var req dto.RedirectRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	rule, err := c.repo.CreateRedirectRule(linkCode(ctx, codeReq.Code), userID, ctx.GetString("role"), &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendCreatedResponse(ctx, rule, "Redirect rule created successfully")
+}
+
+// UpdateRedirectRule replaces a redirect rule
+func (c *Controller) UpdateRedirectRule(ctx *gin.Context) {
+	var uriReq dto.RedirectRuleURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	var req dto.RedirectRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	rule, err := c.repo.UpdateRedirectRule(linkCode(ctx, uriReq.Code), uriReq.RuleID, userID, ctx.GetString("role"), &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, rule, "Redirect rule updated successfully")
+}
+
+// DeleteRedirectRule removes a redirect rule
+func (c *Controller) DeleteRedirectRule(ctx *gin.Context) {
+	var uriReq dto.RedirectRuleURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	if err := c.repo.DeleteRedirectRule(linkCode(ctx, uriReq.Code), uriReq.RuleID, userID, ctx.GetString("role")); err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, nil, "Redirect rule deleted successfully")
+}
+
+// ReorderRedirectRules sets the order in which redirect rules are evaluated
+func (c *Controller) ReorderRedirectRules(ctx *gin.Context) {
+	var codeReq dto.CodeRequest
+	if err := ctx.ShouldBindUri(&codeReq); err != nil {
+		validator.SendValidationError(ctx, err, &codeReq)
+		return
+	}
+
+	var req dto.ReorderRedirectRulesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	rules, err := c.repo.ReorderRedirectRules(linkCode(ctx, codeReq.Code), userID, ctx.GetString("role"), &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, rules, "Redirect rules reordered successfully")
+}
@@ -0,0 +1,38 @@
+package shortlink
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// GetShortCodeSettings returns how codes are generated for the caller's links
+func (c *Controller) GetShortCodeSettings(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	settings, err := c.repo.GetShortCodeSettings(userID)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, settings, "Short code settings retrieved successfully")
+}
+
+// UpdateShortCodeSettings sets the prefix of the caller's generated codes
+func (c *Controller) UpdateShortCodeSettings(ctx *gin.Context) {
+	var req dto.UpdateShortCodeSettingsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	settings, err := c.repo.UpdateShortCodeSettings(userID, &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, settings, "Short code settings updated successfully")
+}
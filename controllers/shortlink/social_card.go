@@ -0,0 +1,117 @@
+package shortlink
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/gin-gonic/gin"
+)
+
+const maxSocialCardImageSizeBytes int64 = 5 * 1024 * 1024
+
+var allowedSocialCardImageContentTypes = map[string]struct{}{
+	"image/jpeg": {},
+	"image/png":  {},
+	"image/webp": {},
+	"image/gif":  {},
+}
+
+// UploadSocialCardImage stores an image and makes it the og:image of a short link's social card
+func (c *Controller) UploadSocialCardImage(ctx *gin.Context) {
+	var req dto.CodeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	if c.socialCardImageStore == nil {
+		httputil.SendErrorResponse(ctx, http.StatusServiceUnavailable, "SOCIAL_CARD_IMAGE_STORAGE_NOT_CONFIGURED", "Social card image storage is not configured on server", "image")
+		return
+	}
+
+	link, err := c.repo.OwnedShortLink(linkCode(ctx, req.Code), userID, ctx.GetString("role"))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("image")
+	if err != nil || fileHeader == nil {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"image": "Image file is required"})
+		return
+	}
+	if fileHeader.Size <= 0 {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"image": "Image file is empty"})
+		return
+	}
+	if fileHeader.Size > maxSocialCardImageSizeBytes {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"image": "Image file must be less than or equal to 5MB"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		httputil.SendErrorResponse(ctx, http.StatusInternalServerError, "SOCIAL_CARD_IMAGE_READ_FAILED", "Failed to read social card image", "image")
+		return
+	}
+	defer file.Close()
+
+	contentType, err := detectImageContentType(file)
+	if err != nil {
+		httputil.SendErrorResponse(ctx, http.StatusBadRequest, "SOCIAL_CARD_IMAGE_INVALID", "Invalid social card image", "image")
+		return
+	}
+	if _, ok := allowedSocialCardImageContentTypes[contentType]; !ok {
+		httputil.SendValidationErrorResponse(ctx, "Validation failed", map[string]string{"image": "Only JPG, PNG, WEBP, or GIF images are allowed"})
+		return
+	}
+
+	imageURL, objectKey, err := c.socialCardImageStore.UploadImage(
+		ctx.Request.Context(),
+		link.ID,
+		file,
+		fileHeader.Size,
+		contentType,
+	)
+	if err != nil {
+		var responseErr *smithyhttp.ResponseError
+		if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusRequestEntityTooLarge {
+			httputil.SendErrorResponse(ctx, http.StatusRequestEntityTooLarge, "SOCIAL_CARD_IMAGE_TOO_LARGE_FOR_STORAGE", "Image size exceeds the upstream storage gateway limit", "image")
+			return
+		}
+		logger.Logger.Error("Failed uploading social card image", "user_id", userID, "short_code", link.ShortCode, "error", err.Error())
+		httputil.SendErrorResponse(ctx, http.StatusInternalServerError, "SOCIAL_CARD_IMAGE_UPLOAD_FAILED", "Failed to upload social card image", "image")
+		return
+	}
+
+	if err := c.repo.SetSocialCardImage(link, imageURL, revisionActor(ctx)); err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendCreatedResponse(ctx, gin.H{
+		"image_url":   imageURL,
+		"object_key":  objectKey,
+		"social_card": dto.NewSocialCardResponse(link),
+	}, "Social card image uploaded successfully")
+}
+
+func detectImageContentType(file multipart.File) (string, error) {
+	buffer := make([]byte, 512)
+	n, err := file.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buffer[:n]), nil
+}
@@ -16,7 +16,7 @@ func (c *Controller) GetShortLinkStats(ctx *gin.Context) {
 		return
 	}
 
-	stats, err := c.repo.GetStatsShortLink(req.Code, ctx.GetString("user_id"), ctx.GetString("role"))
+	stats, err := c.repo.GetStatsShortLink(linkCode(ctx, req.Code), ctx.GetString("user_id"), ctx.GetString("role"))
 	if err != nil {
 		httputil.HandleError(ctx, err, ctx.GetString("user_id"))
 		return
@@ -0,0 +1,30 @@
+package shortlink
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *Controller) GetShortLinkTimeseries(ctx *gin.Context) {
+	var req dto.CodeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	var query dto.TimeseriesRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		validator.SendValidationError(ctx, err, &query)
+		return
+	}
+
+	timeseries, err := c.repo.GetShortLinkTimeseries(ctx.Request.Context(), linkCode(ctx, req.Code), ctx.GetString("user_id"), ctx.GetString("role"), &query)
+	if err != nil {
+		httputil.HandleError(ctx, err, ctx.GetString("user_id"))
+		return
+	}
+
+	httputil.SendOKResponse(ctx, timeseries, "Short link timeseries retrieved successfully")
+}
@@ -10,14 +10,13 @@ import (
 func (c *Controller) SwitchActiveInActiveShort(ctx *gin.Context) {
 	var codeData dto.CodeRequest
 	userID := ctx.GetString("user_id")
-	role := ctx.GetString("role")
 
 	if err := ctx.ShouldBindUri(&codeData); err != nil {
 		validator.SendValidationError(ctx, err, &codeData)
 		return
 	}
 
-	if err := c.repo.ToggleActiveInActiveShort(codeData.Code, userID, role); err != nil {
+	if err := c.repo.ToggleActiveInActiveShort(linkCode(ctx, codeData.Code), revisionActor(ctx)); err != nil {
 		http.HandleError(ctx, err, userID)
 		return
 	}	
@@ -15,7 +15,7 @@ func (c *Controller) AdminUnbanShortLink(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.repo.RestoreShortByAdmin(unbanData.Code); err != nil {
+	if err := c.repo.RestoreShortByAdmin(linkCode(ctx, unbanData.Code), revisionActor(ctx)); err != nil {
 		httputil.HandleError(ctx, err, ctx.GetString("user_id"))
 		return
 	}
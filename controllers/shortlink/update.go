@@ -20,7 +20,7 @@ func (c *Controller) UpdateShortLink(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.repo.UpdateShortLink(shortCode, ctx.GetString("user_id"), ctx.GetString("user_role"), &updateData); err != nil {
+	if err := c.repo.UpdateShortLink(linkCode(ctx, shortCode), revisionActor(ctx), &updateData); err != nil {
 		httputil.HandleError(ctx, err, ctx.GetString("user_id"))
 		return
 	}
@@ -30,8 +30,7 @@ func (c *Controller) UpdateShortLink(ctx *gin.Context) {
 
 func (c *Controller) RemovePasscode(ctx *gin.Context) {
 	shortCode := ctx.Param("code")
-	userID := ctx.GetString("user_id")
-	userRole := ctx.GetString("user_role")
+	actor := revisionActor(ctx)
 
 	// Construct request to set passcode to empty string (which repo converts to 0)
 	passcode := ""
@@ -39,8 +38,8 @@ func (c *Controller) RemovePasscode(ctx *gin.Context) {
 		Passcode: &passcode,
 	}
 
-	if err := c.repo.UpdateShortLink(shortCode, userID, userRole, &updateReq); err != nil {
-		httputil.HandleError(ctx, err, userID)
+	if err := c.repo.UpdateShortLink(linkCode(ctx, shortCode), actor, &updateReq); err != nil {
+		httputil.HandleError(ctx, err, actor.UserID)
 		return
 	}
 
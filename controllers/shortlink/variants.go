@@ -0,0 +1,109 @@
+package shortlink
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	httputil "github.com/adehusnim37/lihatin-go/internal/pkg/http"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// ListLinkVariants returns the A/B variants of a short link with their click counts
+func (c *Controller) ListLinkVariants(ctx *gin.Context) {
+	var req dto.CodeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	variants, err := c.repo.ListLinkVariants(linkCode(ctx, req.Code), userID, ctx.GetString("role"))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, variants, "Link variants retrieved successfully")
+}
+
+// CreateLinkVariant adds a weighted destination to a short link's A/B split
+func (c *Controller) CreateLinkVariant(ctx *gin.Context) {
+	var codeReq dto.CodeRequest
+	if err := ctx.ShouldBindUri(&codeReq); err != nil {
+		validator.SendValidationError(ctx, err, &codeReq)
+		return
+	}
+
+	var req dto.LinkVariantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	variants, err := c.repo.CreateLinkVariant(linkCode(ctx, codeReq.Code), userID, ctx.GetString("role"), &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendCreatedResponse(ctx, variants, "Link variant created successfully")
+}
+
+// UpdateLinkVariant replaces an A/B variant
+func (c *Controller) UpdateLinkVariant(ctx *gin.Context) {
+	var uriReq dto.LinkVariantURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	var req dto.LinkVariantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validator.SendValidationError(ctx, err, &req)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	variants, err := c.repo.UpdateLinkVariant(linkCode(ctx, uriReq.Code), uriReq.VariantID, userID, ctx.GetString("role"), &req)
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, variants, "Link variant updated successfully")
+}
+
+// DeleteLinkVariant removes an A/B variant
+func (c *Controller) DeleteLinkVariant(ctx *gin.Context) {
+	var uriReq dto.LinkVariantURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	if err := c.repo.DeleteLinkVariant(linkCode(ctx, uriReq.Code), uriReq.VariantID, userID, ctx.GetString("role")); err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, nil, "Link variant deleted successfully")
+}
+
+// PromoteLinkVariant makes a variant the short link's original URL and ends the split
+func (c *Controller) PromoteLinkVariant(ctx *gin.Context) {
+	var uriReq dto.LinkVariantURIRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validator.SendValidationError(ctx, err, &uriReq)
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	variants, err := c.repo.PromoteLinkVariant(linkCode(ctx, uriReq.Code), uriReq.VariantID, revisionActor(ctx))
+	if err != nil {
+		httputil.HandleError(ctx, err, userID)
+		return
+	}
+
+	httputil.SendOKResponse(ctx, variants, "Link variant promoted successfully")
+}
@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"time"
+
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+// LinkTagRequest creates a tag or renames an existing one
+type LinkTagRequest struct {
+	Name  string `json:"name" label:"Nama Tag" binding:"required,min=1,max=50"`
+	Color string `json:"color,omitempty" label:"Warna Tag" binding:"omitempty,hexcolor,len=7"`
+}
+
+// LinkTagURIRequest binds the tag ID from the URI
+type LinkTagURIRequest struct {
+	TagID string `json:"tag_id" uri:"tagID" label:"ID Tag" binding:"required,uuid"`
+}
+
+// LinkTagResponse represents a tag. LinkCount is only set when listing tags.
+type LinkTagResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color,omitempty"`
+	LinkCount *int64    `json:"link_count,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewLinkTagResponses returns the tags on a link, or nil when it has none.
+func NewLinkTagResponses(tags []shortlink.LinkTag) []LinkTagResponse {
+	if len(tags) == 0 {
+		return nil
+	}
+	responses := make([]LinkTagResponse, 0, len(tags))
+	for _, tag := range tags {
+		responses = append(responses, LinkTagResponse{
+			ID:        tag.ID,
+			Name:      tag.Name,
+			Color:     tag.Color,
+			CreatedAt: tag.CreatedAt,
+			UpdatedAt: tag.UpdatedAt,
+		})
+	}
+	return responses
+}
+
+// CollectionRequest creates a collection or replaces an existing one's name
+// and description
+type CollectionRequest struct {
+	Name        string `json:"name" label:"Nama Koleksi" binding:"required,min=1,max=100"`
+	Description string `json:"description,omitempty" label:"Deskripsi Koleksi" binding:"omitempty,max=500"`
+}
+
+// CollectionURIRequest binds the collection ID from the URI
+type CollectionURIRequest struct {
+	CollectionID string `json:"collection_id" uri:"collectionID" label:"ID Koleksi" binding:"required,uuid"`
+}
+
+// CollectionResponse represents a collection with the number of links in it
+type CollectionResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	LinkCount   int64     `json:"link_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ShortLinkFilter narrows link listings and stats to the links carrying a tag
+// and/or filed in a collection
+type ShortLinkFilter struct {
+	TagID        string `form:"tag_id" label:"Tag" binding:"omitempty,uuid"`
+	CollectionID string `form:"collection_id" label:"Koleksi" binding:"omitempty,uuid"`
+}
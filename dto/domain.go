@@ -0,0 +1,59 @@
+package dto
+
+import "time"
+
+// CreateCustomDomainRequest claims a custom domain for the current user
+type CreateCustomDomainRequest struct {
+	Domain             string `json:"domain" label:"Domain" binding:"required,max=253"`
+	VerificationMethod string `json:"verification_method,omitempty" label:"Metode Verifikasi" binding:"omitempty,oneof=dns"`
+	FallbackURL        string `json:"fallback_url,omitempty" label:"URL Cadangan" binding:"omitempty,url,max=2048"`
+}
+
+// UpdateCustomDomainRequest changes a custom domain's settings; an empty
+// FallbackURL removes it
+type UpdateCustomDomainRequest struct {
+	FallbackURL *string `json:"fallback_url" label:"URL Cadangan" binding:"required,max=2048"`
+}
+
+// UpdateDomainAppLinksRequest sets the apps allowed to open the domain's
+// links as universal/app links; empty values stop serving the files
+type UpdateDomainAppLinksRequest struct {
+	IOSAppIDs               []string `json:"ios_app_ids" label:"ID Aplikasi iOS" binding:"max=10,dive,max=255"`
+	AndroidPackage          string   `json:"android_package" label:"Paket Android" binding:"max=255"`
+	AndroidCertFingerprints []string `json:"android_cert_fingerprints" label:"Sidik Jari Sertifikat Android" binding:"max=10,dive,max=95"`
+}
+
+// DomainAppLinks is the app association a domain serves from /.well-known
+type DomainAppLinks struct {
+	IOSAppIDs               []string `json:"ios_app_ids,omitempty"`
+	AndroidPackage          string   `json:"android_package,omitempty"`
+	AndroidCertFingerprints []string `json:"android_cert_fingerprints,omitempty"`
+}
+
+// CustomDomainIDRequest binds the domain ID from the URI
+type CustomDomainIDRequest struct {
+	ID string `json:"id" uri:"id" label:"ID Domain" binding:"required,uuid"`
+}
+
+// DomainVerificationInstructions tells the user how to prove ownership
+type DomainVerificationInstructions struct {
+	TXTName  string `json:"txt_name"`
+	TXTValue string `json:"txt_value"`
+}
+
+// CustomDomainResponse represents a custom domain in API responses
+type CustomDomainResponse struct {
+	ID                 string                         `json:"id"`
+	Domain             string                         `json:"domain"`
+	VerificationMethod string                         `json:"verification_method"`
+	Status             string                         `json:"status"`
+	VerifiedAt         *time.Time                     `json:"verified_at,omitempty"`
+	LastCheckedAt      *time.Time                     `json:"last_checked_at,omitempty"`
+	LastError          string                         `json:"last_error,omitempty"`
+	FailedChecks       int                            `json:"failed_checks"`
+	FallbackURL        string                         `json:"fallback_url,omitempty"`
+	Verification       DomainVerificationInstructions `json:"verification"`
+	AppLinks           *DomainAppLinks                `json:"app_links,omitempty"`
+	CreatedAt          time.Time                      `json:"created_at"`
+	UpdatedAt          time.Time                      `json:"updated_at"`
+}
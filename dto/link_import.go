@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"time"
+
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+// LinkImportRequest is the form an import file is uploaded with. Format
+// defaults to the one the file name's extension stands for.
+type LinkImportRequest struct {
+	Format string `form:"format" label:"Format" binding:"omitempty,oneof=csv ndjson"`
+}
+
+// LinkImportPayload is a row of an import waiting to be created: the link,
+// and the names of the owner's tags and collection to file it under.
+type LinkImportPayload struct {
+	Link       CreateShortLinkRequest `json:"link"`
+	TagNames   []string               `json:"tag_names,omitempty"`
+	Collection string                 `json:"collection,omitempty"`
+}
+
+// LinkImportURIRequest binds the import ID from the URI
+type LinkImportURIRequest struct {
+	ImportID string `json:"import_id" uri:"importID" label:"ID Impor" binding:"required,uuid"`
+}
+
+// LinkImportRowsRequest pages through an import's rows, optionally only the
+// created or the failed ones
+type LinkImportRowsRequest struct {
+	Status string `form:"status" label:"Status" binding:"omitempty,oneof=pending created failed"`
+	Page   int    `form:"page" label:"Halaman" binding:"omitempty,min=1"`
+	Limit  int    `form:"limit" label:"Limit" binding:"omitempty,min=1,max=100"`
+}
+
+// LinkExportRequest picks the format of a link export; the filter narrows it
+// to a tag or collection
+type LinkExportRequest struct {
+	Format string `form:"format" label:"Format" binding:"omitempty,oneof=csv ndjson"`
+	ShortLinkFilter
+}
+
+// LinkImportResponse is an import and how far its rows have got
+type LinkImportResponse struct {
+	ID            string     `json:"id"`
+	Format        string     `json:"format"`
+	FileName      string     `json:"file_name,omitempty"`
+	Status        string     `json:"status"`
+	TotalRows     int        `json:"total_rows"`
+	ProcessedRows int        `json:"processed_rows"`
+	SucceededRows int        `json:"succeeded_rows"`
+	FailedRows    int        `json:"failed_rows"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// NewLinkImportResponse converts an import model into its response
+func NewLinkImportResponse(linkImport shortlink.LinkImport) LinkImportResponse {
+	return LinkImportResponse{
+		ID:            linkImport.ID,
+		Format:        linkImport.Format,
+		FileName:      linkImport.FileName,
+		Status:        linkImport.Status,
+		TotalRows:     linkImport.TotalRows,
+		ProcessedRows: linkImport.ProcessedRows,
+		SucceededRows: linkImport.SucceededRows,
+		FailedRows:    linkImport.FailedRows,
+		StartedAt:     linkImport.StartedAt,
+		CompletedAt:   linkImport.CompletedAt,
+		CreatedAt:     linkImport.CreatedAt,
+		UpdatedAt:     linkImport.UpdatedAt,
+	}
+}
+
+// LinkImportRowResponse is the result of one row: the short code it got, or
+// why it failed
+type LinkImportRowResponse struct {
+	Line         int    `json:"line"`
+	OriginalURL  string `json:"original_url,omitempty"`
+	Status       string `json:"status"`
+	ShortCode    string `json:"short_code,omitempty"`
+	ErrorCode    string `json:"error_code,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// PaginatedLinkImportRowsResponse is a page of an import's rows by line
+type PaginatedLinkImportRowsResponse struct {
+	Rows       []LinkImportRowResponse `json:"rows"`
+	TotalCount int64                   `json:"total_count"`
+	Page       int                     `json:"page"`
+	Limit      int                     `json:"limit"`
+	TotalPages int                     `json:"total_pages"`
+}
@@ -0,0 +1,153 @@
+package dto
+
+import (
+	"time"
+
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+// LinkRevisionURIRequest binds the short code and revision ID from the URI
+type LinkRevisionURIRequest struct {
+	Code       string `json:"code" uri:"code" label:"Kode Short Link" binding:"required,min=1,max=100,no_space,saveurlshort"`
+	RevisionID string `json:"revision_id" uri:"revisionID" label:"ID Revisi" binding:"required,uuid"`
+}
+
+// LinkRevisionsRequest pages through a link's revisions, newest first
+type LinkRevisionsRequest struct {
+	Page  int `form:"page" label:"Halaman" binding:"omitempty,min=1"`
+	Limit int `form:"limit" label:"Limit" binding:"omitempty,min=1,max=100"`
+}
+
+// LinkRevisionDiffRequest picks the revision a revision is compared with;
+// empty compares it with the one before it
+type LinkRevisionDiffRequest struct {
+	CompareTo string `form:"compare_to" label:"Bandingkan Dengan" binding:"omitempty,uuid"`
+}
+
+// LinkRevisionResponse is one change of a link: who made it, when, and which
+// fields it touched. Current marks the revision in effect.
+type LinkRevisionResponse struct {
+	ID            string    `json:"id"`
+	Number        int       `json:"number"`
+	ChangedFields []string  `json:"changed_fields"`
+	ActorType     string    `json:"actor_type"`
+	ActorID       *string   `json:"actor_id,omitempty"`
+	APIKeyID      *string   `json:"api_key_id,omitempty"`
+	RestoredFrom  *int      `json:"restored_from,omitempty"`
+	Current       bool      `json:"current"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PaginatedLinkRevisionsResponse is a page of a link's revisions
+type PaginatedLinkRevisionsResponse struct {
+	Revisions  []LinkRevisionResponse `json:"revisions"`
+	TotalCount int64                  `json:"total_count"`
+	Page       int                    `json:"page"`
+	Limit      int                    `json:"limit"`
+	TotalPages int                    `json:"total_pages"`
+}
+
+// LinkRevisionStateResponse is the state a revision recorded. The passcode
+// itself is never returned, only whether the link had one.
+type LinkRevisionStateResponse struct {
+	OriginalURL         string     `json:"original_url"`
+	Domain              string     `json:"domain,omitempty"`
+	ShortCode           string     `json:"short_code"`
+	Title               string     `json:"title,omitempty"`
+	Description         string     `json:"description,omitempty"`
+	IsActive            bool       `json:"is_active"`
+	Deleted             bool       `json:"deleted"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+	CollectionID        *string    `json:"collection_id,omitempty"`
+	TagIDs              []string   `json:"tag_ids"`
+	OGTitle             string     `json:"og_title,omitempty"`
+	OGDescription       string     `json:"og_description,omitempty"`
+	OGImageURL          string     `json:"og_image_url,omitempty"`
+	TwitterCard         string     `json:"twitter_card,omitempty"`
+	IOSDeepLink         string     `json:"ios_deep_link,omitempty"`
+	AndroidDeepLink     string     `json:"android_deep_link,omitempty"`
+	DeepLinkFallbackURL string     `json:"deep_link_fallback_url,omitempty"`
+
+	HasPasscode          bool   `json:"has_passcode"`
+	ClickLimit           int    `json:"click_limit"`
+	ClickLimitMode       string `json:"click_limit_mode,omitempty"`
+	DedupWindowMinutes   *int   `json:"dedup_window_minutes,omitempty"`
+	EnableStats          bool   `json:"enable_stats"`
+	VariantMode          string `json:"variant_mode,omitempty"`
+	InterstitialMode     string `json:"interstitial_mode,omitempty"`
+	PrivacyMode          string `json:"privacy_mode,omitempty"`
+	HonorDNT             *bool  `json:"honor_dnt,omitempty"`
+	UTMSource            string `json:"utm_source,omitempty"`
+	UTMMedium            string `json:"utm_medium,omitempty"`
+	UTMCampaign          string `json:"utm_campaign,omitempty"`
+	UTMTerm              string `json:"utm_term,omitempty"`
+	UTMContent           string `json:"utm_content,omitempty"`
+	RedirectStatus       int    `json:"redirect_status,omitempty"`
+	RedirectCacheSeconds int    `json:"redirect_cache_seconds,omitempty"`
+	ForwardQuery         bool   `json:"forward_query"`
+	QueryConflictMode    string `json:"query_conflict_mode,omitempty"`
+}
+
+// NewLinkRevisionStateResponse converts a recorded state into its response
+func NewLinkRevisionStateResponse(state shortlink.LinkRevisionState) LinkRevisionStateResponse {
+	tagIDs := state.TagIDs
+	if tagIDs == nil {
+		tagIDs = []string{}
+	}
+	return LinkRevisionStateResponse{
+		OriginalURL:         state.OriginalURL,
+		Domain:              state.Domain,
+		ShortCode:           state.ShortCode,
+		Title:               state.Title,
+		Description:         state.Description,
+		IsActive:            state.IsActive,
+		Deleted:             state.Deleted,
+		ExpiresAt:           state.ExpiresAt,
+		CollectionID:        state.CollectionID,
+		TagIDs:              tagIDs,
+		OGTitle:             state.OGTitle,
+		OGDescription:       state.OGDescription,
+		OGImageURL:          state.OGImageURL,
+		TwitterCard:         state.TwitterCard,
+		IOSDeepLink:         state.IOSDeepLink,
+		AndroidDeepLink:     state.AndroidDeepLink,
+		DeepLinkFallbackURL: state.DeepLinkFallbackURL,
+
+		HasPasscode:          state.PasscodeHash != "",
+		ClickLimit:           state.ClickLimit,
+		ClickLimitMode:       state.ClickLimitMode,
+		DedupWindowMinutes:   state.DedupWindowMinutes,
+		EnableStats:          state.EnableStats,
+		VariantMode:          state.VariantMode,
+		InterstitialMode:     state.InterstitialMode,
+		PrivacyMode:          state.PrivacyMode,
+		HonorDNT:             state.HonorDNT,
+		UTMSource:            state.UTMSource,
+		UTMMedium:            state.UTMMedium,
+		UTMCampaign:          state.UTMCampaign,
+		UTMTerm:              state.UTMTerm,
+		UTMContent:           state.UTMContent,
+		RedirectStatus:       state.RedirectStatus,
+		RedirectCacheSeconds: state.RedirectCacheSeconds,
+		ForwardQuery:         state.ForwardQuery,
+		QueryConflictMode:    state.QueryConflictMode,
+	}
+}
+
+// LinkRevisionChange is one field that differs between two revisions. The
+// passcode is reported as whether the link had one.
+type LinkRevisionChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// LinkRevisionDiffResponse is a revision, the state it recorded and how it
+// differs from the revision it is compared with. ComparedTo is empty for a
+// link's first revision, which is compared with nothing.
+type LinkRevisionDiffResponse struct {
+	Revision   LinkRevisionResponse      `json:"revision"`
+	State      LinkRevisionStateResponse `json:"state"`
+	ComparedTo *LinkRevisionResponse     `json:"compared_to,omitempty"`
+	Changes    []LinkRevisionChange      `json:"changes"`
+}
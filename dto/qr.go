@@ -0,0 +1,17 @@
+package dto
+
+// QRCodeRequest styles a short link's QR code. Colours are #rrggbb, with or
+// without the #; empty fields keep the defaults (black on white, 512px,
+// error correction M, a four module quiet zone).
+type QRCodeRequest struct {
+	Format     string `form:"format" label:"Format" binding:"omitempty,oneof=png svg"`
+	Size       int    `form:"size" label:"Ukuran" binding:"omitempty,min=128,max=2048"`
+	Level      string `form:"level" label:"Tingkat Koreksi" binding:"omitempty,oneof=L M Q H"`
+	Foreground string `form:"foreground" label:"Warna Depan" binding:"omitempty,max=7"`
+	Background string `form:"background" label:"Warna Latar" binding:"omitempty,max=7"`
+	Margin     *int   `form:"margin" label:"Margin" binding:"omitempty,min=0,max=16"`
+	// Logo set to false leaves the uploaded logo out
+	Logo *bool `form:"logo" label:"Logo"`
+	// Download serves the code as an attachment
+	Download bool `form:"download" label:"Unduh"`
+}
@@ -0,0 +1,72 @@
+package dto
+
+import "time"
+
+// RedirectRuleConditions is the match part of a redirect rule. Every set
+// condition must match; a list matches when any of its entries does.
+type RedirectRuleConditions struct {
+	Countries     []string                `json:"countries,omitempty" label:"Negara" binding:"omitempty,max=50,unique,dive,len=2,alpha"`
+	Devices       []string                `json:"devices,omitempty" label:"Perangkat" binding:"omitempty,max=5,unique,dive,oneof=Desktop Mobile iPad Bot API"`
+	OS            []string                `json:"os,omitempty" label:"Sistem Operasi" binding:"omitempty,max=20,unique,dive,min=1,max=50"`
+	Languages     []string                `json:"languages,omitempty" label:"Bahasa" binding:"omitempty,max=20,unique,dive,bcp47_language_tag"`
+	ReferrerHosts []string                `json:"referrer_hosts,omitempty" label:"Host Perujuk" binding:"omitempty,max=20,unique,dive,fqdn"`
+	Query         *RedirectRuleQuery      `json:"query,omitempty" label:"Parameter Query" binding:"omitempty"`
+	TimeWindow    *RedirectRuleTimeWindow `json:"time_window,omitempty" label:"Rentang Waktu" binding:"omitempty"`
+}
+
+// RedirectRuleQuery matches a query parameter; an empty Value only requires
+// the parameter to be present
+type RedirectRuleQuery struct {
+	Key   string `json:"key" label:"Kunci Query" binding:"required,max=100"`
+	Value string `json:"value,omitempty" label:"Nilai Query" binding:"omitempty,max=255"`
+}
+
+// RedirectRuleTimeWindow matches an absolute range and/or a daily window.
+// Days are 0 (Sunday) to 6 (Saturday); times are HH:MM in Timezone.
+type RedirectRuleTimeWindow struct {
+	From      *time.Time `json:"from,omitempty" label:"Mulai Tanggal"`
+	Until     *time.Time `json:"until,omitempty" label:"Sampai Tanggal"`
+	Days      []int      `json:"days,omitempty" label:"Hari" binding:"omitempty,max=7,unique,dive,min=0,max=6"`
+	StartTime string     `json:"start_time,omitempty" label:"Jam Mulai" binding:"omitempty,datetime=15:04"`
+	EndTime   string     `json:"end_time,omitempty" label:"Jam Selesai" binding:"omitempty,datetime=15:04"`
+	Timezone  string     `json:"timezone,omitempty" label:"Zona Waktu" binding:"omitempty,timezone"`
+}
+
+// RedirectRuleRequest creates a rule or replaces an existing one
+type RedirectRuleRequest struct {
+	Name           string                 `json:"name,omitempty" label:"Nama Aturan" binding:"omitempty,max=100"`
+	DestinationURL string                 `json:"destination_url" label:"URL Tujuan" binding:"required,url,max=2048"`
+	IsActive       *bool                  `json:"is_active,omitempty" label:"Aktif"`
+	Conditions     RedirectRuleConditions `json:"conditions" label:"Kondisi"`
+}
+
+// ReorderRedirectRulesRequest sets the evaluation order of all rules of a link
+type ReorderRedirectRulesRequest struct {
+	RuleIDs []string `json:"rule_ids" label:"Urutan Aturan" binding:"required,min=1,unique,dive,uuid"`
+}
+
+// RedirectRuleURIRequest binds the short code and rule ID from the URI
+type RedirectRuleURIRequest struct {
+	Code   string `json:"code" uri:"code" label:"Kode Short Link" binding:"required,min=1,max=100,no_space,saveurlshort"`
+	RuleID string `json:"rule_id" uri:"ruleID" label:"ID Aturan" binding:"required,uuid"`
+}
+
+// RedirectRuleResponse represents a redirect rule in API responses
+type RedirectRuleResponse struct {
+	ID             string                 `json:"id"`
+	Position       int                    `json:"position"`
+	Name           string                 `json:"name,omitempty"`
+	DestinationURL string                 `json:"destination_url"`
+	IsActive       bool                   `json:"is_active"`
+	Conditions     RedirectRuleConditions `json:"conditions"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// RuleHit counts the human clicks a rule sent to its destination. The entry
+// with an empty RuleID is the link's default URL.
+type RuleHit struct {
+	RuleID string `json:"rule_id,omitempty"`
+	Name   string `json:"name"`
+	Count  int    `json:"count"`
+}
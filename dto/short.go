@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"time"
+
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
 )
 
 // CreateShortLinkRequest represents request to create short link
@@ -14,10 +16,103 @@ type CreateShortLinkRequest struct {
 	Title       string     `json:"title,omitempty" label:"Judul" binding:"omitempty,max=255"`
 	Description string     `json:"description,omitempty" label:"Deskripsi"`
 	CustomCode  string     `json:"custom_code,omitempty" label:"Kode Kustom" binding:"omitempty,min=3,max=100,saveurlshort,no_space"`
+	Domain      string     `json:"domain,omitempty" label:"Domain Kustom" binding:"omitempty,max=253"` // Verified custom domain; empty for the main host
 	ExpiresAt   *time.Time `json:"expires_at,omitempty" label:"Tanggal Kadaluarsa"`
 	Limit       *int       `json:"limit,omitempty" label:"Limit" binding:"omitempty,numeric,min=1,max=1000000"`
 	EnableStats *bool      `json:"enable_stats,omitempty" label:"Aktifkan Statistik" binding:"omitempty"`
 	Tags        *Tags      `json:"tags,omitempty" label:"Tags" binding:"omitempty"`
+	PrivacyMode string     `json:"privacy_mode,omitempty" label:"Mode Privasi" binding:"omitempty,oneof=off hash truncate"`
+	HonorDNT    *bool      `json:"honor_dnt,omitempty" label:"Hormati Do-Not-Track" binding:"omitempty"`
+	// ClickLimitMode picks the counter Limit applies to: total or unique clicks
+	ClickLimitMode     string `json:"click_limit_mode,omitempty" label:"Mode Batas Klik" binding:"omitempty,oneof=total unique"`
+	DedupWindowMinutes *int   `json:"dedup_window_minutes,omitempty" label:"Jendela Klik Unik" binding:"omitempty,min=0,max=10080"`
+	InterstitialMode   string `json:"interstitial_mode,omitempty" label:"Mode Halaman Peringatan" binding:"omitempty,oneof=on off"`
+	// SocialCard is what link preview crawlers show for the link
+	SocialCard *SocialCard `json:"social_card,omitempty" label:"Kartu Sosial"`
+	// DeepLinks opens the link in the native app on mobile
+	DeepLinks *DeepLinks `json:"deep_links,omitempty" label:"Deep Link"`
+	// RedirectBehavior picks the redirect status and caching, and how query
+	// parameters reach the destination
+	RedirectBehavior *RedirectBehavior `json:"redirect_behavior,omitempty" label:"Perilaku Redirect"`
+	// TagIDs and CollectionID file the link under the owner's tags and
+	// collection
+	TagIDs       []string `json:"tag_ids,omitempty" label:"Tag" binding:"omitempty,max=20,unique,dive,uuid"`
+	CollectionID string   `json:"collection_id,omitempty" label:"Koleksi" binding:"omitempty,uuid"`
+}
+
+// RedirectBehavior is how a link redirects. StatusCode is 301, 302, 307 or
+// 308 and defaults to 307; CacheSeconds lets browsers cache the redirect,
+// which skips the click tracking of repeat visits. ForwardQuery passes the
+// visitor's query string on, and QueryConflict decides what happens to a
+// stored UTM tag or forwarded parameter the destination already has: keep
+// (the default), override or append.
+type RedirectBehavior struct {
+	StatusCode    int    `json:"status_code,omitempty" label:"Kode Status Redirect" binding:"omitempty,oneof=301 302 307 308"`
+	CacheSeconds  int    `json:"cache_seconds,omitempty" label:"Durasi Cache Redirect" binding:"omitempty,min=0,max=31536000"`
+	ForwardQuery  bool   `json:"forward_query,omitempty" label:"Teruskan Query"`
+	QueryConflict string `json:"query_conflict,omitempty" label:"Konflik Parameter" binding:"omitempty,oneof=keep override append"`
+}
+
+// NewRedirectBehaviorResponse returns detail's redirect behaviour, or nil
+// when it uses the defaults.
+func NewRedirectBehaviorResponse(detail *shortlink.ShortLinkDetail) *RedirectBehavior {
+	if detail == nil {
+		return nil
+	}
+	behavior := RedirectBehavior{
+		StatusCode:    detail.RedirectStatus,
+		CacheSeconds:  detail.RedirectCacheSeconds,
+		ForwardQuery:  detail.ForwardQuery,
+		QueryConflict: detail.QueryConflictMode,
+	}
+	if behavior == (RedirectBehavior{}) {
+		return nil
+	}
+	return &behavior
+}
+
+// DeepLinks holds a link's per-platform app targets. Each is a universal/app
+// link or a custom scheme URL such as myapp://product/42; FallbackURL is where
+// a custom scheme sends visitors without the app, the destination when empty.
+type DeepLinks struct {
+	IOS         string `json:"ios,omitempty" label:"Deep Link iOS" binding:"omitempty,max=2048"`
+	Android     string `json:"android,omitempty" label:"Deep Link Android" binding:"omitempty,max=2048"`
+	FallbackURL string `json:"fallback_url,omitempty" label:"URL Cadangan Deep Link" binding:"omitempty,url,max=2048"`
+}
+
+// NewDeepLinksResponse returns link's deep links, or nil when it has none.
+func NewDeepLinksResponse(link *shortlink.ShortLink) *DeepLinks {
+	if link == nil || !link.HasDeepLinks() {
+		return nil
+	}
+	return &DeepLinks{
+		IOS:         link.IOSDeepLink,
+		Android:     link.AndroidDeepLink,
+		FallbackURL: link.DeepLinkFallbackURL,
+	}
+}
+
+// SocialCard holds the Open Graph and Twitter card tags link preview crawlers
+// get instead of the redirect. Leaving title, description and image empty
+// sends crawlers to the destination as before.
+type SocialCard struct {
+	OGTitle       string `json:"og_title,omitempty" label:"Judul OG" binding:"omitempty,max=255"`
+	OGDescription string `json:"og_description,omitempty" label:"Deskripsi OG" binding:"omitempty,max=500"`
+	OGImageURL    string `json:"og_image_url,omitempty" label:"Gambar OG" binding:"omitempty,url,max=2048"`
+	TwitterCard   string `json:"twitter_card,omitempty" label:"Twitter Card" binding:"omitempty,oneof=summary summary_large_image"`
+}
+
+// NewSocialCardResponse returns link's social card, or nil when it has none.
+func NewSocialCardResponse(link *shortlink.ShortLink) *SocialCard {
+	if link == nil || (!link.HasSocialCard() && link.TwitterCard == "") {
+		return nil
+	}
+	return &SocialCard{
+		OGTitle:       link.OGTitle,
+		OGDescription: link.OGDescription,
+		OGImageURL:    link.OGImageURL,
+		TwitterCard:   link.TwitterCard,
+	}
 }
 
 // Tags represents tags for short link
@@ -49,68 +144,101 @@ type BulkShortLinkResponse struct {
 type ShortsLinkResponse struct {
 	ID          string     `json:"id"`
 	UserID      *string    `json:"user_id,omitempty"`
+	Domain      string     `json:"domain,omitempty"`
 	ShortCode   string     `json:"short_code"`
 	OriginalURL string     `json:"original_url"`
 	Title       string     `json:"title,omitempty"`
 	Description string     `json:"description,omitempty"`
+	FaviconURL  string     `json:"favicon_url,omitempty"`
 	IsActive    bool       `json:"is_active"`
 	ExpiresAt   *time.Time `json:"expires_at"`
 	CreatedAt   time.Time  `json:"created_at,omitempty"`
 	UpdatedAt   time.Time  `json:"updated_at,omitempty"`
 	ClickCount  int        `json:"click_count,omitempty"`
+
+	CollectionID *string           `json:"collection_id,omitempty"`
+	Tags         []LinkTagResponse `json:"tags,omitempty"`
 }
 
 type ShortLinkResponse struct {
 	ID              string                    `json:"id"`
 	UserID          *string                   `json:"user_id,omitempty"`
+	Domain          string                    `json:"domain,omitempty"`
 	ShortCode       string                    `json:"short_code"`
 	OriginalURL     string                    `json:"original_url"`
 	Title           string                    `json:"title,omitempty"`
 	Description     string                    `json:"description,omitempty"`
+	FaviconURL      string                    `json:"favicon_url,omitempty"`
 	IsActive        bool                      `json:"is_active"`
 	ExpiresAt       *time.Time                `json:"expires_at"`
 	CreatedAt       time.Time                 `json:"created_at"`
 	UpdatedAt       time.Time                 `json:"updated_at,omitempty"`
+	SocialCard      *SocialCard               `json:"social_card,omitempty"`
+	DeepLinks       *DeepLinks                `json:"deep_links,omitempty"`
+	CollectionID    *string                   `json:"collection_id,omitempty"`
+	Tags            []LinkTagResponse         `json:"tags,omitempty"`
 	ShortLinkDetail *ShortLinkDetailsResponse `json:"detail,omitempty"`
 }
 type ShortLinkDetailsResponse struct {
-	ID            string  `json:"id"`
-	Passcode      int     `json:"passcode,omitempty"`
-	ClickLimit    int     `json:"click_limit,omitempty"`
-	CurrentClicks int     `json:"current_clicks,omitempty"`
-	EnableStats   bool    `json:"enable_stats,omitempty"`
-	CustomDomain  string  `json:"custom_domain,omitempty"`
-	UTMSource     string  `json:"utm_source,omitempty"`
-	UTMMedium     string  `json:"utm_medium,omitempty"`
-	UTMCampaign   string  `json:"utm_campaign,omitempty"`
-	UTMTerm       string  `json:"utm_term,omitempty"`
-	UTMContent    string  `json:"utm_content,omitempty"`
-	IsBanned      bool    `json:"is_banned,omitempty"`
-	BannedReason  string  `json:"banned_reason,omitempty"`
-	BannedBy      *string `json:"banned_by,omitempty"`
+	ID                 string  `json:"id"`
+	HasPasscode        bool    `json:"has_passcode"`
+	ClickLimit         int     `json:"click_limit,omitempty"`
+	CurrentClicks      int     `json:"current_clicks,omitempty"`
+	UniqueClicks       int     `json:"unique_clicks,omitempty"`
+	ClickLimitMode     string  `json:"click_limit_mode,omitempty"`
+	DedupWindowMinutes *int    `json:"dedup_window_minutes,omitempty"`
+	EnableStats        bool    `json:"enable_stats,omitempty"`
+	CustomDomain       string  `json:"custom_domain,omitempty"`
+	UTMSource          string  `json:"utm_source,omitempty"`
+	UTMMedium          string  `json:"utm_medium,omitempty"`
+	UTMCampaign        string  `json:"utm_campaign,omitempty"`
+	UTMTerm            string  `json:"utm_term,omitempty"`
+	UTMContent         string  `json:"utm_content,omitempty"`
+	IsBanned           bool    `json:"is_banned,omitempty"`
+	BannedReason       string  `json:"banned_reason,omitempty"`
+	BannedBy           *string `json:"banned_by,omitempty"`
+	PrivacyMode        string  `json:"privacy_mode,omitempty"`
+	HonorDNT           *bool   `json:"honor_dnt,omitempty"`
+	InterstitialMode   string  `json:"interstitial_mode,omitempty"`
+	IsFlagged          bool    `json:"is_flagged,omitempty"`
+	FlaggedReason      string  `json:"flagged_reason,omitempty"`
+
+	RedirectBehavior *RedirectBehavior `json:"redirect_behavior,omitempty"`
 }
 
 type ViewLinkDetailResponse struct {
-	ID        string    `json:"id"`
-	IPAddress string    `json:"ip_address,omitempty"`
-	UserAgent string    `json:"user_agent,omitempty"`
-	Referer   string    `json:"referer,omitempty"`
-	Country   string    `json:"country,omitempty"`
-	City      string    `json:"city,omitempty"`
-	Device    string    `json:"device,omitempty"`
-	Browser   string    `json:"browser,omitempty"`
-	OS        string    `json:"os,omitempty"`
-	ClickedAt time.Time `json:"clicked_at,omitempty"`
+	ID         string    `json:"id"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	Referer    string    `json:"referer,omitempty"`
+	Country    string    `json:"country,omitempty"`
+	Region     string    `json:"region,omitempty"`
+	City       string    `json:"city,omitempty"`
+	Latitude   *float64  `json:"latitude,omitempty"`
+	Longitude  *float64  `json:"longitude,omitempty"`
+	ASN        uint      `json:"asn,omitempty"`
+	ASOrg      string    `json:"as_organization,omitempty"`
+	Device     string    `json:"device,omitempty"`
+	Browser    string    `json:"browser,omitempty"`
+	OS         string    `json:"os,omitempty"`
+	IsBot      bool      `json:"is_bot"`
+	BotName    string    `json:"bot_name,omitempty"`
+	RuleID     string    `json:"rule_id,omitempty"`
+	VariantID  string    `json:"variant_id,omitempty"`
+	RevisionID string    `json:"revision_id,omitempty"`
+	Source     string    `json:"source,omitempty"`
+	ClickedAt  time.Time `json:"clicked_at,omitempty"`
 }
 
 type PaginatedViewLinkDetailResponse struct {
-	Views      []ViewLinkDetailResponse `json:"recent_views"`
-	TotalCount int64                    `json:"total_count"`
-	Page       int                      `json:"page"`
-	Limit      int                      `json:"limit"`
-	TotalPages int                      `json:"total_pages"`
-	Sort       string                   `json:"sort"`
-	OrderBy    string                   `json:"order_by"`
+	Views       []ViewLinkDetailResponse `json:"recent_views"`
+	TotalCount  int64                    `json:"total_count"`
+	Page        int                      `json:"page"`
+	Limit       int                      `json:"limit"`
+	TotalPages  int                      `json:"total_pages"`
+	Sort        string                   `json:"sort"`
+	OrderBy     string                   `json:"order_by"`
+	PrivacyMode string                   `json:"privacy_mode"`
 }
 
 type TopReferrer struct {
@@ -123,6 +251,13 @@ type TopDevice struct {
 	Count  int    `json:"count"`
 }
 
+// TopBot counts clicks from one bot or crawler; bots are left out of every
+// other statistic
+type TopBot struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
 type Country struct {
 	Country string `json:"country"`
 	Count   int    `json:"count"`
@@ -142,6 +277,12 @@ type ShortLinkWithStatsResponse struct {
 	TopCountries       []Country          `json:"top_countries"`
 	ClickHistory       []ClickHistoryItem `json:"click_history"`
 	ClickHistoryHourly []ClickHistoryItem `json:"click_history_hourly"`
+	BotClicks          int                `json:"bot_clicks"`
+	TopBots            []TopBot           `json:"top_bots"`
+	RuleHits           []RuleHit          `json:"rule_hits"`
+
+	// Variants is set when the link has A/B variants
+	Variants *LinkVariantsResponse `json:"variants,omitempty"`
 }
 
 type ClickHistoryItem struct {
@@ -165,6 +306,8 @@ type DashboardSummary struct {
 	TopDevices          []TopDevice        `json:"top_devices"`
 	TopReferrers        []TopReferrer      `json:"top_referrers"`
 	ClickHistory        []ClickHistoryItem `json:"click_history"`
+	BotClicks           int64              `json:"bot_clicks"`
+	TopBots             []TopBot           `json:"top_bots"`
 }
 
 // DashboardStatsResponse is the simplified response for dashboard stats endpoint
@@ -172,27 +315,84 @@ type DashboardStatsResponse struct {
 	Summary *DashboardSummary `json:"summary"`
 }
 
+// TimeseriesRequest is the query of the click time-series endpoint. from and
+// to accept RFC3339 timestamps or YYYY-MM-DD dates in tz; a date-only to
+// includes that whole day.
+type TimeseriesRequest struct {
+	From        string   `form:"from" label:"Dari" binding:"omitempty,max=35"`
+	To          string   `form:"to" label:"Sampai" binding:"omitempty,max=35"`
+	Granularity string   `form:"granularity" label:"Granularitas" binding:"omitempty,oneof=minute hour day week month"`
+	TZ          string   `form:"tz" label:"Zona Waktu" binding:"omitempty,max=64"`
+	Breakdown   []string `form:"breakdown" collection_format:"csv" label:"Rincian" binding:"omitempty,max=8,unique,dive,oneof=country device referrer browser os bot rule variant revision source"`
+}
+
+type TimeseriesPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Clicks    int64     `json:"clicks"`
+}
+
+// TimeseriesSeries is the zero-filled series of one breakdown value
+type TimeseriesSeries struct {
+	Value  string            `json:"value"`
+	Total  int64             `json:"total"`
+	Points []TimeseriesPoint `json:"points"`
+}
+
+type TimeseriesBreakdown struct {
+	Dimension string             `json:"dimension"`
+	Series    []TimeseriesSeries `json:"series"`
+}
+
+type ShortLinkTimeseriesResponse struct {
+	ShortCode   string                `json:"short"`
+	From        time.Time             `json:"from"`
+	To          time.Time             `json:"to"`
+	Granularity string                `json:"granularity"`
+	Timezone    string                `json:"timezone"`
+	Total       int64                 `json:"total"`
+	Points      []TimeseriesPoint     `json:"points"`
+	Breakdowns  []TimeseriesBreakdown `json:"breakdowns,omitempty"`
+}
+
 type PaginatedShortLinkDetailWithStatsResponse struct {
 	Views PaginatedViewLinkDetailResponse `json:"views"`
 }
 
 // UpdateShortLinkRequest represents request to update short link
 type UpdateShortLinkRequest struct {
-	Title        *string    `json:"title,omitempty" label:"Judul" binding:"omitempty,max=255,min=3"`
-	Description  *string    `json:"description,omitempty" label:"Deskripsi" binding:"omitempty,max=500,min=3"`
-	ShortCode    *string    `json:"short_code,omitempty" label:"Kode Pendek" binding:"omitempty,min=3,max=100,no_space,saveurlshort"`
-	IsActive     *bool      `json:"is_active,omitempty" label:"Status Aktif" binding:"omitempty"`
-	ExpiresAt    *time.Time `json:"expires_at,omitempty" label:"Tanggal Kadaluarsa" binding:"omitempty,gt=now"`
-	ExpiresAtSet bool       `json:"-"`
-	Passcode     *string    `json:"passcode,omitempty" label:"Kode Akses" binding:"omitempty,len=6,numeric,not_same_digit"`
-	ClickLimit   *int       `json:"click_limit,omitempty" label:"Batas Klik" binding:"omitempty,min=0"` // 0 means unlimited
-	EnableStats  *bool      `json:"enable_stats,omitempty" label:"Aktifkan Statistik" binding:"omitempty"`
-	CustomDomain *string    `json:"custom_domain,omitempty" label:"Domain Kustom" binding:"omitempty,url"`
-	UTMSource    *string    `json:"utm_source,omitempty" label:"UTM Source" binding:"omitempty"`
-	UTMMedium    *string    `json:"utm_medium,omitempty" label:"UTM Medium" binding:"omitempty"`
-	UTMCampaign  *string    `json:"utm_campaign,omitempty" label:"UTM Campaign" binding:"omitempty"`
-	UTMTerm      *string    `json:"utm_term,omitempty" label:"UTM Term" binding:"omitempty"`
-	UTMContent   *string    `json:"utm_content,omitempty" label:"UTM Content" binding:"omitempty"`
+	OriginalURL        *string    `json:"original_url,omitempty" label:"URL Asli" binding:"omitempty,url"`
+	Title              *string    `json:"title,omitempty" label:"Judul" binding:"omitempty,max=255,min=3"`
+	Description        *string    `json:"description,omitempty" label:"Deskripsi" binding:"omitempty,max=500,min=3"`
+	ShortCode          *string    `json:"short_code,omitempty" label:"Kode Pendek" binding:"omitempty,min=3,max=100,no_space,saveurlshort"`
+	IsActive           *bool      `json:"is_active,omitempty" label:"Status Aktif" binding:"omitempty"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty" label:"Tanggal Kadaluarsa" binding:"omitempty,gt=now"`
+	ExpiresAtSet       bool       `json:"-"`
+	Passcode           *string    `json:"passcode,omitempty" label:"Kode Akses" binding:"omitempty,len=6,numeric,not_same_digit"`
+	ClickLimit         *int       `json:"click_limit,omitempty" label:"Batas Klik" binding:"omitempty,min=0"` // 0 means unlimited
+	EnableStats        *bool      `json:"enable_stats,omitempty" label:"Aktifkan Statistik" binding:"omitempty"`
+	CustomDomain       *string    `json:"custom_domain,omitempty" label:"Domain Kustom" binding:"omitempty,max=255"`
+	UTMSource          *string    `json:"utm_source,omitempty" label:"UTM Source" binding:"omitempty"`
+	UTMMedium          *string    `json:"utm_medium,omitempty" label:"UTM Medium" binding:"omitempty"`
+	UTMCampaign        *string    `json:"utm_campaign,omitempty" label:"UTM Campaign" binding:"omitempty"`
+	UTMTerm            *string    `json:"utm_term,omitempty" label:"UTM Term" binding:"omitempty"`
+	UTMContent         *string    `json:"utm_content,omitempty" label:"UTM Content" binding:"omitempty"`
+	PrivacyMode        *string    `json:"privacy_mode,omitempty" label:"Mode Privasi" binding:"omitempty,oneof=inherit off hash truncate"` // inherit falls back to the global default
+	HonorDNT           *bool      `json:"honor_dnt,omitempty" label:"Hormati Do-Not-Track" binding:"omitempty"`
+	ClickLimitMode     *string    `json:"click_limit_mode,omitempty" label:"Mode Batas Klik" binding:"omitempty,oneof=total unique"`
+	DedupWindowMinutes *int       `json:"dedup_window_minutes,omitempty" label:"Jendela Klik Unik" binding:"omitempty,min=0,max=10080"`
+	VariantMode        *string    `json:"variant_mode,omitempty" label:"Mode Varian" binding:"omitempty,oneof=cookie ip random"`
+	InterstitialMode   *string    `json:"interstitial_mode,omitempty" label:"Mode Halaman Peringatan" binding:"omitempty,oneof=inherit on off"` // inherit falls back to the global setting
+	// SocialCard replaces all four card tags; empty values clear them
+	SocialCard *SocialCard `json:"social_card,omitempty" label:"Kartu Sosial"`
+	// DeepLinks replaces the deep links; empty values remove them
+	DeepLinks *DeepLinks `json:"deep_links,omitempty" label:"Deep Link"`
+	// RedirectBehavior replaces the redirect behaviour; empty values restore
+	// the defaults
+	RedirectBehavior *RedirectBehavior `json:"redirect_behavior,omitempty" label:"Perilaku Redirect"`
+	// TagIDs replaces the link's tags; an empty list removes them all
+	TagIDs *[]string `json:"tag_ids,omitempty" label:"Tag" binding:"omitempty,max=20,unique,dive,uuid"`
+	// CollectionID moves the link to a collection; empty takes it out
+	CollectionID *string `json:"collection_id,omitempty" label:"Koleksi" binding:"omitempty,uuid"`
 }
 
 // UnmarshalJSON records whether expires_at was present in the payload.
@@ -260,8 +460,7 @@ type DeleteRequest struct {
 }
 
 type CodeRequest struct {
-	Code     string `json:"code" label:"Kode Short Link" binding:"required,min=1,max=100,no_space,saveurlshort" uri:"code"`
-	Passcode string `json:"passcode" label:"Kode Akses" binding:"omitempty,len=6,numeric,not_same_digit" uri:"passcode"`
+	Code string `json:"code" label:"Kode Short Link" binding:"required,min=1,max=100,no_space,saveurlshort" uri:"code"`
 }
 
 // ShortLinkPreviewResponse contains only the public information needed to let a
@@ -284,6 +483,58 @@ type BannedRequest struct {
 	Reason string `json:"reason" label:"Alasan Pemblokiran" binding:"required,min=3,max=255,no_special"`
 }
 
-type PasscodeRequest struct {
-	Passcode int `form:"passcode" label:"Kode Akses" binding:"omitempty,six_digit"`
+// FlagRequest forces the interstitial warning on a link; Reason is shown to
+// visitors.
+type FlagRequest struct {
+	Reason string `json:"reason" label:"Alasan Peringatan" binding:"required,min=3,max=255"`
+}
+
+// InterstitialPolicyResponse is the admin-global interstitial setting.
+type InterstitialPolicyResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// UpdateInterstitialPolicyRequest switches the interstitial on or off for
+// every link that inherits the global setting.
+type UpdateInterstitialPolicyRequest struct {
+	Enabled *bool `json:"enabled" label:"Aktifkan Halaman Peringatan" binding:"required"`
+}
+
+// ShortCodeSettingsResponse is how codes are generated for the caller's links.
+type ShortCodeSettingsResponse struct {
+	Prefix   string `json:"prefix"`
+	Strategy string `json:"strategy"`
+	Length   int    `json:"length"`
+}
+
+// UpdateShortCodeSettingsRequest sets the prefix of the caller's generated
+// codes; an empty prefix removes it.
+type UpdateShortCodeSettingsRequest struct {
+	Prefix string `json:"prefix" label:"Awalan Kode" binding:"omitempty,max=20,saveurlshort"`
+}
+
+// ReservedCodeResponse is a word short codes may not use.
+type ReservedCodeResponse struct {
+	ID        uint      `json:"id"`
+	Word      string    `json:"word"`
+	Kind      string    `json:"kind"`
+	CreatedBy *string   `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListReservedCodesRequest filters the reserved code list by kind.
+type ListReservedCodesRequest struct {
+	Kind string `form:"kind" label:"Jenis" binding:"omitempty,oneof=reserved blocked"`
+}
+
+// CreateReservedCodeRequest adds a word codes may not use: reserved words
+// cannot be a whole code, blocked ones cannot appear anywhere in a code.
+type CreateReservedCodeRequest struct {
+	Word string `json:"word" label:"Kata" binding:"required,min=2,max=100,saveurlshort"`
+	Kind string `json:"kind" label:"Jenis" binding:"required,oneof=reserved blocked"`
+}
+
+// ReservedCodeIDRequest identifies a reserved code entry.
+type ReservedCodeIDRequest struct {
+	ID uint `uri:"id" label:"ID" binding:"required,min=1"`
 }
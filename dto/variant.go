@@ -0,0 +1,40 @@
+package dto
+
+import "time"
+
+// LinkVariantRequest creates an A/B variant or replaces an existing one
+type LinkVariantRequest struct {
+	Name           string `json:"name,omitempty" label:"Nama Varian" binding:"omitempty,max=100"`
+	DestinationURL string `json:"destination_url" label:"URL Tujuan" binding:"required,url,max=2048"`
+	Weight         int    `json:"weight" label:"Bobot" binding:"required,min=1,max=10000"`
+	IsActive       *bool  `json:"is_active,omitempty" label:"Aktif"`
+}
+
+// LinkVariantURIRequest binds the short code and variant ID from the URI
+type LinkVariantURIRequest struct {
+	Code      string `json:"code" uri:"code" label:"Kode Short Link" binding:"required,min=1,max=100,no_space,saveurlshort"`
+	VariantID string `json:"variant_id" uri:"variantID" label:"ID Varian" binding:"required,uuid"`
+}
+
+// LinkVariantResponse represents an A/B variant with its traffic share and
+// click counts. Share is the percentage of split traffic the variant receives
+// given the weights of the active variants.
+type LinkVariantResponse struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name,omitempty"`
+	DestinationURL string    `json:"destination_url"`
+	Weight         int       `json:"weight"`
+	Share          float64   `json:"share"`
+	IsActive       bool      `json:"is_active"`
+	Clicks         int64     `json:"clicks"`
+	UniqueVisitors int64     `json:"unique_visitors"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// LinkVariantsResponse lists the A/B variants of a link and how they stick to
+// a visitor
+type LinkVariantsResponse struct {
+	VariantMode string                `json:"variant_mode"`
+	Variants    []LinkVariantResponse `json:"variants"`
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/linkmeta"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlinkrepo "github.com/adehusnim37/lihatin-go/repositories/shortlink"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// FetchLinkMetadataJob retries the destination metadata fetch for links whose
+// fetch at creation failed, and picks up links created before fetching was
+// enabled.
+type FetchLinkMetadataJob struct {
+	repo        *shortlinkrepo.ShortLinkRepository
+	batchSize   int
+	maxAttempts int
+}
+
+// NewFetchLinkMetadataJob creates a new instance of the job. redisClient may
+// be nil; fetched titles then reach the redirect cache when its entry expires.
+func NewFetchLinkMetadataJob(db *gorm.DB, redisClient *redis.Client) *FetchLinkMetadataJob {
+	return &FetchLinkMetadataJob{
+		repo:        shortlinkrepo.NewShortLinkRepository(db).WithRedirectCache(shortlinkrepo.NewRedirectCache(redisClient)),
+		batchSize:   config.GetEnvAsInt(config.EnvLinkMetadataBatchSize, 100),
+		maxAttempts: config.GetEnvAsInt(config.EnvLinkMetadataMaxAttempts, 3),
+	}
+}
+
+// Name returns the job name for logging
+func (j *FetchLinkMetadataJob) Name() string {
+	return "fetch-link-metadata"
+}
+
+// Schedule returns when the job should run
+// Runs every 10 minutes
+func (j *FetchLinkMetadataJob) Schedule() string {
+	return config.GetEnvOrDefault("LINK_METADATA_RETRY_CRON", "0 */10 * * * *")
+}
+
+// Run executes the job logic
+func (j *FetchLinkMetadataJob) Run(ctx context.Context) error {
+	fetcher := linkmeta.Global()
+	if fetcher == nil || j.batchSize <= 0 {
+		return nil
+	}
+
+	result, err := j.repo.FetchPendingMetadata(ctx, fetcher, j.batchSize, j.maxAttempts)
+	if result.Fetched > 0 || result.Failed > 0 {
+		logger.Logger.Info("Fetched pending link metadata",
+			"fetched", result.Fetched,
+			"failed", result.Failed,
+		)
+	}
+	return err
+}
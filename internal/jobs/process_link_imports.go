@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/shortcode"
+	shortlinkrepo "github.com/adehusnim37/lihatin-go/repositories/shortlink"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// ProcessLinkImportsJob creates the links of uploaded import files. Each run
+// works through waiting imports one at a time until none is left; runs that
+// overlap take different imports.
+type ProcessLinkImportsJob struct {
+	repo      *shortlinkrepo.ShortLinkRepository
+	batchSize int
+}
+
+// NewProcessLinkImportsJob creates a new instance of the job. redisClient may
+// be nil. Imported links get their destination metadata from the
+// fetch-link-metadata job.
+func NewProcessLinkImportsJob(db *gorm.DB, redisClient *redis.Client) *ProcessLinkImportsJob {
+	return &ProcessLinkImportsJob{
+		repo: shortlinkrepo.NewShortLinkRepository(db).
+			WithRedirectCache(shortlinkrepo.NewRedirectCache(redisClient)).
+			WithCodeAllocator(shortcode.Global()),
+		batchSize: config.GetEnvAsInt(config.EnvLinkImportBatchSize, 100),
+	}
+}
+
+// Name returns the job name for logging
+func (j *ProcessLinkImportsJob) Name() string {
+	return "process-link-imports"
+}
+
+// Schedule returns when the job should run
+// Runs every 15 seconds
+func (j *ProcessLinkImportsJob) Schedule() string {
+	return config.GetEnvOrDefault("LINK_IMPORT_CRON", "*/15 * * * * *")
+}
+
+// Run executes the job logic
+func (j *ProcessLinkImportsJob) Run(ctx context.Context) error {
+	if j.batchSize <= 0 {
+		return nil
+	}
+
+	for {
+		result, err := j.repo.ProcessPendingImport(ctx, j.batchSize)
+		if result != nil && (result.Created > 0 || result.Failed > 0) {
+			logger.Logger.Info("Processed link import",
+				"import_id", result.ImportID,
+				"created", result.Created,
+				"failed", result.Failed,
+			)
+		}
+		if err != nil || result == nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/analytics"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// PruneRawClicksJob exports raw clicks past their retention to object storage
+// and removes them from view_link_details. Stats keep working from the rollups.
+type PruneRawClicksJob struct {
+	service *analytics.RetentionService
+}
+
+// NewPruneRawClicksJob creates a new instance of the job using the retention
+// policy and OSS settings from the environment
+func NewPruneRawClicksJob(db *gorm.DB) *PruneRawClicksJob {
+	policy := analytics.RetentionPolicyFromEnv()
+
+	var uploader analytics.ArchiveUploader
+	if policy.Enabled() && policy.Archive {
+		archive, err := storage.NewS3ClickArchiveStorageFromEnv()
+		if err != nil {
+			// Run refuses to delete anything until archive storage is configured
+			logger.Logger.Warn("Click archive storage unavailable", "error", err.Error())
+		} else {
+			uploader = archive
+		}
+	}
+
+	return &PruneRawClicksJob{service: analytics.NewRetentionService(db, policy, uploader)}
+}
+
+// Name returns the job name for logging
+func (j *PruneRawClicksJob) Name() string {
+	return "prune-raw-clicks"
+}
+
+// Schedule returns when the job should run
+// Runs daily at 03:30:00, outside the busiest redirect hours
+func (j *PruneRawClicksJob) Schedule() string {
+	return config.GetEnvOrDefault("CLICK_RETENTION_CRON", "0 30 3 * * *")
+}
+
+// Run executes the job logic
+func (j *PruneRawClicksJob) Run(ctx context.Context) error {
+	result, err := j.service.Run(ctx)
+	if result.Deleted > 0 {
+		logger.Logger.Info("Pruned raw clicks",
+			"deleted", result.Deleted,
+			"archived", result.Archived,
+			"objects", result.Objects,
+		)
+	}
+	return err
+}
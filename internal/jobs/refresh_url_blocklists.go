@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/urlsafety"
+)
+
+// RefreshURLBlocklistsJob refreshes the malware and phishing URL blocklists
+// from their sources.
+type RefreshURLBlocklistsJob struct{}
+
+// NewRefreshURLBlocklistsJob creates job instance.
+func NewRefreshURLBlocklistsJob() *RefreshURLBlocklistsJob {
+	return &RefreshURLBlocklistsJob{}
+}
+
+// Name returns job name.
+func (j *RefreshURLBlocklistsJob) Name() string {
+	return "refresh-url-blocklists"
+}
+
+// Schedule runs every hour at minute 5; phishing feeds change quickly.
+func (j *RefreshURLBlocklistsJob) Schedule() string {
+	return config.GetEnvOrDefault("URL_SAFETY_REFRESH_CRON", "0 5 * * * *")
+}
+
+// Run executes job.
+func (j *RefreshURLBlocklistsJob) Run(ctx context.Context) error {
+	policy := urlsafety.Global()
+	if policy == nil {
+		return nil
+	}
+	return policy.RefreshFromSource(ctx)
+}
@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/urlsafety"
+	shortlinkrepo "github.com/adehusnim37/lihatin-go/repositories/shortlink"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// RescanLinkSafetyJob re-checks existing short links against the current URL
+// safety policy and bans those whose destination has turned unsafe since they
+// were created.
+type RescanLinkSafetyJob struct {
+	repo      *shortlinkrepo.ShortLinkRepository
+	batchSize int
+}
+
+// NewRescanLinkSafetyJob creates a new instance of the job. redisClient may be
+// nil; banned links then leave the redirect cache when their entry expires.
+func NewRescanLinkSafetyJob(db *gorm.DB, redisClient *redis.Client) *RescanLinkSafetyJob {
+	return &RescanLinkSafetyJob{
+		repo:      shortlinkrepo.NewShortLinkRepository(db).WithRedirectCache(shortlinkrepo.NewRedirectCache(redisClient)),
+		batchSize: config.GetEnvAsInt(config.EnvURLSafetyRescanBatchSize, 500),
+	}
+}
+
+// Name returns the job name for logging
+func (j *RescanLinkSafetyJob) Name() string {
+	return "rescan-link-safety"
+}
+
+// Schedule returns when the job should run
+// Runs every hour at minute 20, after the blocklists were refreshed
+func (j *RescanLinkSafetyJob) Schedule() string {
+	return config.GetEnvOrDefault("URL_SAFETY_RESCAN_CRON", "0 20 * * * *")
+}
+
+// Run executes the job logic
+func (j *RescanLinkSafetyJob) Run(ctx context.Context) error {
+	policy := urlsafety.Global()
+	if policy == nil || j.batchSize <= 0 {
+		return nil
+	}
+
+	result, err := j.repo.RescanLinkSafety(ctx, policy, j.batchSize)
+	if result.Banned > 0 {
+		logger.Logger.Warn("Banned short links with unsafe destinations",
+			"scanned", result.Scanned,
+			"banned", result.Banned,
+		)
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/analytics"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// RollupClicksJob folds closed hours of raw clicks into the hourly and daily
+// rollup tables that back the stats endpoints
+type RollupClicksJob struct {
+	service *analytics.RollupService
+}
+
+// NewRollupClicksJob creates a new instance of the job
+func NewRollupClicksJob(db *gorm.DB) *RollupClicksJob {
+	return &RollupClicksJob{service: analytics.NewRollupService(db)}
+}
+
+// Name returns the job name for logging
+func (j *RollupClicksJob) Name() string {
+	return "rollup-clicks"
+}
+
+// Schedule returns when the job should run
+// Runs every 5 minutes so a closed hour is rolled up shortly after it ends
+func (j *RollupClicksJob) Schedule() string {
+	return config.GetEnvOrDefault("CLICK_ROLLUP_CRON", "0 */5 * * * *")
+}
+
+// Run executes the job logic
+func (j *RollupClicksJob) Run(ctx context.Context) error {
+	hours, err := j.service.Run(ctx)
+	if hours > 0 {
+		logger.Logger.Info("Rolled up click hours", "hours", hours)
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/domains"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// VerifyCustomDomainsJob re-checks custom domain ownership so domains whose
+// DNS no longer proves ownership stop routing short links.
+type VerifyCustomDomainsJob struct {
+	service *domains.Service
+}
+
+// NewVerifyCustomDomainsJob creates a new instance of the job
+func NewVerifyCustomDomainsJob(db *gorm.DB) *VerifyCustomDomainsJob {
+	return &VerifyCustomDomainsJob{service: domains.NewService(db, nil)}
+}
+
+// Name returns the job name for logging
+func (j *VerifyCustomDomainsJob) Name() string {
+	return "verify-custom-domains"
+}
+
+// Schedule returns when the job should run
+// Runs every hour at minute 15
+func (j *VerifyCustomDomainsJob) Schedule() string {
+	return config.GetEnvOrDefault("CUSTOM_DOMAIN_VERIFY_CRON", "0 15 * * * *")
+}
+
+// Run executes the job logic
+func (j *VerifyCustomDomainsJob) Run(ctx context.Context) error {
+	result, err := j.service.RecheckAll(ctx)
+	if result.Checked > 0 || result.Released > 0 {
+		logger.Logger.Info("Re-checked custom domains",
+			"checked", result.Checked,
+			"verified", result.Verified,
+			"failed", result.Failed,
+			"released", result.Released,
+		)
+	}
+	return err
+}
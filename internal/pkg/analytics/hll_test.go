@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestSketchEstimate(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{0, 1, 50, 1000, 20000} {
+		sketch := NewSketch()
+		for i := 0; i < n; i++ {
+			sketch.Add(fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+			sketch.Add(fmt.Sprintf("10.0.%d.%d", i/256, i%256)) // duplicates must not count
+		}
+
+		got := sketch.Estimate()
+		if tolerance := math.Max(1, float64(n)*0.05); math.Abs(float64(got-int64(n))) > tolerance {
+			t.Errorf("Estimate() with %d keys = %d, want within %.0f", n, got, tolerance)
+		}
+	}
+}
+
+func TestSketchMergeAndMarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		keys int
+	}{
+		{name: "empty", keys: 0},
+		{name: "sparse", keys: 20},
+		{name: "dense", keys: 5000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, b := NewSketch(), NewSketch()
+			for i := 0; i < tt.keys; i++ {
+				if i%2 == 0 {
+					a.Add(fmt.Sprintf("visitor-%d", i))
+				} else {
+					b.Add(fmt.Sprintf("visitor-%d", i))
+				}
+			}
+			a.Merge(b)
+
+			data, err := a.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			var decoded Sketch
+			if err := decoded.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+			if decoded.registers != a.registers {
+				t.Fatal("decoded sketch differs from the original")
+			}
+		})
+	}
+}
+
+func TestSketchUnmarshalRejectsCorruptData(t *testing.T) {
+	t.Parallel()
+
+	for _, data := range [][]byte{{hllFormatDense, 1, 2}, {hllFormatSparse, 0}, {hllFormatSparse, 0xff, 0xff, 1}, {9}} {
+		var sketch Sketch
+		if err := sketch.UnmarshalBinary(data); err == nil {
+			t.Errorf("UnmarshalBinary(%v) error = nil, want error", data)
+		}
+	}
+}
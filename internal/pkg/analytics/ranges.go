@@ -0,0 +1,197 @@
+package analytics
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Labels used for empty dimension values, matching what the stats endpoints
+// have always returned.
+const (
+	UnknownValue   = "Unknown"
+	DirectReferrer = "Direct / None"
+	// DefaultRule is the rule dimension value of clicks that went to the
+	// link's original URL.
+	DefaultRule = "default"
+	// InitialRevision is the revision dimension value of clicks made before
+	// the link's first change was recorded, while its first revision was in
+	// effect.
+	InitialRevision = "initial"
+	// LinkSource is the source dimension value of ordinary clicks, made by
+	// following the link rather than scanning its QR code.
+	LinkSource = "link"
+)
+
+// timeRange is a half-open interval [From, To). A zero From means "since the
+// beginning".
+type timeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r timeRange) empty() bool {
+	return !r.To.After(r.From)
+}
+
+// rangePlan says which table serves which part of a query range.
+type rangePlan struct {
+	Hourly []timeRange
+	Daily  *timeRange
+	Raw    *timeRange
+}
+
+// planRange splits [from, to) so that whole days before rolledUntil are read
+// from the daily rollups, the remaining whole hours from the hourly rollups,
+// and anything at or after rolledUntil (the current partial hour) from the raw
+// view_link_details table. from is aligned down to the hour, so rolled-up
+// ranges have hour granularity.
+func planRange(from, to, rolledUntil time.Time, loc *time.Location) rangePlan {
+	var plan rangePlan
+	if !from.IsZero() {
+		from = truncateHour(from)
+	}
+
+	rolledEnd := truncateHour(to)
+	if rolledUntil.Before(rolledEnd) {
+		rolledEnd = rolledUntil
+	}
+
+	if raw := (timeRange{From: maxTime(from, rolledEnd), To: to}); !raw.empty() {
+		plan.Raw = &raw
+	}
+
+	if !rolledEnd.After(from) {
+		return plan
+	}
+
+	firstDay := from
+	if !from.IsZero() {
+		firstDay = startOfDay(from, loc)
+		if firstDay.Before(from) {
+			firstDay = firstDay.AddDate(0, 0, 1)
+		}
+	}
+	lastDay := startOfDay(rolledEnd, loc)
+
+	if !lastDay.After(firstDay) {
+		plan.Hourly = append(plan.Hourly, timeRange{From: from, To: rolledEnd})
+		return plan
+	}
+
+	if lead := (timeRange{From: from, To: firstDay}); !lead.empty() {
+		plan.Hourly = append(plan.Hourly, lead)
+	}
+	plan.Daily = &timeRange{From: firstDay, To: lastDay}
+	if tail := (timeRange{From: lastDay, To: rolledEnd}); !tail.empty() {
+		plan.Hourly = append(plan.Hourly, tail)
+	}
+	return plan
+}
+
+// ParseGranularity validates a granularity name.
+func ParseGranularity(raw string) (Granularity, bool) {
+	switch g := Granularity(strings.ToLower(strings.TrimSpace(raw))); g {
+	case GranularityMinute, GranularityHour, GranularityDay, GranularityWeek, GranularityMonth:
+		return g, true
+	}
+	return "", false
+}
+
+// BucketStart returns the start of the granularity bucket containing t in
+// loc. Weeks start on Monday.
+func BucketStart(t time.Time, granularity Granularity, loc *time.Location) time.Time {
+	local := t.In(loc)
+	year, month, day := local.Date()
+	switch granularity {
+	case GranularityMinute:
+		return time.Date(year, month, day, local.Hour(), local.Minute(), 0, 0, loc)
+	case GranularityHour:
+		return time.Date(year, month, day, local.Hour(), 0, 0, 0, loc)
+	case GranularityWeek:
+		offset := (int(local.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, loc)
+	case GranularityMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+}
+
+// NextBucket returns the start of the bucket following the one starting at
+// start.
+func NextBucket(start time.Time, granularity Granularity) time.Time {
+	switch granularity {
+	case GranularityMinute:
+		return start.Add(time.Minute)
+	case GranularityHour:
+		return start.Add(time.Hour)
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// Buckets lists the bucket starts covering [from, to). ok is false when there
+// would be more than limit buckets.
+func Buckets(from, to time.Time, granularity Granularity, loc *time.Location, limit int) (starts []time.Time, ok bool) {
+	for start := BucketStart(from, granularity, loc); start.Before(to); start = NextBucket(start, granularity) {
+		if len(starts) == limit {
+			return nil, false
+		}
+		starts = append(starts, start)
+	}
+	return starts, true
+}
+
+func truncateHour(t time.Time) time.Time {
+	return t.Truncate(time.Hour)
+}
+
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// ReferrerHost reduces a Referer header to its host, the way the stats
+// endpoints group referrers.
+func ReferrerHost(referer string) string {
+	referer = strings.TrimSpace(referer)
+	if referer == "" {
+		return DirectReferrer
+	}
+	if u, err := url.Parse(referer); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return referer
+}
+
+// dimensionValue normalizes a raw column value for a rollup dimension.
+func dimensionValue(dimension, raw string) string {
+	value := strings.TrimSpace(raw)
+	if dimension == dimensionReferrer {
+		value = ReferrerHost(value)
+	} else if dimension == dimensionRule && value == "" {
+		value = DefaultRule
+	} else if dimension == dimensionRevision && value == "" {
+		value = InitialRevision
+	} else if dimension == dimensionSource && value == "" {
+		value = LinkSource
+	} else if value == "" {
+		value = UnknownValue
+	}
+	if len(value) > maxDimensionValueLength {
+		value = strings.ToValidUTF8(value[:maxDimensionValueLength], "")
+	}
+	return value
+}
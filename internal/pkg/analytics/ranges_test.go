@@ -0,0 +1,173 @@
+package analytics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanRange(t *testing.T) {
+	t.Parallel()
+
+	wib := time.FixedZone("WIB", 7*60*60)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, wib)
+	}
+	span := func(from, to time.Time) timeRange { return timeRange{From: from, To: to} }
+	ptr := func(r timeRange) *timeRange { return &r }
+
+	tests := []struct {
+		name        string
+		from, to    time.Time
+		rolledUntil time.Time
+		want        rangePlan
+	}{
+		{
+			name:        "nothing rolled up reads raw",
+			from:        at(10, 8, 0),
+			to:          at(12, 9, 30),
+			rolledUntil: time.Time{},
+			want:        rangePlan{Raw: ptr(span(at(10, 8, 0), at(12, 9, 30)))},
+		},
+		{
+			name:        "same day uses hourly and raw for the partial hour",
+			from:        at(12, 2, 45),
+			to:          at(12, 9, 30),
+			rolledUntil: at(12, 9, 0),
+			want: rangePlan{
+				Hourly: []timeRange{span(at(12, 2, 0), at(12, 9, 0))},
+				Raw:    ptr(span(at(12, 9, 0), at(12, 9, 30))),
+			},
+		},
+		{
+			name:        "whole days come from the daily rollup",
+			from:        at(10, 8, 0),
+			to:          at(12, 9, 30),
+			rolledUntil: at(12, 9, 0),
+			want: rangePlan{
+				Hourly: []timeRange{span(at(10, 8, 0), at(11, 0, 0)), span(at(12, 0, 0), at(12, 9, 0))},
+				Daily:  ptr(span(at(11, 0, 0), at(12, 0, 0))),
+				Raw:    ptr(span(at(12, 9, 0), at(12, 9, 30))),
+			},
+		},
+		{
+			name:        "lagging watermark widens the raw part",
+			from:        at(12, 0, 0),
+			to:          at(12, 9, 30),
+			rolledUntil: at(12, 6, 0),
+			want: rangePlan{
+				Hourly: []timeRange{span(at(12, 0, 0), at(12, 6, 0))},
+				Raw:    ptr(span(at(12, 6, 0), at(12, 9, 30))),
+			},
+		},
+		{
+			name:        "all time starts from the first daily bucket",
+			from:        time.Time{},
+			to:          at(12, 9, 0),
+			rolledUntil: at(12, 9, 0),
+			want: rangePlan{
+				Hourly: []timeRange{span(at(12, 0, 0), at(12, 9, 0))},
+				Daily:  ptr(span(time.Time{}, at(12, 0, 0))),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := planRange(tt.from, tt.to, tt.rolledUntil, wib)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("planRange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDimensionValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dimension string
+		raw       string
+		want      string
+	}{
+		{dimensionReferrer, "", DirectReferrer},
+		{dimensionReferrer, "https://news.ycombinator.com/item?id=1", "news.ycombinator.com"},
+		{dimensionReferrer, "android-app://com.slack", "com.slack"},
+		{dimensionReferrer, "not a url", "not a url"},
+		{dimensionRule, "", DefaultRule},
+		{dimensionRule, "rule-1", "rule-1"},
+		{dimensionRevision, "", InitialRevision},
+		{dimensionRevision, "revision-1", "revision-1"},
+		{dimensionSource, "", LinkSource},
+		{dimensionSource, "qr", "qr"},
+		{"country", "  ", UnknownValue},
+		{"country", "Indonesia", "Indonesia"},
+		{"browser", strings.Repeat("é", 200), strings.Repeat("é", 95)},
+	}
+
+	for _, tt := range tests {
+		if got := dimensionValue(tt.dimension, tt.raw); got != tt.want {
+			t.Errorf("dimensionValue(%q, %q) = %q, want %q", tt.dimension, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	t.Parallel()
+
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	// Thursday 2026-03-12 01:30 WIB is still Wednesday in UTC
+	at := time.Date(2026, time.March, 11, 18, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		granularity Granularity
+		want        time.Time
+	}{
+		{GranularityMinute, time.Date(2026, time.March, 12, 1, 30, 0, 0, jakarta)},
+		{GranularityHour, time.Date(2026, time.March, 12, 1, 0, 0, 0, jakarta)},
+		{GranularityDay, time.Date(2026, time.March, 12, 0, 0, 0, 0, jakarta)},
+		{GranularityWeek, time.Date(2026, time.March, 9, 0, 0, 0, 0, jakarta)},
+		{GranularityMonth, time.Date(2026, time.March, 1, 0, 0, 0, 0, jakarta)},
+	}
+
+	for _, tt := range tests {
+		if got := BucketStart(at, tt.granularity, jakarta); !got.Equal(tt.want) {
+			t.Errorf("BucketStart(%s) = %s, want %s", tt.granularity, got, tt.want)
+		}
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2026, time.January, 31, 12, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	got, ok := Buckets(from, to, GranularityMonth, time.UTC, 10)
+	want := []time.Time{
+		time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("Buckets(month) = %v, %v, want %v", got, ok, want)
+	}
+
+	if _, ok := Buckets(from, to, GranularityHour, time.UTC, 100); ok {
+		t.Fatal("Buckets(hour) over two months should exceed the limit")
+	}
+}
+
+func TestParseGranularity(t *testing.T) {
+	t.Parallel()
+
+	if g, ok := ParseGranularity(" Week "); !ok || g != GranularityWeek {
+		t.Fatalf("ParseGranularity(Week) = %q, %v", g, ok)
+	}
+	if _, ok := ParseGranularity("year"); ok {
+		t.Fatal("ParseGranularity(year) should fail")
+	}
+}
@@ -0,0 +1,420 @@
+package analytics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/adehusnim37/lihatin-go/models/user"
+	"gorm.io/gorm"
+)
+
+// ArchiveFormat is the encoding of exported raw clicks.
+type ArchiveFormat string
+
+const (
+	ArchiveNDJSON ArchiveFormat = "ndjson"
+	ArchiveCSV    ArchiveFormat = "csv"
+)
+
+const (
+	defaultRetentionBatchSize = 5000
+	maxRetentionBatchesPerRun = 200
+	defaultRetentionClass     = "default"
+)
+
+var errArchiveUnavailable = errors.New("click archive storage is not configured; refusing to delete raw clicks without exporting them")
+
+// ArchiveUploader stores one exported batch and returns its object key.
+type ArchiveUploader interface {
+	UploadArchive(ctx context.Context, name string, data []byte, contentType string) (string, error)
+}
+
+// RetentionPolicy says how long raw view_link_details rows are kept.
+// Aggregated stats live in the rollup tables and are not affected.
+type RetentionPolicy struct {
+	// DefaultDays applies to links of free users and anonymous links.
+	// Zero keeps raw clicks forever.
+	DefaultDays int
+	// TierDays overrides DefaultDays for links owned by users with active
+	// premium access of the given tier. Zero keeps that tier's clicks forever.
+	TierDays map[string]int
+	// Archive exports rows to object storage before they are deleted.
+	Archive   bool
+	Format    ArchiveFormat
+	BatchSize int
+}
+
+// RetentionPolicyFromEnv reads the retention policy. CLICK_RETENTION_TIER_DAYS
+// is a comma separated list of tier=days pairs, e.g. "premium=365,business=730".
+func RetentionPolicyFromEnv() RetentionPolicy {
+	format := ArchiveFormat(strings.ToLower(strings.TrimSpace(config.GetEnvOrDefault(config.EnvClickArchiveFormat, string(ArchiveNDJSON)))))
+	if format != ArchiveCSV {
+		format = ArchiveNDJSON
+	}
+
+	return RetentionPolicy{
+		DefaultDays: config.GetEnvAsInt(config.EnvClickRetentionDays, 0),
+		TierDays:    parseTierDays(config.GetEnvOrDefault(config.EnvClickRetentionTierDays, "")),
+		Archive:     config.GetEnvAsBool(config.EnvClickArchiveEnabled, true),
+		Format:      format,
+		BatchSize:   config.GetEnvAsInt(config.EnvClickRetentionBatchSize, defaultRetentionBatchSize),
+	}
+}
+
+func parseTierDays(raw string) map[string]int {
+	tiers := make(map[string]int)
+	for _, pair := range strings.Split(raw, ",") {
+		tier, days, ok := strings.Cut(pair, "=")
+		tier = strings.ToLower(strings.TrimSpace(tier))
+		if !ok || tier == "" {
+			continue
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(days))
+		if err != nil || value < 0 {
+			logger.Logger.Warn("Ignoring invalid click retention tier", "tier", tier, "days", days)
+			continue
+		}
+		tiers[tier] = value
+	}
+	return tiers
+}
+
+// Enabled reports whether any raw clicks are ever deleted.
+func (p RetentionPolicy) Enabled() bool {
+	if p.DefaultDays > 0 {
+		return true
+	}
+	for _, days := range p.TierDays {
+		if days > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RetentionResult summarizes one retention run.
+type RetentionResult struct {
+	Archived int64
+	Deleted  int64
+	Objects  int
+}
+
+// RetentionService exports and deletes raw clicks past their retention.
+type RetentionService struct {
+	db       *gorm.DB
+	policy   RetentionPolicy
+	uploader ArchiveUploader
+	now      func() time.Time
+}
+
+// NewRetentionService creates a retention service. uploader may be nil when
+// the policy does not archive.
+func NewRetentionService(db *gorm.DB, policy RetentionPolicy, uploader ArchiveUploader) *RetentionService {
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = defaultRetentionBatchSize
+	}
+	return &RetentionService{
+		db:       db,
+		policy:   policy,
+		uploader: uploader,
+		now:      time.Now,
+	}
+}
+
+// Run removes raw clicks older than their retention. A row is only removed
+// once it is counted in the rollup tables: its hour is before the rollup
+// watermark and the rollup has checked for late clicks since the row was
+// inserted, so a click inserted after its hour was rolled up has been rolled
+// up again.
+func (s *RetentionService) Run(ctx context.Context) (RetentionResult, error) {
+	var result RetentionResult
+	if !s.policy.Enabled() {
+		return result, nil
+	}
+	if s.policy.Archive && s.uploader == nil {
+		return result, errArchiveUnavailable
+	}
+
+	state, err := rollupState(ctx, s.db)
+	if err != nil {
+		return result, err
+	}
+	if state == nil || state.RolledUntil.IsZero() || state.LateCheckedUntil.IsZero() {
+		logger.Logger.Warn("Skipping click retention: clicks have not been rolled up yet")
+		return result, nil
+	}
+	rolledUntil := state.RolledUntil
+	// Rows inserted within the overlap may not have been visible to the last
+	// check yet; the next one looks at them again
+	insertedBefore := state.LateCheckedUntil.Add(-lateInsertOverlap)
+
+	now := s.now()
+	for _, class := range s.classes() {
+		if class.days <= 0 {
+			continue
+		}
+		cutoff := now.AddDate(0, 0, -class.days)
+		if rolledUntil.Before(cutoff) {
+			cutoff = rolledUntil
+		}
+
+		if err := s.pruneClass(ctx, class, cutoff, insertedBefore, &result); err != nil {
+			return result, fmt.Errorf("click retention for %s failed: %w", class.name, err)
+		}
+	}
+	return result, nil
+}
+
+type retentionClass struct {
+	name  string
+	days  int
+	scope func(tx *gorm.DB) *gorm.DB
+}
+
+// classes splits links by owner tier. Tier membership is evaluated at run
+// time, so a user who loses premium falls back to the default retention.
+func (s *RetentionService) classes() []retentionClass {
+	tiers := make([]string, 0, len(s.policy.TierDays))
+	for tier := range s.policy.TierDays {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+
+	premiumLinks := func(tiers []string) *gorm.DB {
+		return s.db.Table("short_links AS sl").
+			Select("sl.id").
+			Joins("JOIN user_premium_access AS pa ON pa.user_id = sl.user_id").
+			Where("pa.tier IN ? AND pa.status = ?", tiers, user.PremiumAccessStatusActive).
+			Where("(pa.expires_at IS NULL OR pa.expires_at > ?)", s.now())
+	}
+
+	classes := []retentionClass{{
+		name: defaultRetentionClass,
+		days: s.policy.DefaultDays,
+		scope: func(tx *gorm.DB) *gorm.DB {
+			if len(tiers) == 0 {
+				return tx
+			}
+			return tx.Where("short_link_id NOT IN (?)", premiumLinks(tiers))
+		},
+	}}
+	for _, tier := range tiers {
+		tier := tier
+		classes = append(classes, retentionClass{
+			name: tier,
+			days: s.policy.TierDays[tier],
+			scope: func(tx *gorm.DB) *gorm.DB {
+				return tx.Where("short_link_id IN (?)", premiumLinks([]string{tier}))
+			},
+		})
+	}
+	return classes
+}
+
+func (s *RetentionService) pruneClass(ctx context.Context, class retentionClass, cutoff, insertedBefore time.Time, result *RetentionResult) error {
+	for batch := 0; batch < maxRetentionBatchesPerRun; batch++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var rows []shortlink.ViewLinkDetail
+		query := s.db.WithContext(ctx).Unscoped().Model(&shortlink.ViewLinkDetail{}).
+			Where("clicked_at < ? AND created_at < ?", cutoff, insertedBefore)
+		if err := class.scope(query).
+			Order("clicked_at ASC, id ASC").
+			Limit(s.policy.BatchSize).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		if s.policy.Archive {
+			data, err := encodeArchive(rows, s.policy.Format)
+			if err != nil {
+				return err
+			}
+			objectKey, err := s.uploader.UploadArchive(ctx, archiveName(class.name, s.policy.Format, rows[0].ClickedAt), data, "application/gzip")
+			if err != nil {
+				return err
+			}
+			result.Archived += int64(len(rows))
+			result.Objects++
+			logger.Logger.Info("Archived raw clicks",
+				"class", class.name,
+				"rows", len(rows),
+				"object_key", objectKey,
+			)
+		}
+
+		// Export happens before delete, so a failed delete only means the
+		// batch is exported again on the next run.
+		ids := make([]string, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		deleted := s.db.WithContext(ctx).Unscoped().Where("id IN ?", ids).Delete(&shortlink.ViewLinkDetail{})
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		result.Deleted += deleted.RowsAffected
+
+		if len(rows) < s.policy.BatchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// archiveName groups objects by the day of their oldest click.
+func archiveName(class string, format ArchiveFormat, oldest time.Time) string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s/%s/%d-%s.%s.gz",
+		class,
+		oldest.Format("2006/01/02"),
+		time.Now().UnixNano(),
+		hex.EncodeToString(suffix),
+		format,
+	)
+}
+
+// archiveRecord is the exported shape of one raw click.
+type archiveRecord struct {
+	ID             string     `json:"id"`
+	ShortLinkID    string     `json:"short_link_id"`
+	IPAddress      string     `json:"ip_address"`
+	VisitorHash    string     `json:"visitor_hash"`
+	UserAgent      string     `json:"user_agent"`
+	Referer        string     `json:"referer"`
+	Country        string     `json:"country"`
+	CountryCode    string     `json:"country_code"`
+	Region         string     `json:"region"`
+	City           string     `json:"city"`
+	Latitude       *float64   `json:"latitude"`
+	Longitude      *float64   `json:"longitude"`
+	ASN            uint       `json:"asn"`
+	ASOrganization string     `json:"as_organization"`
+	Device         string     `json:"device"`
+	Browser        string     `json:"browser"`
+	OS             string     `json:"os"`
+	IsBot          bool       `json:"is_bot"`
+	BotName        string     `json:"bot_name"`
+	RuleID         string     `json:"rule_id"`
+	VariantID      string     `json:"variant_id"`
+	RevisionID     string     `json:"revision_id"`
+	Source         string     `json:"source"`
+	ClickedAt      time.Time  `json:"clicked_at"`
+	DeletedAt      *time.Time `json:"deleted_at"`
+}
+
+var archiveCSVHeader = []string{
+	"id", "short_link_id", "ip_address", "user_agent", "referer",
+	"country", "country_code", "region", "city", "latitude", "longitude",
+	"asn", "as_organization", "device", "browser", "os", "clicked_at", "deleted_at", "visitor_hash",
+	"is_bot", "bot_name", "rule_id", "variant_id", "revision_id", "source",
+}
+
+func toArchiveRecord(row shortlink.ViewLinkDetail) archiveRecord {
+	record := archiveRecord{
+		ID:             row.ID,
+		ShortLinkID:    row.ShortLinkID,
+		IPAddress:      row.IPAddress,
+		VisitorHash:    row.VisitorHash,
+		UserAgent:      row.UserAgent,
+		Referer:        row.Referer,
+		Country:        row.Country,
+		CountryCode:    row.CountryCode,
+		Region:         row.Region,
+		City:           row.City,
+		Latitude:       row.Latitude,
+		Longitude:      row.Longitude,
+		ASN:            row.ASN,
+		ASOrganization: row.ASOrganization,
+		Device:         row.Device,
+		Browser:        row.Browser,
+		OS:             row.OS,
+		IsBot:          row.IsBot,
+		BotName:        row.BotName,
+		RuleID:         row.RuleID,
+		VariantID:      row.VariantID,
+		RevisionID:     row.RevisionID,
+		Source:         row.Source,
+		ClickedAt:      row.ClickedAt,
+	}
+	if row.DeletedAt.Valid {
+		deletedAt := row.DeletedAt.Time
+		record.DeletedAt = &deletedAt
+	}
+	return record
+}
+
+// encodeArchive renders rows as gzip-compressed NDJSON or CSV.
+func encodeArchive(rows []shortlink.ViewLinkDetail, format ArchiveFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+
+	switch format {
+	case ArchiveCSV:
+		w := csv.NewWriter(zw)
+		if err := w.Write(archiveCSVHeader); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if err := w.Write(archiveCSVRow(toArchiveRecord(row))); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	default:
+		enc := json.NewEncoder(zw)
+		for _, row := range rows {
+			if err := enc.Encode(toArchiveRecord(row)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func archiveCSVRow(r archiveRecord) []string {
+	formatFloat := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+	deletedAt := ""
+	if r.DeletedAt != nil {
+		deletedAt = r.DeletedAt.Format(time.RFC3339)
+	}
+	return []string{
+		r.ID, r.ShortLinkID, r.IPAddress, r.UserAgent, r.Referer,
+		r.Country, r.CountryCode, r.Region, r.City, formatFloat(r.Latitude), formatFloat(r.Longitude),
+		strconv.FormatUint(uint64(r.ASN), 10), r.ASOrganization, r.Device, r.Browser, r.OS,
+		r.ClickedAt.Format(time.RFC3339), deletedAt, r.VisitorHash,
+		strconv.FormatBool(r.IsBot), r.BotName, r.RuleID, r.VariantID, r.RevisionID, r.Source,
+	}
+}
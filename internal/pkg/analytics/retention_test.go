@@ -0,0 +1,121 @@
+package analytics
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+func TestParseTierDays(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want map[string]int
+	}{
+		{raw: "", want: map[string]int{}},
+		{raw: "premium=365", want: map[string]int{"premium": 365}},
+		{raw: " Premium = 365 , business=0,", want: map[string]int{"premium": 365, "business": 0}},
+		{raw: "premium=forever,business=-1,=30,pro", want: map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		if got := parseTierDays(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTierDays(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestRetentionPolicyEnabled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   bool
+	}{
+		{name: "keep forever", policy: RetentionPolicy{}, want: false},
+		{name: "default only", policy: RetentionPolicy{DefaultDays: 90}, want: true},
+		{name: "tier only", policy: RetentionPolicy{TierDays: map[string]int{"premium": 365}}, want: true},
+		{name: "tier keeps forever", policy: RetentionPolicy{TierDays: map[string]int{"premium": 0}}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.Enabled(); got != tt.want {
+			t.Errorf("%s: Enabled() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeArchive(t *testing.T) {
+	t.Parallel()
+
+	lat := -6.2
+	clickedAt := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	rows := []shortlink.ViewLinkDetail{
+		{ID: "v1", ShortLinkID: "l1", IPAddress: "203.0.113.7", Referer: "https://a.example/x,y", Country: "Indonesia", Latitude: &lat, ASN: 7713, ClickedAt: clickedAt},
+		{ID: "v2", ShortLinkID: "l1", UserAgent: "curl/8.0", Source: shortlink.ClickSourceQR, ClickedAt: clickedAt.Add(time.Minute)},
+	}
+
+	t.Run("ndjson", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := encodeArchive(rows, ArchiveNDJSON)
+		if err != nil {
+			t.Fatalf("encodeArchive() error = %v", err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(gunzip(t, data)))
+		var records []archiveRecord
+		for scanner.Scan() {
+			var record archiveRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+			}
+			records = append(records, record)
+		}
+		if len(records) != 2 || records[0].ID != "v1" || records[0].ASN != 7713 || *records[0].Latitude != lat || !records[1].ClickedAt.Equal(rows[1].ClickedAt) || records[1].Source != shortlink.ClickSourceQR {
+			t.Fatalf("decoded records = %+v", records)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := encodeArchive(rows, ArchiveCSV)
+		if err != nil {
+			t.Fatalf("encodeArchive() error = %v", err)
+		}
+		lines, err := csv.NewReader(bytes.NewReader(gunzip(t, data))).ReadAll()
+		if err != nil {
+			t.Fatalf("archive is not valid CSV: %v", err)
+		}
+		if len(lines) != 3 || !reflect.DeepEqual(lines[0], archiveCSVHeader) {
+			t.Fatalf("CSV lines = %v", lines)
+		}
+		if lines[1][4] != "https://a.example/x,y" || lines[1][9] != "-6.2" || lines[2][9] != "" || lines[2][len(archiveCSVHeader)-1] != shortlink.ClickSourceQR {
+			t.Fatalf("CSV row = %v", lines[1])
+		}
+	})
+}
+
+func gunzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("archive is not gzip: %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip: %v", err)
+	}
+	return out
+}
@@ -0,0 +1,506 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+const (
+	rollupStateName         = "view_link_details"
+	dimensionReferrer       = shortlink.RollupDimensionReferrer
+	dimensionRule           = shortlink.RollupDimensionRule
+	dimensionRevision       = shortlink.RollupDimensionRevision
+	dimensionSource         = shortlink.RollupDimensionSource
+	maxDimensionValueLength = 191
+
+	// rollupGrace keeps the rollup behind the wall clock so clicks still in
+	// the ingestion queue land in raw storage before their hour is closed.
+	rollupGrace = 5 * time.Minute
+
+	// lateInsertOverlap is how far before the previous check late clicks are
+	// looked for again. A click's created_at is set before its batch
+	// commits, so a row may become visible with a created_at that is already
+	// behind the last check.
+	lateInsertOverlap = 5 * time.Minute
+
+	defaultMaxHoursPerRun = 72
+	rollupInsertBatchSize = 500
+
+	// visitorKeyExpr identifies a visitor: the privacy hash when the click
+	// was hashed, otherwise the (possibly truncated) IP. Do-Not-Track clicks
+	// have neither and are left out of unique visitor counts.
+	visitorKeyExpr = "NULLIF(COALESCE(NULLIF(visitor_hash, ''), ip_address), '')"
+)
+
+// rollupDimension maps a rollup dimension to its view_link_details column.
+// Bots selects which clicks it covers: bot clicks only, or human clicks only
+// like the click totals.
+type rollupDimension struct {
+	Dimension string
+	Column    string
+	Bots      bool
+}
+
+var rollupDimensions = []rollupDimension{
+	{shortlink.RollupDimensionCountry, "country", false},
+	{shortlink.RollupDimensionReferrer, "referer", false},
+	{shortlink.RollupDimensionDevice, "device", false},
+	{shortlink.RollupDimensionBrowser, "browser", false},
+	{shortlink.RollupDimensionOS, "os", false},
+	{shortlink.RollupDimensionBot, "bot_name", true},
+	{shortlink.RollupDimensionRule, "rule_id", false},
+	{shortlink.RollupDimensionVariant, "variant_id", false},
+	{shortlink.RollupDimensionRevision, "revision_id", false},
+	{shortlink.RollupDimensionSource, "source", false},
+}
+
+// ErrUnknownDimension is returned for a dimension that is not rolled up.
+var ErrUnknownDimension = errors.New("unknown rollup dimension")
+
+func lookupDimension(dimension string) (rollupDimension, bool) {
+	for _, dim := range rollupDimensions {
+		if dim.Dimension == dimension {
+			return dim, true
+		}
+	}
+	return rollupDimension{}, false
+}
+
+// RollupService incrementally folds closed hours of view_link_details into the
+// hourly and daily rollup tables.
+type RollupService struct {
+	db             *gorm.DB
+	loc            *time.Location
+	maxHoursPerRun int
+	now            func() time.Time
+}
+
+// NewRollupService creates a rollup service. Day buckets follow the process
+// time zone, the same one the MySQL DSN uses.
+func NewRollupService(db *gorm.DB) *RollupService {
+	return &RollupService{
+		db:             db,
+		loc:            time.Local,
+		maxHoursPerRun: defaultMaxHoursPerRun,
+		now:            time.Now,
+	}
+}
+
+// WithMaxHoursPerRun bounds how many hours a single run may roll up, so a
+// large backfill is spread over several runs.
+func (s *RollupService) WithMaxHoursPerRun(hours int) *RollupService {
+	if hours > 0 {
+		s.maxHoursPerRun = hours
+	}
+	return s
+}
+
+// Run rolls up every closed hour since the last run, and again every hour
+// that got clicks inserted after it was rolled up, and returns how many
+// non-empty hours were written.
+func (s *RollupService) Run(ctx context.Context) (int, error) {
+	now := s.now()
+	limit := truncateHour(now.Add(-rollupGrace))
+
+	state, err := s.loadState(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	late, err := s.rollupLateHours(ctx, state, now)
+	if err != nil {
+		return late, err
+	}
+
+	cursor := state.RolledUntil
+	processed := 0
+	for cursor.Before(limit) && processed < s.maxHoursPerRun {
+		if err := ctx.Err(); err != nil {
+			return late + processed, err
+		}
+
+		next, found, err := s.nextClickHour(ctx, cursor, limit)
+		if err != nil {
+			return late + processed, err
+		}
+		if !found {
+			// Nothing left to roll up: jump the watermark to the last closed hour
+			state.RolledUntil = limit
+			return late + processed, s.saveState(s.db.WithContext(ctx), state)
+		}
+
+		if err := s.rollupHour(ctx, next, nil, state); err != nil {
+			return late + processed, fmt.Errorf("rollup of hour %s failed: %w", next.Format(time.RFC3339), err)
+		}
+		cursor = next.Add(time.Hour)
+		processed++
+	}
+
+	return late + processed, nil
+}
+
+// rollupLateHours rolls up again the hours before the watermark that got
+// clicks inserted since the previous check: spilled clicks replayed after a
+// restart, a queue backlog or retried batches all keep their original
+// clicked_at. It then moves LateCheckedUntil to checkUntil.
+func (s *RollupService) rollupLateHours(ctx context.Context, state *shortlink.ClickRollupState, checkUntil time.Time) (int, error) {
+	if state.LateCheckedUntil.IsZero() {
+		// Nothing was rolled up before this run could see it
+		state.LateCheckedUntil = checkUntil
+		return 0, s.saveState(s.db.WithContext(ctx), state)
+	}
+
+	hours, err := s.lateClickHours(ctx, state.LateCheckedUntil.Add(-lateInsertOverlap), checkUntil, state.RolledUntil)
+	if err != nil {
+		return 0, err
+	}
+	for i, hour := range hours {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		// Only the links with late clicks are rebuilt, so the rollups of
+		// links whose raw clicks were since pruned are left alone
+		if err := s.rollupHour(ctx, hour.start, hour.linkIDs, nil); err != nil {
+			return i, fmt.Errorf("rollup of late hour %s failed: %w", hour.start.Format(time.RFC3339), err)
+		}
+	}
+	if len(hours) > 0 {
+		logger.Logger.Info("Rolled up late click hours", "hours", len(hours))
+	}
+
+	state.LateCheckedUntil = checkUntil
+	return len(hours), s.saveState(s.db.WithContext(ctx), state)
+}
+
+// lateHour is an hour before the watermark and the links that got clicks in
+// it after it was rolled up.
+type lateHour struct {
+	start   time.Time
+	linkIDs []string
+}
+
+// lateClickHours returns the hours before rolledUntil of clicks inserted in
+// [from, to), oldest first.
+func (s *RollupService) lateClickHours(ctx context.Context, from, to, rolledUntil time.Time) ([]lateHour, error) {
+	// Group per minute in SQL and truncate in Go, as hour boundaries of the
+	// DSN time zone need not be whole hours of UTC
+	var rows []struct {
+		ShortLinkID string
+		Minute      string
+	}
+	if err := s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{}).
+		Select("short_link_id, DATE_FORMAT(clicked_at, '%Y-%m-%d %H:%i') AS minute").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("clicked_at < ?", rolledUntil).
+		Group("short_link_id, minute").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	links := make(map[time.Time]map[string]bool)
+	for _, row := range rows {
+		at, err := time.ParseInLocation("2006-01-02 15:04", row.Minute, s.loc)
+		if err != nil {
+			return nil, err
+		}
+		hour := truncateHour(at)
+		if links[hour] == nil {
+			links[hour] = make(map[string]bool)
+		}
+		links[hour][row.ShortLinkID] = true
+	}
+
+	hours := make([]lateHour, 0, len(links))
+	for start, ids := range links {
+		hour := lateHour{start: start, linkIDs: make([]string, 0, len(ids))}
+		for id := range ids {
+			hour.linkIDs = append(hour.linkIDs, id)
+		}
+		sort.Strings(hour.linkIDs)
+		hours = append(hours, hour)
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].start.Before(hours[j].start) })
+	return hours, nil
+}
+
+// RolledUntil returns the rollup watermark. A zero time means nothing has been
+// rolled up yet and every query falls back to the raw table.
+func RolledUntil(ctx context.Context, db *gorm.DB) (time.Time, error) {
+	state, err := rollupState(ctx, db)
+	if err != nil || state == nil {
+		return time.Time{}, err
+	}
+	return state.RolledUntil, nil
+}
+
+// rollupState returns the saved rollup state, or nil before the first run.
+func rollupState(ctx context.Context, db *gorm.DB) (*shortlink.ClickRollupState, error) {
+	var state shortlink.ClickRollupState
+	err := db.WithContext(ctx).Where("name = ?", rollupStateName).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *RollupService) loadState(ctx context.Context) (*shortlink.ClickRollupState, error) {
+	var state shortlink.ClickRollupState
+	err := s.db.WithContext(ctx).Where("name = ?", rollupStateName).First(&state).Error
+	if err == nil {
+		return &state, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// First run: start from the oldest raw click so history is backfilled
+	var oldest struct {
+		ClickedAt *time.Time
+	}
+	if err := s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{}).
+		Select("MIN(clicked_at) AS clicked_at").
+		Scan(&oldest).Error; err != nil {
+		return nil, err
+	}
+
+	state = shortlink.ClickRollupState{Name: rollupStateName, RolledUntil: truncateHour(s.now().Add(-rollupGrace))}
+	if oldest.ClickedAt != nil {
+		state.RolledUntil = truncateHour(*oldest.ClickedAt)
+	}
+	return &state, nil
+}
+
+func (s *RollupService) saveState(tx *gorm.DB, state *shortlink.ClickRollupState) error {
+	return tx.Save(state).Error
+}
+
+// nextClickHour finds the first hour in [from, limit) that has raw clicks.
+func (s *RollupService) nextClickHour(ctx context.Context, from, limit time.Time) (time.Time, bool, error) {
+	var next struct {
+		ClickedAt *time.Time
+	}
+	if err := s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{}).
+		Select("MIN(clicked_at) AS clicked_at").
+		Where("clicked_at >= ? AND clicked_at < ?", from, limit).
+		Scan(&next).Error; err != nil {
+		return time.Time{}, false, err
+	}
+	if next.ClickedAt == nil {
+		return time.Time{}, false, nil
+	}
+	return truncateHour(*next.ClickedAt), true, nil
+}
+
+type linkAggregate struct {
+	clicks  int64
+	unique  int64
+	sketch  *Sketch
+	dimVals map[string]map[string]int64
+}
+
+// rollupHour rebuilds the hourly rows of one hour and the daily rows of the
+// links it touched, then advances the watermark of state past it, all in one
+// transaction. linkIDs limits the rebuild to those links, and state is nil,
+// when an hour before the watermark is rebuilt for late clicks.
+func (s *RollupService) rollupHour(ctx context.Context, hour time.Time, linkIDs []string, state *shortlink.ClickRollupState) error {
+	end := hour.Add(time.Hour)
+	scope := func(tx *gorm.DB) *gorm.DB {
+		if linkIDs == nil {
+			return tx
+		}
+		return tx.Where("short_link_id IN ?", linkIDs)
+	}
+	aggregates := make(map[string]*linkAggregate)
+	aggregateFor := func(linkID string) *linkAggregate {
+		agg, ok := aggregates[linkID]
+		if !ok {
+			agg = &linkAggregate{sketch: NewSketch(), dimVals: make(map[string]map[string]int64)}
+			aggregates[linkID] = agg
+		}
+		return agg
+	}
+
+	var visitors []struct {
+		ShortLinkID string
+		VisitorKey  *string
+		Clicks      int64
+	}
+	if err := scope(s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{})).
+		Select("short_link_id, "+visitorKeyExpr+" AS visitor_key, COUNT(*) AS clicks").
+		Where("clicked_at >= ? AND clicked_at < ?", hour, end).
+		Where("is_bot = ?", false).
+		Group("short_link_id, visitor_key").
+		Scan(&visitors).Error; err != nil {
+		return err
+	}
+	for _, visitor := range visitors {
+		agg := aggregateFor(visitor.ShortLinkID)
+		agg.clicks += visitor.Clicks
+		if visitor.VisitorKey == nil {
+			continue
+		}
+		agg.unique++
+		agg.sketch.Add(*visitor.VisitorKey)
+	}
+
+	for _, dim := range rollupDimensions {
+		var rows []struct {
+			ShortLinkID string
+			Value       string
+			Clicks      int64
+		}
+		if err := scope(s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{})).
+			Select("short_link_id, "+dim.Column+" AS value, COUNT(*) AS clicks").
+			Where("clicked_at >= ? AND clicked_at < ?", hour, end).
+			Where("is_bot = ?", dim.Bots).
+			Group("short_link_id, " + dim.Column).
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			agg := aggregateFor(row.ShortLinkID)
+			values, ok := agg.dimVals[dim.Dimension]
+			if !ok {
+				values = make(map[string]int64)
+				agg.dimVals[dim.Dimension] = values
+			}
+			values[dimensionValue(dim.Dimension, row.Value)] += row.Clicks
+		}
+	}
+
+	hourlyRows := make([]shortlink.ClickRollupHourly, 0, len(aggregates))
+	dimensionRows := make([]shortlink.ClickRollupDimensionHourly, 0)
+	touched := make([]string, 0, len(aggregates))
+	for linkID, agg := range aggregates {
+		sketch, err := agg.sketch.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		touched = append(touched, linkID)
+		hourlyRows = append(hourlyRows, shortlink.ClickRollupHourly{ClickRollup: shortlink.ClickRollup{
+			ShortLinkID:    linkID,
+			BucketStart:    hour,
+			Clicks:         agg.clicks,
+			UniqueVisitors: agg.unique,
+			VisitorSketch:  sketch,
+		}})
+		for dimension, values := range agg.dimVals {
+			for value, clicks := range values {
+				dimensionRows = append(dimensionRows, shortlink.ClickRollupDimensionHourly{ClickRollupDimension: shortlink.ClickRollupDimension{
+					ShortLinkID: linkID,
+					BucketStart: hour,
+					Dimension:   dimension,
+					Value:       value,
+					Clicks:      clicks,
+				}})
+			}
+		}
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := scope(tx.Where("bucket_start = ?", hour)).Delete(&shortlink.ClickRollupHourly{}).Error; err != nil {
+			return err
+		}
+		if err := scope(tx.Where("bucket_start = ?", hour)).Delete(&shortlink.ClickRollupDimensionHourly{}).Error; err != nil {
+			return err
+		}
+		if len(hourlyRows) > 0 {
+			if err := tx.CreateInBatches(&hourlyRows, rollupInsertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(dimensionRows) > 0 {
+			if err := tx.CreateInBatches(&dimensionRows, rollupInsertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if err := s.rebuildDaily(tx, startOfDay(hour, s.loc), touched); err != nil {
+			return err
+		}
+
+		logger.Logger.Debug("Rolled up click hour",
+			"hour", hour.Format(time.RFC3339),
+			"links", len(touched),
+		)
+		if state == nil {
+			return nil
+		}
+		state.RolledUntil = end
+		return s.saveState(tx, state)
+	})
+}
+
+// rebuildDaily recomputes the daily rows of day for linkIDs from the hourly
+// rollups, merging visitor sketches so unique visitors stay correct.
+func (s *RollupService) rebuildDaily(tx *gorm.DB, day time.Time, linkIDs []string) error {
+	if len(linkIDs) == 0 {
+		return nil
+	}
+	dayEnd := day.AddDate(0, 0, 1)
+
+	var hourly []shortlink.ClickRollupHourly
+	if err := tx.Where("short_link_id IN ? AND bucket_start >= ? AND bucket_start < ?", linkIDs, day, dayEnd).
+		Find(&hourly).Error; err != nil {
+		return err
+	}
+
+	merged := make(map[string]*linkAggregate)
+	for _, row := range hourly {
+		agg, ok := merged[row.ShortLinkID]
+		if !ok {
+			agg = &linkAggregate{sketch: NewSketch()}
+			merged[row.ShortLinkID] = agg
+		}
+		agg.clicks += row.Clicks
+		var sketch Sketch
+		if err := sketch.UnmarshalBinary(row.VisitorSketch); err != nil {
+			return err
+		}
+		agg.sketch.Merge(&sketch)
+	}
+
+	dailyRows := make([]shortlink.ClickRollupDaily, 0, len(merged))
+	for linkID, agg := range merged {
+		sketch, err := agg.sketch.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		dailyRows = append(dailyRows, shortlink.ClickRollupDaily{ClickRollup: shortlink.ClickRollup{
+			ShortLinkID:    linkID,
+			BucketStart:    day,
+			Clicks:         agg.clicks,
+			UniqueVisitors: agg.sketch.Estimate(),
+			VisitorSketch:  sketch,
+		}})
+	}
+
+	if err := tx.Where("short_link_id IN ? AND bucket_start = ?", linkIDs, day).Delete(&shortlink.ClickRollupDaily{}).Error; err != nil {
+		return err
+	}
+	if len(dailyRows) > 0 {
+		if err := tx.CreateInBatches(&dailyRows, rollupInsertBatchSize).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("short_link_id IN ? AND bucket_start = ?", linkIDs, day).Delete(&shortlink.ClickRollupDimensionDaily{}).Error; err != nil {
+		return err
+	}
+	return tx.Exec(
+		"INSERT INTO click_rollup_dimensions_daily (short_link_id, bucket_start, dimension, value, clicks) "+
+			"SELECT short_link_id, ?, dimension, value, SUM(clicks) FROM click_rollup_dimensions_hourly "+
+			"WHERE short_link_id IN ? AND bucket_start >= ? AND bucket_start < ? "+
+			"GROUP BY short_link_id, dimension, value",
+		day, linkIDs, day, dayEnd,
+	).Error
+}
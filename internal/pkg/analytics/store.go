@@ -0,0 +1,395 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+// Granularity of a click history series.
+type Granularity string
+
+const (
+	GranularityMinute Granularity = "minute"
+	GranularityHour   Granularity = "hour"
+	GranularityDay    Granularity = "day"
+	GranularityWeek   Granularity = "week"
+	GranularityMonth  Granularity = "month"
+)
+
+// Totals is the click and unique-visitor count of a range.
+type Totals struct {
+	Clicks         int64
+	UniqueVisitors int64
+}
+
+// Point is one bucket of a click history series.
+type Point struct {
+	Start  time.Time
+	Clicks int64
+}
+
+// Store answers stats queries from the rollup tables, reading the raw
+// view_link_details table only for the part of a range that has not been
+// rolled up yet (normally the current partial hour).
+type Store struct {
+	db  *gorm.DB
+	loc *time.Location
+}
+
+// NewStore creates a stats reader. Day buckets follow the process time zone.
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db, loc: time.Local}
+}
+
+func (s *Store) plan(ctx context.Context, from, to time.Time, allowDaily bool) (rangePlan, error) {
+	rolledUntil, err := RolledUntil(ctx, s.db)
+	if err != nil {
+		return rangePlan{}, err
+	}
+	plan := planRange(from, to, rolledUntil, s.loc)
+	if !allowDaily && plan.Daily != nil {
+		plan.Hourly = append(plan.Hourly, *plan.Daily)
+		plan.Daily = nil
+	}
+	return plan, nil
+}
+
+func applyRange(q *gorm.DB, column string, r timeRange) *gorm.DB {
+	if !r.From.IsZero() {
+		q = q.Where(column+" >= ?", r.From)
+	}
+	return q.Where(column+" < ?", r.To)
+}
+
+// Clicks counts human clicks of linkIDs in [from, to). A zero from means all
+// time. Bot clicks are only reported through the bot dimension.
+func (s *Store) Clicks(ctx context.Context, linkIDs []string, from, to time.Time) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	plan, err := s.plan(ctx, from, to, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	sum := func(model any, r timeRange) error {
+		var value int64
+		q := s.db.WithContext(ctx).Model(model).
+			Select("COALESCE(SUM(clicks), 0)").
+			Where("short_link_id IN ?", linkIDs)
+		if err := applyRange(q, "bucket_start", r).Scan(&value).Error; err != nil {
+			return err
+		}
+		total += value
+		return nil
+	}
+
+	if plan.Daily != nil {
+		if err := sum(&shortlink.ClickRollupDaily{}, *plan.Daily); err != nil {
+			return 0, err
+		}
+	}
+	for _, r := range plan.Hourly {
+		if err := sum(&shortlink.ClickRollupHourly{}, r); err != nil {
+			return 0, err
+		}
+	}
+	if plan.Raw != nil {
+		var raw int64
+		q := s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{}).Where("short_link_id IN ? AND is_bot = ?", linkIDs, false)
+		if err := applyRange(q, "clicked_at", *plan.Raw).Count(&raw).Error; err != nil {
+			return 0, err
+		}
+		total += raw
+	}
+	return total, nil
+}
+
+// Totals returns human clicks and unique visitors of linkIDs in [from, to).
+// Unique visitors are estimated by merging the visitor sketches of every
+// bucket.
+func (s *Store) Totals(ctx context.Context, linkIDs []string, from, to time.Time) (Totals, error) {
+	var totals Totals
+	if len(linkIDs) == 0 {
+		return totals, nil
+	}
+
+	clicks, err := s.Clicks(ctx, linkIDs, from, to)
+	if err != nil {
+		return totals, err
+	}
+	totals.Clicks = clicks
+
+	plan, err := s.plan(ctx, from, to, true)
+	if err != nil {
+		return totals, err
+	}
+
+	// Nothing rolled up yet: an exact COUNT(DISTINCT) is cheapest
+	if plan.Daily == nil && len(plan.Hourly) == 0 {
+		if plan.Raw != nil {
+			q := s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{}).Where("short_link_id IN ? AND is_bot = ?", linkIDs, false)
+			if err := applyRange(q, "clicked_at", *plan.Raw).Select("COUNT(DISTINCT " + visitorKeyExpr + ")").Scan(&totals.UniqueVisitors).Error; err != nil {
+				return totals, err
+			}
+		}
+		return totals, nil
+	}
+
+	sketch := NewSketch()
+	merge := func(model any, r timeRange) error {
+		var sketches [][]byte
+		q := s.db.WithContext(ctx).Model(model).Where("short_link_id IN ?", linkIDs)
+		if err := applyRange(q, "bucket_start", r).Pluck("visitor_sketch", &sketches).Error; err != nil {
+			return err
+		}
+		for _, raw := range sketches {
+			var bucket Sketch
+			if err := bucket.UnmarshalBinary(raw); err != nil {
+				return err
+			}
+			sketch.Merge(&bucket)
+		}
+		return nil
+	}
+
+	if plan.Daily != nil {
+		if err := merge(&shortlink.ClickRollupDaily{}, *plan.Daily); err != nil {
+			return totals, err
+		}
+	}
+	for _, r := range plan.Hourly {
+		if err := merge(&shortlink.ClickRollupHourly{}, r); err != nil {
+			return totals, err
+		}
+	}
+	if plan.Raw != nil {
+		var keys []string
+		q := s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{}).
+			Where("short_link_id IN ? AND is_bot = ?", linkIDs, false).
+			Where(visitorKeyExpr + " IS NOT NULL")
+		if err := applyRange(q, "clicked_at", *plan.Raw).Distinct().Pluck(visitorKeyExpr, &keys).Error; err != nil {
+			return totals, err
+		}
+		for _, key := range keys {
+			sketch.Add(key)
+		}
+	}
+
+	totals.UniqueVisitors = sketch.Estimate()
+	return totals, nil
+}
+
+// Breakdown returns clicks per value of dimension (see the
+// shortlink.RollupDimension* constants) for linkIDs in [from, to).
+func (s *Store) Breakdown(ctx context.Context, linkIDs []string, dimension string, from, to time.Time) (map[string]int64, error) {
+	result := make(map[string]int64)
+	if len(linkIDs) == 0 {
+		return result, nil
+	}
+
+	dim, ok := lookupDimension(dimension)
+	if !ok {
+		return nil, ErrUnknownDimension
+	}
+
+	plan, err := s.plan(ctx, from, to, true)
+	if err != nil {
+		return nil, err
+	}
+
+	collect := func(model any, r timeRange) error {
+		var rows []struct {
+			Value  string
+			Clicks int64
+		}
+		q := s.db.WithContext(ctx).Model(model).
+			Select("value, SUM(clicks) AS clicks").
+			Where("short_link_id IN ? AND dimension = ?", linkIDs, dimension)
+		if err := applyRange(q, "bucket_start", r).Group("value").Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			result[row.Value] += row.Clicks
+		}
+		return nil
+	}
+
+	if plan.Daily != nil {
+		if err := collect(&shortlink.ClickRollupDimensionDaily{}, *plan.Daily); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range plan.Hourly {
+		if err := collect(&shortlink.ClickRollupDimensionHourly{}, r); err != nil {
+			return nil, err
+		}
+	}
+	if plan.Raw != nil {
+		var rows []struct {
+			Value  string
+			Clicks int64
+		}
+		q := s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{}).
+			Select(dim.Column+" AS value, COUNT(*) AS clicks").
+			Where("short_link_id IN ? AND is_bot = ?", linkIDs, dim.Bots)
+		if err := applyRange(q, "clicked_at", *plan.Raw).Group(dim.Column).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			result[dimensionValue(dimension, row.Value)] += row.Clicks
+		}
+	}
+
+	return result, nil
+}
+
+// History returns non-empty buckets of clicks for linkIDs in [from, to),
+// ordered by time. Buckets follow the process time zone.
+func (s *Store) History(ctx context.Context, linkIDs []string, from, to time.Time, granularity Granularity) ([]Point, error) {
+	series, err := s.Series(ctx, linkIDs, from, to, granularity, s.loc, "")
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(series[""]))
+	for start, clicks := range series[""] {
+		points = append(points, Point{Start: start, Clicks: clicks})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Start.Before(points[j].Start)
+	})
+	return points, nil
+}
+
+// Series returns clicks per granularity bucket in loc for linkIDs in
+// [from, to). With a dimension the clicks are split by dimension value,
+// otherwise everything is keyed by "". Buckets without clicks are absent.
+//
+// Minute buckets only exist in the raw table, so they are empty for clicks
+// that have already been pruned by the retention job. Day, week and month
+// buckets use the daily rollups only when loc is the process time zone.
+func (s *Store) Series(ctx context.Context, linkIDs []string, from, to time.Time, granularity Granularity, loc *time.Location, dimension string) (map[string]map[time.Time]int64, error) {
+	series := make(map[string]map[time.Time]int64)
+	if len(linkIDs) == 0 {
+		return series, nil
+	}
+
+	var dim rollupDimension
+	if dimension != "" {
+		var ok bool
+		if dim, ok = lookupDimension(dimension); !ok {
+			return nil, ErrUnknownDimension
+		}
+	}
+
+	var plan rangePlan
+	switch granularity {
+	case GranularityMinute:
+		plan.Raw = &timeRange{From: from, To: to}
+	default:
+		allowDaily := granularity != GranularityHour && loc.String() == s.loc.String()
+		var err error
+		if plan, err = s.plan(ctx, from, to, allowDaily); err != nil {
+			return nil, err
+		}
+	}
+
+	add := func(value string, at time.Time, clicks int64) {
+		buckets, ok := series[value]
+		if !ok {
+			buckets = make(map[time.Time]int64)
+			series[value] = buckets
+		}
+		buckets[BucketStart(at, granularity, loc)] += clicks
+	}
+
+	collect := func(model any, r timeRange) error {
+		var rows []struct {
+			BucketStart time.Time
+			Value       string
+			Clicks      int64
+		}
+		q := s.db.WithContext(ctx).Model(model).Where("short_link_id IN ?", linkIDs)
+		if dimension != "" {
+			q = q.Select("bucket_start, value, SUM(clicks) AS clicks").
+				Where("dimension = ?", dimension).
+				Group("bucket_start, value")
+		} else {
+			q = q.Select("bucket_start, SUM(clicks) AS clicks").Group("bucket_start")
+		}
+		if err := applyRange(q, "bucket_start", r).Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			add(row.Value, row.BucketStart, row.Clicks)
+		}
+		return nil
+	}
+
+	totals, dims := any(&shortlink.ClickRollupHourly{}), any(&shortlink.ClickRollupDimensionHourly{})
+	dailyTotals, dailyDims := any(&shortlink.ClickRollupDaily{}), any(&shortlink.ClickRollupDimensionDaily{})
+	if dimension != "" {
+		totals, dailyTotals = dims, dailyDims
+	}
+
+	if plan.Daily != nil {
+		if err := collect(dailyTotals, *plan.Daily); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range plan.Hourly {
+		if err := collect(totals, r); err != nil {
+			return nil, err
+		}
+	}
+	if plan.Raw != nil {
+		// Group raw clicks per minute in SQL, then re-bucket in Go
+		selectExpr := "DATE_FORMAT(clicked_at, '%Y-%m-%d %H:%i') AS minute, COUNT(*) AS clicks"
+		groupExpr := "minute"
+		if dim.Column != "" {
+			selectExpr += ", " + dim.Column + " AS value"
+			groupExpr += ", " + dim.Column
+		}
+
+		var rows []struct {
+			Minute string
+			Value  string
+			Clicks int64
+		}
+		q := s.db.WithContext(ctx).Model(&shortlink.ViewLinkDetail{}).
+			Select(selectExpr).
+			Where("short_link_id IN ? AND is_bot = ?", linkIDs, dim.Bots)
+		if err := applyRange(q, "clicked_at", *plan.Raw).Group(groupExpr).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			// DATETIME values are stored in the DSN time zone, the process zone
+			at, err := time.ParseInLocation("2006-01-02 15:04", row.Minute, s.loc)
+			if err != nil {
+				return nil, err
+			}
+			value := ""
+			if dim.Column != "" {
+				value = dimensionValue(dimension, row.Value)
+			}
+			add(value, at, row.Clicks)
+		}
+	}
+
+	// Rolled-up buckets may start before from when it is not hour aligned
+	first := BucketStart(from, granularity, loc)
+	for _, buckets := range series {
+		for start := range buckets {
+			if start.Before(first) || !start.Before(to) {
+				delete(buckets, start)
+			}
+		}
+	}
+	return series, nil
+}
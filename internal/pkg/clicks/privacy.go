@@ -0,0 +1,67 @@
+package clicks
+
+import (
+	"context"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/ip"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/privacy"
+)
+
+// Anonymize strips identifying fields from an event according to its privacy
+// mode and marks it Anonymized. It must run after geolocation, since the
+// lookup needs the full IP.
+//
+//   - DoNotTrack drops the IP, user agent and city-level location and keeps
+//     only the referrer origin; the click is still counted.
+//   - ModeHash replaces the IP with a salted daily hash, falling back to
+//     truncation when no hash can be computed.
+//   - ModeTruncate keeps only the IP's network prefix.
+func Anonymize(ctx context.Context, event Event, hasher *privacy.Hasher) Event {
+	event.Anonymized = true
+	if event.DoNotTrack {
+		event.IPAddress = ""
+		event.UserAgent = ""
+		event.VisitorHash = ""
+		event.Referer = privacy.ReferrerOrigin(event.Referer)
+		event.Location = ip.Location{
+			Country:     event.Location.Country,
+			CountryCode: event.Location.CountryCode,
+		}
+		return event
+	}
+
+	switch event.PrivacyMode {
+	case privacy.ModeHash:
+		if hasher != nil && event.IPAddress != "" {
+			hash, err := hasher.Hash(ctx, event.ClickedAt, event.ShortLinkID, event.IPAddress, event.UserAgent)
+			if err == nil {
+				event.VisitorHash = hash
+				event.IPAddress = ""
+				event.UserAgent = ""
+				dropCoordinates(&event.Location)
+				return event
+			}
+			logger.Logger.Warn("Failed to hash visitor, truncating IP instead",
+				"short_link_id", event.ShortLinkID,
+				"error", err.Error(),
+			)
+		}
+		fallthrough
+	case privacy.ModeTruncate:
+		event.IPAddress = privacy.TruncateIP(event.IPAddress)
+		event.UserAgent = ""
+		dropCoordinates(&event.Location)
+	}
+	return event
+}
+
+// needsAnonymizing reports whether Anonymize strips anything from event.
+func needsAnonymizing(event Event) bool {
+	return event.DoNotTrack || event.PrivacyMode == privacy.ModeHash || event.PrivacyMode == privacy.ModeTruncate
+}
+
+func dropCoordinates(location *ip.Location) {
+	location.Latitude = nil
+	location.Longitude = nil
+}
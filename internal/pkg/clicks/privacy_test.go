@@ -0,0 +1,53 @@
+package clicks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/ip"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/privacy"
+)
+
+func TestAnonymize(t *testing.T) {
+	t.Parallel()
+
+	lat, lng := -6.2, 106.8
+	base := Event{
+		ShortLinkID: "l1",
+		IPAddress:   "203.0.113.77",
+		UserAgent:   "Mozilla/5.0",
+		Referer:     "https://news.example.com/a?uid=42",
+		Location:    ip.Location{Country: "Indonesia", CountryCode: "ID", City: "Jakarta", Latitude: &lat, Longitude: &lng},
+	}
+
+	off := Anonymize(context.Background(), base, nil)
+	if off.IPAddress != base.IPAddress || off.UserAgent != base.UserAgent || off.Location.Latitude == nil {
+		t.Fatalf("mode off changed the event: %+v", off)
+	}
+
+	truncated := base
+	truncated.PrivacyMode = privacy.ModeTruncate
+	truncated = Anonymize(context.Background(), truncated, nil)
+	if truncated.IPAddress != "203.0.113.0" || truncated.UserAgent != "" || truncated.Location.Latitude != nil || truncated.Location.City != "Jakarta" {
+		t.Fatalf("truncate = %+v", truncated)
+	}
+
+	// Without a hasher the hash mode must not leak the full IP
+	hashed := base
+	hashed.PrivacyMode = privacy.ModeHash
+	hashed = Anonymize(context.Background(), hashed, nil)
+	if hashed.IPAddress != "203.0.113.0" || hashed.VisitorHash != "" || hashed.UserAgent != "" {
+		t.Fatalf("hash fallback = %+v", hashed)
+	}
+
+	dnt := base
+	dnt.PrivacyMode = privacy.ModeHash
+	dnt.DoNotTrack = true
+	dnt = Anonymize(context.Background(), dnt, nil)
+	if dnt.IPAddress != "" || dnt.UserAgent != "" || dnt.VisitorHash != "" {
+		t.Fatalf("dnt kept identifiers: %+v", dnt)
+	}
+	if dnt.Referer != "https://news.example.com" || dnt.Location.City != "" || dnt.Location.CountryCode != "ID" {
+		t.Fatalf("dnt = %+v", dnt)
+	}
+}
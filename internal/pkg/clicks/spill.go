@@ -0,0 +1,157 @@
+package clicks
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	spillFileName    = "clicks-spill.ndjson"
+	replayFileName   = "clicks-spill.replay.ndjson"
+	deadLetterName   = "clicks-dead-letter.ndjson"
+	spillFilePerm    = 0o600
+	spillDirPerm     = 0o700
+	bytesPerMegabyte = 1 << 20
+)
+
+var errSpillFull = errors.New("click spill file is full")
+
+// spillFile is an append-only NDJSON file holding events that did not fit in
+// the in-memory queue. It is replayed into the queue once there is room again.
+type spillFile struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	file     *os.File
+	size     int64
+}
+
+func newSpillFile(dir string, maxMB int) (*spillFile, error) {
+	if err := os.MkdirAll(dir, spillDirPerm); err != nil {
+		return nil, err
+	}
+
+	s := &spillFile{
+		dir:      dir,
+		maxBytes: int64(maxMB) * bytesPerMegabyte,
+	}
+	if info, err := os.Stat(s.path()); err == nil {
+		s.size = info.Size()
+	}
+	return s, nil
+}
+
+func (s *spillFile) path() string {
+	return filepath.Join(s.dir, spillFileName)
+}
+
+func (s *spillFile) replayPath() string {
+	return filepath.Join(s.dir, replayFileName)
+}
+
+// append writes one event as a JSON line.
+func (s *spillFile) append(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.appendRaw(append(line, '\n'))
+}
+
+func (s *spillFile) appendRaw(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxBytes > 0 && s.size+int64(len(data)) > s.maxBytes {
+		return errSpillFull
+	}
+
+	if s.file == nil {
+		file, err := os.OpenFile(s.path(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, spillFilePerm)
+		if err != nil {
+			return err
+		}
+		s.file = file
+	}
+
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	return err
+}
+
+// appendDeadLetter writes events that keep failing to a file of their own,
+// outside the spill size limit, for an operator to inspect.
+func (s *spillFile) appendDeadLetter(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(filepath.Join(s.dir, deadLetterName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, spillFilePerm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// pending reports whether there is anything to replay.
+func (s *spillFile) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size > 0 {
+		return true
+	}
+	_, err := os.Stat(s.replayPath())
+	return err == nil
+}
+
+// bytes returns the current size of the spill file.
+func (s *spillFile) bytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// takeForReplay moves the spill file aside so new overflow can keep appending
+// while it is replayed. A replay file left behind by a crash is returned first.
+func (s *spillFile) takeForReplay() (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.replayPath()); err == nil {
+		return s.replayPath(), true, nil
+	}
+	if s.size == 0 {
+		return "", false, nil
+	}
+
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return "", false, err
+		}
+		s.file = nil
+	}
+	if err := os.Rename(s.path(), s.replayPath()); err != nil {
+		return "", false, err
+	}
+	s.size = 0
+	return s.replayPath(), true, nil
+}
+
+func (s *spillFile) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
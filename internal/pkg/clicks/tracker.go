@@ -0,0 +1,779 @@
+// Package clicks provides the asynchronous ingestion pipeline for short link
+// clicks. The redirect handler only enqueues an Event into a bounded queue; a
+// fixed pool of workers resolves geolocation and a single writer inserts view
+// rows and click counter increments into MySQL in batches, so the redirect
+// hot path never waits on the database or on a geolocation lookup.
+package clicks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/ip"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/privacy"
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultQueueSize       = 10000
+	defaultBatchSize       = 200
+	defaultFlushIntervalMS = 1000
+	defaultWorkers         = 4
+	defaultSpillDir        = "data/click-spill"
+	defaultSpillMaxMB      = 64
+
+	// A failed batch is retried flushAttempts times in all, doubling
+	// flushRetryBackoff in between, before it is spilled.
+	flushAttempts     = 3
+	flushRetryBackoff = 250 * time.Millisecond
+
+	// maxEventFlushFailures is how many times a click's own insert may fail
+	// while the database is reachable before it is moved to the dead-letter
+	// file instead of being spilled again.
+	maxEventFlushFailures = 3
+)
+
+// OverflowPolicy decides what happens to a click when the queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowDrop discards the view row; the caller still counts the click.
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowSpill appends the event to a local NDJSON file that is replayed
+	// into the queue once it has room again.
+	OverflowSpill OverflowPolicy = "spill"
+)
+
+// Event is a single click accepted by the redirect handler.
+type Event struct {
+	ShortLinkID string    `json:"short_link_id"`
+	DetailID    string    `json:"detail_id"`
+	ShortCode   string    `json:"short_code"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	Referer     string    `json:"referer"`
+	Device      string    `json:"device"`
+	Browser     string    `json:"browser"`
+	OS          string    `json:"os"`
+	ClickedAt   time.Time `json:"clicked_at"`
+	IsBot       bool      `json:"is_bot,omitempty"`
+	BotName     string    `json:"bot_name,omitempty"`
+	RuleID      string    `json:"rule_id,omitempty"`
+	VariantID   string    `json:"variant_id,omitempty"`
+	RevisionID  string    `json:"revision_id,omitempty"`
+	Source      string    `json:"source,omitempty"`
+
+	// CountClick is true when current_clicks still has to be incremented in
+	// MySQL. It is false when the caller already applied the increment
+	// synchronously (e.g. the Redis-less click-limit fallback).
+	CountClick bool `json:"count_click"`
+	// CountUnique is the same for unique_clicks; it is set for the first
+	// click of a visitor within the link's dedup window.
+	CountUnique bool `json:"count_unique,omitempty"`
+	// UniqueVisitor marks that first click even when unique_clicks was
+	// already incremented; it drives the variant's unique counter.
+	UniqueVisitor bool `json:"unique_visitor,omitempty"`
+
+	// PrivacyMode is the resolved privacy mode of the link and DoNotTrack is
+	// set when the visitor opted out and the link honours it. Both are
+	// applied before anything reaches MySQL or the spill file.
+	PrivacyMode privacy.Mode `json:"privacy_mode,omitempty"`
+	DoNotTrack  bool         `json:"do_not_track,omitempty"`
+	VisitorHash string       `json:"visitor_hash,omitempty"`
+
+	// Filled in by the worker pool before the event reaches the writer,
+	// unless the redirect already resolved it to match a country rule. It is
+	// kept in the spill file so a failed batch is not looked up again from
+	// an IP address its privacy mode already truncated or dropped.
+	Location ip.Location `json:"location,omitempty"`
+	// Anonymized is set once the event has been located and its privacy mode
+	// applied, so a spilled event is not processed again on replay.
+	Anonymized bool `json:"anonymized,omitempty"`
+	// FlushFailures counts the inserts of this event alone that failed while
+	// the database was reachable.
+	FlushFailures int `json:"flush_failures,omitempty"`
+}
+
+// Options configures a Tracker.
+type Options struct {
+	QueueSize      int
+	BatchSize      int
+	FlushInterval  time.Duration
+	Workers        int
+	OverflowPolicy OverflowPolicy
+	SpillDir       string
+	SpillMaxMB     int
+}
+
+// OptionsFromEnv reads tracker options from environment variables.
+func OptionsFromEnv() Options {
+	return Options{
+		QueueSize:      config.GetEnvAsInt(config.EnvClickQueueSize, defaultQueueSize),
+		BatchSize:      config.GetEnvAsInt(config.EnvClickBatchSize, defaultBatchSize),
+		FlushInterval:  time.Duration(config.GetEnvAsInt(config.EnvClickFlushIntervalMS, defaultFlushIntervalMS)) * time.Millisecond,
+		Workers:        config.GetEnvAsInt(config.EnvClickWorkers, defaultWorkers),
+		OverflowPolicy: OverflowPolicy(strings.ToLower(strings.TrimSpace(config.GetEnvOrDefault(config.EnvClickOverflowPolicy, string(OverflowDrop))))),
+		SpillDir:       config.GetEnvOrDefault(config.EnvClickSpillDir, defaultSpillDir),
+		SpillMaxMB:     config.GetEnvAsInt(config.EnvClickSpillMaxMB, defaultSpillMaxMB),
+	}
+}
+
+// Metrics is a point-in-time snapshot of the tracker counters.
+type Metrics struct {
+	QueueDepth     int    `json:"queue_depth"`
+	QueueCapacity  int    `json:"queue_capacity"`
+	Workers        int    `json:"workers"`
+	OverflowPolicy string `json:"overflow_policy"`
+	Enqueued       int64  `json:"enqueued"`
+	Dropped        int64  `json:"dropped"`
+	Spilled        int64  `json:"spilled"`
+	Replayed       int64  `json:"replayed"`
+	Written        int64  `json:"written"`
+	FailedWrites   int64  `json:"failed_writes"`
+	DeadLettered   int64  `json:"dead_lettered"`
+	SpillBytes     int64  `json:"spill_bytes"`
+}
+
+// Tracker buffers click events in a bounded queue, enriches them on a fixed
+// worker pool and flushes them in batches.
+type Tracker struct {
+	db            *gorm.DB
+	events        chan Event
+	enriched      chan Event
+	batchSize     int
+	flushInterval time.Duration
+	workers       int
+	policy        OverflowPolicy
+	spill         *spillFile
+	locate        func(ipAddress string) ip.Location
+	hasher        *privacy.Hasher
+	flush         func(ctx context.Context, batch []Event) error
+	ping          func(ctx context.Context) error
+	retryBackoff  time.Duration
+
+	enqueued     atomic.Int64
+	dropped      atomic.Int64
+	spilled      atomic.Int64
+	replayed     atomic.Int64
+	written      atomic.Int64
+	failedWrites atomic.Int64
+	deadLettered atomic.Int64
+	replaying    atomic.Bool
+
+	mu       sync.RWMutex
+	started  bool
+	stopped  bool
+	quit     chan struct{}
+	workerWG sync.WaitGroup
+	writerWG sync.WaitGroup
+	replayWG sync.WaitGroup
+}
+
+var (
+	globalTracker   *Tracker
+	globalTrackerMu sync.RWMutex
+)
+
+// NewTracker creates a tracker writing to db. Call Start before Track.
+func NewTracker(db *gorm.DB, opts Options) *Tracker {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushIntervalMS * time.Millisecond
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+
+	t := &Tracker{
+		db:            db,
+		events:        make(chan Event, opts.QueueSize),
+		enriched:      make(chan Event, opts.BatchSize),
+		batchSize:     opts.BatchSize,
+		flushInterval: opts.FlushInterval,
+		workers:       opts.Workers,
+		policy:        OverflowDrop,
+		locate:        ip.Locate,
+		hasher:        privacy.Global(),
+		retryBackoff:  flushRetryBackoff,
+		quit:          make(chan struct{}),
+	}
+	t.flush = t.writeBatch
+	if db != nil {
+		t.ping = func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}
+	}
+
+	if opts.OverflowPolicy == OverflowSpill {
+		if opts.SpillDir == "" {
+			opts.SpillDir = defaultSpillDir
+		}
+		spill, err := newSpillFile(opts.SpillDir, opts.SpillMaxMB)
+		if err != nil {
+			logger.Logger.Error("Click spill directory unavailable, falling back to drop policy",
+				"dir", opts.SpillDir,
+				"error", err.Error(),
+			)
+		} else {
+			t.policy = OverflowSpill
+			t.spill = spill
+		}
+	}
+
+	return t
+}
+
+// InitGlobal creates, starts and registers the global tracker instance.
+func InitGlobal(db *gorm.DB) (*Tracker, error) {
+	if db == nil {
+		return nil, errors.New("gorm db is required")
+	}
+
+	tracker := NewTracker(db, OptionsFromEnv())
+	tracker.Start()
+
+	globalTrackerMu.Lock()
+	globalTracker = tracker
+	globalTrackerMu.Unlock()
+
+	return tracker, nil
+}
+
+// Global returns the initialized global tracker, or nil.
+func Global() *Tracker {
+	globalTrackerMu.RLock()
+	defer globalTrackerMu.RUnlock()
+	return globalTracker
+}
+
+// Start launches the worker pool and the batch writer, and replays any events
+// spilled to disk by a previous run.
+func (t *Tracker) Start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started || t.stopped {
+		return
+	}
+	t.started = true
+
+	for i := 0; i < t.workers; i++ {
+		t.workerWG.Add(1)
+		go t.work()
+	}
+
+	t.writerWG.Add(1)
+	go t.write()
+
+	t.startReplayLocked()
+
+	logger.Logger.Info("Click tracker started",
+		"workers", t.workers,
+		"queue_size", cap(t.events),
+		"batch_size", t.batchSize,
+		"overflow_policy", string(t.policy),
+	)
+}
+
+// Track enqueues an event without blocking. When the queue is full the event
+// is spilled to disk under the spill policy; Track returns false only when the
+// event was dropped, so the caller can decide how to degrade.
+func (t *Tracker) Track(event Event) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.stopped {
+		t.dropped.Add(1)
+		return false
+	}
+
+	if event.ClickedAt.IsZero() {
+		event.ClickedAt = time.Now()
+	}
+
+	select {
+	case t.events <- event:
+		t.enqueued.Add(1)
+		return true
+	default:
+	}
+
+	if t.spill != nil {
+		// The spill file must not keep what the link's privacy mode drops, so
+		// such events are processed before they reach the disk
+		if needsAnonymizing(event) {
+			event = t.prepare(event)
+		}
+		if err := t.spill.append(event); err == nil {
+			t.spilled.Add(1)
+			return true
+		} else if !errors.Is(err, errSpillFull) {
+			logger.Logger.Error("Failed to spill click event", "error", err.Error())
+		}
+	}
+
+	t.dropped.Add(1)
+	return false
+}
+
+// Metrics returns the current queue depth and counters.
+func (t *Tracker) Metrics() Metrics {
+	m := Metrics{
+		QueueDepth:     len(t.events),
+		QueueCapacity:  cap(t.events),
+		Workers:        t.workers,
+		OverflowPolicy: string(t.policy),
+		Enqueued:       t.enqueued.Load(),
+		Dropped:        t.dropped.Load(),
+		Spilled:        t.spilled.Load(),
+		Replayed:       t.replayed.Load(),
+		Written:        t.written.Load(),
+		FailedWrites:   t.failedWrites.Load(),
+		DeadLettered:   t.deadLettered.Load(),
+	}
+	if t.spill != nil {
+		m.SpillBytes = t.spill.bytes()
+	}
+	return m
+}
+
+// Stop stops accepting events and flushes everything still buffered, the same
+// way the job scheduler waits for running jobs before returning.
+func (t *Tracker) Stop() {
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return
+	}
+	t.stopped = true
+	started := t.started
+	t.mu.Unlock()
+
+	logger.Logger.Info("Stopping click tracker...", "pending", len(t.events))
+	close(t.quit)
+
+	// Track refuses new events once stopped is set and the replayer has
+	// returned, so nothing can send on events after this point.
+	t.replayWG.Wait()
+	close(t.events)
+
+	if started {
+		t.workerWG.Wait()
+		close(t.enriched)
+		t.writerWG.Wait()
+	}
+
+	if t.spill != nil {
+		if err := t.spill.close(); err != nil {
+			logger.Logger.Warn("Failed to close click spill file", "error", err.Error())
+		}
+	}
+
+	logger.Logger.Info("Click tracker stopped",
+		"written", t.written.Load(),
+		"dropped", t.dropped.Load(),
+		"spilled", t.spilled.Load(),
+	)
+}
+
+// work resolves geolocation and applies the link's privacy mode for queued
+// events until the queue is closed.
+func (t *Tracker) work() {
+	defer t.workerWG.Done()
+
+	for event := range t.events {
+		t.enriched <- t.prepare(event)
+	}
+}
+
+// prepare resolves the event's location, unless the redirect already did,
+// and applies its privacy mode. Events already processed before they were
+// spilled are returned as they are.
+func (t *Tracker) prepare(event Event) Event {
+	if event.Anonymized {
+		return event
+	}
+	if event.Location.Country == "" {
+		event.Location = t.locate(event.IPAddress)
+	}
+	return Anonymize(context.Background(), event, t.hasher)
+}
+
+// write batches enriched events by size or interval until the pool is drained.
+func (t *Tracker) write() {
+	defer t.writerWG.Done()
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, t.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		t.flushBatch(batch)
+		batch = make([]Event, 0, t.batchSize)
+	}
+
+	for {
+		select {
+		case event, ok := <-t.enriched:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= t.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			t.maybeReplay()
+		}
+	}
+}
+
+// flushBatch writes a batch, retrying with backoff. When it still fails but
+// the database is reachable, a row of the batch is to blame, so the events
+// are written one by one to keep the good ones. What could not be written is
+// spilled to disk so its view rows and counter increments are replayed
+// later; it is only lost when there is no spill file or it is full.
+func (t *Tracker) flushBatch(batch []Event) {
+	backoff := t.retryBackoff
+	var err error
+	for attempt := 1; attempt <= flushAttempts; attempt++ {
+		if err = t.flush(context.Background(), batch); err == nil {
+			t.written.Add(int64(len(batch)))
+			return
+		}
+		if attempt < flushAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	if t.reachable() {
+		batch, err = t.flushEach(batch)
+		if len(batch) == 0 {
+			return
+		}
+	}
+	t.spillFailed(batch, err)
+}
+
+// reachable reports whether the database answers, telling a failed insert
+// caused by its rows apart from an outage.
+func (t *Tracker) reachable() bool {
+	if t.ping == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return t.ping(ctx) == nil
+}
+
+// flushEach writes the events of a failed batch one at a time and returns
+// those that still fail, with the failure counted against each. Events that
+// failed maxEventFlushFailures times are moved to the dead-letter file.
+func (t *Tracker) flushEach(batch []Event) ([]Event, error) {
+	var failed, dead []Event
+	var lastErr error
+	for _, event := range batch {
+		err := t.flush(context.Background(), []Event{event})
+		if err == nil {
+			t.written.Add(1)
+			continue
+		}
+		lastErr = err
+		event.FlushFailures++
+		if event.FlushFailures >= maxEventFlushFailures {
+			dead = append(dead, event)
+		} else {
+			failed = append(failed, event)
+		}
+	}
+
+	if len(dead) > 0 {
+		t.deadLetter(dead, lastErr)
+	}
+	return failed, lastErr
+}
+
+// spillFailed spills events that could not be written, counting them as
+// failed writes when there is no room for them.
+func (t *Tracker) spillFailed(batch []Event, err error) {
+	if t.spill != nil {
+		if spillErr := t.spillBatch(batch); spillErr == nil {
+			t.spilled.Add(int64(len(batch)))
+			logger.Logger.Warn("Spilled click batch after failed flush",
+				"size", len(batch),
+				"error", err.Error(),
+			)
+			return
+		} else if !errors.Is(spillErr, errSpillFull) {
+			logger.Logger.Error("Failed to spill click batch", "error", spillErr.Error())
+		}
+	}
+
+	t.failedWrites.Add(int64(len(batch)))
+	logger.Logger.Error("Failed to flush click batch",
+		"size", len(batch),
+		"attempts", flushAttempts,
+		"error", err.Error(),
+	)
+}
+
+// deadLetter sets aside events the database keeps rejecting, so they are
+// neither replayed again nor lost.
+func (t *Tracker) deadLetter(events []Event, err error) {
+	if t.spill != nil {
+		data, encodeErr := encodeEvents(events)
+		if encodeErr == nil {
+			encodeErr = t.spill.appendDeadLetter(data)
+		}
+		if encodeErr == nil {
+			t.deadLettered.Add(int64(len(events)))
+			logger.Logger.Error("Moved click events to the dead-letter file",
+				"size", len(events),
+				"error", err.Error(),
+			)
+			return
+		}
+		logger.Logger.Error("Failed to dead-letter click events", "error", encodeErr.Error())
+	}
+
+	t.failedWrites.Add(int64(len(events)))
+	logger.Logger.Error("Dropped click events the database keeps rejecting",
+		"size", len(events),
+		"error", err.Error(),
+	)
+}
+
+// spillBatch appends a whole batch in one write, so it is either spilled
+// completely or not at all.
+func (t *Tracker) spillBatch(batch []Event) error {
+	data, err := encodeEvents(batch)
+	if err != nil {
+		return err
+	}
+	return t.spill.appendRaw(data)
+}
+
+// encodeEvents renders events as NDJSON lines.
+func encodeEvents(events []Event) ([]byte, error) {
+	var data []byte
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	return data, nil
+}
+
+// maybeReplay starts a replay of spilled events once the queue is at most
+// half full.
+func (t *Tracker) maybeReplay() {
+	if t.spill == nil || len(t.events) > cap(t.events)/2 || !t.spill.pending() {
+		return
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.stopped {
+		return
+	}
+	t.startReplayLocked()
+}
+
+// startReplayLocked must be called with t.mu held.
+func (t *Tracker) startReplayLocked() {
+	if t.spill == nil || !t.replaying.CompareAndSwap(false, true) {
+		return
+	}
+
+	t.replayWG.Add(1)
+	go func() {
+		defer t.replayWG.Done()
+		defer t.replaying.Store(false)
+		t.replaySpill()
+	}()
+}
+
+// replaySpill feeds spilled events back into the queue. If the tracker stops
+// halfway, the unread remainder is appended back to the spill file so it is
+// replayed on the next start.
+func (t *Tracker) replaySpill() {
+	path, ok, err := t.spill.takeForReplay()
+	if err != nil {
+		logger.Logger.Error("Failed to prepare click spill replay", "error", err.Error())
+		return
+	}
+	if !ok {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Logger.Error("Failed to open click spill replay", "path", path, "error", err.Error())
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			logger.Logger.Warn("Skipping malformed spilled click", "error", err.Error())
+			continue
+		}
+
+		select {
+		case t.events <- event:
+			t.replayed.Add(1)
+		case <-t.quit:
+			t.respill(line, scanner)
+			_ = os.Remove(path)
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Logger.Error("Failed to read click spill replay", "path", path, "error", err.Error())
+		return
+	}
+
+	if err := os.Remove(path); err != nil {
+		logger.Logger.Warn("Failed to remove click spill replay", "path", path, "error", err.Error())
+	}
+}
+
+func (t *Tracker) respill(current []byte, scanner *bufio.Scanner) {
+	pending := append(append([]byte(nil), current...), '\n')
+	for scanner.Scan() {
+		pending = append(pending, scanner.Bytes()...)
+		pending = append(pending, '\n')
+	}
+	if err := t.spill.appendRaw(pending); err != nil {
+		logger.Logger.Error("Failed to persist unreplayed clicks", "bytes", len(pending), "error", err.Error())
+	}
+}
+
+// VariantCounterUpdates is the column update applying clicks and unique
+// increments to a link variant.
+func VariantCounterUpdates(clicks, unique int) map[string]interface{} {
+	updates := map[string]interface{}{"clicks": gorm.Expr("clicks + ?", clicks)}
+	if unique > 0 {
+		updates["unique_clicks"] = gorm.Expr("unique_clicks + ?", unique)
+	}
+	return updates
+}
+
+// counterDelta is the aggregated counter increment of one short link detail
+// or variant.
+type counterDelta struct {
+	clicks int
+	unique int
+}
+
+// writeBatch inserts view rows and applies the aggregated current_clicks and
+// unique_clicks increments, and those of the A/B variants served, in a single
+// transaction.
+func (t *Tracker) writeBatch(ctx context.Context, batch []Event) error {
+	views := make([]shortlink.ViewLinkDetail, 0, len(batch))
+	increments := make(map[string]counterDelta)
+	variants := make(map[string]counterDelta)
+	for _, event := range batch {
+		views = append(views, shortlink.ViewLinkDetail{
+			ID:             uuid.New().String(),
+			ShortLinkID:    event.ShortLinkID,
+			IPAddress:      event.IPAddress,
+			VisitorHash:    event.VisitorHash,
+			UserAgent:      event.UserAgent,
+			Referer:        event.Referer,
+			Country:        event.Location.Country,
+			CountryCode:    event.Location.CountryCode,
+			Region:         event.Location.Region,
+			City:           event.Location.City,
+			Latitude:       event.Location.Latitude,
+			Longitude:      event.Location.Longitude,
+			ASN:            event.Location.ASN,
+			ASOrganization: event.Location.ASOrganization,
+			Device:         event.Device,
+			Browser:        event.Browser,
+			OS:             event.OS,
+			IsBot:          event.IsBot,
+			BotName:        event.BotName,
+			RuleID:         event.RuleID,
+			VariantID:      event.VariantID,
+			RevisionID:     event.RevisionID,
+			Source:         event.Source,
+			ClickedAt:      event.ClickedAt,
+		})
+		if event.DetailID != "" && (event.CountClick || event.CountUnique) {
+			delta := increments[event.DetailID]
+			if event.CountClick {
+				delta.clicks++
+			}
+			if event.CountUnique {
+				delta.unique++
+			}
+			increments[event.DetailID] = delta
+		}
+		if event.VariantID != "" && !event.IsBot {
+			delta := variants[event.VariantID]
+			delta.clicks++
+			if event.UniqueVisitor {
+				delta.unique++
+			}
+			variants[event.VariantID] = delta
+		}
+	}
+
+	now := time.Now()
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for detailID, delta := range increments {
+			updates := map[string]interface{}{"updated_at": now}
+			if delta.clicks > 0 {
+				updates["current_clicks"] = gorm.Expr("current_clicks + ?", delta.clicks)
+			}
+			if delta.unique > 0 {
+				updates["unique_clicks"] = gorm.Expr("unique_clicks + ?", delta.unique)
+			}
+			if err := tx.Model(&shortlink.ShortLinkDetail{}).
+				Where("id = ?", detailID).
+				Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		for variantID, delta := range variants {
+			if err := tx.Model(&shortlink.LinkVariant{}).
+				Where("id = ?", variantID).
+				UpdateColumns(VariantCounterUpdates(delta.clicks, delta.unique)).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.CreateInBatches(&views, t.batchSize).Error
+	})
+}
@@ -0,0 +1,293 @@
+package clicks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/ip"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/privacy"
+)
+
+func newTestTracker(opts Options) (*Tracker, *[][]Event, *sync.Mutex) {
+	tracker := NewTracker(nil, opts)
+	tracker.locate = func(string) ip.Location { return ip.Location{Country: "Indonesia", City: "Jakarta"} }
+
+	var mu sync.Mutex
+	var batches [][]Event
+	tracker.flush = func(_ context.Context, batch []Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, append([]Event(nil), batch...))
+		return nil
+	}
+	return tracker, &batches, &mu
+}
+
+func TestTrackerStopDrainsQueue(t *testing.T) {
+	t.Parallel()
+
+	tracker, batches, mu := newTestTracker(Options{QueueSize: 16, BatchSize: 4, FlushInterval: time.Hour})
+	tracker.Start()
+
+	for i := 0; i < 10; i++ {
+		if !tracker.Track(Event{ShortCode: "abc"}) {
+			t.Fatalf("Track() #%d = false, want true", i)
+		}
+	}
+	tracker.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	total := 0
+	for _, batch := range *batches {
+		if len(batch) > 4 {
+			t.Fatalf("batch size = %d, want <= 4", len(batch))
+		}
+		total += len(batch)
+	}
+	if total != 10 {
+		t.Fatalf("flushed %d events, want 10", total)
+	}
+}
+
+func TestTrackerRejectsWhenFullOrStopped(t *testing.T) {
+	t.Parallel()
+
+	// Not started, so nothing drains the queue.
+	tracker, _, _ := newTestTracker(Options{QueueSize: 1, BatchSize: 1, FlushInterval: time.Hour})
+
+	if !tracker.Track(Event{}) {
+		t.Fatal("Track() on empty queue = false, want true")
+	}
+	if tracker.Track(Event{}) {
+		t.Fatal("Track() on full queue = true, want false")
+	}
+
+	tracker.Stop()
+	if tracker.Track(Event{}) {
+		t.Fatal("Track() after Stop = true, want false")
+	}
+}
+
+func TestTrackerSpillsOverflowAndReplays(t *testing.T) {
+	t.Parallel()
+
+	tracker, batches, mu := newTestTracker(Options{
+		QueueSize:      1,
+		BatchSize:      10,
+		FlushInterval:  time.Hour,
+		Workers:        1,
+		OverflowPolicy: OverflowSpill,
+		SpillDir:       t.TempDir(),
+		SpillMaxMB:     1,
+	})
+
+	// Queue holds one event; the other two must go to the spill file.
+	for i := 0; i < 3; i++ {
+		if !tracker.Track(Event{ShortCode: "abc", CountClick: true}) {
+			t.Fatalf("Track() #%d = false, want true", i)
+		}
+	}
+	if got := tracker.Metrics(); got.Spilled != 2 || got.Dropped != 0 {
+		t.Fatalf("spilled=%d dropped=%d, want 2 and 0", got.Spilled, got.Dropped)
+	}
+
+	tracker.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for tracker.Metrics().Replayed < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	tracker.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	total := 0
+	for _, batch := range *batches {
+		for _, event := range batch {
+			if event.Location.Country != "Indonesia" || !event.CountClick {
+				t.Fatalf("event = %+v, want enriched event with CountClick", event)
+			}
+		}
+		total += len(batch)
+	}
+	if total != 3 {
+		t.Fatalf("flushed %d events, want 3", total)
+	}
+}
+
+func TestTrackerRetriesAndSpillsFailedBatches(t *testing.T) {
+	t.Parallel()
+
+	spillDir := t.TempDir()
+	tracker, _, _ := newTestTracker(Options{
+		QueueSize:      8,
+		BatchSize:      2,
+		FlushInterval:  time.Hour,
+		Workers:        1,
+		OverflowPolicy: OverflowSpill,
+		SpillDir:       spillDir,
+		SpillMaxMB:     1,
+	})
+	tracker.retryBackoff = time.Millisecond
+
+	var attempts atomic.Int64
+	tracker.flush = func(context.Context, []Event) error {
+		attempts.Add(1)
+		return errors.New("database unavailable")
+	}
+
+	tracker.Start()
+	tracker.Track(Event{ShortCode: "abc", CountClick: true})
+	tracker.Track(Event{ShortCode: "abc", CountClick: true})
+	tracker.Stop()
+
+	if got := attempts.Load(); got != flushAttempts {
+		t.Fatalf("flush attempts = %d, want %d", got, flushAttempts)
+	}
+	if got := tracker.Metrics(); got.Spilled != 2 || got.FailedWrites != 0 {
+		t.Fatalf("spilled=%d failed=%d, want 2 and 0", got.Spilled, got.FailedWrites)
+	}
+
+	// The next run replays the spilled batch with its location intact
+	replay, batches, mu := newTestTracker(Options{
+		QueueSize:      8,
+		BatchSize:      2,
+		FlushInterval:  time.Hour,
+		Workers:        1,
+		OverflowPolicy: OverflowSpill,
+		SpillDir:       spillDir,
+		SpillMaxMB:     1,
+	})
+	replay.locate = func(string) ip.Location { return ip.Location{} }
+	replay.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for replay.Metrics().Replayed < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	replay.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	total := 0
+	for _, batch := range *batches {
+		for _, event := range batch {
+			if event.Location.Country != "Indonesia" || !event.CountClick {
+				t.Fatalf("event = %+v, want the spilled location and CountClick", event)
+			}
+		}
+		total += len(batch)
+	}
+	if total != 2 {
+		t.Fatalf("replayed %d events, want 2", total)
+	}
+}
+
+func TestTrackerAnonymizesBeforeSpilling(t *testing.T) {
+	t.Parallel()
+
+	spillDir := t.TempDir()
+	tracker, _, _ := newTestTracker(Options{
+		QueueSize:      1,
+		BatchSize:      10,
+		FlushInterval:  time.Hour,
+		OverflowPolicy: OverflowSpill,
+		SpillDir:       spillDir,
+		SpillMaxMB:     1,
+	})
+
+	// Not started, so the second event overflows into the spill file
+	tracker.Track(Event{ShortCode: "abc"})
+	tracker.Track(Event{ShortCode: "abc", IPAddress: "203.0.113.77", UserAgent: "Mozilla/5.0", PrivacyMode: privacy.ModeTruncate})
+	tracker.Stop()
+
+	data, err := os.ReadFile(filepath.Join(spillDir, spillFileName))
+	if err != nil {
+		t.Fatalf("read spill file: %v", err)
+	}
+	spilled := string(data)
+	if strings.Contains(spilled, "203.0.113.77") || strings.Contains(spilled, "Mozilla") {
+		t.Fatalf("spill file kept identifiers: %s", spilled)
+	}
+	if !strings.Contains(spilled, `"anonymized":true`) || !strings.Contains(spilled, "Jakarta") {
+		t.Fatalf("spilled event was not processed: %s", spilled)
+	}
+}
+
+func TestTrackerSetsAsideRowsTheDatabaseRejects(t *testing.T) {
+	t.Parallel()
+
+	spillDir := t.TempDir()
+	tracker, batches, mu := newTestTracker(Options{
+		BatchSize:      10,
+		FlushInterval:  time.Hour,
+		OverflowPolicy: OverflowSpill,
+		SpillDir:       spillDir,
+		SpillMaxMB:     1,
+	})
+	tracker.retryBackoff = time.Millisecond
+	tracker.ping = func(context.Context) error { return nil }
+	flush := tracker.flush
+	tracker.flush = func(ctx context.Context, batch []Event) error {
+		for _, event := range batch {
+			if event.ShortCode == "bad" {
+				return errors.New("data too long")
+			}
+		}
+		return flush(ctx, batch)
+	}
+
+	// The good row of the batch is written and the bad one spilled
+	tracker.flushBatch([]Event{{ShortCode: "good"}, {ShortCode: "bad"}})
+	if got := tracker.Metrics(); got.Written != 1 || got.Spilled != 1 || got.DeadLettered != 0 {
+		t.Fatalf("written=%d spilled=%d dead=%d, want 1, 1 and 0", got.Written, got.Spilled, got.DeadLettered)
+	}
+	mu.Lock()
+	if len(*batches) != 1 || (*batches)[0][0].ShortCode != "good" {
+		t.Fatalf("batches = %+v, want only the good row", *batches)
+	}
+	mu.Unlock()
+
+	// A row that keeps failing is moved to the dead-letter file
+	tracker.flushBatch([]Event{{ShortCode: "bad", FlushFailures: maxEventFlushFailures - 1}})
+	if got := tracker.Metrics(); got.Spilled != 1 || got.DeadLettered != 1 {
+		t.Fatalf("spilled=%d dead=%d, want 1 and 1", got.Spilled, got.DeadLettered)
+	}
+	tracker.Stop()
+
+	data, err := os.ReadFile(filepath.Join(spillDir, deadLetterName))
+	if err != nil || !strings.Contains(string(data), `"short_code":"bad"`) {
+		t.Fatalf("dead-letter file = %q, %v", data, err)
+	}
+}
+
+func TestTrackerKeepsBatchesDuringOutage(t *testing.T) {
+	t.Parallel()
+
+	tracker, _, _ := newTestTracker(Options{
+		BatchSize:      10,
+		FlushInterval:  time.Hour,
+		OverflowPolicy: OverflowSpill,
+		SpillDir:       t.TempDir(),
+		SpillMaxMB:     1,
+	})
+	tracker.retryBackoff = time.Millisecond
+	tracker.ping = func(context.Context) error { return errors.New("connection refused") }
+	tracker.flush = func(context.Context, []Event) error { return errors.New("connection refused") }
+
+	// No row is blamed while the database is down
+	tracker.flushBatch([]Event{{ShortCode: "a", FlushFailures: maxEventFlushFailures - 1}, {ShortCode: "b"}})
+	tracker.Stop()
+	if got := tracker.Metrics(); got.Spilled != 2 || got.DeadLettered != 0 {
+		t.Fatalf("spilled=%d dead=%d, want 2 and 0", got.Spilled, got.DeadLettered)
+	}
+}
@@ -0,0 +1,247 @@
+// Package codepolicy decides which short codes may be used. Reserved words
+// cannot be a whole code, and blocked terms, such as profanity and protected
+// brand names, cannot appear anywhere in one. Both are matched after
+// leetspeak normalization, so l0gin and b4ngs4t are caught too.
+package codepolicy
+
+import (
+	"embed"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+const (
+	// entriesCacheTTL bounds how long an admin change takes to reach every
+	// instance
+	entriesCacheTTL = 30 * time.Second
+	// minSubstringLength is the shortest normalized blocked term matched
+	// inside a code; shorter ones only match whole words
+	minSubstringLength = 4
+)
+
+var (
+	ErrReserved = errors.New("short code is reserved")
+	ErrBlocked  = errors.New("short code contains a blocked term")
+)
+
+//go:embed data/reserved.conf data/blocked.conf
+var seedFS embed.FS
+
+// leetReplacer maps look-alike characters to the letter they stand for. l
+// and i are folded together because 1 and | stand for either.
+var leetReplacer = strings.NewReplacer(
+	"0", "o",
+	"1", "i",
+	"!", "i",
+	"|", "i",
+	"l", "i",
+	"2", "z",
+	"3", "e",
+	"4", "a",
+	"@", "a",
+	"5", "s",
+	"$", "s",
+	"6", "g",
+	"7", "t",
+	"8", "b",
+	"9", "g",
+)
+
+// Normalize returns the form codes and words are compared in: lower case,
+// look-alikes replaced, runs of one letter collapsed and separators
+// dropped.
+func Normalize(s string) string {
+	s = leetReplacer.Replace(strings.ToLower(s))
+
+	var b strings.Builder
+	var last rune
+	for _, r := range s {
+		if r == '-' || r == '_' || r == ' ' || r == '.' || (b.Len() > 0 && r == last) {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
+
+// Matcher checks codes against a fixed set of words.
+type Matcher struct {
+	reserved map[string]struct{}
+	// Blocked terms matched inside codes, and short ones matched whole
+	blockedSubstrings []string
+	blockedWords      map[string]struct{}
+}
+
+// NewMatcher builds a matcher over entries.
+func NewMatcher(entries []shortlink.ReservedCode) *Matcher {
+	m := &Matcher{
+		reserved:     make(map[string]struct{}),
+		blockedWords: make(map[string]struct{}),
+	}
+	for _, entry := range entries {
+		word := Normalize(entry.Word)
+		if word == "" {
+			continue
+		}
+		switch entry.Kind {
+		case shortlink.ReservedCodeKindReserved:
+			m.reserved[word] = struct{}{}
+		case shortlink.ReservedCodeKindBlocked:
+			if len(word) >= minSubstringLength {
+				m.blockedSubstrings = append(m.blockedSubstrings, word)
+			} else {
+				m.blockedWords[word] = struct{}{}
+			}
+		}
+	}
+	return m
+}
+
+// Check returns ErrReserved or ErrBlocked when code may not be used.
+func (m *Matcher) Check(code string) error {
+	normalized := Normalize(code)
+	if _, reserved := m.reserved[normalized]; reserved {
+		return ErrReserved
+	}
+
+	for _, term := range m.blockedSubstrings {
+		if strings.Contains(normalized, term) {
+			return ErrBlocked
+		}
+	}
+	if _, blocked := m.blockedWords[normalized]; blocked {
+		return ErrBlocked
+	}
+	for _, part := range strings.FieldsFunc(code, func(r rune) bool { return r == '-' || r == '_' }) {
+		if _, blocked := m.blockedWords[Normalize(part)]; blocked {
+			return ErrBlocked
+		}
+	}
+	return nil
+}
+
+// Policy is a Matcher over the reserved_codes table, reloaded every
+// entriesCacheTTL.
+type Policy struct {
+	db *gorm.DB
+
+	mu       sync.Mutex
+	matcher  *Matcher
+	loadedAt time.Time
+	now      func() time.Time
+}
+
+var (
+	globalPolicy   *Policy
+	globalPolicyMu sync.RWMutex
+)
+
+// NewPolicy creates a policy reading its words from db.
+func NewPolicy(db *gorm.DB) *Policy {
+	return &Policy{db: db, matcher: NewMatcher(nil), now: time.Now}
+}
+
+// InitGlobal initializes the global policy, copying the seed lists into an
+// empty reserved_codes table first.
+func InitGlobal(db *gorm.DB) error {
+	if db == nil {
+		return errors.New("gorm db is required")
+	}
+
+	var count int64
+	if err := db.Model(&shortlink.ReservedCode{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		seeds := SeedEntries()
+		if err := db.CreateInBatches(seeds, 100).Error; err != nil {
+			return err
+		}
+		logger.Logger.Info("Seeded reserved short codes", "count", len(seeds))
+	}
+
+	policy := NewPolicy(db)
+	globalPolicyMu.Lock()
+	globalPolicy = policy
+	globalPolicyMu.Unlock()
+	return nil
+}
+
+// Global returns the initialized global policy, or nil before InitGlobal.
+func Global() *Policy {
+	globalPolicyMu.RLock()
+	defer globalPolicyMu.RUnlock()
+	return globalPolicy
+}
+
+// Check returns ErrReserved or ErrBlocked when code may not be used. A
+// failed reload keeps the last known words.
+func (p *Policy) Check(code string) error {
+	return p.current().Check(code)
+}
+
+// Invalidate makes the next Check reload the words, so an admin change
+// applies on this instance right away.
+func (p *Policy) Invalidate() {
+	p.mu.Lock()
+	p.loadedAt = time.Time{}
+	p.mu.Unlock()
+}
+
+func (p *Policy) current() *Matcher {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.loadedAt.IsZero() && p.now().Sub(p.loadedAt) < entriesCacheTTL {
+		return p.matcher
+	}
+
+	var entries []shortlink.ReservedCode
+	if err := p.db.Find(&entries).Error; err != nil {
+		logger.Logger.Warn("Failed to load reserved short codes, using last known list", "error", err.Error())
+	} else {
+		p.matcher = NewMatcher(entries)
+	}
+	p.loadedAt = p.now()
+	return p.matcher
+}
+
+// SeedEntries returns the embedded seed lists.
+func SeedEntries() []shortlink.ReservedCode {
+	var entries []shortlink.ReservedCode
+	for _, seed := range []struct{ kind, file string }{
+		{shortlink.ReservedCodeKindReserved, "data/reserved.conf"},
+		{shortlink.ReservedCodeKindBlocked, "data/blocked.conf"},
+	} {
+		raw, err := seedFS.ReadFile(seed.file)
+		if err != nil {
+			logger.Logger.Warn("Failed to load seed short code list", "file", seed.file, "error", err.Error())
+			continue
+		}
+		for _, word := range ParseList(string(raw)) {
+			entries = append(entries, shortlink.ReservedCode{Word: word, Kind: seed.kind})
+		}
+	}
+	return entries
+}
+
+// ParseList parses a newline-separated word list, skipping blank lines and
+// # comments.
+func ParseList(raw string) []string {
+	var words []string
+	for _, line := range strings.Split(raw, "\n") {
+		word := strings.ToLower(strings.TrimSpace(line))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
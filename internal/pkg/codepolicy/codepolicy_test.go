@@ -0,0 +1,87 @@
+package codepolicy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Login", want: "iogin"},
+		{in: "L0G1N", want: "iogin"},
+		{in: "b4ngs4t", want: "bangsat"},
+		{in: "baaabi", want: "babi"},
+		{in: "sh-i_t", want: "shit"},
+		{in: "$h!7", want: "shit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			if got := Normalize(tt.in); got != tt.want {
+				t.Fatalf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherCheck(t *testing.T) {
+	t.Parallel()
+
+	matcher := NewMatcher([]shortlink.ReservedCode{
+		{Word: "admin", Kind: shortlink.ReservedCodeKindReserved},
+		{Word: "login", Kind: shortlink.ReservedCodeKindReserved},
+		{Word: "bangsat", Kind: shortlink.ReservedCodeKindBlocked},
+		{Word: "lihatin", Kind: shortlink.ReservedCodeKindBlocked},
+		{Word: "ass", Kind: shortlink.ReservedCodeKindBlocked},
+	})
+
+	tests := []struct {
+		name string
+		code string
+		want error
+	}{
+		{name: "reserved word", code: "admin", want: ErrReserved},
+		{name: "reserved word in other case", code: "ADMIN", want: ErrReserved},
+		{name: "reserved look-alike", code: "l0g1n", want: ErrReserved},
+		{name: "reserved word inside a code is fine", code: "admin-guide", want: nil},
+		{name: "blocked term", code: "bangsat", want: ErrBlocked},
+		{name: "blocked term inside a code", code: "promo-b4ngs4t-2024", want: ErrBlocked},
+		{name: "stretched blocked term", code: "baaangsaaat", want: ErrBlocked},
+		{name: "brand inside a code", code: "LihatinPromo", want: ErrBlocked},
+		{name: "short blocked term as a whole code", code: "a55", want: ErrBlocked},
+		{name: "short blocked term as a part", code: "kick_ass", want: ErrBlocked},
+		{name: "short blocked term inside a word is fine", code: "classic", want: nil},
+		{name: "ordinary code", code: "summer-sale", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := matcher.Check(tt.code); !errors.Is(err, tt.want) {
+				t.Fatalf("Check(%q) = %v, want %v", tt.code, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeedEntries(t *testing.T) {
+	t.Parallel()
+
+	matcher := NewMatcher(SeedEntries())
+	for _, code := range []string{"admin", "api", "login"} {
+		if err := matcher.Check(code); !errors.Is(err, ErrReserved) {
+			t.Fatalf("Check(%q) = %v, want ErrReserved", code, err)
+		}
+	}
+	if err := matcher.Check("my-link"); err != nil {
+		t.Fatalf("Check(my-link) = %v, want nil", err)
+	}
+}
@@ -145,8 +145,71 @@ const (
 	// Disposable email policy
 	EnvDisposableEmailBlockListURL = "DISPOSABLE_EMAIL_BLOCK_LIST_URL"
 
+	// Destination URL safety scanning
+	// #nosec G101 -- These are validation messages, not credentials.
+	EnvURLSafetyEnabled         = "URL_SAFETY_ENABLED"
+	EnvURLSafetyBlockListURLs   = "URL_SAFETY_BLOCK_LIST_URLS"
+	EnvURLSafetySafeBrowsingKey = "URL_SAFETY_SAFE_BROWSING_KEY"
+	EnvURLSafetyRescanBatchSize = "URL_SAFETY_RESCAN_BATCH_SIZE"
+
+	// Redirect interstitial page
+	EnvInterstitialNewLinkHours = "INTERSTITIAL_NEW_LINK_HOURS"
+
+	// Passcode protected links
+	EnvPasscodeUnlockMinutes = "PASSCODE_UNLOCK_MINUTES"
+	EnvPasscodeLimitPerLink  = "PASSCODE_LIMIT_PER_LINK"
+	EnvPasscodeLimitPerIP    = "PASSCODE_LIMIT_PER_IP"
+	EnvPasscodeWindowMinutes = "PASSCODE_WINDOW_MINUTES"
+
+	// Destination title, description and favicon fetching
+	EnvLinkMetadataEnabled        = "LINK_METADATA_ENABLED"
+	EnvLinkMetadataTimeoutSeconds = "LINK_METADATA_TIMEOUT_SECONDS"
+	EnvLinkMetadataMaxKB          = "LINK_METADATA_MAX_KB"
+	EnvLinkMetadataMaxRedirects   = "LINK_METADATA_MAX_REDIRECTS"
+	EnvLinkMetadataMaxAttempts    = "LINK_METADATA_MAX_ATTEMPTS"
+	EnvLinkMetadataBatchSize      = "LINK_METADATA_BATCH_SIZE"
+
+	// Generated short codes
+	EnvShortCodeStrategy    = "SHORT_CODE_STRATEGY"
+	EnvShortCodeLength      = "SHORT_CODE_LENGTH"
+	EnvShortCodeMaxAttempts = "SHORT_CODE_MAX_ATTEMPTS"
+
+	// Bulk link imports
+	EnvLinkImportMaxRows   = "LINK_IMPORT_MAX_ROWS"
+	EnvLinkImportMaxFileMB = "LINK_IMPORT_MAX_FILE_MB"
+	EnvLinkImportBatchSize = "LINK_IMPORT_BATCH_SIZE"
+
 	// Support + captcha
 	EnvTurnstileSecretKey = "TURNSTILE_SECRET_KEY"
 	EnvTurnstileSiteKey   = "TURNSTILE_SITE_KEY"
 	EnvSupportAlertEmails = "SUPPORT_ALERT_EMAILS"
+
+	// Redirect hot path + click ingestion
+	EnvRedirectCacheTTLSeconds = "REDIRECT_CACHE_TTL_SECONDS"
+	EnvClickQueueSize          = "CLICK_QUEUE_SIZE"
+	EnvClickBatchSize          = "CLICK_BATCH_SIZE"
+	EnvClickFlushIntervalMS    = "CLICK_FLUSH_INTERVAL_MS"
+	EnvClickWorkers            = "CLICK_WORKERS"
+	EnvClickOverflowPolicy     = "CLICK_OVERFLOW_POLICY"
+	EnvClickSpillDir           = "CLICK_SPILL_DIR"
+	EnvClickSpillMaxMB         = "CLICK_SPILL_MAX_MB"
+	EnvClickDedupWindowMinutes = "CLICK_DEDUP_WINDOW_MINUTES"
+
+	// Raw click retention + archival export
+	EnvClickRetentionDays      = "CLICK_RETENTION_DAYS"
+	EnvClickRetentionTierDays  = "CLICK_RETENTION_TIER_DAYS"
+	EnvClickRetentionBatchSize = "CLICK_RETENTION_BATCH_SIZE"
+	EnvClickArchiveEnabled     = "CLICK_ARCHIVE_ENABLED"
+	EnvClickArchiveFormat      = "CLICK_ARCHIVE_FORMAT"
+
+	// Visitor privacy defaults for click tracking
+	EnvClickPrivacyMode     = "CLICK_PRIVACY_MODE"
+	EnvClickPrivacyHonorDNT = "CLICK_PRIVACY_HONOR_DNT"
+
+	// GeoIP provider selection
+	EnvGeoIPProvider        = "GEOIP_PROVIDER"
+	EnvGeoIPCityDBPath      = "GEOIP_CITY_DB_PATH"
+	EnvGeoIPASNDBPath       = "GEOIP_ASN_DB_PATH"
+	EnvGeoIPCacheSize       = "GEOIP_CACHE_SIZE"
+	EnvGeoIPCacheTTLMinutes = "GEOIP_CACHE_TTL_MINUTES"
 )
@@ -0,0 +1,71 @@
+package deeplink
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Well-known paths iOS and Android fetch from a domain to trust its app links
+const (
+	AppleAppSiteAssociationPath = "/.well-known/apple-app-site-association"
+	AssetLinksPath              = "/.well-known/assetlinks.json"
+)
+
+var (
+	iosAppIDPattern        = regexp.MustCompile(`^[A-Z0-9]{10}\.[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*$`)
+	androidPackagePattern  = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*(\.[a-zA-Z][a-zA-Z0-9_]*)+$`)
+	certFingerprintPattern = regexp.MustCompile(`^([0-9A-F]{2}:){31}[0-9A-F]{2}$`)
+)
+
+// ValidIOSAppID reports whether id is TEAMID.bundle.identifier.
+func ValidIOSAppID(id string) bool {
+	return iosAppIDPattern.MatchString(id)
+}
+
+// ValidAndroidPackage reports whether name is a Java-style package name.
+func ValidAndroidPackage(name string) bool {
+	return androidPackagePattern.MatchString(name)
+}
+
+// NormalizeCertFingerprint upper-cases a SHA-256 signing certificate
+// fingerprint, reporting false unless it is 32 colon separated hex bytes.
+func NormalizeCertFingerprint(fingerprint string) (string, bool) {
+	fingerprint = strings.ToUpper(strings.TrimSpace(fingerprint))
+	return fingerprint, certFingerprintPattern.MatchString(fingerprint)
+}
+
+// AppleAppSiteAssociation returns the association file that lets the apps in
+// appIDs open every path on the domain as a universal link.
+func AppleAppSiteAssociation(appIDs []string) ([]byte, error) {
+	type component struct {
+		Path string `json:"/"`
+	}
+	type detail struct {
+		AppIDs     []string    `json:"appIDs"`
+		Components []component `json:"components"`
+	}
+	return json.Marshal(map[string]any{
+		"applinks": map[string]any{
+			"details": []detail{{AppIDs: appIDs, Components: []component{{Path: "*"}}}},
+		},
+	})
+}
+
+// AssetLinks returns the Digital Asset Links statement that lets the app
+// signed with fingerprints open the domain's links.
+func AssetLinks(packageName string, fingerprints []string) ([]byte, error) {
+	type target struct {
+		Namespace    string   `json:"namespace"`
+		PackageName  string   `json:"package_name"`
+		Fingerprints []string `json:"sha256_cert_fingerprints"`
+	}
+	type statement struct {
+		Relation []string `json:"relation"`
+		Target   target   `json:"target"`
+	}
+	return json.Marshal([]statement{{
+		Relation: []string{"delegate_permission/common.handle_all_urls"},
+		Target:   target{Namespace: "android_app", PackageName: packageName, Fingerprints: fingerprints},
+	}})
+}
@@ -0,0 +1,156 @@
+// Package deeplink sends mobile visitors of a short link into the native app.
+// A link's iOS and Android targets are either universal/app links, which the
+// OS hands to the app when it is installed, or custom scheme URLs, which need
+// a fallback to the web when it is not.
+package deeplink
+
+import (
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"encoding/base64"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Platforms a link can carry a deep link for
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
+// fallbackDelayMS is how long the open page waits for the app before it
+// loads the fallback
+const fallbackDelayMS = 1500
+
+var (
+	schemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+
+	// Schemes that run code or read local data in the browser instead of
+	// opening an app
+	blockedSchemes = map[string]struct{}{
+		"javascript": {},
+		"vbscript":   {},
+		"data":       {},
+		"file":       {},
+		"blob":       {},
+		"about":      {},
+		"intent":     {},
+	}
+)
+
+//go:embed templates/open.html
+var templateFS embed.FS
+
+var openTemplate = template.Must(template.ParseFS(templateFS, "templates/open.html"))
+
+// PlatformFor maps the OS and device names the redirect already derives from
+// the User-Agent to a platform, or "" for anything else.
+func PlatformFor(os, device string) string {
+	lower := strings.ToLower(os)
+	switch {
+	case strings.HasPrefix(lower, "android"):
+		return PlatformAndroid
+	case strings.Contains(lower, "iphone os"), strings.HasPrefix(lower, "cpu os"), strings.HasPrefix(lower, "ios"), device == "iPad":
+		return PlatformIOS
+	}
+	return ""
+}
+
+// Choose returns the target for platform out of a link's iOS and Android
+// deep links, or "" when the link has none for it.
+func Choose(platform, iosTarget, androidTarget string) string {
+	switch platform {
+	case PlatformIOS:
+		return iosTarget
+	case PlatformAndroid:
+		return androidTarget
+	}
+	return ""
+}
+
+// ValidTarget reports whether raw may be used as a deep link: an absolute
+// http(s) URL, or a URL in an app's custom scheme. Empty is valid and means
+// no deep link.
+func ValidTarget(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || !schemePattern.MatchString(parsed.Scheme) {
+		return false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme == "http" || scheme == "https" {
+		return parsed.Host != ""
+	}
+	_, blocked := blockedSchemes[scheme]
+	return !blocked
+}
+
+// IsAppLink reports whether target is a universal/app link, which the OS
+// opens in the app or, without it, as a normal web page.
+func IsAppLink(target string) bool {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	return scheme == "http" || scheme == "https"
+}
+
+// AndroidIntentURL wraps a custom scheme target in an intent: URL, so Chrome
+// opens the app when it is installed and fallback otherwise.
+func AndroidIntentURL(target, fallback string) string {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return fallback
+	}
+	scheme := parsed.Scheme
+	parsed.Scheme = ""
+	rest := strings.TrimPrefix(parsed.String(), "//")
+	return "intent://" + rest + "#Intent;scheme=" + scheme +
+		";S.browser_fallback_url=" + url.QueryEscape(fallback) + ";end"
+}
+
+// OpenPage is the page that tries a custom scheme target on iOS and falls
+// back to the web when the app does not take over.
+type OpenPage struct {
+	AppURL      string
+	FallbackURL string
+	// Nonce lets the page's inline script through its CSP
+	Nonce string
+}
+
+// NewNonce returns a random CSP nonce.
+func NewNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(buf), nil
+}
+
+// RenderOpenPage returns the open page as HTML. AppURL must have passed
+// ValidTarget.
+func RenderOpenPage(page OpenPage) ([]byte, error) {
+	var buf bytes.Buffer
+	err := openTemplate.Execute(&buf, struct {
+		AppURL          template.URL
+		FallbackURL     string
+		Nonce           string
+		FallbackDelayMS int
+	}{
+		// #nosec G203 -- ValidTarget rejects script and local schemes
+		AppURL:          template.URL(page.AppURL),
+		FallbackURL:     page.FallbackURL,
+		Nonce:           page.Nonce,
+		FallbackDelayMS: fallbackDelayMS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
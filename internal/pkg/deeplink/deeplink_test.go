@@ -0,0 +1,138 @@
+package deeplink
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPlatformFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		os     string
+		device string
+		want   string
+	}{
+		{"CPU iPhone OS 17_0 like Mac OS X", "Mobile", PlatformIOS},
+		{"CPU OS 16_6 like Mac OS X", "iPad", PlatformIOS},
+		{"Android 14", "Mobile", PlatformAndroid},
+		{"Intel Mac OS X 10_15_7", "Desktop", ""},
+		{"Windows 10", "Desktop", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := PlatformFor(tt.os, tt.device); got != tt.want {
+			t.Errorf("PlatformFor(%q, %q) = %q, want %q", tt.os, tt.device, got, tt.want)
+		}
+	}
+}
+
+func TestValidTarget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"", true},
+		{"https://app.example.com/product/42", true},
+		{"myapp://product/42?ref=short", true},
+		{"fb://profile/123", true},
+		{"https:///missing-host", false},
+		{"javascript:alert(1)", false},
+		{"JavaScript:alert(1)", false},
+		{"data:text/html,hi", false},
+		{"intent://x#Intent;end", false},
+		{"/relative/path", false},
+		{"not a url", false},
+	}
+	for _, tt := range tests {
+		if got := ValidTarget(tt.raw); got != tt.want {
+			t.Errorf("ValidTarget(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestAndroidIntentURL(t *testing.T) {
+	t.Parallel()
+
+	got := AndroidIntentURL("myapp://product/42?ref=short", "https://example.com/p/42?a=1&b=2")
+	want := "intent://product/42?ref=short#Intent;scheme=myapp;S.browser_fallback_url=https%3A%2F%2Fexample.com%2Fp%2F42%3Fa%3D1%26b%3D2;end"
+	if got != want {
+		t.Errorf("AndroidIntentURL() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderOpenPage(t *testing.T) {
+	t.Parallel()
+
+	body, err := RenderOpenPage(OpenPage{
+		AppURL:      "myapp://product/42",
+		FallbackURL: `https://example.com/?q="</script>`,
+		Nonce:       "abc123",
+	})
+	if err != nil {
+		t.Fatalf("RenderOpenPage() error = %v", err)
+	}
+
+	html := string(body)
+	for _, want := range []string{`href="myapp://product/42"`, `<script nonce="abc123">`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("RenderOpenPage() missing %q", want)
+		}
+	}
+	if strings.Count(html, "</script>") != 1 {
+		t.Error("RenderOpenPage() does not escape the fallback URL in the script")
+	}
+}
+
+func TestAssociationFiles(t *testing.T) {
+	t.Parallel()
+
+	aasa, err := AppleAppSiteAssociation([]string{"ABCDE12345.com.example.app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apple struct {
+		AppLinks struct {
+			Details []struct {
+				AppIDs     []string            `json:"appIDs"`
+				Components []map[string]string `json:"components"`
+			} `json:"details"`
+		} `json:"applinks"`
+	}
+	if err := json.Unmarshal(aasa, &apple); err != nil {
+		t.Fatal(err)
+	}
+	if len(apple.AppLinks.Details) != 1 || apple.AppLinks.Details[0].AppIDs[0] != "ABCDE12345.com.example.app" || apple.AppLinks.Details[0].Components[0]["/"] != "*" {
+		t.Errorf("AppleAppSiteAssociation() = %s", aasa)
+	}
+
+	fingerprint := strings.Repeat("AB:", 31) + "AB"
+	links, err := AssetLinks("com.example.app", []string{fingerprint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"relation":["delegate_permission/common.handle_all_urls"],"target":{"namespace":"android_app","package_name":"com.example.app","sha256_cert_fingerprints":["` + fingerprint + `"]}}]`
+	if string(links) != want {
+		t.Errorf("AssetLinks() = %s, want %s", links, want)
+	}
+}
+
+func TestAppLinkValidation(t *testing.T) {
+	t.Parallel()
+
+	if !ValidIOSAppID("ABCDE12345.com.example.app") || ValidIOSAppID("com.example.app") {
+		t.Error("ValidIOSAppID() misjudges the team ID prefix")
+	}
+	if !ValidAndroidPackage("com.example.app") || ValidAndroidPackage("example") || ValidAndroidPackage("com.1example") {
+		t.Error("ValidAndroidPackage() misjudges package names")
+	}
+	if got, ok := NormalizeCertFingerprint(" " + strings.Repeat("ab:", 31) + "ab "); !ok || got != strings.Repeat("AB:", 31)+"AB" {
+		t.Errorf("NormalizeCertFingerprint() = %q, %v", got, ok)
+	}
+	if _, ok := NormalizeCertFingerprint("AB:CD"); ok {
+		t.Error("NormalizeCertFingerprint() accepted a short fingerprint")
+	}
+}
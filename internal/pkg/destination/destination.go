@@ -0,0 +1,140 @@
+// Package destination builds the URL a redirect sends the visitor to: the
+// link's destination with its stored UTM tags and, when the link forwards
+// them, the visitor's own query parameters merged in.
+package destination
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Conflict modes for a parameter the URL already has
+const (
+	// ConflictKeep leaves the parameter as it is; the default
+	ConflictKeep = "keep"
+	// ConflictOverride replaces its values
+	ConflictOverride = "override"
+	// ConflictAppend adds the new values after the existing ones
+	ConflictAppend = "append"
+)
+
+// Options says what Build merges into a destination.
+type Options struct {
+	// UTM holds the link's stored UTM tags; empty values are skipped
+	UTM url.Values
+	// Incoming is the visitor's query string, merged only when Forward is set
+	Incoming url.Values
+	Forward  bool
+	// Exclude names incoming parameters that are never forwarded
+	Exclude []string
+	// Conflict is one of the conflict modes; empty means ConflictKeep
+	Conflict string
+}
+
+// queryPair is one key=value pair of a query string, kept as it was written
+// so parameters Build does not touch are passed on unchanged.
+type queryPair struct {
+	key string
+	raw string
+}
+
+// Build returns rawURL with the UTM tags merged in, then the forwarded
+// incoming parameters. A parameter the URL already has, from the
+// destination or the UTM tags, is handled by opts.Conflict. rawURL is
+// returned untouched when there is nothing to merge.
+func Build(rawURL string, opts Options) (string, error) {
+	utm := withoutEmpty(opts.UTM)
+	var forwarded url.Values
+	if opts.Forward {
+		forwarded = without(opts.Incoming, opts.Exclude)
+	}
+	if len(utm) == 0 && len(forwarded) == 0 {
+		return rawURL, nil
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	pairs := splitQuery(target.RawQuery)
+	pairs = merge(pairs, utm, opts.Conflict)
+	pairs = merge(pairs, forwarded, opts.Conflict)
+	target.RawQuery = joinQuery(pairs)
+	return target.String(), nil
+}
+
+// merge adds params to pairs in key order, resolving keys pairs already has
+// with conflict.
+func merge(pairs []queryPair, params url.Values, conflict string) []queryPair {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		hasKey := func(pair queryPair) bool { return pair.key == key }
+		if slices.ContainsFunc(pairs, hasKey) {
+			switch conflict {
+			case ConflictOverride:
+				pairs = slices.DeleteFunc(pairs, hasKey)
+			case ConflictAppend:
+			default:
+				continue
+			}
+		}
+		for _, value := range params[key] {
+			pairs = append(pairs, queryPair{key: key, raw: url.QueryEscape(key) + "=" + url.QueryEscape(value)})
+		}
+	}
+	return pairs
+}
+
+func splitQuery(rawQuery string) []queryPair {
+	var pairs []queryPair
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(raw, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		pairs = append(pairs, queryPair{key: key, raw: raw})
+	}
+	return pairs
+}
+
+func joinQuery(pairs []queryPair) string {
+	raw := make([]string, len(pairs))
+	for i, pair := range pairs {
+		raw[i] = pair.raw
+	}
+	return strings.Join(raw, "&")
+}
+
+func withoutEmpty(params url.Values) url.Values {
+	out := url.Values{}
+	for key, values := range params {
+		for _, value := range values {
+			if value != "" {
+				out.Add(key, value)
+			}
+		}
+	}
+	return out
+}
+
+func without(params url.Values, exclude []string) url.Values {
+	out := url.Values{}
+	for key, values := range params {
+		if key == "" || slices.Contains(exclude, key) {
+			continue
+		}
+		out[key] = values
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package destination
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	utm := url.Values{"utm_source": {"newsletter"}, "utm_medium": {"email"}, "utm_term": {""}}
+
+	tests := []struct {
+		name string
+		url  string
+		opts Options
+		want string
+	}{
+		{
+			name: "nothing to merge leaves the URL untouched",
+			url:  "https://example.com/a?b=1&a=%7e",
+			opts: Options{Incoming: url.Values{"x": {"1"}}},
+			want: "https://example.com/a?b=1&a=%7e",
+		},
+		{
+			name: "stored UTM tags are added and empty ones skipped",
+			url:  "https://example.com/page",
+			opts: Options{UTM: utm},
+			want: "https://example.com/page?utm_medium=email&utm_source=newsletter",
+		},
+		{
+			name: "destination parameters keep their order and encoding",
+			url:  "https://example.com/page?z=1&a=%7e#top",
+			opts: Options{UTM: url.Values{"utm_source": {"x y"}}},
+			want: "https://example.com/page?z=1&a=%7e&utm_source=x+y#top",
+		},
+		{
+			name: "keep leaves the destination value",
+			url:  "https://example.com/?utm_source=site",
+			opts: Options{UTM: utm},
+			want: "https://example.com/?utm_source=site&utm_medium=email",
+		},
+		{
+			name: "override replaces the destination value",
+			url:  "https://example.com/?utm_source=site&id=4",
+			opts: Options{UTM: utm, Conflict: ConflictOverride},
+			want: "https://example.com/?id=4&utm_medium=email&utm_source=newsletter",
+		},
+		{
+			name: "append adds after the destination value",
+			url:  "https://example.com/?utm_source=site",
+			opts: Options{UTM: utm, Conflict: ConflictAppend},
+			want: "https://example.com/?utm_source=site&utm_medium=email&utm_source=newsletter",
+		},
+		{
+			name: "incoming query is only merged when forwarded",
+			url:  "https://example.com/",
+			opts: Options{Incoming: url.Values{"ref": {"tw"}}},
+			want: "https://example.com/",
+		},
+		{
+			name: "forwarded query skips excluded parameters",
+			url:  "https://example.com/",
+			opts: Options{
+				Incoming: url.Values{"ref": {"tw"}, "passcode": {"123456"}, "continue": {"1"}},
+				Forward:  true,
+				Exclude:  []string{"passcode", "continue"},
+			},
+			want: "https://example.com/?ref=tw",
+		},
+		{
+			name: "stored tags win over forwarded ones by default",
+			url:  "https://example.com/",
+			opts: Options{
+				UTM:      url.Values{"utm_source": {"newsletter"}},
+				Incoming: url.Values{"utm_source": {"tw"}},
+				Forward:  true,
+			},
+			want: "https://example.com/?utm_source=newsletter",
+		},
+		{
+			name: "forwarded tags override stored ones with override",
+			url:  "https://example.com/",
+			opts: Options{
+				UTM:      url.Values{"utm_source": {"newsletter"}},
+				Incoming: url.Values{"utm_source": {"tw"}},
+				Forward:  true,
+				Conflict: ConflictOverride,
+			},
+			want: "https://example.com/?utm_source=tw",
+		},
+		{
+			name: "forwarded values are escaped",
+			url:  "https://example.com/",
+			opts: Options{Incoming: url.Values{"q": {"a&b=c"}}, Forward: true},
+			want: "https://example.com/?q=a%26b%3Dc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Build(tt.url, tt.opts)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Build() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInvalidURL(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Build("http://[::1", Options{UTM: url.Values{"utm_source": {"x"}}}); err == nil {
+		t.Fatal("Build() error = nil, want parse error")
+	}
+}
@@ -0,0 +1,121 @@
+// Package domains verifies ownership of custom short link domains and maps
+// request hosts to them.
+package domains
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+)
+
+const (
+	// TXTRecordPrefix is prepended to the domain to form the DNS record name
+	// checked by DNS verification.
+	TXTRecordPrefix = "_lihatin-verify."
+	// TXTValuePrefix is prepended to the token in the record value.
+	TXTValuePrefix = "lihatin-verify="
+
+	maxDomainLength = 191
+	tokenBytes      = 20
+)
+
+// ErrInvalidDomain is returned by Normalize for hosts that cannot be claimed.
+var ErrInvalidDomain = errors.New("invalid domain")
+
+// Normalize reduces a host, host:port or URL to a lowercased host name and
+// rejects IP addresses, single-label names and localhost.
+func Normalize(raw string) (string, error) {
+	host := strings.ToLower(strings.TrimSpace(raw))
+	if strings.Contains(host, "://") {
+		parsed, err := url.Parse(host)
+		if err != nil {
+			return "", ErrInvalidDomain
+		}
+		host = parsed.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	if host == "" || len(host) > maxDomainLength || net.ParseIP(host) != nil {
+		return "", ErrInvalidDomain
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return "", ErrInvalidDomain
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidDomain
+	}
+	for _, label := range labels {
+		if !validLabel(label) {
+			return "", ErrInvalidDomain
+		}
+	}
+	return host, nil
+}
+
+func validLabel(label string) bool {
+	if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, r := range label {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// PrimaryHosts lists the hosts the service itself answers on. They are never
+// treated as custom domains and cannot be claimed.
+func PrimaryHosts() []string {
+	var hosts []string
+	for _, raw := range []string{
+		config.GetEnvOrDefault(config.EnvBackendURL, ""),
+		config.GetEnvOrDefault(config.EnvFrontendURL, ""),
+		config.GetEnvOrDefault(config.EnvDomain, ""),
+	} {
+		if host, err := Normalize(strings.TrimPrefix(raw, ".")); err == nil {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// IsPrimaryHost reports whether host is, or is a subdomain of, one of the
+// service's own hosts.
+func IsPrimaryHost(host string, primary []string) bool {
+	for _, p := range primary {
+		if host == p || strings.HasSuffix(host, "."+p) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewToken returns a random verification token.
+func NewToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// TXTRecordName returns the DNS name checked for domain.
+func TXTRecordName(domain string) string {
+	return TXTRecordPrefix + domain
+}
+
+// TXTRecordValue returns the record value expected for token.
+func TXTRecordValue(token string) string {
+	return TXTValuePrefix + token
+}
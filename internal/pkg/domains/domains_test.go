@@ -0,0 +1,91 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"go.customer.com", "go.customer.com", false},
+		{"  GO.Customer.COM. ", "go.customer.com", false},
+		{"go.customer.com:8443", "go.customer.com", false},
+		{"https://go.customer.com/path", "go.customer.com", false},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example", false},
+		{"", "", true},
+		{"localhost", "", true},
+		{"app.localhost", "", true},
+		{"intranet", "", true},
+		{"203.0.113.7", "", true},
+		{"[2001:db8::1]:443", "", true},
+		{"-bad.example.com", "", true},
+		{"bad_label.example.com", "", true},
+		{"a..b.com", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestIsPrimaryHost(t *testing.T) {
+	t.Parallel()
+
+	primary := []string{"lihatin.id"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"lihatin.id", true},
+		{"api.lihatin.id", true},
+		{"notlihatin.id", false},
+		{"go.customer.com", false},
+	}
+	for _, tt := range tests {
+		if got := IsPrimaryHost(tt.host, primary); got != tt.want {
+			t.Errorf("IsPrimaryHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyDNS(t *testing.T) {
+	t.Parallel()
+
+	records := map[string][]string{
+		"_lihatin-verify.go.customer.com": {"v=spf1 -all", " lihatin-verify=abc123 "},
+	}
+	verifier := &Verifier{
+		LookupTXT: func(_ context.Context, name string) ([]string, error) {
+			if values, ok := records[name]; ok {
+				return values, nil
+			}
+			return nil, errors.New("no such host")
+		},
+	}
+
+	tests := []struct {
+		name    string
+		domain  string
+		token   string
+		wantErr bool
+	}{
+		{"matching record", "go.customer.com", "abc123", false},
+		{"wrong token", "go.customer.com", "other", true},
+		{"missing record", "links.other.com", "abc123", true},
+	}
+	for _, tt := range tests {
+		err := verifier.Verify(context.Background(), tt.domain, tt.token)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Verify() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+const (
+	resolverTTL        = time.Minute
+	resolverMaxEntries = 10000
+)
+
+type resolverEntry struct {
+	domain  *shortlink.CustomDomain
+	expires time.Time
+}
+
+// Resolver maps request hosts to registered custom domains. Lookups are
+// cached in memory for a minute, including misses, so unknown Host headers do
+// not reach MySQL on every request; other instances pick up changes once
+// their entry expires.
+type Resolver struct {
+	db      *gorm.DB
+	primary []string
+	now     func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]resolverEntry
+}
+
+var (
+	globalResolver   *Resolver
+	globalResolverMu sync.RWMutex
+)
+
+// NewResolver creates a resolver that ignores the service's own hosts.
+func NewResolver(db *gorm.DB) *Resolver {
+	return &Resolver{
+		db:      db,
+		primary: PrimaryHosts(),
+		now:     time.Now,
+		entries: make(map[string]resolverEntry),
+	}
+}
+
+// InitGlobal creates and registers the global resolver instance.
+func InitGlobal(db *gorm.DB) (*Resolver, error) {
+	if db == nil {
+		return nil, errors.New("gorm db is required")
+	}
+
+	resolver := NewResolver(db)
+	globalResolverMu.Lock()
+	globalResolver = resolver
+	globalResolverMu.Unlock()
+	return resolver, nil
+}
+
+// Global returns the initialized global resolver, or nil.
+func Global() *Resolver {
+	globalResolverMu.RLock()
+	defer globalResolverMu.RUnlock()
+	return globalResolver
+}
+
+// Lookup returns the custom domain registered for host, whatever its status.
+// ok is false for primary hosts, IPs and unregistered hosts.
+func (r *Resolver) Lookup(ctx context.Context, host string) (*shortlink.CustomDomain, bool) {
+	name, err := Normalize(host)
+	if err != nil || IsPrimaryHost(name, r.primary) {
+		return nil, false
+	}
+
+	now := r.now()
+	r.mu.RLock()
+	entry, cached := r.entries[name]
+	r.mu.RUnlock()
+	if cached && now.Before(entry.expires) {
+		return entry.domain, entry.domain != nil
+	}
+
+	var domain shortlink.CustomDomain
+	found := true
+	if err := r.db.WithContext(ctx).Where("domain = ?", name).First(&domain).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			// Do not cache lookup failures
+			return nil, false
+		}
+		found = false
+	}
+
+	entry = resolverEntry{expires: now.Add(resolverTTL)}
+	if found {
+		entry.domain = &domain
+	}
+
+	r.mu.Lock()
+	if len(r.entries) >= resolverMaxEntries {
+		r.entries = make(map[string]resolverEntry)
+	}
+	r.entries[name] = entry
+	r.mu.Unlock()
+
+	return entry.domain, found
+}
+
+// Invalidate drops the cached entries for domains after they change.
+func (r *Resolver) Invalidate(domains ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, domain := range domains {
+		delete(r.entries, domain)
+	}
+}
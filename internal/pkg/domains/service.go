@@ -0,0 +1,122 @@
+package domains
+
+import (
+	"context"
+	"time"
+
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxFailedChecks consecutive failed re-checks take a verified domain out
+	// of routing, so a single DNS hiccup does not break live links.
+	maxFailedChecks = 3
+	// pendingDomainTTL is how long a claim may stay unverified before it is
+	// released for someone else to register.
+	pendingDomainTTL = 7 * 24 * time.Hour
+	recheckBatchSize = 200
+	maxLastErrorLen  = 255
+)
+
+// RecheckResult summarizes one RecheckAll run.
+type RecheckResult struct {
+	Checked  int
+	Verified int
+	Failed   int
+	Released int64
+}
+
+// Service verifies custom domains and records the outcome.
+type Service struct {
+	db       *gorm.DB
+	verifier *Verifier
+	now      func() time.Time
+}
+
+// NewService returns a service using the given verifier, or the system
+// resolver when verifier is nil.
+func NewService(db *gorm.DB, verifier *Verifier) *Service {
+	if verifier == nil {
+		verifier = NewVerifier()
+	}
+	return &Service{db: db, verifier: verifier, now: time.Now}
+}
+
+// Check verifies domain now and stores the result on it. verified reports
+// whether the token was found; err is only set when the result could not be
+// saved. A verified domain is marked failed after maxFailedChecks consecutive
+// failures; pending and failed domains recover on the next successful check.
+func (s *Service) Check(ctx context.Context, domain *shortlink.CustomDomain) (verified bool, err error) {
+	verifyErr := s.verifier.Verify(ctx, domain.Domain, domain.VerificationToken)
+	now := s.now()
+
+	domain.LastCheckedAt = &now
+	if verifyErr == nil {
+		if domain.Status != shortlink.DomainStatusVerified || domain.VerifiedAt == nil {
+			domain.VerifiedAt = &now
+		}
+		domain.Status = shortlink.DomainStatusVerified
+		domain.FailedChecks = 0
+		domain.LastError = ""
+	} else {
+		message := verifyErr.Error()
+		if len(message) > maxLastErrorLen {
+			message = message[:maxLastErrorLen]
+		}
+		domain.FailedChecks++
+		domain.LastError = message
+		if domain.Status == shortlink.DomainStatusVerified && domain.FailedChecks >= maxFailedChecks {
+			domain.Status = shortlink.DomainStatusFailed
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Model(domain).
+		Select("status", "verified_at", "last_checked_at", "last_error", "failed_checks").
+		Updates(domain).Error; err != nil {
+		return false, err
+	}
+	if resolver := Global(); resolver != nil {
+		resolver.Invalidate(domain.Domain)
+	}
+	return verifyErr == nil, nil
+}
+
+// RecheckAll re-verifies the least recently checked domains and releases
+// claims that stayed unverified past pendingDomainTTL.
+func (s *Service) RecheckAll(ctx context.Context) (RecheckResult, error) {
+	var result RecheckResult
+
+	released := s.db.WithContext(ctx).
+		Where("status = ? AND verified_at IS NULL AND created_at < ?", shortlink.DomainStatusPending, s.now().Add(-pendingDomainTTL)).
+		Delete(&shortlink.CustomDomain{})
+	if released.Error != nil {
+		return result, released.Error
+	}
+	result.Released = released.RowsAffected
+
+	var batch []shortlink.CustomDomain
+	if err := s.db.WithContext(ctx).
+		Order("last_checked_at IS NOT NULL, last_checked_at ASC").
+		Limit(recheckBatchSize).
+		Find(&batch).Error; err != nil {
+		return result, err
+	}
+
+	for i := range batch {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		verified, err := s.Check(ctx, &batch[i])
+		if err != nil {
+			return result, err
+		}
+		result.Checked++
+		if verified {
+			result.Verified++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, nil
+}
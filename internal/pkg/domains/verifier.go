@@ -0,0 +1,38 @@
+package domains
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Verifier checks that a domain carries its verification token in DNS. The
+// lookup is swappable so tests do not need the network.
+//
+// There is no HTTP check: once a domain points at this service, a file on it
+// is answered by this service, which proves nothing about who owns the domain.
+type Verifier struct {
+	LookupTXT func(ctx context.Context, name string) ([]string, error)
+}
+
+// NewVerifier returns a verifier using the system resolver.
+func NewVerifier() *Verifier {
+	return &Verifier{LookupTXT: net.DefaultResolver.LookupTXT}
+}
+
+// Verify checks the TXT record of domain for token. A nil error means the
+// token was found.
+func (v *Verifier) Verify(ctx context.Context, domain, token string) error {
+	expected := TXTRecordValue(token)
+	records, err := v.LookupTXT(ctx, TXTRecordName(domain))
+	if err != nil {
+		return fmt.Errorf("TXT lookup for %s failed: %w", TXTRecordName(domain), err)
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected {
+			return nil
+		}
+	}
+	return fmt.Errorf("no TXT record %q found on %s", expected, TXTRecordName(domain))
+}
@@ -404,6 +404,42 @@ var (
 		http.StatusBadRequest,
 		"short_link",
 	)
+	ErrShortFlagFailed = NewAppError(
+		"SHORT_FLAG_FAILED",
+		"Failed to update short link flag",
+		http.StatusInternalServerError,
+		"short_link",
+	)
+	ErrInterstitialPolicyFailed = NewAppError(
+		"INTERSTITIAL_POLICY_FAILED",
+		"Failed to update interstitial policy",
+		http.StatusInternalServerError,
+		"policy",
+	)
+	ErrInterstitialRenderFailed = NewAppError(
+		"INTERSTITIAL_RENDER_FAILED",
+		"Failed to render interstitial page",
+		http.StatusInternalServerError,
+		"short_link",
+	)
+	ErrInvalidDeepLink = NewAppError(
+		"INVALID_DEEP_LINK",
+		"Deep links must be http(s) app links or custom scheme URLs such as myapp://path",
+		http.StatusBadRequest,
+		"deep_links",
+	)
+	ErrDeepLinkRenderFailed = NewAppError(
+		"DEEP_LINK_RENDER_FAILED",
+		"Failed to render deep link page",
+		http.StatusInternalServerError,
+		"short_link",
+	)
+	ErrSocialCardRenderFailed = NewAppError(
+		"SOCIAL_CARD_RENDER_FAILED",
+		"Failed to render social card",
+		http.StatusInternalServerError,
+		"short_link",
+	)
 	ErrShortResetPasscodeFailed = NewAppError(
 		"SHORT_RESET_PASSCODE_FAILED",
 		"Failed to reset passcode",
@@ -422,6 +458,30 @@ var (
 		http.StatusInternalServerError,
 		"short_link",
 	)
+	ErrTimeseriesInvalidRange = NewAppError(
+		"TIMESERIES_INVALID_RANGE",
+		"Invalid time range, from and to must be RFC3339 or YYYY-MM-DD with from before to",
+		http.StatusBadRequest,
+		"from",
+	)
+	ErrTimeseriesInvalidTimezone = NewAppError(
+		"TIMESERIES_INVALID_TIMEZONE",
+		"Unknown time zone, use an IANA name such as Asia/Jakarta",
+		http.StatusBadRequest,
+		"tz",
+	)
+	ErrTimeseriesTooManyPoints = NewAppError(
+		"TIMESERIES_TOO_MANY_POINTS",
+		"Time range is too large for the requested granularity",
+		http.StatusBadRequest,
+		"granularity",
+	)
+	ErrShortStatsInvalidRange = NewAppError(
+		"SHORT_STATS_INVALID_RANGE",
+		"Invalid stats date range, expected YYYY-MM-DD with start_date before end_date",
+		http.StatusBadRequest,
+		"start_date",
+	)
 	ErrShortBulkCreateFailed = NewAppError(
 		"SHORT_BULK_CREATE_FAILED",
 		"Failed to create bulk short links",
@@ -470,6 +530,48 @@ var (
 		http.StatusConflict,
 		"short_code",
 	)
+	ErrReservedShortCode = NewAppError(
+		"RESERVED_SHORT_CODE",
+		"Short code is reserved",
+		http.StatusBadRequest,
+		"short_code",
+	)
+	ErrBlockedShortCode = NewAppError(
+		"BLOCKED_SHORT_CODE",
+		"Short code contains a word that is not allowed",
+		http.StatusBadRequest,
+		"short_code",
+	)
+	ErrReservedCodeExists = NewAppError(
+		"RESERVED_CODE_EXISTS",
+		"Word is already on the list",
+		http.StatusConflict,
+		"word",
+	)
+	ErrReservedCodeNotFound = NewAppError(
+		"RESERVED_CODE_NOT_FOUND",
+		"Reserved code not found",
+		http.StatusNotFound,
+		"id",
+	)
+	ErrReservedCodeFailed = NewAppError(
+		"RESERVED_CODE_FAILED",
+		"Failed to update reserved codes",
+		http.StatusInternalServerError,
+		"word",
+	)
+	ErrShortCodeGenerationFailed = NewAppError(
+		"SHORT_CODE_GENERATION_FAILED",
+		"Failed to generate a free short code",
+		http.StatusServiceUnavailable,
+		"short_code",
+	)
+	ErrShortCodeSettingsFailed = NewAppError(
+		"SHORT_CODE_SETTINGS_FAILED",
+		"Failed to update short code settings",
+		http.StatusInternalServerError,
+		"prefix",
+	)
 	ErrInvalidOriginalURL = NewAppError(
 		"INVALID_ORIGINAL_URL",
 		"Invalid original URL",
@@ -536,6 +638,18 @@ var (
 		http.StatusUnauthorized,
 		"passcode_incorrect",
 	)
+	ErrPasscodeTooManyAttempts = NewAppError(
+		"PASSCODE_TOO_MANY_ATTEMPTS",
+		"Too many incorrect passcode attempts, please try again later",
+		http.StatusTooManyRequests,
+		"passcode",
+	)
+	ErrPasscodeProcessFailed = NewAppError(
+		"PASSCODE_PROCESS_FAILED",
+		"Failed to process passcode",
+		http.StatusInternalServerError,
+		"passcode",
+	)
 	ErrClickLimitReached = NewAppError(
 		"CLICK_LIMIT_REACHED",
 		"Click limit reached",
@@ -1213,3 +1327,359 @@ var (
 		"attachment",
 	)
 )
+
+// Custom Domain Errors
+var (
+	ErrCustomDomainNotFound = NewAppError(
+		"CUSTOM_DOMAIN_NOT_FOUND",
+		"Custom domain not found",
+		http.StatusNotFound,
+		"domain",
+	)
+	ErrCustomDomainInvalid = NewAppError(
+		"CUSTOM_DOMAIN_INVALID",
+		"Custom domain must be a valid public host name",
+		http.StatusBadRequest,
+		"domain",
+	)
+	ErrCustomDomainReserved = NewAppError(
+		"CUSTOM_DOMAIN_RESERVED",
+		"This domain is used by the service and cannot be claimed",
+		http.StatusBadRequest,
+		"domain",
+	)
+	ErrCustomDomainExists = NewAppError(
+		"CUSTOM_DOMAIN_EXISTS",
+		"Custom domain is already registered",
+		http.StatusConflict,
+		"domain",
+	)
+	ErrCustomDomainNotVerified = NewAppError(
+		"CUSTOM_DOMAIN_NOT_VERIFIED",
+		"Custom domain is not verified for this account",
+		http.StatusForbidden,
+		"domain",
+	)
+	ErrCustomDomainVerificationFailed = NewAppError(
+		"CUSTOM_DOMAIN_VERIFICATION_FAILED",
+		"Custom domain ownership could not be verified",
+		http.StatusUnprocessableEntity,
+		"domain",
+	)
+	ErrCustomDomainInUse = NewAppError(
+		"CUSTOM_DOMAIN_IN_USE",
+		"Custom domain still has short links",
+		http.StatusConflict,
+		"domain",
+	)
+	ErrCustomDomainInvalidFallback = NewAppError(
+		"CUSTOM_DOMAIN_INVALID_FALLBACK",
+		"Fallback URL must be an absolute http or https URL",
+		http.StatusBadRequest,
+		"fallback_url",
+	)
+	ErrCustomDomainInvalidAppLinks = NewAppError(
+		"CUSTOM_DOMAIN_INVALID_APP_LINKS",
+		"App IDs must look like TEAMID.com.example.app, the package like com.example.app, and fingerprints like AB:CD:... (32 bytes)",
+		http.StatusBadRequest,
+		"app_links",
+	)
+	ErrCustomDomainCreateFailed = NewAppError(
+		"CUSTOM_DOMAIN_CREATE_FAILED",
+		"Failed to create custom domain",
+		http.StatusInternalServerError,
+		"domain",
+	)
+	ErrCustomDomainFindFailed = NewAppError(
+		"CUSTOM_DOMAIN_FIND_FAILED",
+		"Failed to find custom domain",
+		http.StatusInternalServerError,
+		"domain",
+	)
+	ErrCustomDomainUpdateFailed = NewAppError(
+		"CUSTOM_DOMAIN_UPDATE_FAILED",
+		"Failed to update custom domain",
+		http.StatusInternalServerError,
+		"domain",
+	)
+	ErrCustomDomainDeleteFailed = NewAppError(
+		"CUSTOM_DOMAIN_DELETE_FAILED",
+		"Failed to delete custom domain",
+		http.StatusInternalServerError,
+		"domain",
+	)
+)
+
+// Redirect Rule Errors
+var (
+	ErrRedirectRuleNotFound = NewAppError(
+		"REDIRECT_RULE_NOT_FOUND",
+		"Redirect rule not found",
+		http.StatusNotFound,
+		"rule_id",
+	)
+	ErrRedirectRuleNoConditions = NewAppError(
+		"REDIRECT_RULE_NO_CONDITIONS",
+		"A redirect rule needs at least one condition",
+		http.StatusBadRequest,
+		"conditions",
+	)
+	ErrRedirectRuleInvalidTimeWindow = NewAppError(
+		"REDIRECT_RULE_INVALID_TIME_WINDOW",
+		"Time window needs a valid range, weekdays 0-6, both start and end times and a known time zone",
+		http.StatusBadRequest,
+		"conditions.time_window",
+	)
+	ErrRedirectRuleLimitReached = NewAppError(
+		"REDIRECT_RULE_LIMIT_REACHED",
+		"This short link already has the maximum number of redirect rules",
+		http.StatusBadRequest,
+		"rules",
+	)
+	ErrRedirectRuleOrderMismatch = NewAppError(
+		"REDIRECT_RULE_ORDER_MISMATCH",
+		"Rule order must list every rule of the short link exactly once",
+		http.StatusBadRequest,
+		"rule_ids",
+	)
+	ErrRedirectRuleFindFailed = NewAppError(
+		"REDIRECT_RULE_FIND_FAILED",
+		"Failed to retrieve redirect rules",
+		http.StatusInternalServerError,
+		"rules",
+	)
+	ErrRedirectRuleCreateFailed = NewAppError(
+		"REDIRECT_RULE_CREATE_FAILED",
+		"Failed to create redirect rule",
+		http.StatusInternalServerError,
+		"rules",
+	)
+	ErrRedirectRuleUpdateFailed = NewAppError(
+		"REDIRECT_RULE_UPDATE_FAILED",
+		"Failed to update redirect rule",
+		http.StatusInternalServerError,
+		"rules",
+	)
+	ErrRedirectRuleDeleteFailed = NewAppError(
+		"REDIRECT_RULE_DELETE_FAILED",
+		"Failed to delete redirect rule",
+		http.StatusInternalServerError,
+		"rules",
+	)
+)
+
+// Link Variant Errors
+var (
+	ErrLinkVariantNotFound = NewAppError(
+		"LINK_VARIANT_NOT_FOUND",
+		"Link variant not found",
+		http.StatusNotFound,
+		"variant_id",
+	)
+	ErrLinkVariantLimitReached = NewAppError(
+		"LINK_VARIANT_LIMIT_REACHED",
+		"This short link already has the maximum number of variants",
+		http.StatusBadRequest,
+		"variants",
+	)
+	ErrLinkVariantFindFailed = NewAppError(
+		"LINK_VARIANT_FIND_FAILED",
+		"Failed to retrieve link variants",
+		http.StatusInternalServerError,
+		"variants",
+	)
+	ErrLinkVariantCreateFailed = NewAppError(
+		"LINK_VARIANT_CREATE_FAILED",
+		"Failed to create link variant",
+		http.StatusInternalServerError,
+		"variants",
+	)
+	ErrLinkVariantUpdateFailed = NewAppError(
+		"LINK_VARIANT_UPDATE_FAILED",
+		"Failed to update link variant",
+		http.StatusInternalServerError,
+		"variants",
+	)
+	ErrLinkVariantDeleteFailed = NewAppError(
+		"LINK_VARIANT_DELETE_FAILED",
+		"Failed to delete link variant",
+		http.StatusInternalServerError,
+		"variants",
+	)
+)
+
+// Link Tag and Collection Errors
+var (
+	ErrLinkTagNotFound = NewAppError(
+		"LINK_TAG_NOT_FOUND",
+		"Tag not found",
+		http.StatusNotFound,
+		"tag_id",
+	)
+	ErrLinkTagExists = NewAppError(
+		"LINK_TAG_EXISTS",
+		"You already have a tag with this name",
+		http.StatusConflict,
+		"name",
+	)
+	ErrLinkTagFailed = NewAppError(
+		"LINK_TAG_FAILED",
+		"Failed to update tags",
+		http.StatusInternalServerError,
+		"tags",
+	)
+	ErrCollectionNotFound = NewAppError(
+		"COLLECTION_NOT_FOUND",
+		"Collection not found",
+		http.StatusNotFound,
+		"collection_id",
+	)
+	ErrCollectionExists = NewAppError(
+		"COLLECTION_EXISTS",
+		"You already have a collection with this name",
+		http.StatusConflict,
+		"name",
+	)
+	ErrCollectionFailed = NewAppError(
+		"COLLECTION_FAILED",
+		"Failed to update collections",
+		http.StatusInternalServerError,
+		"collections",
+	)
+)
+
+// QR Code Errors
+var (
+	ErrQRCodeInvalid = NewAppError(
+		"QR_CODE_INVALID",
+		"The QR code cannot be drawn with these options",
+		http.StatusBadRequest,
+		"qr",
+	)
+	ErrQRCodeRenderFailed = NewAppError(
+		"QR_CODE_RENDER_FAILED",
+		"Failed to render QR code",
+		http.StatusInternalServerError,
+		"qr",
+	)
+	ErrQRLogoUnavailable = NewAppError(
+		"QR_LOGO_UNAVAILABLE",
+		"The QR code logo could not be loaded",
+		http.StatusBadGateway,
+		"logo",
+	)
+	ErrQRLogoUpdateFailed = NewAppError(
+		"QR_LOGO_UPDATE_FAILED",
+		"Failed to update QR code logo",
+		http.StatusInternalServerError,
+		"logo",
+	)
+)
+
+// Link Import Errors
+var (
+	ErrLinkImportNotFound = NewAppError(
+		"LINK_IMPORT_NOT_FOUND",
+		"Link import not found",
+		http.StatusNotFound,
+		"import_id",
+	)
+	ErrLinkImportLimitExceeded = NewAppError(
+		"LINK_IMPORT_LIMIT_EXCEEDED",
+		"Too many imports are still running; wait for one to finish",
+		http.StatusTooManyRequests,
+		"file",
+	)
+	ErrLinkImportCreateFailed = NewAppError(
+		"LINK_IMPORT_CREATE_FAILED",
+		"Failed to queue link import",
+		http.StatusInternalServerError,
+		"file",
+	)
+	ErrLinkImportFindFailed = NewAppError(
+		"LINK_IMPORT_FIND_FAILED",
+		"Failed to retrieve link imports",
+		http.StatusInternalServerError,
+		"import_id",
+	)
+	ErrLinkImportProcessFailed = NewAppError(
+		"LINK_IMPORT_PROCESS_FAILED",
+		"Failed to process link import",
+		http.StatusInternalServerError,
+		"import_id",
+	)
+	ErrLinkImportUnknownTag = NewAppError(
+		"LINK_IMPORT_UNKNOWN_TAG",
+		"The row names a tag you do not have",
+		http.StatusBadRequest,
+		"tags",
+	)
+	ErrLinkImportUnknownCollection = NewAppError(
+		"LINK_IMPORT_UNKNOWN_COLLECTION",
+		"The row names a collection you do not have",
+		http.StatusBadRequest,
+		"collection",
+	)
+	ErrLinkExportFailed = NewAppError(
+		"LINK_EXPORT_FAILED",
+		"Failed to export short links",
+		http.StatusInternalServerError,
+		"format",
+	)
+)
+
+// Link Revision Errors
+var (
+	ErrLinkRevisionNotFound = NewAppError(
+		"LINK_REVISION_NOT_FOUND",
+		"Short link revision not found",
+		http.StatusNotFound,
+		"revision_id",
+	)
+	ErrLinkRevisionFindFailed = NewAppError(
+		"LINK_REVISION_FIND_FAILED",
+		"Failed to retrieve short link revisions",
+		http.StatusInternalServerError,
+		"revision_id",
+	)
+	ErrLinkRevisionCreateFailed = NewAppError(
+		"LINK_REVISION_CREATE_FAILED",
+		"Failed to record short link revision",
+		http.StatusInternalServerError,
+		"revision_id",
+	)
+	ErrLinkRevisionAlreadyCurrent = NewAppError(
+		"LINK_REVISION_ALREADY_CURRENT",
+		"The short link already matches this revision",
+		http.StatusConflict,
+		"revision_id",
+	)
+	ErrLinkRollbackFailed = NewAppError(
+		"LINK_ROLLBACK_FAILED",
+		"Failed to roll back short link",
+		http.StatusInternalServerError,
+		"revision_id",
+	)
+)
+
+// URL Safety Errors
+var (
+	ErrUnsafeDestinationURL = NewAppError(
+		"UNSAFE_DESTINATION_URL",
+		"The destination URL was flagged as unsafe and cannot be shortened",
+		http.StatusUnprocessableEntity,
+		"url",
+	)
+	ErrSafetyScanFailed = NewAppError(
+		"SAFETY_SCAN_FAILED",
+		"Failed to scan short link destinations",
+		http.StatusInternalServerError,
+		"url",
+	)
+	ErrLinkMetadataFailed = NewAppError(
+		"LINK_METADATA_FAILED",
+		"Failed to store short link metadata",
+		http.StatusInternalServerError,
+		"url",
+	)
+)
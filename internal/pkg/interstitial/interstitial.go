@@ -0,0 +1,230 @@
+// Package interstitial decides when a redirect shows a warning page before
+// sending the visitor on, and renders that page.
+package interstitial
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/adehusnim37/lihatin-go/repositories/userrepo"
+	"gorm.io/gorm"
+)
+
+const (
+	// SettingKeyEnabled is the admin-global switch for links that inherit.
+	SettingKeyEnabled = userrepo.SettingRedirectInterstitialEnabled
+
+	// PreviewSuffix appended to a code always shows the page, as on bit.ly.
+	PreviewSuffix = "+"
+	// ContinueParam carries the signed token of the page's continue button.
+	ContinueParam = "continue"
+
+	defaultNewLinkHours = 24
+	settingCacheTTL     = 30 * time.Second
+	// continueTokenTTL is how long the continue button of a shown page works.
+	continueTokenTTL = 10 * time.Minute
+)
+
+//go:embed templates/interstitial.html
+var templateFS embed.FS
+
+var pageTemplate = template.Must(template.ParseFS(templateFS, "templates/interstitial.html"))
+
+// Page is what the interstitial shows about a link.
+type Page struct {
+	ShortCode   string
+	Title       string
+	Description string
+	// Destination is empty for passcode protected links, which only reveal
+	// DestinationHost before the passcode is given
+	Destination     string
+	DestinationHost string
+	// DestinationVaries is set when redirect rules or A/B variants may send
+	// the visitor somewhere other than Destination
+	DestinationVaries bool
+	// ContinueToken lets the visitor past the page for the link; ContinueURL
+	// is the address of the continue button carrying it
+	ContinueToken string
+	ContinueURL   string
+	Preview       bool
+	Flagged       bool
+	FlaggedReason string
+	NewLink       bool
+}
+
+// Policy holds the admin-global setting, cached briefly because it is read on
+// every redirect, and signs the continue tokens of shown pages.
+type Policy struct {
+	mu       sync.Mutex
+	enabled  bool
+	loadedAt time.Time
+
+	settingRepo userrepo.SystemSettingRepository
+	newLinkAge  time.Duration
+	secret      []byte
+	now         func() time.Time
+}
+
+var (
+	globalPolicy   *Policy
+	globalPolicyMu sync.RWMutex
+)
+
+// NewPolicy creates a policy. Links younger than newLinkAge get a safety
+// notice on the page; continue tokens are signed with secret.
+func NewPolicy(settingRepo userrepo.SystemSettingRepository, newLinkAge time.Duration, secret []byte) *Policy {
+	return &Policy{settingRepo: settingRepo, newLinkAge: newLinkAge, secret: secret, now: time.Now}
+}
+
+// InitGlobal initializes the global policy instance. Continue tokens are
+// signed with SESSION_SECRET, or an ephemeral key that invalidates them on
+// restart.
+func InitGlobal(gormDB *gorm.DB) error {
+	if gormDB == nil {
+		return errors.New("gorm db is required")
+	}
+
+	secret := []byte(config.GetEnvOrDefault(config.EnvSessionSecret, ""))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("generate interstitial continue key: %w", err)
+		}
+		logger.Logger.Warn("Interstitial: No SESSION_SECRET set, using an ephemeral continue key")
+	}
+
+	hours := config.GetEnvAsInt(config.EnvInterstitialNewLinkHours, defaultNewLinkHours)
+	policy := NewPolicy(userrepo.NewSystemSettingRepository(gormDB), time.Duration(hours)*time.Hour, secret)
+	if err := policy.settingRepo.EnsureBool(SettingKeyEnabled, false, "system"); err != nil {
+		return err
+	}
+
+	globalPolicyMu.Lock()
+	globalPolicy = policy
+	globalPolicyMu.Unlock()
+
+	return nil
+}
+
+// Global returns the initialized global policy, or nil before InitGlobal.
+func Global() *Policy {
+	globalPolicyMu.RLock()
+	defer globalPolicyMu.RUnlock()
+	return globalPolicy
+}
+
+// Enabled reports the admin-global setting. A failed read keeps the last
+// known value.
+func (p *Policy) Enabled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.loadedAt.IsZero() && p.now().Sub(p.loadedAt) < settingCacheTTL {
+		return p.enabled
+	}
+	if enabled, err := p.settingRepo.GetBool(SettingKeyEnabled, false); err == nil {
+		p.enabled = enabled
+	}
+	p.loadedAt = p.now()
+	return p.enabled
+}
+
+// SetEnabled stores the admin-global setting.
+func (p *Policy) SetEnabled(enabled bool, adminID string) error {
+	if err := p.settingRepo.SetBool(SettingKeyEnabled, enabled, adminID); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.enabled = enabled
+	p.loadedAt = p.now()
+	p.mu.Unlock()
+	return nil
+}
+
+// IsNewLink reports whether a link created at createdAt still gets the new
+// link notice.
+func (p *Policy) IsNewLink(createdAt time.Time) bool {
+	return p.newLinkAge > 0 && !createdAt.IsZero() && p.now().Sub(createdAt) < p.newLinkAge
+}
+
+// ContinueToken returns the token letting a visitor of the page past it to
+// linkID for the next continueTokenTTL.
+func (p *Policy) ContinueToken(linkID string) string {
+	expires := strconv.FormatInt(p.now().Add(continueTokenTTL).Unix(), 10)
+	return expires + "." + p.sign(linkID, expires)
+}
+
+// VerifyContinue reports whether token is an unexpired continue token for
+// linkID.
+func (p *Policy) VerifyContinue(token, linkID string) bool {
+	expires, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || p.now().Unix() >= unix {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(p.sign(linkID, expires)))
+}
+
+func (p *Policy) sign(linkID, expires string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte("interstitial\x00" + linkID + "\x00" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Required reports whether a redirect shows the page: always for previews and
+// flagged links, otherwise as the link's mode says, falling back to the
+// admin-global setting.
+func Required(mode string, flagged, preview, globalEnabled bool) bool {
+	if preview || flagged {
+		return true
+	}
+	switch mode {
+	case shortlink.InterstitialModeOn:
+		return true
+	case shortlink.InterstitialModeOff:
+		return false
+	default:
+		return globalEnabled
+	}
+}
+
+// ContinueURL returns the address the continue button follows: the short
+// link itself without the preview suffix, keeping the query and carrying the
+// page's continue token.
+func ContinueURL(requestURL *url.URL, token string) string {
+	query := requestURL.Query()
+	query.Set(ContinueParam, token)
+	continueURL := url.URL{
+		Path:     strings.TrimSuffix(requestURL.Path, PreviewSuffix),
+		RawQuery: query.Encode(),
+	}
+	return continueURL.String()
+}
+
+// Render returns the page as HTML.
+func Render(page Page) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
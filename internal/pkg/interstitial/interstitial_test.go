@@ -0,0 +1,140 @@
+package interstitial
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+func TestRequired(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		mode          string
+		flagged       bool
+		preview       bool
+		globalEnabled bool
+		want          bool
+	}{
+		{"inherit global off", "", false, false, false, false},
+		{"inherit global on", "", false, false, true, true},
+		{"link on", shortlink.InterstitialModeOn, false, false, false, true},
+		{"link off beats global", shortlink.InterstitialModeOff, false, false, true, false},
+		{"flagged beats link off", shortlink.InterstitialModeOff, true, false, false, true},
+		{"preview beats link off", shortlink.InterstitialModeOff, false, true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Required(tt.mode, tt.flagged, tt.preview, tt.globalEnabled); got != tt.want {
+				t.Errorf("Required() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContinueURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"/v1/short/abc+", "/v1/short/abc?continue=tok"},
+		{"/v1/short/abc?ref=mail", "/v1/short/abc?continue=tok&ref=mail"},
+		{"/abc+?utm_source=mail", "/abc?continue=tok&utm_source=mail"},
+		{"/abc?continue=1", "/abc?continue=tok"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.raw)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.raw, err)
+		}
+		if got := ContinueURL(u, "tok"); got != tt.want {
+			t.Errorf("ContinueURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestIsNewLink(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	p := NewPolicy(nil, 24*time.Hour, nil)
+	p.now = func() time.Time { return now }
+
+	if !p.IsNewLink(now.Add(-time.Hour)) {
+		t.Error("link created an hour ago is not new")
+	}
+	if p.IsNewLink(now.Add(-25 * time.Hour)) {
+		t.Error("link created 25 hours ago is still new")
+	}
+	if p.IsNewLink(time.Time{}) {
+		t.Error("link without a creation time is new")
+	}
+}
+
+func TestContinueToken(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	p := NewPolicy(nil, 24*time.Hour, []byte("secret"))
+	p.now = func() time.Time { return now }
+	token := p.ContinueToken("link-1")
+
+	tests := []struct {
+		name   string
+		token  string
+		linkID string
+		after  time.Duration
+		want   bool
+	}{
+		{"valid", token, "link-1", time.Minute, true},
+		{"other link", token, "link-2", time.Minute, false},
+		{"expired", token, "link-1", continueTokenTTL, false},
+		{"bare marker", "1", "link-1", 0, false},
+		{"forged expiry", "9999999999." + strings.SplitN(token, ".", 2)[1], "link-1", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			verifier := NewPolicy(nil, 24*time.Hour, []byte("secret"))
+			verifier.now = func() time.Time { return now.Add(tt.after) }
+			if got := verifier.VerifyContinue(tt.token, tt.linkID); got != tt.want {
+				t.Errorf("VerifyContinue(%q, %q) = %v, want %v", tt.token, tt.linkID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderEscapesLinkContent(t *testing.T) {
+	t.Parallel()
+
+	body, err := Render(Page{
+		ShortCode:     "abc",
+		Title:         "<script>alert(1)</script>",
+		Destination:   "https://example.com/?q=<b>",
+		ContinueURL:   "/v1/short/abc?continue=tok",
+		Flagged:       true,
+		FlaggedReason: "Reported as phishing",
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	html := string(body)
+	if strings.Contains(html, "<script>alert(1)</script>") {
+		t.Error("title was not escaped")
+	}
+	for _, want := range []string{"Reported as phishing", "https://example.com/?q=&lt;b&gt;", `href="/v1/short/abc?continue=tok"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page is missing %q", want)
+		}
+	}
+}
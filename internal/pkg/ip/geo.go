@@ -0,0 +1,190 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+)
+
+const (
+	GeoProviderAuto = "auto"
+	GeoProviderMMDB = "mmdb"
+	GeoProviderHTTP = "http"
+	GeoProviderNone = "none"
+
+	defaultGeoCacheSize       = 10000
+	defaultGeoCacheTTLMinutes = 60
+	defaultGeoLookupTimeout   = 5 * time.Second
+
+	unknownCountry = "Unknown Country"
+	unknownCity    = "Unknown City"
+	localMachine   = "Local Machine"
+)
+
+// ErrLocationNotFound is returned when a provider has no data for an IP.
+var ErrLocationNotFound = errors.New("location not found")
+
+// Location is the geolocation result for a single IP address.
+type Location struct {
+	Country        string   `json:"country,omitempty"`
+	CountryCode    string   `json:"country_code,omitempty"`
+	Region         string   `json:"region,omitempty"`
+	City           string   `json:"city,omitempty"`
+	Latitude       *float64 `json:"latitude,omitempty"`
+	Longitude      *float64 `json:"longitude,omitempty"`
+	ASN            uint     `json:"asn,omitempty"`
+	ASOrganization string   `json:"as_organization,omitempty"`
+}
+
+// GeoProvider resolves an IP address to a Location.
+type GeoProvider interface {
+	Name() string
+	Lookup(ctx context.Context, ip string) (*Location, error)
+}
+
+var (
+	defaultProvider     GeoProvider
+	defaultProviderOnce sync.Once
+	defaultProviderMu   sync.RWMutex
+)
+
+// DefaultProvider returns the provider configured through GEOIP_PROVIDER.
+// It is built lazily on first use.
+func DefaultProvider() GeoProvider {
+	defaultProviderOnce.Do(func() {
+		provider := NewProviderFromEnv()
+		defaultProviderMu.Lock()
+		if defaultProvider == nil {
+			defaultProvider = provider
+		}
+		defaultProviderMu.Unlock()
+	})
+
+	defaultProviderMu.RLock()
+	defer defaultProviderMu.RUnlock()
+	return defaultProvider
+}
+
+// SetDefaultProvider overrides the provider used by GetLocation and Locate.
+func SetDefaultProvider(provider GeoProvider) {
+	defaultProviderOnce.Do(func() {})
+	defaultProviderMu.Lock()
+	defaultProvider = provider
+	defaultProviderMu.Unlock()
+}
+
+// NewProviderFromEnv builds the provider chain from configuration.
+//
+//   - mmdb: local MMDB only, no visitor IP ever leaves the server
+//   - http: ipgeolocation.io only
+//   - none: geolocation disabled
+//   - auto (default): local MMDB when configured, then ipgeolocation.io when an
+//     API key is set
+//
+// The selected providers are wrapped in a ChainProvider with an LRU cache.
+func NewProviderFromEnv() GeoProvider {
+	mode := strings.ToLower(strings.TrimSpace(config.GetEnvOrDefault(config.EnvGeoIPProvider, GeoProviderAuto)))
+	cityPath := strings.TrimSpace(config.GetEnvOrDefault(config.EnvGeoIPCityDBPath, ""))
+	asnPath := strings.TrimSpace(config.GetEnvOrDefault(config.EnvGeoIPASNDBPath, ""))
+	apiKey := strings.TrimSpace(config.GetEnvOrDefault(config.EnvIPGeoAPIKey, ""))
+
+	var providers []GeoProvider
+	addMMDB := func() {
+		if cityPath == "" && asnPath == "" {
+			return
+		}
+		provider, err := NewMMDBProvider(cityPath, asnPath)
+		if err != nil {
+			logger.Logger.Error("Failed to open GeoIP database",
+				"city_db", cityPath,
+				"asn_db", asnPath,
+				"error", err.Error(),
+			)
+			return
+		}
+		providers = append(providers, provider)
+	}
+	addHTTP := func() {
+		if apiKey == "" {
+			return
+		}
+		providers = append(providers, NewHTTPProvider(apiKey))
+	}
+
+	switch mode {
+	case GeoProviderNone:
+	case GeoProviderMMDB:
+		addMMDB()
+	case GeoProviderHTTP:
+		addHTTP()
+	default:
+		if mode != GeoProviderAuto {
+			logger.Logger.Warn("Unknown GeoIP provider, using auto", "provider", mode)
+		}
+		addMMDB()
+		addHTTP()
+	}
+
+	if len(providers) == 0 {
+		logger.Logger.Warn("No GeoIP provider configured, locations will be recorded as unknown", "provider", mode)
+		return nil
+	}
+
+	cacheSize := config.GetEnvAsInt(config.EnvGeoIPCacheSize, defaultGeoCacheSize)
+	cacheTTL := time.Duration(config.GetEnvAsInt(config.EnvGeoIPCacheTTLMinutes, defaultGeoCacheTTLMinutes)) * time.Minute
+	return NewChainProvider(cacheSize, cacheTTL, providers...)
+}
+
+// Locate resolves ip with the default provider. Loopback addresses resolve to
+// "Local Machine", private addresses are never sent to a provider, and missing
+// country/city are reported as "Unknown Country"/"Unknown City".
+func Locate(ip string) Location {
+	if isLocalIP(ip) {
+		return Location{Country: localMachine, City: localMachine}
+	}
+
+	unknown := Location{Country: unknownCountry, City: unknownCity}
+	provider := DefaultProvider()
+	if provider == nil || isPrivateIP(ip) {
+		return unknown
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultGeoLookupTimeout)
+	defer cancel()
+
+	location, err := provider.Lookup(ctx, ip)
+	if err != nil || location == nil {
+		return unknown
+	}
+
+	result := *location
+	if result.Country == "" {
+		result.Country = unknownCountry
+	}
+	if result.City == "" {
+		result.City = unknownCity
+	}
+	return result
+}
+
+func isLocalIP(ip string) bool {
+	if ip == "" || ip == "localhost" {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.IsLoopback()
+}
+
+func isPrivateIP(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return true
+	}
+	return parsed.IsPrivate() || parsed.IsUnspecified() || parsed.IsLinkLocalUnicast()
+}
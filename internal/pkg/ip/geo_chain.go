@@ -0,0 +1,156 @@
+package ip
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// geoNegativeCacheTTL bounds how long a failed lookup is remembered, so a
+// flaky upstream is retried soon while bursts from one IP stay cheap.
+const geoNegativeCacheTTL = time.Minute
+
+// ChainProvider asks each provider in order and returns the first hit.
+// Results, including misses, are kept in an in-memory LRU cache.
+type ChainProvider struct {
+	providers []GeoProvider
+	cache     *geoCache
+}
+
+// NewChainProvider creates a chain in front of an LRU cache of cacheSize
+// entries. A cacheSize of zero or less disables caching.
+func NewChainProvider(cacheSize int, ttl time.Duration, providers ...GeoProvider) *ChainProvider {
+	if ttl <= 0 {
+		ttl = defaultGeoCacheTTLMinutes * time.Minute
+	}
+	return &ChainProvider{
+		providers: providers,
+		cache:     newGeoCache(cacheSize, ttl),
+	}
+}
+
+func (p *ChainProvider) Name() string {
+	names := make([]string, 0, len(p.providers))
+	for _, provider := range p.providers {
+		names = append(names, provider.Name())
+	}
+	return "chain(" + strings.Join(names, ",") + ")"
+}
+
+func (p *ChainProvider) Lookup(ctx context.Context, ip string) (*Location, error) {
+	if location, ok := p.cache.get(ip); ok {
+		if location == nil {
+			return nil, ErrLocationNotFound
+		}
+		copied := *location
+		return &copied, nil
+	}
+
+	var errs []error
+	for _, provider := range p.providers {
+		location, err := provider.Lookup(ctx, ip)
+		if err == nil && location != nil {
+			p.cache.set(ip, location, false)
+			copied := *location
+			return &copied, nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Do not cache a miss caused by the caller giving up
+	if ctx.Err() == nil {
+		p.cache.set(ip, nil, true)
+	}
+	if len(errs) == 0 {
+		return nil, ErrLocationNotFound
+	}
+	return nil, errors.Join(errs...)
+}
+
+type geoCacheEntry struct {
+	ip        string
+	location  *Location
+	expiresAt time.Time
+}
+
+// geoCache is a fixed-size LRU keyed by IP address.
+type geoCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+func newGeoCache(size int, ttl time.Duration) *geoCache {
+	return &geoCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+func (c *geoCache) get(ip string) (*Location, bool) {
+	if c.size <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[ip]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*geoCacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, ip)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.location, true
+}
+
+func (c *geoCache) set(ip string, location *Location, miss bool) {
+	if c.size <= 0 {
+		return
+	}
+
+	ttl := c.ttl
+	if miss && (ttl <= 0 || ttl > geoNegativeCacheTTL) {
+		ttl = geoNegativeCacheTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[ip]; ok {
+		entry := element.Value.(*geoCacheEntry)
+		entry.location = location
+		entry.expiresAt = c.now().Add(ttl)
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[ip] = c.order.PushFront(&geoCacheEntry{
+		ip:        ip,
+		location:  location,
+		expiresAt: c.now().Add(ttl),
+	})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*geoCacheEntry).ip)
+	}
+}
@@ -0,0 +1,115 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeGeoProvider struct {
+	name     string
+	location *Location
+	err      error
+	calls    int
+}
+
+func (p *fakeGeoProvider) Name() string { return p.name }
+
+func (p *fakeGeoProvider) Lookup(context.Context, string) (*Location, error) {
+	p.calls++
+	return p.location, p.err
+}
+
+func TestChainProviderFallsThroughAndCaches(t *testing.T) {
+	t.Parallel()
+
+	offline := &fakeGeoProvider{name: "mmdb", err: ErrLocationNotFound}
+	online := &fakeGeoProvider{name: "http", location: &Location{Country: "Indonesia", City: "Bandung", ASN: 7713}}
+	chain := NewChainProvider(10, time.Hour, offline, online)
+
+	for i := 0; i < 3; i++ {
+		location, err := chain.Lookup(context.Background(), "203.0.113.7")
+		if err != nil {
+			t.Fatalf("Lookup() error = %v", err)
+		}
+		if location.City != "Bandung" || location.ASN != 7713 {
+			t.Fatalf("Lookup() = %+v, want Bandung/7713", location)
+		}
+	}
+
+	if offline.calls != 1 || online.calls != 1 {
+		t.Fatalf("provider calls = %d/%d, want 1/1 (cached)", offline.calls, online.calls)
+	}
+}
+
+func TestChainProviderCachesMisses(t *testing.T) {
+	t.Parallel()
+
+	failing := &fakeGeoProvider{name: "http", err: errors.New("boom")}
+	chain := NewChainProvider(10, time.Hour, failing)
+
+	for i := 0; i < 2; i++ {
+		if _, err := chain.Lookup(context.Background(), "198.51.100.1"); err == nil {
+			t.Fatal("Lookup() error = nil, want error")
+		}
+	}
+	if failing.calls != 1 {
+		t.Fatalf("provider calls = %d, want 1", failing.calls)
+	}
+}
+
+func TestGeoCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	cache := newGeoCache(2, time.Hour)
+	cache.set("a", &Location{City: "A"}, false)
+	cache.set("b", &Location{City: "B"}, false)
+	cache.get("a")
+	cache.set("c", &Location{City: "C"}, false)
+
+	if _, ok := cache.get("b"); ok {
+		t.Fatal("b should have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Fatalf("%s should still be cached", key)
+		}
+	}
+}
+
+func TestGeoCacheExpiresEntries(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cache := newGeoCache(10, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	cache.set("hit", &Location{City: "A"}, false)
+	cache.set("miss", nil, true)
+
+	now = now.Add(2 * geoNegativeCacheTTL)
+	if _, ok := cache.get("miss"); ok {
+		t.Fatal("negative entry should expire after geoNegativeCacheTTL")
+	}
+	if _, ok := cache.get("hit"); !ok {
+		t.Fatal("positive entry should still be cached")
+	}
+}
+
+func TestParseASNumber(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]uint{
+		"AS15169": 15169,
+		"as7713":  7713,
+		"13335":   13335,
+		"":        0,
+		"ASxyz":   0,
+	}
+	for input, want := range tests {
+		if got := parseASNumber(input); got != want {
+			t.Errorf("parseASNumber(%q) = %d, want %d", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package ip
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPProvider resolves locations through the ipgeolocation.io API.
+type HTTPProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPProvider creates an ipgeolocation.io provider.
+func NewHTTPProvider(apiKey string) *HTTPProvider {
+	return &HTTPProvider{
+		apiKey: apiKey,
+		client: &http.Client{
+			Timeout: 5 * time.Second, // Timeout is critical
+		},
+	}
+}
+
+func (p *HTTPProvider) Name() string {
+	return GeoProviderHTTP
+}
+
+func (p *HTTPProvider) Lookup(ctx context.Context, ip string) (*Location, error) {
+	response, err := fetchIPGeolocation(ctx, p.client, p.apiKey, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	location := &Location{
+		Country:        response.Location.CountryName,
+		CountryCode:    response.Location.CountryCode2,
+		Region:         response.Location.StateProv,
+		City:           response.Location.City,
+		Latitude:       parseCoordinate(response.Location.Latitude),
+		Longitude:      parseCoordinate(response.Location.Longitude),
+		ASN:            parseASNumber(response.ASN.ASNumber),
+		ASOrganization: response.ASN.Organization,
+	}
+	if location.Country == "" && location.City == "" {
+		return nil, ErrLocationNotFound
+	}
+	return location, nil
+}
+
+func parseCoordinate(value string) *float64 {
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+// parseASNumber accepts both "AS15169" and "15169".
+func parseASNumber(value string) uint {
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "AS")
+	parsed, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint(parsed)
+}
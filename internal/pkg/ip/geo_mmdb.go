@@ -0,0 +1,135 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// MMDBProvider reads MaxMind-format databases (GeoLite2, DB-IP lite) from
+// disk, so lookups work offline and never leave the server.
+type MMDBProvider struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+type mmdbNames map[string]string
+
+func (n mmdbNames) english() string {
+	if name := n["en"]; name != "" {
+		return name
+	}
+	for _, name := range n {
+		return name
+	}
+	return ""
+}
+
+type mmdbCityRecord struct {
+	Country struct {
+		ISOCode string    `maxminddb:"iso_code"`
+		Names   mmdbNames `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names mmdbNames `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names mmdbNames `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+type mmdbASNRecord struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// NewMMDBProvider opens the city and/or ASN database. Either path may be
+// empty, but not both.
+func NewMMDBProvider(cityPath, asnPath string) (*MMDBProvider, error) {
+	if cityPath == "" && asnPath == "" {
+		return nil, errors.New("no GeoIP database path configured")
+	}
+
+	provider := &MMDBProvider{}
+	if cityPath != "" {
+		reader, err := maxminddb.Open(cityPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open city database: %w", err)
+		}
+		provider.city = reader
+	}
+	if asnPath != "" {
+		reader, err := maxminddb.Open(asnPath)
+		if err != nil {
+			provider.Close()
+			return nil, fmt.Errorf("failed to open ASN database: %w", err)
+		}
+		provider.asn = reader
+	}
+
+	return provider, nil
+}
+
+func (p *MMDBProvider) Name() string {
+	return GeoProviderMMDB
+}
+
+func (p *MMDBProvider) Lookup(_ context.Context, ip string) (*Location, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("invalid IP address %q", ip)
+	}
+
+	location := &Location{}
+	found := false
+
+	if p.city != nil {
+		var record mmdbCityRecord
+		if err := p.city.Lookup(parsed, &record); err != nil {
+			return nil, fmt.Errorf("city lookup failed: %w", err)
+		}
+		location.Country = record.Country.Names.english()
+		location.CountryCode = record.Country.ISOCode
+		if len(record.Subdivisions) > 0 {
+			location.Region = record.Subdivisions[0].Names.english()
+		}
+		location.City = record.City.Names.english()
+		location.Latitude = record.Location.Latitude
+		location.Longitude = record.Location.Longitude
+		found = location.Country != "" || location.City != ""
+	}
+
+	if p.asn != nil {
+		var record mmdbASNRecord
+		if err := p.asn.Lookup(parsed, &record); err != nil {
+			return nil, fmt.Errorf("ASN lookup failed: %w", err)
+		}
+		location.ASN = record.Number
+		location.ASOrganization = record.Organization
+		found = found || record.Number != 0
+	}
+
+	if !found {
+		return nil, ErrLocationNotFound
+	}
+	return location, nil
+}
+
+// Close releases the memory-mapped databases.
+func (p *MMDBProvider) Close() error {
+	var errs []error
+	if p.city != nil {
+		errs = append(errs, p.city.Close())
+	}
+	if p.asn != nil {
+		errs = append(errs, p.asn.Close())
+	}
+	return errors.Join(errs...)
+}
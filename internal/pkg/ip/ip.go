@@ -1,12 +1,12 @@
 package ip
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
 	"github.com/gin-gonic/gin"
@@ -38,6 +38,10 @@ type LocationResponse struct {
 		TLD         string   `json:"tld"`
 		Languages   []string `json:"languages"`
 	} `json:"country_metadata"`
+	ASN struct {
+		ASNumber     string `json:"as_number"`
+		Organization string `json:"organization"`
+	} `json:"asn"`
 }
 
 func IPGeolocation(ip string) (*LocationResponse, error) {
@@ -46,13 +50,17 @@ func IPGeolocation(ip string) (*LocationResponse, error) {
 		// Return specific error so caller knows to skip
 		return nil, fmt.Errorf("IP_GEOLOCATION_API_KEY not set")
 	}
-	url := `https://api.ipgeolocation.io/v2/ipgeo?apiKey=` + APIKey + `&ip=` + ip
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultGeoLookupTimeout)
+	defer cancel()
+	return fetchIPGeolocation(ctx, http.DefaultClient, APIKey, ip)
+}
+
+func fetchIPGeolocation(ctx context.Context, client *http.Client, apiKey, ip string) (*LocationResponse, error) {
+	url := `https://api.ipgeolocation.io/v2/ipgeo?apiKey=` + apiKey + `&ip=` + ip
 	method := "GET"
 
-	client := &http.Client{
-		Timeout: 5 * time.Second, // Timeout is critical
-	}
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -101,27 +109,10 @@ func GetLocationString(ip string) string {
 	return joinWithComma(parts)
 }
 
+// GetLocation resolves country and city through the configured GeoProvider.
 func GetLocation(ip string) (country, city string) {
-	if ip == "" || ip == "127.0.0.1" || ip == "::1" || ip == "localhost" {
-		return "Local Machine", "Local Machine"
-	}
-
-	locationResponse, err := IPGeolocation(ip)
-	if err != nil {
-		return "Unknown Country", "Unknown City"
-	}
-
-	c := locationResponse.Location.CountryName
-	if c == "" {
-		c = "Unknown Country"
-	}
-
-	cy := locationResponse.Location.City
-	if cy == "" {
-		cy = "Unknown City"
-	}
-
-	return c, cy
+	location := Locate(ip)
+	return location.Country, location.City
 }
 
 func GetCountryName(ip string) string {
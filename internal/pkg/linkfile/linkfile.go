@@ -0,0 +1,443 @@
+// Package linkfile reads and writes the CSV and NDJSON files links are
+// imported from and exported to. Both formats carry the same fields, so an
+// export can be imported again.
+package linkfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// TagSeparator joins tag names in a CSV cell
+const TagSeparator = "|"
+
+// maxLineBytes bounds one NDJSON line
+const maxLineBytes = 1024 * 1024
+
+var (
+	ErrUnknownFormat = errors.New("linkfile: format must be csv or ndjson")
+	ErrNoURLColumn   = errors.New("linkfile: the header has no original_url column")
+	ErrNoRows        = errors.New("linkfile: the file has no rows")
+	ErrTooManyRows   = errors.New("linkfile: the file has too many rows")
+)
+
+// Record is one link. Tags and Collection are names rather than IDs so
+// files move between accounts. The fields after Collection are written on
+// export and ignored on import.
+type Record struct {
+	OriginalURL string     `json:"original_url"`
+	ShortCode   string     `json:"short_code,omitempty"`
+	Domain      string     `json:"domain,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Passcode    string     `json:"passcode,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ClickLimit  *int       `json:"click_limit,omitempty"`
+	EnableStats *bool      `json:"enable_stats,omitempty"`
+	UTMSource   string     `json:"utm_source,omitempty"`
+	UTMMedium   string     `json:"utm_medium,omitempty"`
+	UTMCampaign string     `json:"utm_campaign,omitempty"`
+	UTMTerm     string     `json:"utm_term,omitempty"`
+	UTMContent  string     `json:"utm_content,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Collection  string     `json:"collection,omitempty"`
+
+	IsActive      *bool      `json:"is_active,omitempty"`
+	CurrentClicks *int       `json:"current_clicks,omitempty"`
+	UniqueClicks  *int       `json:"unique_clicks,omitempty"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+}
+
+// Row is a record read from a file. Line is where it starts; Err is why a
+// field could not be read, leaving Record partly filled.
+type Row struct {
+	Line   int
+	Record Record
+	Err    error
+}
+
+// FormatOf returns the format a file name's extension stands for, or "".
+func FormatOf(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	default:
+		return ""
+	}
+}
+
+// ContentType returns the media type of a file in format.
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Read reads every row of a file in format. A CSV file starts with a header
+// naming its columns; unknown columns are ignored. Files with more than
+// maxRows rows are refused.
+func Read(r io.Reader, format string, maxRows int) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r, maxRows)
+	case FormatNDJSON:
+		return readNDJSON(r, maxRows)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+func readCSV(r io.Reader, maxRows int) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("linkfile: %w", err)
+	}
+
+	byIndex := make([]*column, len(header))
+	hasURL := false
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		for c := range columns {
+			if columns[c].name == name {
+				byIndex[i] = &columns[c]
+				hasURL = hasURL || name == "original_url"
+			}
+		}
+	}
+	if !hasURL {
+		return nil, ErrNoURLColumn
+	}
+
+	var rows []Row
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("linkfile: %w", err)
+		}
+		if blank(fields) {
+			continue
+		}
+		if len(rows) == maxRows {
+			return nil, ErrTooManyRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := Row{Line: line}
+		for i, field := range fields {
+			if i >= len(byIndex) || byIndex[i] == nil || byIndex[i].set == nil {
+				continue
+			}
+			value := strings.TrimSpace(field)
+			if value == "" {
+				continue
+			}
+			if err := byIndex[i].set(&row.Record, value); err != nil && row.Err == nil {
+				row.Err = err
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return rows, nil
+}
+
+func blank(fields []string) bool {
+	for _, field := range fields {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func readNDJSON(r io.Reader, maxRows int) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+
+	var rows []Row
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if line == 1 {
+			text = bytes.TrimPrefix(text, []byte("\ufeff"))
+		}
+		if len(text) == 0 {
+			continue
+		}
+		if len(rows) == maxRows {
+			return nil, ErrTooManyRows
+		}
+
+		row := Row{Line: line}
+		if err := json.Unmarshal(text, &row.Record); err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("linkfile: %w", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return rows, nil
+}
+
+// Writer writes records to a file in one format.
+type Writer struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// NewWriter starts a file in format on w; a CSV file gets its header at once.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case FormatCSV:
+		writer := &Writer{csv: csv.NewWriter(w)}
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = c.name
+		}
+		if err := writer.csv.Write(header); err != nil {
+			return nil, err
+		}
+		return writer, nil
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return &Writer{json: encoder}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// Write adds a record.
+func (w *Writer) Write(record Record) error {
+	if w.json != nil {
+		return w.json.Encode(record)
+	}
+
+	fields := make([]string, len(columns))
+	for i, c := range columns {
+		fields[i] = c.get(&record)
+	}
+	return w.csv.Write(fields)
+}
+
+// Flush writes out buffered records.
+func (w *Writer) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Failure is a row an import could not create, as listed in error reports.
+type Failure struct {
+	Line        int    `json:"line"`
+	OriginalURL string `json:"original_url,omitempty"`
+	Code        string `json:"error_code"`
+	Message     string `json:"error_message"`
+}
+
+// WriteFailures writes an error report in format.
+func WriteFailures(w io.Writer, format string, failures []Failure) error {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"line", "original_url", "error_code", "error_message"}); err != nil {
+			return err
+		}
+		for _, f := range failures {
+			if err := writer.Write([]string{strconv.Itoa(f.Line), escapeFormula(f.OriginalURL), f.Code, escapeFormula(f.Message)}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		for _, f := range failures {
+			if err := encoder.Encode(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+// column maps a CSV column to a Record field. Columns without set are only
+// written.
+type column struct {
+	name string
+	get  func(*Record) string
+	set  func(*Record, string) error
+}
+
+var columns = []column{
+	textColumn("original_url", func(r *Record) *string { return &r.OriginalURL }),
+	textColumn("short_code", func(r *Record) *string { return &r.ShortCode }),
+	textColumn("domain", func(r *Record) *string { return &r.Domain }),
+	textColumn("title", func(r *Record) *string { return &r.Title }),
+	textColumn("description", func(r *Record) *string { return &r.Description }),
+	textColumn("passcode", func(r *Record) *string { return &r.Passcode }),
+	timeColumn("expires_at", func(r *Record) **time.Time { return &r.ExpiresAt }),
+	intColumn("click_limit", func(r *Record) **int { return &r.ClickLimit }),
+	boolColumn("enable_stats", func(r *Record) **bool { return &r.EnableStats }),
+	textColumn("utm_source", func(r *Record) *string { return &r.UTMSource }),
+	textColumn("utm_medium", func(r *Record) *string { return &r.UTMMedium }),
+	textColumn("utm_campaign", func(r *Record) *string { return &r.UTMCampaign }),
+	textColumn("utm_term", func(r *Record) *string { return &r.UTMTerm }),
+	textColumn("utm_content", func(r *Record) *string { return &r.UTMContent }),
+	{
+		name: "tags",
+		get:  func(r *Record) string { return escapeFormula(strings.Join(r.Tags, TagSeparator)) },
+		set: func(r *Record, value string) error {
+			for _, tag := range strings.Split(unescapeFormula(value), TagSeparator) {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					r.Tags = append(r.Tags, tag)
+				}
+			}
+			return nil
+		},
+	},
+	textColumn("collection", func(r *Record) *string { return &r.Collection }),
+	writeOnly(boolColumn("is_active", func(r *Record) **bool { return &r.IsActive })),
+	writeOnly(intColumn("current_clicks", func(r *Record) **int { return &r.CurrentClicks })),
+	writeOnly(intColumn("unique_clicks", func(r *Record) **int { return &r.UniqueClicks })),
+	writeOnly(timeColumn("created_at", func(r *Record) **time.Time { return &r.CreatedAt })),
+}
+
+func textColumn(name string, field func(*Record) *string) column {
+	return column{
+		name: name,
+		get:  func(r *Record) string { return escapeFormula(*field(r)) },
+		set: func(r *Record, value string) error {
+			*field(r) = unescapeFormula(value)
+			return nil
+		},
+	}
+}
+
+func intColumn(name string, field func(*Record) **int) column {
+	return column{
+		name: name,
+		get: func(r *Record) string {
+			if *field(r) == nil {
+				return ""
+			}
+			return strconv.Itoa(**field(r))
+		},
+		set: func(r *Record, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a whole number", name, value)
+			}
+			*field(r) = &n
+			return nil
+		},
+	}
+}
+
+func boolColumn(name string, field func(*Record) **bool) column {
+	return column{
+		name: name,
+		get: func(r *Record) string {
+			if *field(r) == nil {
+				return ""
+			}
+			return strconv.FormatBool(**field(r))
+		},
+		set: func(r *Record, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not true or false", name, value)
+			}
+			*field(r) = &b
+			return nil
+		},
+	}
+}
+
+// timeColumn holds RFC 3339 times; a bare date is read as midnight UTC.
+func timeColumn(name string, field func(*Record) **time.Time) column {
+	return column{
+		name: name,
+		get: func(r *Record) string {
+			if *field(r) == nil {
+				return ""
+			}
+			return (*field(r)).UTC().Format(time.RFC3339)
+		},
+		set: func(r *Record, value string) error {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				if t, err = time.Parse(time.DateOnly, value); err != nil {
+					return fmt.Errorf("%s: %q is not an RFC 3339 time or a date", name, value)
+				}
+			}
+			*field(r) = &t
+			return nil
+		},
+	}
+}
+
+func writeOnly(c column) column {
+	c.set = nil
+	return c
+}
+
+// escapeFormula keeps spreadsheets from evaluating a cell as a formula.
+// Titles and descriptions come from destination pages, so they are not
+// trusted.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
@@ -0,0 +1,186 @@
+package linkfile
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSV(t *testing.T) {
+	t.Parallel()
+
+	file := "\ufeffOriginal_URL,title,click_limit,enable_stats,tags,expires_at,extra,current_clicks\n" +
+		"https://example.com/a,'=SUM(1),5,false,news | promo,2030-01-02,x,99\n" +
+		",,,,,,,\n" +
+		"https://example.com/b,B,many,yes,,2030-01-02T03:04:05Z,,\n"
+
+	rows, err := Read(strings.NewReader(file), FormatCSV, 10)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Read() returned %d rows, want 2 (blank rows skipped)", len(rows))
+	}
+
+	limit, stats := 5, false
+	expires := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	want := Record{
+		OriginalURL: "https://example.com/a",
+		Title:       "=SUM(1)",
+		ClickLimit:  &limit,
+		EnableStats: &stats,
+		Tags:        []string{"news", "promo"},
+		ExpiresAt:   &expires,
+	}
+	if rows[0].Err != nil || rows[0].Line != 2 || !reflect.DeepEqual(rows[0].Record, want) {
+		t.Errorf("row 1 = %+v, want line 2 with %+v", rows[0], want)
+	}
+
+	if rows[1].Line != 4 || rows[1].Err == nil || !strings.Contains(rows[1].Err.Error(), "click_limit") {
+		t.Errorf("row 2 = %+v, want a click_limit error on line 4", rows[1])
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	t.Parallel()
+
+	file := `{"original_url":"https://example.com/a","tags":["news"],"enable_stats":false}` + "\n\n" +
+		`{"original_url":` + "\n"
+
+	rows, err := Read(strings.NewReader(file), FormatNDJSON, 10)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Read() returned %d rows, want 2", len(rows))
+	}
+	if rows[0].Err != nil || rows[0].Record.OriginalURL != "https://example.com/a" || rows[0].Record.EnableStats == nil {
+		t.Errorf("row 1 = %+v", rows[0])
+	}
+	if rows[1].Line != 3 || rows[1].Err == nil {
+		t.Errorf("row 2 = %+v, want a JSON error on line 3", rows[1])
+	}
+}
+
+func TestReadRefusesFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		file   string
+		format string
+		want   error
+	}{
+		{name: "unknown format", file: "x", format: "xml", want: ErrUnknownFormat},
+		{name: "empty csv", file: "", format: FormatCSV, want: ErrNoRows},
+		{name: "header only", file: "original_url\n", format: FormatCSV, want: ErrNoRows},
+		{name: "no url column", file: "title\nA\n", format: FormatCSV, want: ErrNoURLColumn},
+		{name: "too many csv rows", file: "original_url\na\nb\nc\n", format: FormatCSV, want: ErrTooManyRows},
+		{name: "too many ndjson rows", file: "{}\n{}\n{}\n", format: FormatNDJSON, want: ErrTooManyRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := Read(strings.NewReader(tt.file), tt.format, 2); !errors.Is(err, tt.want) {
+				t.Errorf("Read() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	limit, clicks, active := 10, 3, true
+	created := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	record := Record{
+		OriginalURL:   "https://example.com/a?x=1&y=2",
+		ShortCode:     "promo",
+		Title:         "+1, \"quoted\"",
+		ClickLimit:    &limit,
+		UTMSource:     "mail",
+		Tags:          []string{"news", "promo"},
+		Collection:    "Spring",
+		IsActive:      &active,
+		CurrentClicks: &clicks,
+		CreatedAt:     &created,
+	}
+	imported := record
+	imported.IsActive, imported.CurrentClicks, imported.CreatedAt = nil, nil, nil
+
+	for _, format := range []string{FormatCSV, FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			writer, err := NewWriter(&buf, format)
+			if err != nil {
+				t.Fatalf("NewWriter() error = %v", err)
+			}
+			if err := writer.Write(record); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := writer.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if format == FormatCSV && !strings.Contains(buf.String(), `"'+1, ""quoted"""`) {
+				t.Errorf("CSV title not escaped: %s", buf.String())
+			}
+
+			rows, err := Read(&buf, format, 10)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			want := imported
+			if format == FormatNDJSON {
+				// NDJSON keeps the export fields; importers ignore them
+				want = record
+			}
+			if len(rows) != 1 || rows[0].Err != nil || !reflect.DeepEqual(rows[0].Record, want) {
+				t.Errorf("round trip = %+v, want %+v", rows, want)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]string{
+		"links.CSV":    FormatCSV,
+		"links.ndjson": FormatNDJSON,
+		"links.jsonl":  FormatNDJSON,
+		"links.xlsx":   "",
+	} {
+		if got := FormatOf(name); got != want {
+			t.Errorf("FormatOf(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWriteFailures(t *testing.T) {
+	t.Parallel()
+
+	failures := []Failure{{Line: 3, OriginalURL: "=HYPERLINK(1)", Code: "VALIDATION_ERROR", Message: "URL Asli wajib diisi"}}
+
+	var csvOut bytes.Buffer
+	if err := WriteFailures(&csvOut, FormatCSV, failures); err != nil {
+		t.Fatalf("WriteFailures(csv) error = %v", err)
+	}
+	want := "line,original_url,error_code,error_message\n3,'=HYPERLINK(1),VALIDATION_ERROR,URL Asli wajib diisi\n"
+	if csvOut.String() != want {
+		t.Errorf("WriteFailures(csv) = %q, want %q", csvOut.String(), want)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := WriteFailures(&jsonOut, FormatNDJSON, failures); err != nil {
+		t.Fatalf("WriteFailures(ndjson) error = %v", err)
+	}
+	if !strings.Contains(jsonOut.String(), `"error_code":"VALIDATION_ERROR"`) {
+		t.Errorf("WriteFailures(ndjson) = %s", jsonOut.String())
+	}
+}
@@ -0,0 +1,233 @@
+// Package linkmeta fetches the title, description and favicon of a short
+// link's destination page. Fetches are bounded in time, size and redirects,
+// and never connect to loopback, private or otherwise internal addresses.
+package linkmeta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	defaultTimeout      = 5 * time.Second
+	defaultMaxBytes     = 512 * 1024
+	defaultMaxRedirects = 3
+	userAgent           = "Mozilla/5.0 (compatible; LihatinBot/1.0; +https://lihat.in)"
+)
+
+var (
+	ErrBlockedAddress    = errors.New("destination resolves to a non-public address")
+	ErrUnsupportedScheme = errors.New("destination is not an http or https URL")
+	ErrTooManyRedirects  = errors.New("destination redirects too many times")
+	ErrNotHTML           = errors.New("destination is not an HTML page")
+)
+
+// StatusError reports a non-2xx answer from the destination.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("destination answered with status %d", e.Code)
+}
+
+// Permanent reports whether retrying a fetch that failed with err is
+// pointless, because the destination will not become fetchable by itself.
+func Permanent(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= 400 && statusErr.Code < 500 &&
+			statusErr.Code != http.StatusRequestTimeout && statusErr.Code != http.StatusTooManyRequests
+	}
+	return errors.Is(err, ErrBlockedAddress) ||
+		errors.Is(err, ErrUnsupportedScheme) ||
+		errors.Is(err, ErrTooManyRedirects) ||
+		errors.Is(err, ErrNotHTML)
+}
+
+// Options bounds a single fetch.
+type Options struct {
+	Timeout      time.Duration
+	MaxBytes     int64
+	MaxRedirects int
+}
+
+// Fetcher retrieves destination pages and extracts their Metadata.
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+var (
+	globalFetcher   *Fetcher
+	globalFetcherMu sync.RWMutex
+)
+
+// NewFetcher creates a fetcher that only connects to public addresses.
+func NewFetcher(opts Options) *Fetcher {
+	return newFetcher(opts, isPublicAddr)
+}
+
+// newFetcher creates a fetcher whose connections are limited to the
+// addresses allowAddr accepts. The check runs on the resolved address of
+// every connection, so DNS answers and redirects cannot get around it.
+func newFetcher(opts Options, allowAddr func(netip.Addr) bool) *Fetcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultMaxBytes
+	}
+	if opts.MaxRedirects < 0 {
+		opts.MaxRedirects = 0
+	}
+
+	dialer := &net.Dialer{
+		Timeout: opts.Timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return ErrBlockedAddress
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !allowAddr(addr.Unmap()) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		// No proxy: it would make the connection for us and skip the address check
+		Proxy:                  nil,
+		DialContext:            dialer.DialContext,
+		TLSHandshakeTimeout:    opts.Timeout,
+		ResponseHeaderTimeout:  opts.Timeout,
+		MaxResponseHeaderBytes: 64 * 1024,
+		DisableKeepAlives:      true,
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > opts.MaxRedirects {
+					return ErrTooManyRedirects
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return ErrUnsupportedScheme
+				}
+				return nil
+			},
+		},
+		maxBytes: opts.MaxBytes,
+	}
+}
+
+// InitGlobal initializes the global fetcher. When LINK_METADATA_ENABLED is
+// false no fetcher is installed and links keep only what their owner sent.
+func InitGlobal() error {
+	if !config.GetEnvAsBool(config.EnvLinkMetadataEnabled, true) {
+		logger.Logger.Info("Link metadata fetching disabled")
+		return nil
+	}
+
+	fetcher := NewFetcher(Options{
+		Timeout:      time.Duration(config.GetEnvAsInt(config.EnvLinkMetadataTimeoutSeconds, int(defaultTimeout/time.Second))) * time.Second,
+		MaxBytes:     int64(config.GetEnvAsInt(config.EnvLinkMetadataMaxKB, defaultMaxBytes/1024)) * 1024,
+		MaxRedirects: config.GetEnvAsInt(config.EnvLinkMetadataMaxRedirects, defaultMaxRedirects),
+	})
+
+	globalFetcherMu.Lock()
+	globalFetcher = fetcher
+	globalFetcherMu.Unlock()
+
+	return nil
+}
+
+// Global returns the initialized global fetcher, or nil when fetching is off.
+func Global() *Fetcher {
+	globalFetcherMu.RLock()
+	defer globalFetcherMu.RUnlock()
+	return globalFetcher
+}
+
+// Fetch retrieves rawURL and returns the metadata in its head.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Metadata, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return Metadata{}, ErrUnsupportedScheme
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return Metadata{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Metadata{}, &StatusError{Code: resp.StatusCode}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return Metadata{}, ErrNotHTML
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.maxBytes), contentType)
+	if err != nil {
+		return Metadata{}, err
+	}
+	return Parse(body, resp.Request.URL), nil
+}
+
+// nonPublicPrefixes are ranges outside the ones netip already classifies
+// that must not be reachable from a fetch.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// isPublicAddr reports whether addr is a globally routable unicast address.
+func isPublicAddr(addr netip.Addr) bool {
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
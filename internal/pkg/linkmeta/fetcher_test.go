@@ -0,0 +1,156 @@
+package linkmeta
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func allowAll(netip.Addr) bool { return true }
+
+func newStub(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Stub page</title><meta name="description" content="From the stub"><link rel="icon" href="/static/icon.png"></head></html>`))
+	})
+	mux.HandleFunc("/latin1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		_, _ = w.Write([]byte("<title>Caf\xe9</title>"))
+	})
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		hops := strings.Count(strings.TrimPrefix(r.URL.Path, "/redirect/"), "x")
+		if hops == 0 {
+			http.Redirect(w, r, "/page", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "/redirect/"+strings.Repeat("x", hops-1), http.StatusFound)
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<head><!--" + strings.Repeat("a", 4096) + "--><title>Too late</title></head>"))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	server := newStub(t)
+	fetcher := newFetcher(Options{Timeout: 2 * time.Second, MaxBytes: 1024, MaxRedirects: 2}, allowAll)
+
+	got, err := fetcher.Fetch(context.Background(), server.URL+"/redirect/x")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	want := Metadata{Title: "Stub page", Description: "From the stub", FaviconURL: server.URL + "/static/icon.png"}
+	if got != want {
+		t.Errorf("Fetch() = %+v, want %+v", got, want)
+	}
+
+	got, err = fetcher.Fetch(context.Background(), server.URL+"/latin1")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if got.Title != "Café" {
+		t.Errorf("Fetch() title = %q, want %q", got.Title, "Café")
+	}
+
+	got, err = fetcher.Fetch(context.Background(), server.URL+"/big")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if got.Title != "" {
+		t.Errorf("Fetch() read past the size limit, title = %q", got.Title)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	t.Parallel()
+
+	server := newStub(t)
+	fetcher := newFetcher(Options{Timeout: 200 * time.Millisecond, MaxBytes: 1024, MaxRedirects: 2}, allowAll)
+
+	tests := []struct {
+		name          string
+		fetcher       *Fetcher
+		url           string
+		wantErr       error
+		wantPermanent bool
+	}{
+		{"private address", NewFetcher(Options{}), server.URL + "/page", ErrBlockedAddress, true},
+		{"too many redirects", fetcher, server.URL + "/redirect/xx", ErrTooManyRedirects, true},
+		{"not html", fetcher, server.URL + "/json", ErrNotHTML, true},
+		{"unsupported scheme", fetcher, "ftp://example.com/", ErrUnsupportedScheme, true},
+		{"not found", fetcher, server.URL + "/missing", &StatusError{Code: http.StatusNotFound}, true},
+		{"timeout", fetcher, server.URL + "/slow", nil, false},
+	}
+	for _, tt := range tests {
+		_, err := tt.fetcher.Fetch(context.Background(), tt.url)
+		if err == nil {
+			t.Errorf("%s: Fetch() error = nil", tt.name)
+			continue
+		}
+		var statusErr *StatusError
+		switch want := tt.wantErr.(type) {
+		case nil:
+		case *StatusError:
+			if !errors.As(err, &statusErr) || statusErr.Code != want.Code {
+				t.Errorf("%s: Fetch() error = %v, want %v", tt.name, err, want)
+			}
+		default:
+			if !errors.Is(err, want) {
+				t.Errorf("%s: Fetch() error = %v, want %v", tt.name, err, want)
+			}
+		}
+		if got := Permanent(err); got != tt.wantPermanent {
+			t.Errorf("%s: Permanent(%v) = %v, want %v", tt.name, err, got, tt.wantPermanent)
+		}
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package linkmeta
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Longest title and description kept from a page, in runes
+const (
+	maxTitleRunes       = 255
+	maxDescriptionRunes = 500
+)
+
+// Metadata is what a destination page says about itself. Title and
+// Description prefer the Open Graph tags over <title> and the meta
+// description; URLs are absolute.
+type Metadata struct {
+	Title       string
+	Description string
+	ImageURL    string
+	FaviconURL  string
+}
+
+// Parse reads the head of an HTML document served from base. It stops at the
+// body, so only the head has to fit in the fetch limit.
+func Parse(r io.Reader, base *url.URL) Metadata {
+	var (
+		title, ogTitle             string
+		description, ogDescription string
+		image, icon, touchIcon     string
+		inTitle                    bool
+	)
+
+	tokenizer := html.NewTokenizer(r)
+scan:
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			break scan
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = false
+			case atom.Head:
+				break scan
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			tag := atom.Lookup(name)
+			if tag == atom.Body {
+				break scan
+			}
+			if tag == atom.Title {
+				inTitle = tokenType == html.StartTagToken
+				continue
+			}
+			if !hasAttr || (tag != atom.Meta && tag != atom.Link) {
+				continue
+			}
+
+			attrs := readAttrs(tokenizer)
+			if tag == atom.Link {
+				rel := " " + strings.ToLower(attrs["rel"]) + " "
+				switch {
+				case strings.Contains(rel, " icon ") && icon == "":
+					icon = attrs["href"]
+				case strings.Contains(rel, " apple-touch-icon ") && touchIcon == "":
+					touchIcon = attrs["href"]
+				}
+				continue
+			}
+
+			key := strings.ToLower(attrs["property"])
+			if key == "" {
+				key = strings.ToLower(attrs["name"])
+			}
+			content := attrs["content"]
+			switch {
+			case key == "og:title" && ogTitle == "":
+				ogTitle = content
+			case key == "og:description" && ogDescription == "":
+				ogDescription = content
+			case key == "description" && description == "":
+				description = content
+			case (key == "og:image" || key == "og:image:url") && image == "":
+				image = content
+			}
+		}
+	}
+
+	if icon == "" {
+		icon = touchIcon
+	}
+	if icon == "" {
+		icon = "/favicon.ico"
+	}
+	return Metadata{
+		Title:       clean(firstNonEmpty(ogTitle, title), maxTitleRunes),
+		Description: clean(firstNonEmpty(ogDescription, description), maxDescriptionRunes),
+		ImageURL:    resolve(base, image),
+		FaviconURL:  resolve(base, icon),
+	}
+}
+
+func readAttrs(tokenizer *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		key, value, more := tokenizer.TagAttr()
+		attrs[string(key)] = string(value)
+		if !more {
+			return attrs
+		}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// clean collapses whitespace and cuts s to at most limit runes.
+func clean(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit])
+}
+
+// resolve makes ref absolute against base, dropping anything that is not an
+// http or https URL.
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ""
+	}
+	parsed, err := base.Parse(ref)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return parsed.String()
+}
@@ -0,0 +1,70 @@
+package linkmeta
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	base, err := url.Parse("https://example.com/blog/post")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		html string
+		want Metadata
+	}{
+		{
+			name: "title and meta description",
+			html: `<html><head><title> Hello
+				&amp; welcome </title><meta name="description" content="A post"></head><body></body></html>`,
+			want: Metadata{Title: "Hello & welcome", Description: "A post", FaviconURL: "https://example.com/favicon.ico"},
+		},
+		{
+			name: "open graph wins",
+			html: `<head><title>Post | Blog</title>
+				<meta name="description" content="Plain">
+				<meta property="og:title" content="Post">
+				<meta property="og:description" content="Rich">
+				<meta property="og:image" content="/img/cover.png">
+				<link rel="apple-touch-icon" href="/touch.png">
+				<link rel="shortcut icon" href="icon.png"></head>`,
+			want: Metadata{Title: "Post", Description: "Rich", ImageURL: "https://example.com/img/cover.png", FaviconURL: "https://example.com/blog/icon.png"},
+		},
+		{
+			name: "apple touch icon fallback",
+			html: `<head><link rel="apple-touch-icon" href="https://cdn.example.com/touch.png"></head>`,
+			want: Metadata{FaviconURL: "https://cdn.example.com/touch.png"},
+		},
+		{
+			name: "ignores the body",
+			html: `<head></head><body><title>Not it</title><meta name="description" content="Nope"></body>`,
+			want: Metadata{FaviconURL: "https://example.com/favicon.ico"},
+		},
+		{
+			name: "drops non http urls",
+			html: `<head><meta property="og:image" content="javascript:alert(1)"><link rel="icon" href="data:image/png;base64,AAAA"></head>`,
+			want: Metadata{},
+		},
+	}
+	for _, tt := range tests {
+		if got := Parse(strings.NewReader(tt.html), base); got != tt.want {
+			t.Errorf("%s: Parse() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTruncates(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("é", maxTitleRunes+10)
+	got := Parse(strings.NewReader("<title>"+long+"</title>"), nil)
+	if n := len([]rune(got.Title)); n != maxTitleRunes {
+		t.Errorf("Parse() title has %d runes, want %d", n, maxTitleRunes)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/adehusnim37/lihatin-go/internal/pkg/passcode"
 	"github.com/adehusnim37/lihatin-go/models/logging"
 	"github.com/adehusnim37/lihatin-go/models/shortlink"
 	supportmodel "github.com/adehusnim37/lihatin-go/models/support"
@@ -63,19 +64,95 @@ func RunMigrations(db *gorm.DB) error {
 		return fmt.Errorf("failed to migrate PromotionalEmailDelivery model: %w", err)
 	}
 
-	// Migrate ShortLink models
+	// Migrate ShortLink models; tags go first because ShortLink creates the
+	// short_link_tags join table
+	if err := db.AutoMigrate(&shortlink.LinkTag{}); err != nil {
+		return fmt.Errorf("failed to migrate LinkTag model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.Collection{}); err != nil {
+		return fmt.Errorf("failed to migrate Collection model: %w", err)
+	}
+
 	if err := db.AutoMigrate(&shortlink.ShortLink{}); err != nil {
 		return fmt.Errorf("failed to migrate ShortLink model: %w", err)
 	}
+	if err := normalizeShortLinkCodeIndex(db); err != nil {
+		return err
+	}
 
 	if err := db.AutoMigrate(&shortlink.ShortLinkDetail{}); err != nil {
 		return fmt.Errorf("failed to migrate ShortLinkDetail model: %w", err)
 	}
+	if err := hashLegacyPasscodes(db); err != nil {
+		return err
+	}
 
 	if err := db.AutoMigrate(&shortlink.ViewLinkDetail{}); err != nil {
 		return fmt.Errorf("failed to migrate ViewLinkDetail model: %w", err)
 	}
 
+	// Migrate click rollup models
+	if err := db.AutoMigrate(&shortlink.ClickRollupHourly{}); err != nil {
+		return fmt.Errorf("failed to migrate ClickRollupHourly model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.ClickRollupDaily{}); err != nil {
+		return fmt.Errorf("failed to migrate ClickRollupDaily model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.ClickRollupDimensionHourly{}); err != nil {
+		return fmt.Errorf("failed to migrate ClickRollupDimensionHourly model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.ClickRollupDimensionDaily{}); err != nil {
+		return fmt.Errorf("failed to migrate ClickRollupDimensionDaily model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.ClickRollupState{}); err != nil {
+		return fmt.Errorf("failed to migrate ClickRollupState model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.ClickPrivacySalt{}); err != nil {
+		return fmt.Errorf("failed to migrate ClickPrivacySalt model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.CustomDomain{}); err != nil {
+		return fmt.Errorf("failed to migrate CustomDomain model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.RedirectRule{}); err != nil {
+		return fmt.Errorf("failed to migrate RedirectRule model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.LinkVariant{}); err != nil {
+		return fmt.Errorf("failed to migrate LinkVariant model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.ShortCodeSequence{}); err != nil {
+		return fmt.Errorf("failed to migrate ShortCodeSequence model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.ShortCodeSettings{}); err != nil {
+		return fmt.Errorf("failed to migrate ShortCodeSettings model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.ReservedCode{}); err != nil {
+		return fmt.Errorf("failed to migrate ReservedCode model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.LinkImport{}); err != nil {
+		return fmt.Errorf("failed to migrate LinkImport model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.LinkImportRow{}); err != nil {
+		return fmt.Errorf("failed to migrate LinkImportRow model: %w", err)
+	}
+
+	if err := db.AutoMigrate(&shortlink.ShortLinkRevision{}); err != nil {
+		return fmt.Errorf("failed to migrate ShortLinkRevision model: %w", err)
+	}
+
 	// Migrate Logging models
 	if err := db.AutoMigrate(&logging.ActivityLog{}); err != nil {
 		return fmt.Errorf("failed to migrate ActivityLog model: %w", err)
@@ -140,6 +217,66 @@ func normalizeSupportAttachmentSchema(db *gorm.DB) error {
 	return nil
 }
 
+// normalizeShortLinkCodeIndex drops the legacy global unique index on
+// short_links.short_code; codes are now unique per domain.
+func normalizeShortLinkCodeIndex(db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("gorm DB is required")
+	}
+
+	if db.Migrator().HasIndex(&shortlink.ShortLink{}, "idx_short_links_short_code") {
+		log.Println("ℹ️ Dropping legacy short_links.short_code unique index")
+		if err := db.Migrator().DropIndex(&shortlink.ShortLink{}, "idx_short_links_short_code"); err != nil {
+			return fmt.Errorf("failed to drop legacy short_links.short_code index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// hashLegacyPasscodes moves plaintext short_link_details.passcode values into
+// passcode_hash and drops the legacy column.
+func hashLegacyPasscodes(db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("gorm DB is required")
+	}
+
+	if !db.Migrator().HasColumn(&shortlink.ShortLinkDetail{}, "passcode") {
+		return nil
+	}
+
+	var legacy []struct {
+		ID       string
+		Passcode int
+	}
+	if err := db.Model(&shortlink.ShortLinkDetail{}).Unscoped().
+		Select("id", "passcode").
+		Where("passcode <> 0").
+		Find(&legacy).Error; err != nil {
+		return fmt.Errorf("failed to read legacy short_link_details.passcode values: %w", err)
+	}
+
+	log.Printf("ℹ️ Hashing %d legacy short link passcodes", len(legacy))
+	for _, row := range legacy {
+		hash, err := passcode.Hash(row.Passcode)
+		if err != nil {
+			return fmt.Errorf("failed to hash legacy passcode: %w", err)
+		}
+		if err := db.Model(&shortlink.ShortLinkDetail{}).Unscoped().
+			Where("id = ?", row.ID).
+			Update("passcode_hash", hash).Error; err != nil {
+			return fmt.Errorf("failed to store hashed passcode: %w", err)
+		}
+	}
+
+	log.Println("ℹ️ Dropping legacy short_link_details.passcode column")
+	if err := db.Migrator().DropColumn(&shortlink.ShortLinkDetail{}, "passcode"); err != nil {
+		return fmt.Errorf("failed to drop legacy short_link_details.passcode column: %w", err)
+	}
+
+	return nil
+}
+
 func renamePremiumAccessEventTable(db *gorm.DB) error {
 	if db == nil {
 		return fmt.Errorf("gorm DB is required")
@@ -0,0 +1,59 @@
+package passcode
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// FormField is the passcode input on the form.
+const FormField = "passcode"
+
+//go:embed templates/passcode.html
+var templateFS embed.FS
+
+var formTemplate = template.Must(template.ParseFS(templateFS, "templates/passcode.html"))
+
+// FormPage is what the passcode form shows about a protected link.
+type FormPage struct {
+	ShortCode       string
+	Title           string
+	DestinationHost string
+	// Action is the short link itself, which the form posts back to
+	Action    string
+	CSRFField string
+	CSRFToken string
+	Error     string
+}
+
+// FormAction returns the address the form posts to: the request path, keeping
+// the query without any passcode in it.
+func FormAction(requestURL *url.URL) string {
+	query := requestURL.Query()
+	query.Del(FormField)
+	action := url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+	return action.String()
+}
+
+// ParseCode parses a submitted passcode, reporting false unless it is exactly
+// six digits.
+func ParseCode(raw string) (int, bool) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) != 6 || strings.Trim(raw, "0123456789") != "" {
+		return 0, false
+	}
+	code, err := strconv.Atoi(raw)
+	return code, err == nil
+}
+
+// RenderForm returns the form as HTML.
+func RenderForm(page FormPage) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := formTemplate.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
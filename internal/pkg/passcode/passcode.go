@@ -0,0 +1,226 @@
+// Package passcode hashes short link passcodes, signs the cookie that unlocks
+// a protected link after the passcode form, and throttles wrong guesses.
+package passcode
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// CookieName holds the unlock grant. It is scoped to the short link's
+	// path, so every link keeps its own.
+	CookieName = "lihatin_passcode"
+
+	defaultUnlockMinutes = 30
+	defaultLimitPerLink  = 30
+	defaultLimitPerIP    = 10
+	defaultWindowMinutes = 15
+)
+
+// ErrLimiterUnavailable is returned when the attempt counters cannot be read.
+var ErrLimiterUnavailable = errors.New("passcode attempt limiter unavailable")
+
+// Hash returns a salted bcrypt hash of a six digit passcode.
+func Hash(code int) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(strconv.Itoa(code)), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// Matches reports whether code is the passcode hashed into hash.
+func Matches(hash string, code int) bool {
+	if hash == "" || code == 0 {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(strconv.Itoa(code))) == nil
+}
+
+// Guard signs unlock grants and counts failed attempts in Redis.
+type Guard struct {
+	redisClient *redis.Client
+	secret      []byte
+	unlockTTL   time.Duration
+
+	limitPerLink int
+	limitPerIP   int
+	window       time.Duration
+	now          func() time.Time
+}
+
+var (
+	globalGuard   *Guard
+	globalGuardMu sync.RWMutex
+)
+
+// NewGuard creates a guard. Grants last unlockTTL; a link or an IP is locked
+// out for the rest of window once it reaches its limit of failed attempts.
+func NewGuard(redisClient *redis.Client, secret []byte, unlockTTL time.Duration, limitPerLink, limitPerIP int, window time.Duration) *Guard {
+	return &Guard{
+		redisClient:  redisClient,
+		secret:       secret,
+		unlockTTL:    unlockTTL,
+		limitPerLink: limitPerLink,
+		limitPerIP:   limitPerIP,
+		window:       window,
+		now:          time.Now,
+	}
+}
+
+// InitGlobal initializes the global guard instance. Grants are signed with
+// SESSION_SECRET, or an ephemeral key that invalidates them on restart.
+func InitGlobal(redisClient *redis.Client) error {
+	secret := []byte(config.GetEnvOrDefault(config.EnvSessionSecret, ""))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("generate passcode grant key: %w", err)
+		}
+		logger.Logger.Warn("Passcode: No SESSION_SECRET set, using an ephemeral grant key")
+	}
+
+	guard := NewGuard(
+		redisClient,
+		secret,
+		time.Duration(positiveOrDefault(config.GetEnvAsInt(config.EnvPasscodeUnlockMinutes, defaultUnlockMinutes), defaultUnlockMinutes))*time.Minute,
+		positiveOrDefault(config.GetEnvAsInt(config.EnvPasscodeLimitPerLink, defaultLimitPerLink), defaultLimitPerLink),
+		positiveOrDefault(config.GetEnvAsInt(config.EnvPasscodeLimitPerIP, defaultLimitPerIP), defaultLimitPerIP),
+		time.Duration(positiveOrDefault(config.GetEnvAsInt(config.EnvPasscodeWindowMinutes, defaultWindowMinutes), defaultWindowMinutes))*time.Minute,
+	)
+
+	globalGuardMu.Lock()
+	globalGuard = guard
+	globalGuardMu.Unlock()
+
+	return nil
+}
+
+// Global returns the initialized global guard, or nil before InitGlobal.
+func Global() *Guard {
+	globalGuardMu.RLock()
+	defer globalGuardMu.RUnlock()
+	return globalGuard
+}
+
+// UnlockMaxAge is the grant cookie lifetime in seconds.
+func (g *Guard) UnlockMaxAge() int {
+	return int(g.unlockTTL.Seconds())
+}
+
+// Grant returns the cookie value unlocking linkID. hash is the link's current
+// passcode hash, so changing the passcode revokes every grant.
+func (g *Guard) Grant(linkID, hash string) string {
+	expires := strconv.FormatInt(g.now().Add(g.unlockTTL).Unix(), 10)
+	return expires + "." + g.sign(linkID, hash, expires)
+}
+
+// VerifyGrant reports whether value is an unexpired grant for linkID and hash.
+func (g *Guard) VerifyGrant(value, linkID, hash string) bool {
+	expires, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || g.now().Unix() >= unix {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(g.sign(linkID, hash, expires)))
+}
+
+func (g *Guard) sign(linkID, hash, expires string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(linkID + "\x00" + hash + "\x00" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Blocked reports whether linkID or clientIP has used up its failed attempts,
+// and for how many more seconds.
+func (g *Guard) Blocked(ctx context.Context, linkID, clientIP string) (blocked bool, retryAfterSeconds int, err error) {
+	if g.redisClient == nil {
+		return false, 0, ErrLimiterUnavailable
+	}
+
+	perLinkKey, perIPKey := attemptKeys(linkID, clientIP)
+	linkBlocked, linkRetry, err := g.checkLimit(ctx, perLinkKey, g.limitPerLink)
+	if err != nil {
+		return false, 0, err
+	}
+	ipBlocked, ipRetry, err := g.checkLimit(ctx, perIPKey, g.limitPerIP)
+	if err != nil {
+		return false, 0, err
+	}
+	return linkBlocked || ipBlocked, max(linkRetry, ipRetry), nil
+}
+
+// RecordFailure counts a wrong passcode against both linkID and clientIP.
+func (g *Guard) RecordFailure(ctx context.Context, linkID, clientIP string) error {
+	if g.redisClient == nil {
+		return ErrLimiterUnavailable
+	}
+
+	perLinkKey, perIPKey := attemptKeys(linkID, clientIP)
+	for _, key := range []string{perLinkKey, perIPKey} {
+		count, err := g.redisClient.Incr(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if count == 1 {
+			if err := g.redisClient.Expire(ctx, key, g.window).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *Guard) checkLimit(ctx context.Context, key string, limit int) (blocked bool, retryAfterSeconds int, err error) {
+	count, err := g.redisClient.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+
+	if count < int64(limit) {
+		return false, 0, nil
+	}
+
+	ttl, err := g.redisClient.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		return true, 1, nil
+	}
+
+	return true, max(int(ttl.Seconds()), 1), nil
+}
+
+func attemptKeys(linkID, clientIP string) (perLinkKey, perIPKey string) {
+	clientIP = strings.TrimSpace(clientIP)
+	if clientIP == "" {
+		clientIP = "unknown"
+	}
+	return fmt.Sprintf("passcode_risk:link:%s", linkID), fmt.Sprintf("passcode_risk:ip:%s", clientIP)
+}
+
+func positiveOrDefault(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+package passcode
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHashMatches(t *testing.T) {
+	t.Parallel()
+
+	hash, err := Hash(135790)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if strings.Contains(hash, "135790") {
+		t.Fatal("Hash() leaks the passcode")
+	}
+
+	other, err := Hash(135790)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if hash == other {
+		t.Fatal("Hash() is not salted")
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		code int
+		want bool
+	}{
+		{"match", hash, 135790, true},
+		{"wrong code", hash, 135791, false},
+		{"no passcode given", hash, 0, false},
+		{"link without passcode", "", 135790, false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.hash, tt.code); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGrant(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	guard := NewGuard(nil, []byte("0123456789abcdef0123456789abcdef"), 30*time.Minute, 30, 10, 15*time.Minute)
+	guard.now = func() time.Time { return now }
+
+	grant := guard.Grant("link-1", "hash-1")
+	otherKey := NewGuard(nil, []byte("fedcba9876543210fedcba9876543210"), 30*time.Minute, 30, 10, 15*time.Minute)
+	otherKey.now = guard.now
+
+	tests := []struct {
+		name   string
+		guard  *Guard
+		value  string
+		linkID string
+		hash   string
+		want   bool
+	}{
+		{"valid", guard, grant, "link-1", "hash-1", true},
+		{"other link", guard, grant, "link-2", "hash-1", false},
+		{"passcode changed", guard, grant, "link-1", "hash-2", false},
+		{"other key", otherKey, grant, "link-1", "hash-1", false},
+		{"tampered expiry", guard, "9999999999" + grant[strings.Index(grant, "."):], "link-1", "hash-1", false},
+		{"malformed", guard, "garbage", "link-1", "hash-1", false},
+		{"empty", guard, "", "link-1", "hash-1", false},
+	}
+	for _, tt := range tests {
+		if got := tt.guard.VerifyGrant(tt.value, tt.linkID, tt.hash); got != tt.want {
+			t.Errorf("%s: VerifyGrant() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	now = now.Add(31 * time.Minute)
+	if guard.VerifyGrant(grant, "link-1", "hash-1") {
+		t.Error("VerifyGrant() accepted an expired grant")
+	}
+}
+
+func TestParseCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw    string
+		want   int
+		wantOK bool
+	}{
+		{"135790", 135790, true},
+		{" 135790 ", 135790, true},
+		{"012345", 12345, true},
+		{"12345", 0, false},
+		{"1234567", 0, false},
+		{"+12345", 0, false},
+		{"12a456", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCode(tt.raw)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseCode(%q) = %d, %v, want %d, %v", tt.raw, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFormAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"/v1/short/abc", "/v1/short/abc"},
+		{"/v1/short/abc?passcode=135790", "/v1/short/abc"},
+		{"/abc?continue=1&passcode=135790&utm_source=x", "/abc?continue=1&utm_source=x"},
+	}
+	for _, tt := range tests {
+		requestURL, err := url.Parse(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormAction(requestURL); got != tt.want {
+			t.Errorf("FormAction(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderForm(t *testing.T) {
+	t.Parallel()
+
+	body, err := RenderForm(FormPage{
+		ShortCode:       "abc",
+		DestinationHost: "example.com",
+		Action:          "/v1/short/abc",
+		CSRFField:       "_csrf",
+		CSRFToken:       "token",
+		Error:           "<b>Incorrect</b>",
+	})
+	if err != nil {
+		t.Fatalf("RenderForm() error = %v", err)
+	}
+
+	html := string(body)
+	for _, want := range []string{`action="/v1/short/abc"`, `name="_csrf" value="token"`, `name="passcode"`, "example.com", "&lt;b&gt;Incorrect&lt;/b&gt;"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("RenderForm() missing %q", want)
+		}
+	}
+}
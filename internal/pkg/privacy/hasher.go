@@ -0,0 +1,126 @@
+package privacy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	saltDayLayout = "2006-01-02"
+	saltSize      = 32
+	// visitorHashLength is 128 bits in hex, plenty for unique counting
+	visitorHashLength = 32
+)
+
+// Hasher derives visitor hashes from a random salt per UTC day. Salts are
+// shared through MySQL so every instance hashes a visitor the same way, and
+// salts older than yesterday are deleted.
+type Hasher struct {
+	db    *gorm.DB
+	mu    sync.Mutex
+	salts map[string][]byte
+}
+
+var (
+	globalHasher   *Hasher
+	globalHasherMu sync.RWMutex
+)
+
+// NewHasher creates a hasher storing its salts in db.
+func NewHasher(db *gorm.DB) *Hasher {
+	return &Hasher{db: db, salts: make(map[string][]byte)}
+}
+
+// InitGlobal creates and registers the global hasher instance.
+func InitGlobal(db *gorm.DB) (*Hasher, error) {
+	if db == nil {
+		return nil, errors.New("gorm db is required")
+	}
+
+	hasher := NewHasher(db)
+	globalHasherMu.Lock()
+	globalHasher = hasher
+	globalHasherMu.Unlock()
+	return hasher, nil
+}
+
+// Global returns the initialized global hasher, or nil.
+func Global() *Hasher {
+	globalHasherMu.RLock()
+	defer globalHasherMu.RUnlock()
+	return globalHasher
+}
+
+// Hash returns the visitor hash of an IP and user agent for one link on the
+// UTC day of at. The same visitor gets different hashes on different links
+// and different days.
+func (h *Hasher) Hash(ctx context.Context, at time.Time, linkID, ipAddress, userAgent string) (string, error) {
+	salt, err := h.salt(ctx, at.UTC().Format(saltDayLayout))
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(linkID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(ipAddress))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+	return hex.EncodeToString(mac.Sum(nil))[:visitorHashLength], nil
+}
+
+func (h *Hasher) salt(ctx context.Context, day string) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if salt, ok := h.salts[day]; ok {
+		return salt, nil
+	}
+
+	fresh := make([]byte, saltSize)
+	if _, err := rand.Read(fresh); err != nil {
+		return nil, err
+	}
+
+	// Another instance may have created the day's salt first; keep theirs
+	db := h.db.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&shortlink.ClickPrivacySalt{Day: day, Salt: fresh}).Error; err != nil {
+		return nil, err
+	}
+	var stored shortlink.ClickPrivacySalt
+	if err := db.Where("day = ?", day).First(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	h.rotate(ctx, day)
+	h.salts[day] = stored.Salt
+	return stored.Salt, nil
+}
+
+// rotate forgets salts older than the day before current. Yesterday's salt is
+// kept so clicks still queued across midnight hash consistently.
+func (h *Hasher) rotate(ctx context.Context, current string) {
+	today, err := time.Parse(saltDayLayout, current)
+	if err != nil {
+		return
+	}
+	oldest := today.AddDate(0, 0, -1).Format(saltDayLayout)
+
+	for day := range h.salts {
+		if day < oldest {
+			delete(h.salts, day)
+		}
+	}
+	_ = h.db.WithContext(ctx).Where("day < ?", oldest).Delete(&shortlink.ClickPrivacySalt{}).Error
+}
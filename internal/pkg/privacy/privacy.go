@@ -0,0 +1,112 @@
+// Package privacy implements the visitor privacy modes applied to click data:
+// salted daily-rotating IP hashes, IP truncation and Do-Not-Track handling.
+package privacy
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+)
+
+// Mode decides how visitor identifiers are stored for a link.
+type Mode string
+
+const (
+	// ModeOff stores the full IP address and user agent.
+	ModeOff Mode = "off"
+	// ModeHash stores a salted hash that rotates daily instead of the IP,
+	// enough to count unique visitors within a day.
+	ModeHash Mode = "hash"
+	// ModeTruncate stores the IP truncated to its network prefix
+	// (/24 for IPv4, /48 for IPv6).
+	ModeTruncate Mode = "truncate"
+)
+
+// ParseMode validates a mode name. An empty string is not a mode; links use
+// it to inherit the global default.
+func ParseMode(raw string) (Mode, bool) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(raw))); m {
+	case ModeOff, ModeHash, ModeTruncate:
+		return m, true
+	}
+	return "", false
+}
+
+// Settings is the effective privacy configuration of a link.
+type Settings struct {
+	Mode Mode
+	// HonorDNT drops identifying fields for visitors sending DNT: 1 or
+	// Sec-GPC: 1.
+	HonorDNT bool
+}
+
+var (
+	defaultSettings     Settings
+	defaultSettingsOnce sync.Once
+)
+
+// Defaults returns the global settings from CLICK_PRIVACY_MODE and
+// CLICK_PRIVACY_HONOR_DNT, read once.
+func Defaults() Settings {
+	defaultSettingsOnce.Do(func() {
+		defaultSettings = SettingsFromEnv()
+	})
+	return defaultSettings
+}
+
+// SettingsFromEnv reads the global privacy settings.
+func SettingsFromEnv() Settings {
+	mode, ok := ParseMode(config.GetEnvOrDefault(config.EnvClickPrivacyMode, string(ModeOff)))
+	if !ok {
+		mode = ModeOff
+	}
+	return Settings{
+		Mode:     mode,
+		HonorDNT: config.GetEnvAsBool(config.EnvClickPrivacyHonorDNT, true),
+	}
+}
+
+// ForLink applies a link's overrides; an empty mode or nil honorDNT keeps the
+// global value.
+func (s Settings) ForLink(mode string, honorDNT *bool) Settings {
+	if m, ok := ParseMode(mode); ok {
+		s.Mode = m
+	}
+	if honorDNT != nil {
+		s.HonorDNT = *honorDNT
+	}
+	return s
+}
+
+// DoNotTrack reports whether the request opted out of tracking via DNT or
+// Global Privacy Control.
+func DoNotTrack(header http.Header) bool {
+	return strings.TrimSpace(header.Get("DNT")) == "1" || strings.TrimSpace(header.Get("Sec-GPC")) == "1"
+}
+
+// TruncateIP zeroes the host part of an address: IPv4 keeps /24 and IPv6
+// keeps /48. Unparseable input yields "".
+func TruncateIP(address string) string {
+	parsed := net.ParseIP(strings.TrimSpace(address))
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// ReferrerOrigin reduces a referrer to scheme and host, dropping paths and
+// query strings that may carry identifiers.
+func ReferrerOrigin(referer string) string {
+	u, err := url.Parse(strings.TrimSpace(referer))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,75 @@
+package privacy
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTruncateIP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"203.0.113.77", "203.0.113.0"},
+		{" 10.1.2.3 ", "10.1.2.0"},
+		{"::ffff:198.51.100.9", "198.51.100.0"},
+		{"2001:db8:abcd:12:1:2:3:4", "2001:db8:abcd::"},
+		{"not-an-ip", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := TruncateIP(tt.in); got != tt.want {
+			t.Errorf("TruncateIP(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDoNotTrack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   bool
+	}{
+		{"none", http.Header{}, false},
+		{"dnt", http.Header{"Dnt": {"1"}}, true},
+		{"dnt off", http.Header{"Dnt": {"0"}}, false},
+		{"gpc", http.Header{"Sec-Gpc": {"1"}}, true},
+	}
+	for _, tt := range tests {
+		if got := DoNotTrack(tt.header); got != tt.want {
+			t.Errorf("%s: DoNotTrack() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSettingsForLink(t *testing.T) {
+	t.Parallel()
+
+	global := Settings{Mode: ModeHash, HonorDNT: true}
+	no := false
+
+	if got := global.ForLink("", nil); got != global {
+		t.Fatalf("ForLink(inherit) = %+v, want %+v", got, global)
+	}
+	if got := global.ForLink("truncate", &no); got.Mode != ModeTruncate || got.HonorDNT {
+		t.Fatalf("ForLink(truncate, false) = %+v", got)
+	}
+	if got := global.ForLink("bogus", nil); got.Mode != ModeHash {
+		t.Fatalf("ForLink(bogus).Mode = %q, want %q", got.Mode, ModeHash)
+	}
+}
+
+func TestReferrerOrigin(t *testing.T) {
+	t.Parallel()
+
+	if got := ReferrerOrigin("https://news.example.com/a?uid=42"); got != "https://news.example.com" {
+		t.Fatalf("ReferrerOrigin() = %q", got)
+	}
+	if got := ReferrerOrigin("garbage"); got != "" {
+		t.Fatalf("ReferrerOrigin(garbage) = %q, want empty", got)
+	}
+}
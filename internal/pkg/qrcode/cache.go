@@ -0,0 +1,65 @@
+package qrcode
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	cacheKeyPrefix      = "qrcode:"
+	cacheTTL            = 7 * 24 * time.Hour
+	cacheOperationLimit = 500 * time.Millisecond
+)
+
+// Cache keeps rendered codes in Redis under their CacheKey, so each style of
+// a link's code is drawn once. A nil cache or nil client disables caching.
+type Cache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewCache creates a cache on client, which may be nil.
+func NewCache(client *redis.Client) *Cache {
+	return &Cache{client: client, ttl: cacheTTL}
+}
+
+func (c *Cache) enabled() bool {
+	return c != nil && c.client != nil
+}
+
+// Get returns the cached code for key.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cacheOperationLimit)
+	defer cancel()
+
+	data, err := c.client.Get(ctx, cacheKeyPrefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Logger.Warn("QR code cache read failed", "key", key, "error", err.Error())
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// Set caches a rendered code under key.
+func (c *Cache) Set(ctx context.Context, key string, data []byte) {
+	if !c.enabled() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cacheOperationLimit)
+	defer cancel()
+
+	if err := c.client.Set(ctx, cacheKeyPrefix+key, data, c.ttl).Err(); err != nil {
+		logger.Logger.Warn("QR code cache write failed", "key", key, "error", err.Error())
+	}
+}
@@ -0,0 +1,70 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// logoBox is where a logo goes, in pixels from the top left of the code
+// (quiet zone excluded). pad is cleared to the background around logo.
+type logoBox struct {
+	pad  image.Rectangle
+	logo image.Rectangle
+}
+
+// placeLogo centres logo on a code codeWidth pixels wide, keeping its aspect
+// ratio, and returns it resized to fit.
+func placeLogo(logo image.Image, codeWidth, padding float64) (logoBox, *image.RGBA) {
+	bounds := logo.Bounds()
+	maxSide := codeWidth * logoShare
+	ratio := math.Min(maxSide/float64(bounds.Dx()), maxSide/float64(bounds.Dy()))
+	w := max(int(float64(bounds.Dx())*ratio), 1)
+	h := max(int(float64(bounds.Dy())*ratio), 1)
+
+	x := (int(codeWidth) - w) / 2
+	y := (int(codeWidth) - h) / 2
+	pad := int(math.Ceil(padding))
+	box := logoBox{
+		logo: image.Rect(x, y, x+w, y+h),
+		pad:  image.Rect(x-pad, y-pad, x+w+pad, y+h+pad),
+	}
+	return box, resize(logo, w, h)
+}
+
+// resize averages the source pixels under each destination pixel, which is
+// good enough for shrinking a logo and degrades to nearest neighbour when
+// enlarging one.
+func resize(src image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	b := src.Bounds()
+	for y := range h {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/h, y0+1)
+		for x := range w {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(b.Min.X+(x+1)*b.Dx()/w, x0+1)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,291 @@
+// Package qrcode renders the QR codes of short links as PNG or SVG, styled
+// with colours, a quiet zone and an optional centre logo.
+package qrcode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // logo formats
+	_ "image/jpeg"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/barcode/qr"
+)
+
+// Output formats
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+const (
+	DefaultSize   = 512
+	MinSize       = 128
+	MaxSize       = 2048
+	DefaultMargin = 4 // modules, the quiet zone scanners expect
+	MaxMargin     = 16
+	DefaultLevel  = "M"
+
+	// SourceParam is added to the short URL a code encodes so the redirect
+	// can tell a scan from an ordinary click
+	SourceParam = "qr"
+
+	// logoShare is the part of the code's width a logo may cover; with level
+	// H the code survives 30% damage, well above the area this hides
+	logoShare = 0.22
+	// maxLogoPixels caps the width and height of an uploaded logo
+	maxLogoPixels = 4096
+)
+
+var (
+	ErrInvalidColor = errors.New("qrcode: colours must be #rrggbb")
+	ErrSameColors   = errors.New("qrcode: foreground and background must differ")
+	ErrTooSmall     = errors.New("qrcode: size is too small for the code")
+	ErrInvalidLogo  = errors.New("qrcode: logo is not a PNG, JPEG or GIF image")
+)
+
+// Options style a code. The zero value renders a black on white PNG of
+// DefaultSize with error correction M.
+type Options struct {
+	Format     string // FormatPNG (default) or FormatSVG
+	Size       int    // width and height in pixels
+	Level      string // error correction: L, M, Q or H; a logo raises it to H
+	Foreground color.RGBA
+	Background color.RGBA
+	// Margin is the quiet zone in modules; nil means DefaultMargin
+	Margin *int
+	// Logo is drawn over the centre of the code
+	Logo image.Image
+}
+
+func (o Options) withDefaults() Options {
+	if o.Format == "" {
+		o.Format = FormatPNG
+	}
+	if o.Size == 0 {
+		o.Size = DefaultSize
+	}
+	if o.Level == "" {
+		o.Level = DefaultLevel
+	}
+	if o.Logo != nil {
+		o.Level = "H"
+	}
+	// ParseColor always sets alpha, so a transparent colour was never set
+	if o.Foreground.A == 0 {
+		o.Foreground = color.RGBA{A: 0xff}
+	}
+	if o.Background.A == 0 {
+		o.Background = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	}
+	if o.Margin == nil {
+		margin := DefaultMargin
+		o.Margin = &margin
+	}
+	return o
+}
+
+// ContentType returns the media type of a code rendered in format.
+func ContentType(format string) string {
+	if format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// ParseColor parses a #rrggbb colour; the # is optional.
+func ParseColor(value string) (color.RGBA, error) {
+	value = strings.TrimPrefix(value, "#")
+	if len(value) != 6 {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}, nil
+}
+
+// DecodeLogo decodes an uploaded logo.
+func DecodeLogo(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width > maxLogoPixels || config.Height > maxLogoPixels {
+		return nil, ErrInvalidLogo
+	}
+	logo, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidLogo
+	}
+	return logo, nil
+}
+
+// CacheKey identifies the code Render draws for content and opts; logoID
+// stands in for opts.Logo and must change whenever the logo does.
+func CacheKey(content string, opts Options, logoID string) string {
+	opts = opts.withDefaults()
+	level := opts.Level
+	if logoID != "" {
+		level = "H"
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		content,
+		opts.Format,
+		strconv.Itoa(opts.Size),
+		level,
+		hexColor(opts.Foreground),
+		hexColor(opts.Background),
+		strconv.Itoa(*opts.Margin),
+		logoID,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Render encodes content as a QR code styled by opts.
+func Render(content string, opts Options) ([]byte, error) {
+	opts = opts.withDefaults()
+	if opts.Foreground == opts.Background {
+		return nil, ErrSameColors
+	}
+
+	code, err := qr.Encode(content, eccLevel(opts.Level), qr.Auto)
+	if err != nil {
+		return nil, fmt.Errorf("qrcode: encode: %w", err)
+	}
+	m := newMatrix(code)
+
+	if opts.Format == FormatSVG {
+		return renderSVG(m, opts)
+	}
+	return renderPNG(m, opts)
+}
+
+func eccLevel(level string) qr.ErrorCorrectionLevel {
+	switch level {
+	case "L":
+		return qr.L
+	case "Q":
+		return qr.Q
+	case "H":
+		return qr.H
+	default:
+		return qr.M
+	}
+}
+
+// matrix holds the modules of a code, true for dark ones.
+type matrix struct {
+	size    int
+	modules []bool
+}
+
+func newMatrix(code image.Image) matrix {
+	size := code.Bounds().Dx()
+	m := matrix{size: size, modules: make([]bool, size*size)}
+	for y := range size {
+		for x := range size {
+			r, _, _, _ := code.At(x, y).RGBA()
+			m.modules[y*size+x] = r < 0x8000
+		}
+	}
+	return m
+}
+
+func (m matrix) dark(x, y int) bool {
+	return m.modules[y*m.size+x]
+}
+
+func renderPNG(m matrix, opts Options) ([]byte, error) {
+	scale := opts.Size / (m.size + 2**opts.Margin)
+	if scale < 1 {
+		return nil, ErrTooSmall
+	}
+	// Pixels left over from the integer scale widen the quiet zone
+	offset := (opts.Size - scale*m.size) / 2
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Size, opts.Size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	foreground := image.NewUniform(opts.Foreground)
+	for y := range m.size {
+		for x := range m.size {
+			if m.dark(x, y) {
+				module := image.Rect(0, 0, scale, scale).Add(image.Pt(offset+x*scale, offset+y*scale))
+				draw.Draw(img, module, foreground, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if opts.Logo != nil {
+		box, logo := placeLogo(opts.Logo, float64(scale*m.size), float64(scale))
+		pad := box.pad.Add(image.Pt(offset, offset))
+		draw.Draw(img, pad, image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		draw.Draw(img, box.logo.Add(image.Pt(offset, offset)), logo, image.Point{}, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("qrcode: png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func renderSVG(m matrix, opts Options) ([]byte, error) {
+	total := m.size + 2**opts.Margin
+	margin := *opts.Margin
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, total, total, hexColor(opts.Background))
+
+	// One subpath per horizontal run of dark modules
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hexColor(opts.Foreground))
+	for y := range m.size {
+		for x := 0; x < m.size; x++ {
+			if !m.dark(x, y) {
+				continue
+			}
+			run := 1
+			for x+run < m.size && m.dark(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", x+margin, y+margin, run, run)
+			x += run - 1
+		}
+	}
+	buf.WriteString(`"/>`)
+
+	if opts.Logo != nil {
+		// Lay the logo out in pixels of the requested size, then map it
+		// back to modules
+		pixelsPerModule := float64(opts.Size) / float64(total)
+		box, logo := placeLogo(opts.Logo, float64(m.size)*pixelsPerModule, pixelsPerModule)
+		var encoded bytes.Buffer
+		if err := png.Encode(&encoded, logo); err != nil {
+			return nil, fmt.Errorf("qrcode: logo: %w", err)
+		}
+		length := func(px int) string {
+			return strconv.FormatFloat(float64(px)/pixelsPerModule, 'f', 3, 64)
+		}
+		position := func(px int) string {
+			return strconv.FormatFloat(float64(px)/pixelsPerModule+float64(margin), 'f', 3, 64)
+		}
+		fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`,
+			position(box.pad.Min.X), position(box.pad.Min.Y), length(box.pad.Dx()), length(box.pad.Dy()),
+			hexColor(opts.Background))
+		fmt.Fprintf(&buf, `<image x="%s" y="%s" width="%s" height="%s" href="data:image/png;base64,%s"/>`,
+			position(box.logo.Min.X), position(box.logo.Min.Y), length(box.logo.Dx()), length(box.logo.Dy()),
+			base64.StdEncoding.EncodeToString(encoded.Bytes()))
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
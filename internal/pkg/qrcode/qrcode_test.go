@@ -0,0 +1,178 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+const testContent = "https://lihat.in/abc123?qr=1"
+
+func TestParseColor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{value: "#1a2B3c", want: color.RGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}},
+		{value: "ffffff", want: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{value: "#fff", wantErr: true},
+		{value: "#gggggg", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseColor(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseColor(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseColor(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPNG(t *testing.T) {
+	t.Parallel()
+
+	fg := color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}
+	bg := color.RGBA{R: 0xee, G: 0xdd, B: 0xcc, A: 0xff}
+	data, err := Render(testContent, Options{Size: 300, Foreground: fg, Background: bg})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(300, 300) {
+		t.Fatalf("size = %v, want 300x300", got)
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)); got != bg {
+		t.Errorf("quiet zone colour = %v, want %v", got, bg)
+	}
+
+	// The top left finder pattern starts right after the quiet zone
+	var corner image.Point
+	for i := range 300 {
+		if color.RGBAModel.Convert(img.At(i, i)) == fg {
+			corner = image.Pt(i, i)
+			break
+		}
+	}
+	if corner == (image.Point{}) {
+		t.Fatal("no foreground module on the diagonal")
+	}
+	if corner.X < DefaultMargin {
+		t.Errorf("first module at %v, inside the quiet zone", corner)
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	t.Parallel()
+
+	margin := 2
+	data, err := Render(testContent, Options{Format: FormatSVG, Size: 256, Margin: &margin})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	svg := string(data)
+	for _, want := range []string{`width="256"`, `fill="#ffffff"`, `fill="#000000"`, "M2 2h7v1h-7z"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG does not contain %q", want)
+		}
+	}
+	if strings.Contains(svg, "<image") {
+		t.Error("SVG has a logo though none was given")
+	}
+}
+
+func TestRenderWithLogo(t *testing.T) {
+	t.Parallel()
+
+	red := color.RGBA{R: 0xff, A: 0xff}
+	logo := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := range 20 {
+		for x := range 40 {
+			logo.Set(x, y, red)
+		}
+	}
+
+	data, err := Render(testContent, Options{Size: 400, Logo: logo})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if got := color.RGBAModel.Convert(img.At(200, 200)); got != red {
+		t.Errorf("centre colour = %v, want the logo's %v", got, red)
+	}
+
+	data, err = Render(testContent, Options{Format: FormatSVG, Logo: logo})
+	if err != nil {
+		t.Fatalf("Render(svg) error = %v", err)
+	}
+	if !strings.Contains(string(data), `href="data:image/png;base64,`) {
+		t.Error("SVG does not embed the logo")
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	t.Parallel()
+
+	black := color.RGBA{A: 0xff}
+	tests := []struct {
+		name string
+		opts Options
+		want error
+	}{
+		{name: "same colours", opts: Options{Foreground: black, Background: black}, want: ErrSameColors},
+		{name: "too small", opts: Options{Size: 20}, want: ErrTooSmall},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := Render(testContent, tt.opts); !errors.Is(err, tt.want) {
+				t.Errorf("Render() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	t.Parallel()
+
+	base := CacheKey(testContent, Options{}, "")
+	margin := DefaultMargin
+	if got := CacheKey(testContent, Options{Format: FormatPNG, Size: DefaultSize, Level: "M", Margin: &margin}, ""); got != base {
+		t.Errorf("explicit defaults changed the key: %s != %s", got, base)
+	}
+
+	variants := map[string]string{
+		"content": CacheKey(testContent+"x", Options{}, ""),
+		"format":  CacheKey(testContent, Options{Format: FormatSVG}, ""),
+		"size":    CacheKey(testContent, Options{Size: 256}, ""),
+		"level":   CacheKey(testContent, Options{Level: "H"}, ""),
+		"colour":  CacheKey(testContent, Options{Foreground: color.RGBA{R: 1, A: 0xff}}, ""),
+		"logo":    CacheKey(testContent, Options{}, "logos/a.png"),
+	}
+	for name, key := range variants {
+		if key == base {
+			t.Errorf("changing the %s kept the key", name)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package shortcode
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+const (
+	base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	// Letters of pronounceable codes; l and q are left out as easy to misread
+	consonants = "bcdfghjkmnprstvwxz"
+	vowels     = "aeiou"
+)
+
+// RandomGenerator produces uniformly random base62 codes.
+type RandomGenerator struct{}
+
+// Name implements CodeGenerator.
+func (RandomGenerator) Name() string {
+	return StrategyRandom
+}
+
+// Generate implements CodeGenerator.
+func (RandomGenerator) Generate(_ context.Context, length int) (string, error) {
+	return randomString(length, func(int) string { return base62Alphabet })
+}
+
+// WordsGenerator produces pronounceable lowercase codes of alternating
+// consonants and vowels, such as "bakodu", that are easy to read out. The key
+// space is smaller than base62 at the same length, so these codes grow
+// sooner.
+type WordsGenerator struct{}
+
+// Name implements CodeGenerator.
+func (WordsGenerator) Name() string {
+	return StrategyWords
+}
+
+// Generate implements CodeGenerator.
+func (WordsGenerator) Generate(_ context.Context, length int) (string, error) {
+	return randomString(length, func(i int) string {
+		if i%2 == 0 {
+			return consonants
+		}
+		return vowels
+	})
+}
+
+// randomString builds a string of length characters, picking character i
+// from alphabetAt(i).
+func randomString(length int, alphabetAt func(i int) string) (string, error) {
+	var b strings.Builder
+	b.Grow(length)
+	for i := range length {
+		alphabet := alphabetAt(i)
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(alphabet[idx.Int64()])
+	}
+	return b.String(), nil
+}
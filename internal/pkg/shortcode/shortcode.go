@@ -0,0 +1,183 @@
+// Package shortcode generates the codes of short links that are created
+// without a custom one. A CodeGenerator produces candidate codes and an
+// Allocator retries them until one is free, growing the code length when the
+// key space at the current length fills up.
+package shortcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// Strategies a deployment can generate codes with
+const (
+	StrategyRandom = "random"
+	StrategySqids  = "sqids"
+	StrategyWords  = "words"
+)
+
+const (
+	DefaultLength      = 6
+	DefaultMaxAttempts = 10
+	// MinLength and MaxLength bound the generated part of a code; the
+	// short_code column leaves room for a prefix on top of MaxLength
+	MinLength = 3
+	MaxLength = 32
+	// growAfter collisions at one length grow the length of every later code
+	growAfter = 3
+)
+
+// ErrExhausted is returned when every attempt produced a code already taken.
+var ErrExhausted = errors.New("no free short code found")
+
+// CodeGenerator produces candidate codes. Generated codes are not checked
+// against existing links; the Allocator does that.
+type CodeGenerator interface {
+	// Name is the strategy the generator implements
+	Name() string
+	// Generate returns a code of at least length characters
+	Generate(ctx context.Context, length int) (string, error)
+}
+
+// ExistsFunc reports whether code is already in use.
+type ExistsFunc func(ctx context.Context, code string) (bool, error)
+
+// Allocator hands out codes no link uses yet.
+type Allocator struct {
+	generator   CodeGenerator
+	length      atomic.Int32
+	maxAttempts int
+}
+
+var (
+	globalAllocator   *Allocator
+	globalAllocatorMu sync.RWMutex
+)
+
+// NewAllocator creates an allocator starting at length and giving up after
+// maxAttempts taken codes.
+func NewAllocator(generator CodeGenerator, length, maxAttempts int) *Allocator {
+	length = min(max(length, MinLength), MaxLength)
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	allocator := &Allocator{generator: generator, maxAttempts: maxAttempts}
+	allocator.length.Store(int32(length))
+	return allocator
+}
+
+// NewGenerator creates the generator for strategy. The sqids strategy keeps
+// its counter in db.
+func NewGenerator(strategy string, db *gorm.DB) (CodeGenerator, error) {
+	switch strategy {
+	case StrategyRandom:
+		return RandomGenerator{}, nil
+	case StrategyWords:
+		return WordsGenerator{}, nil
+	case StrategySqids:
+		if db == nil {
+			return nil, errors.New("gorm db is required for the sqids strategy")
+		}
+		return NewCounterGenerator(NewSequenceCounter(db, defaultSequenceName)), nil
+	}
+	return nil, fmt.Errorf("unknown short code strategy %q", strategy)
+}
+
+// InitGlobal creates the global allocator from SHORT_CODE_STRATEGY,
+// SHORT_CODE_LENGTH and SHORT_CODE_MAX_ATTEMPTS.
+func InitGlobal(db *gorm.DB) error {
+	strategy := strings.ToLower(strings.TrimSpace(config.GetEnvOrDefault(config.EnvShortCodeStrategy, StrategyRandom)))
+	generator, err := NewGenerator(strategy, db)
+	if err != nil {
+		return err
+	}
+
+	allocator := NewAllocator(generator,
+		config.GetEnvAsInt(config.EnvShortCodeLength, DefaultLength),
+		config.GetEnvAsInt(config.EnvShortCodeMaxAttempts, DefaultMaxAttempts),
+	)
+
+	globalAllocatorMu.Lock()
+	globalAllocator = allocator
+	globalAllocatorMu.Unlock()
+
+	logger.Logger.Info("Short code generator initialized",
+		"strategy", generator.Name(),
+		"length", allocator.Length(),
+	)
+	return nil
+}
+
+// Global returns the initialized global allocator, or nil.
+func Global() *Allocator {
+	globalAllocatorMu.RLock()
+	defer globalAllocatorMu.RUnlock()
+	return globalAllocator
+}
+
+// Strategy is the name of the strategy codes are generated with.
+func (a *Allocator) Strategy() string {
+	return a.generator.Name()
+}
+
+// Length is the length new codes are generated at, prefix not included.
+func (a *Allocator) Length() int {
+	return int(a.length.Load())
+}
+
+// Allocate returns prefix followed by a generated code that exists reports
+// as free. Every growAfter taken codes the length grows by one, for this
+// allocation and every later one, so a filling key space costs a few extra
+// lookups once instead of on every link.
+func (a *Allocator) Allocate(ctx context.Context, prefix string, exists ExistsFunc) (string, error) {
+	length := a.Length()
+	collisions := 0
+	for range a.maxAttempts {
+		code, err := a.generator.Generate(ctx, length)
+		if err != nil {
+			return "", err
+		}
+		code = prefix + code
+
+		taken, err := exists(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+
+		collisions++
+		if collisions >= growAfter && length < MaxLength {
+			length = a.grow(length)
+			collisions = 0
+		}
+	}
+	return "", ErrExhausted
+}
+
+// grow raises the shared length past from, unless a concurrent allocation
+// already has, and returns the length to continue with.
+func (a *Allocator) grow(from int) int {
+	for {
+		current := a.length.Load()
+		if int(current) > from {
+			return int(current)
+		}
+		if a.length.CompareAndSwap(current, int32(from+1)) {
+			logger.Logger.Warn("Short code key space filling up, growing code length",
+				"strategy", a.generator.Name(),
+				"length", from+1,
+			)
+			return from + 1
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package shortcode
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSqidsEncode(t *testing.T) {
+	t.Parallel()
+
+	sqids, err := NewSqids(sqidsDefaultAlphabet)
+	if err != nil {
+		t.Fatalf("NewSqids() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		numbers   []uint64
+		minLength int
+		want      string
+	}{
+		{name: "zero", numbers: []uint64{0}, want: "bM"},
+		{name: "one", numbers: []uint64{1}, want: "Uk"},
+		{name: "several numbers", numbers: []uint64{1, 2, 3}, want: "86Rf07"},
+		{name: "padded", numbers: []uint64{1, 2, 3}, minLength: 10, want: "86Rf07xd4z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := sqids.Encode(tt.numbers, tt.minLength); got != tt.want {
+				t.Fatalf("Encode(%v, %d) = %q, want %q", tt.numbers, tt.minLength, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCounterGeneratorUnique(t *testing.T) {
+	t.Parallel()
+
+	generator := NewCounterGenerator(&memoryCounter{})
+	seen := make(map[string]struct{})
+	for range 5000 {
+		code, err := generator.Generate(context.Background(), DefaultLength)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if len(code) < DefaultLength {
+			t.Fatalf("Generate() = %q, shorter than %d", code, DefaultLength)
+		}
+		if _, dup := seen[code]; dup {
+			t.Fatalf("Generate() repeated %q", code)
+		}
+		seen[code] = struct{}{}
+	}
+}
+
+func TestGeneratorsAlphabet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		generator CodeGenerator
+		valid     func(i int, c rune) bool
+	}{
+		{
+			name:      "random",
+			generator: RandomGenerator{},
+			valid:     func(_ int, c rune) bool { return strings.ContainsRune(base62Alphabet, c) },
+		},
+		{
+			name:      "words",
+			generator: WordsGenerator{},
+			valid: func(i int, c rune) bool {
+				if i%2 == 0 {
+					return strings.ContainsRune(consonants, c)
+				}
+				return strings.ContainsRune(vowels, c)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			for range 100 {
+				code, err := tt.generator.Generate(context.Background(), 8)
+				if err != nil {
+					t.Fatalf("Generate() error = %v", err)
+				}
+				if len(code) != 8 {
+					t.Fatalf("Generate() = %q, want 8 characters", code)
+				}
+				for i, c := range code {
+					if !tt.valid(i, c) {
+						t.Fatalf("Generate() = %q, unexpected %q at %d", code, c, i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAllocatorAllocate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("retries taken codes and adds the prefix", func(t *testing.T) {
+		t.Parallel()
+		allocator := NewAllocator(&fixedGenerator{codes: []string{"aaa", "bbb", "ccc"}}, 3, 5)
+		taken := map[string]bool{"ws-aaa": true, "ws-bbb": true}
+
+		code, err := allocator.Allocate(context.Background(), "ws-", func(_ context.Context, code string) (bool, error) {
+			return taken[code], nil
+		})
+		if err != nil {
+			t.Fatalf("Allocate() error = %v", err)
+		}
+		if code != "ws-ccc" {
+			t.Fatalf("Allocate() = %q, want %q", code, "ws-ccc")
+		}
+	})
+
+	t.Run("grows the length as collisions pile up", func(t *testing.T) {
+		t.Parallel()
+		generator := &fixedGenerator{}
+		allocator := NewAllocator(generator, 4, 10)
+
+		_, err := allocator.Allocate(context.Background(), "", func(_ context.Context, code string) (bool, error) {
+			return len(code) < 5, nil
+		})
+		if err != nil {
+			t.Fatalf("Allocate() error = %v", err)
+		}
+		if got := allocator.Length(); got != 5 {
+			t.Fatalf("Length() = %d, want 5", got)
+		}
+		want := []int{4, 4, 4, 5}
+		if !slices.Equal(generator.lengths, want) {
+			t.Fatalf("generated lengths = %v, want %v", generator.lengths, want)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		t.Parallel()
+		allocator := NewAllocator(&fixedGenerator{}, 4, 3)
+
+		_, err := allocator.Allocate(context.Background(), "", func(context.Context, string) (bool, error) {
+			return true, nil
+		})
+		if !errors.Is(err, ErrExhausted) {
+			t.Fatalf("Allocate() error = %v, want ErrExhausted", err)
+		}
+	})
+
+	t.Run("surfaces lookup errors", func(t *testing.T) {
+		t.Parallel()
+		allocator := NewAllocator(&fixedGenerator{}, 4, 3)
+		lookupErr := errors.New("db down")
+
+		_, err := allocator.Allocate(context.Background(), "", func(context.Context, string) (bool, error) {
+			return false, lookupErr
+		})
+		if !errors.Is(err, lookupErr) {
+			t.Fatalf("Allocate() error = %v, want %v", err, lookupErr)
+		}
+	})
+}
+
+func TestNewGenerator(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewGenerator("emoji", nil); err == nil {
+		t.Fatal("NewGenerator(emoji) error = nil, want unknown strategy")
+	}
+	if _, err := NewGenerator(StrategySqids, nil); err == nil {
+		t.Fatal("NewGenerator(sqids, nil) error = nil, want missing db")
+	}
+	for _, strategy := range []string{StrategyRandom, StrategyWords} {
+		generator, err := NewGenerator(strategy, nil)
+		if err != nil {
+			t.Fatalf("NewGenerator(%s) error = %v", strategy, err)
+		}
+		if generator.Name() != strategy {
+			t.Fatalf("NewGenerator(%s).Name() = %q", strategy, generator.Name())
+		}
+	}
+}
+
+type memoryCounter struct {
+	mu    sync.Mutex
+	value uint64
+}
+
+func (c *memoryCounter) Next(context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value++
+	return c.value, nil
+}
+
+// fixedGenerator returns codes in order, then codes of "x" of the asked
+// length, and records the lengths it was asked for.
+type fixedGenerator struct {
+	codes   []string
+	lengths []int
+}
+
+func (g *fixedGenerator) Name() string {
+	return "fixed"
+}
+
+func (g *fixedGenerator) Generate(_ context.Context, length int) (string, error) {
+	g.lengths = append(g.lengths, length)
+	if len(g.codes) > 0 {
+		code := g.codes[0]
+		g.codes = g.codes[1:]
+		return code, nil
+	}
+	return strings.Repeat("x", length), nil
+}
@@ -0,0 +1,170 @@
+package shortcode
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	sqidsDefaultAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	defaultSequenceName  = "short_links"
+)
+
+// Sqids encodes numbers into short, non-sequential looking IDs following the
+// Sqids algorithm (https://sqids.org). Distinct numbers always give distinct
+// IDs, so codes encoded from a counter never collide with each other.
+type Sqids struct {
+	alphabet []byte
+}
+
+// NewSqids creates an encoder over alphabet, which must hold at least three
+// distinct single-byte characters.
+func NewSqids(alphabet string) (*Sqids, error) {
+	chars := []byte(alphabet)
+	if len(chars) < 3 {
+		return nil, errors.New("sqids alphabet must have at least 3 characters")
+	}
+	seen := make(map[byte]struct{}, len(chars))
+	for _, c := range chars {
+		if _, dup := seen[c]; dup {
+			return nil, errors.New("sqids alphabet must not repeat characters")
+		}
+		seen[c] = struct{}{}
+	}
+	return &Sqids{alphabet: sqidsShuffle(chars)}, nil
+}
+
+// Encode returns the ID of numbers, padded to at least minLength characters.
+func (s *Sqids) Encode(numbers []uint64, minLength int) string {
+	if len(numbers) == 0 {
+		return ""
+	}
+	minLength = min(max(minLength, 0), len(s.alphabet))
+
+	size := uint64(len(s.alphabet))
+	offset := uint64(len(numbers))
+	for i, n := range numbers {
+		offset += uint64(s.alphabet[n%size]) + uint64(i)
+	}
+	offset %= size
+
+	alphabet := append(slices.Clone(s.alphabet[offset:]), s.alphabet[:offset]...)
+	prefix := alphabet[0]
+	slices.Reverse(alphabet)
+
+	id := []byte{prefix}
+	for i, n := range numbers {
+		id = append(id, sqidsToID(n, alphabet[1:])...)
+		if i < len(numbers)-1 {
+			id = append(id, alphabet[0])
+			alphabet = sqidsShuffle(alphabet)
+		}
+	}
+
+	if len(id) < minLength {
+		id = append(id, alphabet[0])
+		for len(id) < minLength {
+			alphabet = sqidsShuffle(alphabet)
+			id = append(id, alphabet[:min(minLength-len(id), len(alphabet))]...)
+		}
+	}
+	return string(id)
+}
+
+// sqidsShuffle is the deterministic shuffle of the Sqids spec. It works on a
+// copy of chars.
+func sqidsShuffle(chars []byte) []byte {
+	out := slices.Clone(chars)
+	for i, j := 0, len(out)-1; j > 0; i, j = i+1, j-1 {
+		r := (i*j + int(out[i]) + int(out[j])) % len(out)
+		out[i], out[r] = out[r], out[i]
+	}
+	return out
+}
+
+func sqidsToID(n uint64, alphabet []byte) []byte {
+	size := uint64(len(alphabet))
+	var id []byte
+	for {
+		id = append(id, alphabet[n%size])
+		n /= size
+		if n == 0 {
+			break
+		}
+	}
+	slices.Reverse(id)
+	return id
+}
+
+// Counter hands out increasing numbers, each exactly once.
+type Counter interface {
+	Next(ctx context.Context) (uint64, error)
+}
+
+// CounterGenerator encodes the next value of a counter with Sqids, so codes
+// only repeat if something else, like a custom code, took one first.
+type CounterGenerator struct {
+	counter Counter
+	sqids   *Sqids
+}
+
+// NewCounterGenerator creates a generator encoding counter's values.
+func NewCounterGenerator(counter Counter) *CounterGenerator {
+	sqids, _ := NewSqids(sqidsDefaultAlphabet)
+	return &CounterGenerator{counter: counter, sqids: sqids}
+}
+
+// Name implements CodeGenerator.
+func (g *CounterGenerator) Name() string {
+	return StrategySqids
+}
+
+// Generate implements CodeGenerator.
+func (g *CounterGenerator) Generate(ctx context.Context, length int) (string, error) {
+	n, err := g.counter.Next(ctx)
+	if err != nil {
+		return "", err
+	}
+	return g.sqids.Encode([]uint64{n}, length), nil
+}
+
+// SequenceCounter is a Counter stored in MySQL, shared by every instance.
+type SequenceCounter struct {
+	db   *gorm.DB
+	name string
+}
+
+// NewSequenceCounter creates a counter stored in the named sequence row.
+func NewSequenceCounter(db *gorm.DB, name string) *SequenceCounter {
+	return &SequenceCounter{db: db, name: name}
+}
+
+// Next implements Counter. The row is locked while it is incremented, so
+// concurrent callers never get the same value.
+func (c *SequenceCounter) Next(ctx context.Context) (uint64, error) {
+	var next uint64
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seq := shortlink.ShortCodeSequence{Name: c.name}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seq).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("name = ?", c.name).
+			First(&seq).Error; err != nil {
+			return err
+		}
+		next = seq.Value + 1
+		return tx.Model(&shortlink.ShortCodeSequence{}).
+			Where("name = ?", c.name).
+			Update("value", next).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return next, nil
+}
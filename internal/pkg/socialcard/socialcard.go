@@ -0,0 +1,75 @@
+// Package socialcard renders the Open Graph and Twitter card document link
+// preview crawlers get for a short link instead of the redirect.
+package socialcard
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+// Twitter card types a link can pick
+const (
+	TwitterCardSummary    = "summary"
+	TwitterCardLargeImage = "summary_large_image"
+	siteName              = "Lihatin"
+)
+
+//go:embed templates/card.html
+var templateFS embed.FS
+
+var cardTemplate = template.Must(template.ParseFS(templateFS, "templates/card.html"))
+
+// Card is the document served to a crawler.
+type Card struct {
+	SiteName string
+	// URL is the short link itself, Destination where the redirect goes
+	URL         string
+	Destination string
+	Title       string
+	Description string
+	ImageURL    string
+	TwitterCard string
+}
+
+// New builds the card for link, reached at shortURL. Tags the link leaves
+// empty fall back to its title and description, and the Twitter card type to
+// a large image whenever there is an image.
+func New(link *shortlink.ShortLink, shortURL string) Card {
+	card := Card{
+		SiteName:    siteName,
+		URL:         shortURL,
+		Destination: link.OriginalURL,
+		Title:       link.OGTitle,
+		Description: link.OGDescription,
+		ImageURL:    link.OGImageURL,
+		TwitterCard: link.TwitterCard,
+	}
+	if card.Title == "" {
+		card.Title = link.Title
+	}
+	if card.Title == "" {
+		card.Title = link.ShortCode
+	}
+	if card.Description == "" {
+		card.Description = link.Description
+	}
+	if card.TwitterCard == "" {
+		card.TwitterCard = TwitterCardSummary
+		if card.ImageURL != "" {
+			card.TwitterCard = TwitterCardLargeImage
+		}
+	}
+	return card
+}
+
+// Render returns the card as HTML.
+func Render(card Card) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := cardTemplate.Execute(&buf, card); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package socialcard
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		link            shortlink.ShortLink
+		wantTitle       string
+		wantDescription string
+		wantTwitterCard string
+	}{
+		{
+			name:            "card tags win",
+			link:            shortlink.ShortLink{ShortCode: "abc", Title: "Link", Description: "About", OGTitle: "Card", OGDescription: "Card about", TwitterCard: TwitterCardSummary, OGImageURL: "https://cdn.example.com/a.png"},
+			wantTitle:       "Card",
+			wantDescription: "Card about",
+			wantTwitterCard: TwitterCardSummary,
+		},
+		{
+			name:            "falls back to link title and description",
+			link:            shortlink.ShortLink{ShortCode: "abc", Title: "Link", Description: "About", OGImageURL: "https://cdn.example.com/a.png"},
+			wantTitle:       "Link",
+			wantDescription: "About",
+			wantTwitterCard: TwitterCardLargeImage,
+		},
+		{
+			name:            "falls back to short code",
+			link:            shortlink.ShortLink{ShortCode: "abc", OGDescription: "Card about"},
+			wantTitle:       "abc",
+			wantDescription: "Card about",
+			wantTwitterCard: TwitterCardSummary,
+		},
+	}
+	for _, tt := range tests {
+		card := New(&tt.link, "https://lihat.in/abc")
+		if card.Title != tt.wantTitle || card.Description != tt.wantDescription || card.TwitterCard != tt.wantTwitterCard {
+			t.Errorf("%s: New() = %q, %q, %q, want %q, %q, %q", tt.name,
+				card.Title, card.Description, card.TwitterCard,
+				tt.wantTitle, tt.wantDescription, tt.wantTwitterCard)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	body, err := Render(New(&shortlink.ShortLink{
+		ShortCode:   "abc",
+		OriginalURL: "https://example.com/page",
+		OGTitle:     `"><script>alert(1)</script>`,
+		OGImageURL:  "https://cdn.example.com/a.png",
+	}, "https://lihat.in/abc"))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	html := string(body)
+	for _, want := range []string{
+		`property="og:url" content="https://lihat.in/abc"`,
+		`property="og:image" content="https://cdn.example.com/a.png"`,
+		`name="twitter:card" content="summary_large_image"`,
+		`href="https://example.com/page"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Render() missing %q", want)
+		}
+	}
+	if strings.Contains(html, "<script>") {
+		t.Error("Render() does not escape the card title")
+	}
+	if strings.Contains(html, "og:description") {
+		t.Error("Render() emits an empty og:description")
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3ClickArchiveStorage stores compressed exports of raw clicks removed by the
+// retention job. Objects are private; they are not exposed via public URLs.
+type S3ClickArchiveStorage struct {
+	base *S3AvatarStorage
+}
+
+func NewS3ClickArchiveStorageFromEnv() (*S3ClickArchiveStorage, error) {
+	base, err := NewS3AvatarStorageFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &S3ClickArchiveStorage{base: base}, nil
+}
+
+// UploadArchive writes data under click-archives/<name> and returns the full
+// object key.
+func (s *S3ClickArchiveStorage) UploadArchive(ctx context.Context, name string, data []byte, contentType string) (string, error) {
+	if s == nil || s.base == nil || s.base.client == nil {
+		return "", fmt.Errorf("click archive storage not configured")
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("empty click archive payload")
+	}
+
+	objectKey := "click-archives/" + strings.TrimLeft(strings.TrimSpace(name), "/")
+	_, err := s.base.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        awsv2.String(s.base.bucket),
+		Key:           awsv2.String(objectKey),
+		Body:          bytes.NewReader(data),
+		ContentLength: awsv2.Int64(int64(len(data))),
+		ContentType:   awsv2.String(contentType),
+		CacheControl:  awsv2.String("private, max-age=0, no-cache"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload click archive: %w", err)
+	}
+
+	return objectKey, nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3QRLogoStorage stores the logos drawn in the centre of short link QR codes.
+// Objects are private; the server reads them back when it renders a code.
+type S3QRLogoStorage struct {
+	base *S3AvatarStorage
+}
+
+func NewS3QRLogoStorageFromEnv() (*S3QRLogoStorage, error) {
+	base, err := NewS3AvatarStorageFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &S3QRLogoStorage{base: base}, nil
+}
+
+// UploadLogo writes a logo for linkID under a fresh key, so codes cached for
+// the previous logo are never served for the new one.
+func (s *S3QRLogoStorage) UploadLogo(ctx context.Context, linkID string, data []byte, contentType string) (string, error) {
+	if s == nil || s.base == nil || s.base.client == nil {
+		return "", fmt.Errorf("qr logo storage not configured")
+	}
+
+	ext := map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+	}[contentType]
+	if ext == "" {
+		return "", fmt.Errorf("unsupported qr logo content type %q", contentType)
+	}
+
+	objectKey := fmt.Sprintf(
+		"qr-logos/%s/%d-%s%s",
+		strings.TrimSpace(linkID),
+		time.Now().UnixNano(),
+		randomHex(8),
+		ext,
+	)
+	_, err := s.base.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        awsv2.String(s.base.bucket),
+		Key:           awsv2.String(objectKey),
+		Body:          bytes.NewReader(data),
+		ContentLength: awsv2.Int64(int64(len(data))),
+		ContentType:   awsv2.String(contentType),
+		CacheControl:  awsv2.String("private, max-age=0, no-cache"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload qr logo: %w", err)
+	}
+
+	return objectKey, nil
+}
+
+// DownloadLogo reads back a logo stored by UploadLogo, refusing objects over
+// maxBytes.
+func (s *S3QRLogoStorage) DownloadLogo(ctx context.Context, objectKey string, maxBytes int64) ([]byte, error) {
+	if s == nil || s.base == nil || s.base.client == nil {
+		return nil, fmt.Errorf("qr logo storage not configured")
+	}
+
+	out, err := s.base.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: awsv2.String(s.base.bucket),
+		Key:    awsv2.String(strings.TrimSpace(objectKey)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download qr logo: %w", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(out.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read qr logo: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("qr logo exceeds %d bytes", maxBytes)
+	}
+	return data, nil
+}
+
+// DeleteLogo removes a logo; an empty key is a no-op.
+func (s *S3QRLogoStorage) DeleteLogo(ctx context.Context, objectKey string) error {
+	if s == nil || s.base == nil || s.base.client == nil {
+		return fmt.Errorf("qr logo storage not configured")
+	}
+
+	normalizedKey := strings.TrimSpace(objectKey)
+	if normalizedKey == "" {
+		return nil
+	}
+
+	_, err := s.base.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: awsv2.String(s.base.bucket),
+		Key:    awsv2.String(normalizedKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete qr logo: %w", err)
+	}
+
+	return nil
+}
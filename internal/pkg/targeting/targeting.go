@@ -0,0 +1,322 @@
+// Package targeting picks the destination of a click: the first matching
+// redirect rule, otherwise one of the link's weighted A/B variants.
+package targeting
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+const clockLayout = "15:04"
+
+// Validation errors returned by Validate
+var (
+	ErrNoConditions      = errors.New("rule has no conditions")
+	ErrInvalidTimeWindow = errors.New("invalid rule time window")
+)
+
+// Rule is the part of a redirect rule needed to answer a redirect.
+type Rule struct {
+	ID          string                   `json:"id"`
+	Destination string                   `json:"destination"`
+	Conditions  shortlink.RuleConditions `json:"conditions"`
+}
+
+// FromModels converts the active rules, already in evaluation order.
+func FromModels(rules []shortlink.RedirectRule) []Rule {
+	active := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.IsActive {
+			continue
+		}
+		active = append(active, Rule{
+			ID:          rule.ID,
+			Destination: rule.DestinationURL,
+			Conditions:  rule.Conditions,
+		})
+	}
+	return active
+}
+
+// Visitor holds what rules can match on. CountryCode is only resolved when a
+// rule needs it (see NeedsCountry).
+type Visitor struct {
+	CountryCode  string
+	Device       string
+	OS           string
+	Language     string
+	ReferrerHost string
+	Query        url.Values
+	Now          time.Time
+}
+
+// NewVisitor builds a Visitor from the request data the redirect already has.
+func NewVisitor(device, os, acceptLanguage, referer string, query url.Values, now time.Time) Visitor {
+	visitor := Visitor{
+		Device:   device,
+		OS:       os,
+		Language: PreferredLanguage(acceptLanguage),
+		Query:    query,
+		Now:      now,
+	}
+	if u, err := url.Parse(referer); err == nil {
+		visitor.ReferrerHost = strings.ToLower(u.Hostname())
+	}
+	return visitor
+}
+
+// NeedsCountry reports whether any rule matches on country, so the geo lookup
+// can be skipped on the redirect path otherwise.
+func NeedsCountry(rules []Rule) bool {
+	for _, rule := range rules {
+		if len(rule.Conditions.Countries) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the first rule matching visitor.
+func Select(rules []Rule, visitor Visitor) (Rule, bool) {
+	for _, rule := range rules {
+		if Matches(rule.Conditions, visitor) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Matches reports whether every condition set in c matches visitor. Empty
+// conditions never match so a broken rule cannot capture all traffic.
+func Matches(c shortlink.RuleConditions, visitor Visitor) bool {
+	if isEmpty(c) {
+		return false
+	}
+	if len(c.Countries) > 0 && !containsFold(c.Countries, visitor.CountryCode) {
+		return false
+	}
+	if len(c.Devices) > 0 && !containsFold(c.Devices, visitor.Device) {
+		return false
+	}
+	if len(c.OS) > 0 && !anyPrefixFold(c.OS, visitor.OS) {
+		return false
+	}
+	if len(c.Languages) > 0 && !matchesLanguage(c.Languages, visitor.Language) {
+		return false
+	}
+	if len(c.ReferrerHosts) > 0 && !matchesHost(c.ReferrerHosts, visitor.ReferrerHost) {
+		return false
+	}
+	if c.Query != nil && !matchesQuery(*c.Query, visitor.Query) {
+		return false
+	}
+	if c.TimeWindow != nil && !matchesTimeWindow(*c.TimeWindow, visitor.Now) {
+		return false
+	}
+	return true
+}
+
+// Validate checks what struct tags cannot: that a rule has at least one
+// condition and that its time window is coherent.
+func Validate(c shortlink.RuleConditions) error {
+	if isEmpty(c) {
+		return ErrNoConditions
+	}
+	if c.TimeWindow == nil {
+		return nil
+	}
+
+	window := c.TimeWindow
+	if window.From == nil && window.Until == nil && len(window.Days) == 0 && window.StartTime == "" && window.EndTime == "" {
+		return ErrInvalidTimeWindow
+	}
+	if window.From != nil && window.Until != nil && !window.From.Before(*window.Until) {
+		return ErrInvalidTimeWindow
+	}
+	for _, day := range window.Days {
+		if day < 0 || day > 6 {
+			return ErrInvalidTimeWindow
+		}
+	}
+	if (window.StartTime == "") != (window.EndTime == "") {
+		return ErrInvalidTimeWindow
+	}
+	if window.StartTime != "" {
+		start, errStart := time.Parse(clockLayout, window.StartTime)
+		end, errEnd := time.Parse(clockLayout, window.EndTime)
+		if errStart != nil || errEnd != nil || start.Equal(end) {
+			return ErrInvalidTimeWindow
+		}
+	}
+	if _, err := time.LoadLocation(window.Timezone); err != nil {
+		return ErrInvalidTimeWindow
+	}
+	return nil
+}
+
+func isEmpty(c shortlink.RuleConditions) bool {
+	return len(c.Countries) == 0 && len(c.Devices) == 0 && len(c.OS) == 0 &&
+		len(c.Languages) == 0 && len(c.ReferrerHosts) == 0 &&
+		c.Query == nil && c.TimeWindow == nil
+}
+
+// PreferredLanguage returns the highest weighted tag of an Accept-Language
+// header, lowercased, or "" when there is none.
+func PreferredLanguage(header string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if value, ok := strings.CutPrefix(param, "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	return tags[0].tag
+}
+
+// matchesLanguage matches a bare language such as "en" against any regional
+// variant, while "pt-BR" only matches itself.
+func matchesLanguage(languages []string, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	primary, _, _ := strings.Cut(tag, "-")
+	for _, language := range languages {
+		language = strings.ToLower(language)
+		if language == tag || language == primary {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesHost(hosts []string, host string) bool {
+	if host == "" {
+		return false
+	}
+	for _, candidate := range hosts {
+		candidate = strings.TrimPrefix(strings.ToLower(candidate), "www.")
+		if host == candidate || strings.HasSuffix(host, "."+candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesQuery(condition shortlink.RuleQuery, query url.Values) bool {
+	if !query.Has(condition.Key) {
+		return false
+	}
+	if condition.Value == "" {
+		return true
+	}
+	for _, value := range query[condition.Key] {
+		if value == condition.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesTimeWindow(window shortlink.RuleTimeWindow, now time.Time) bool {
+	if window.From != nil && now.Before(*window.From) {
+		return false
+	}
+	if window.Until != nil && !now.Before(*window.Until) {
+		return false
+	}
+
+	loc, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return false
+	}
+	local := now.In(loc)
+
+	if window.StartTime == "" {
+		return len(window.Days) == 0 || containsDay(window.Days, local.Weekday())
+	}
+
+	start, errStart := time.Parse(clockLayout, window.StartTime)
+	end, errEnd := time.Parse(clockLayout, window.EndTime)
+	if errStart != nil || errEnd != nil {
+		return false
+	}
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	// The weekday of an overnight window is the day it started on
+	day := local.Weekday()
+	var inWindow bool
+	if startMinute < endMinute {
+		inWindow = minute >= startMinute && minute < endMinute
+	} else if minute >= startMinute {
+		inWindow = true
+	} else if minute < endMinute {
+		inWindow = true
+		day = (day + 6) % 7
+	}
+	if !inWindow {
+		return false
+	}
+	return len(window.Days) == 0 || containsDay(window.Days, day)
+}
+
+func containsDay(days []int, day time.Weekday) bool {
+	for _, d := range days {
+		if time.Weekday(d) == day {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyPrefixFold(prefixes []string, value string) bool {
+	value = strings.ToLower(value)
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(value, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package targeting
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+func TestMatches(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday 2026-01-07 22:30 in Jakarta
+	now := time.Date(2026, 1, 7, 15, 30, 0, 0, time.UTC)
+	visitor := Visitor{
+		CountryCode:  "ID",
+		Device:       "Mobile",
+		OS:           "Android 14",
+		Language:     "id-id",
+		ReferrerHost: "m.facebook.com",
+		Query:        url.Values{"src": {"ig"}},
+		Now:          now,
+	}
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		conditions shortlink.RuleConditions
+		want       bool
+	}{
+		{"empty", shortlink.RuleConditions{}, false},
+		{"country", shortlink.RuleConditions{Countries: []string{"us", "id"}}, true},
+		{"other country", shortlink.RuleConditions{Countries: []string{"US"}}, false},
+		{"device", shortlink.RuleConditions{Devices: []string{"mobile"}}, true},
+		{"os prefix", shortlink.RuleConditions{OS: []string{"android"}}, true},
+		{"bare language", shortlink.RuleConditions{Languages: []string{"id"}}, true},
+		{"regional language", shortlink.RuleConditions{Languages: []string{"id-ID"}}, true},
+		{"other region", shortlink.RuleConditions{Languages: []string{"en-US"}}, false},
+		{"referrer subdomain", shortlink.RuleConditions{ReferrerHosts: []string{"facebook.com"}}, true},
+		{"query present", shortlink.RuleConditions{Query: &shortlink.RuleQuery{Key: "src"}}, true},
+		{"query value", shortlink.RuleConditions{Query: &shortlink.RuleQuery{Key: "src", Value: "tw"}}, false},
+		{"all set", shortlink.RuleConditions{Countries: []string{"ID"}, Devices: []string{"Desktop"}}, false},
+		{"expired window", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{Until: &past}}, false},
+		{"evening in jakarta", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{
+			Days: []int{3}, StartTime: "18:00", EndTime: "23:00", Timezone: "Asia/Jakarta",
+		}}, true},
+		{"office hours utc", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{
+			StartTime: "09:00", EndTime: "15:00",
+		}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := Matches(tt.conditions, visitor); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesOvernightWindow(t *testing.T) {
+	t.Parallel()
+
+	// Friday 22:00 to 02:00 still matches at 01:00 on Saturday
+	window := shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{
+		Days: []int{5}, StartTime: "22:00", EndTime: "02:00",
+	}}
+	saturday := time.Date(2026, 1, 10, 1, 0, 0, 0, time.UTC)
+	if !Matches(window, Visitor{Now: saturday}) {
+		t.Error("overnight window should match after midnight")
+	}
+	if Matches(window, Visitor{Now: saturday.Add(22 * time.Hour)}) {
+		t.Error("overnight window should not start on Saturday")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	rules := FromModels([]shortlink.RedirectRule{
+		{ID: "off", IsActive: false, DestinationURL: "https://off.example", Conditions: shortlink.RuleConditions{Devices: []string{"Mobile"}}},
+		{ID: "us", IsActive: true, DestinationURL: "https://us.example", Conditions: shortlink.RuleConditions{Countries: []string{"US"}}},
+		{ID: "mobile", IsActive: true, DestinationURL: "https://m.example", Conditions: shortlink.RuleConditions{Devices: []string{"Mobile"}}},
+	})
+	if !NeedsCountry(rules) {
+		t.Fatal("NeedsCountry = false with a country rule")
+	}
+
+	rule, ok := Select(rules, Visitor{CountryCode: "ID", Device: "Mobile"})
+	if !ok || rule.ID != "mobile" {
+		t.Fatalf("Select = %q, %v; want mobile", rule.ID, ok)
+	}
+	if _, ok := Select(rules, Visitor{CountryCode: "ID", Device: "Desktop"}); ok {
+		t.Fatal("Select matched without a matching rule")
+	}
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":                            "",
+		"*":                           "",
+		"en-US,en;q=0.9":              "en-us",
+		"en;q=0.5, id-ID;q=0.8, fr":   "fr",
+		"de;q=0, pt-BR;q=0.3":         "pt-br",
+		"es;q=abc":                    "es",
+		" ja ; q=0.7 , ko ; q=0.9 ":   "ko",
+		"zh-Hant-TW;q=0.9,en;q=0.8  ": "zh-hant-tw",
+	}
+	for header, want := range tests {
+		if got := PreferredLanguage(header); got != want {
+			t.Errorf("PreferredLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	until := from.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		conditions shortlink.RuleConditions
+		want       error
+	}{
+		{"empty", shortlink.RuleConditions{}, ErrNoConditions},
+		{"device", shortlink.RuleConditions{Devices: []string{"Mobile"}}, nil},
+		{"empty window", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{}}, ErrInvalidTimeWindow},
+		{"reversed range", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{From: &from, Until: &until}}, ErrInvalidTimeWindow},
+		{"start only", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{StartTime: "09:00"}}, ErrInvalidTimeWindow},
+		{"same start and end", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{StartTime: "09:00", EndTime: "09:00"}}, ErrInvalidTimeWindow},
+		{"bad day", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{Days: []int{7}}}, ErrInvalidTimeWindow},
+		{"bad timezone", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{Days: []int{1}, Timezone: "Mars/Base"}}, ErrInvalidTimeWindow},
+		{"weekdays", shortlink.RuleConditions{TimeWindow: &shortlink.RuleTimeWindow{Days: []int{1, 2, 3, 4, 5}, StartTime: "09:00", EndTime: "17:00", Timezone: "Asia/Jakarta"}}, nil},
+	}
+
+	for _, tt := range tests {
+		if got := Validate(tt.conditions); got != tt.want {
+			t.Errorf("%s: Validate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package targeting
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+// Variant is the part of an A/B variant needed to answer a redirect.
+type Variant struct {
+	ID          string `json:"id"`
+	Destination string `json:"destination"`
+	Weight      int    `json:"weight"`
+}
+
+// VariantsFromModels converts the active variants with a positive weight.
+func VariantsFromModels(variants []shortlink.LinkVariant) []Variant {
+	active := make([]Variant, 0, len(variants))
+	for _, variant := range variants {
+		if !variant.IsActive || variant.Weight <= 0 {
+			continue
+		}
+		active = append(active, Variant{
+			ID:          variant.ID,
+			Destination: variant.DestinationURL,
+			Weight:      variant.Weight,
+		})
+	}
+	return active
+}
+
+// PickVariant chooses the variant served to a visitor.
+//
+//   - cookie (the default): the variant named by current, the visitor's
+//     cookie, while it is still offered; otherwise a weighted random pick
+//   - ip: a weighted pick derived from visitorKey, so the same visitor keeps
+//     landing on the same variant as long as the weights do not change
+//   - random: a weighted random pick on every click
+func PickVariant(variants []Variant, mode, current, visitorKey string) (Variant, bool) {
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return Variant{}, false
+	}
+
+	switch mode {
+	case shortlink.VariantModeIP:
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(visitorKey))
+		return variantAt(variants, int(h.Sum64()%uint64(total))), true
+	case shortlink.VariantModeRandom:
+		return variantAt(variants, rand.IntN(total)), true
+	default:
+		for _, variant := range variants {
+			if current != "" && variant.ID == current {
+				return variant, true
+			}
+		}
+		return variantAt(variants, rand.IntN(total)), true
+	}
+}
+
+// variantAt returns the variant whose cumulative weight range holds bucket,
+// 0 <= bucket < total weight.
+func variantAt(variants []Variant, bucket int) Variant {
+	for _, variant := range variants {
+		if bucket < variant.Weight {
+			return variant
+		}
+		bucket -= variant.Weight
+	}
+	return variants[len(variants)-1]
+}
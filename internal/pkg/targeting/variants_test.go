@@ -0,0 +1,55 @@
+package targeting
+
+import (
+	"testing"
+
+	"github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+func TestPickVariant(t *testing.T) {
+	t.Parallel()
+
+	variants := VariantsFromModels([]shortlink.LinkVariant{
+		{ID: "a", DestinationURL: "https://a.example", Weight: 70, IsActive: true},
+		{ID: "b", DestinationURL: "https://b.example", Weight: 30, IsActive: true},
+		{ID: "off", DestinationURL: "https://off.example", Weight: 50},
+		{ID: "zero", DestinationURL: "https://zero.example", IsActive: true},
+	})
+	if len(variants) != 2 {
+		t.Fatalf("VariantsFromModels kept %d variants, want 2", len(variants))
+	}
+
+	if got := variantAt(variants, 69); got.ID != "a" {
+		t.Errorf("variantAt(69) = %q, want a", got.ID)
+	}
+	if got := variantAt(variants, 70); got.ID != "b" {
+		t.Errorf("variantAt(70) = %q, want b", got.ID)
+	}
+
+	if got, _ := PickVariant(variants, shortlink.VariantModeCookie, "b", ""); got.ID != "b" {
+		t.Errorf("cookie mode ignored the visitor's variant: got %q", got.ID)
+	}
+	if got, ok := PickVariant(variants, shortlink.VariantModeCookie, "off", ""); !ok || got.ID == "off" {
+		t.Errorf("cookie mode served a variant no longer offered: got %q", got.ID)
+	}
+
+	first, _ := PickVariant(variants, shortlink.VariantModeIP, "", "l1|203.0.113.7")
+	for i := 0; i < 20; i++ {
+		if got, _ := PickVariant(variants, shortlink.VariantModeIP, "", "l1|203.0.113.7"); got.ID != first.ID {
+			t.Fatalf("ip mode is not sticky: got %q then %q", first.ID, got.ID)
+		}
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		got, _ := PickVariant(variants, shortlink.VariantModeRandom, "", "")
+		counts[got.ID]++
+	}
+	if counts["a"] < 6500 || counts["a"] > 7500 || counts["a"]+counts["b"] != 10000 {
+		t.Errorf("random mode split = %v, want about 70/30", counts)
+	}
+
+	if _, ok := PickVariant(nil, shortlink.VariantModeRandom, "", ""); ok {
+		t.Error("PickVariant picked from no variants")
+	}
+}
@@ -0,0 +1,200 @@
+package urlsafety
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+// blockedSchemes run code or embed content in the browser instead of
+// navigating anywhere, so a short link must never redirect to them.
+var blockedSchemes = map[string]struct{}{
+	"data":       {},
+	"javascript": {},
+	"vbscript":   {},
+	"file":       {},
+	"blob":       {},
+	"filesystem": {},
+}
+
+// knownShorteners are third-party shortening services. A short link pointing
+// at another short link hides its real destination from every check here.
+var knownShorteners = map[string]struct{}{
+	"bit.ly":      {},
+	"bitly.com":   {},
+	"buff.ly":     {},
+	"cutt.ly":     {},
+	"goo.gl":      {},
+	"is.gd":       {},
+	"lnkd.in":     {},
+	"ow.ly":       {},
+	"rb.gy":       {},
+	"rebrand.ly":  {},
+	"s.id":        {},
+	"shorturl.at": {},
+	"t.co":        {},
+	"t.ly":        {},
+	"tiny.cc":     {},
+	"tinyurl.com": {},
+	"v.gd":        {},
+	"shorte.st":   {},
+	"adf.ly":      {},
+	"clck.ru":     {},
+	"u.to":        {},
+	"qrco.de":     {},
+	"bl.ink":      {},
+	"short.io":    {},
+	"surl.li":     {},
+	"tny.im":      {},
+	"x.co":        {},
+	"po.st":       {},
+	"snip.ly":     {},
+	"trib.al":     {},
+	"dlvr.it":     {},
+	"ift.tt":      {},
+	"mcaf.ee":     {},
+	"gg.gg":       {},
+}
+
+// confusables are Cyrillic and Greek letters drawn like a Latin letter. A
+// label made only of these renders as a Latin word it is not.
+var confusables = map[rune]struct{}{
+	'а': {}, 'в': {}, 'е': {}, 'к': {}, 'м': {}, 'н': {}, 'о': {}, 'р': {},
+	'с': {}, 'т': {}, 'у': {}, 'х': {}, 'ѕ': {}, 'і': {}, 'ј': {}, 'ԁ': {},
+	'ӏ': {}, 'ԛ': {}, 'ԝ': {}, 'һ': {},
+	'α': {}, 'β': {}, 'ε': {}, 'ι': {}, 'κ': {}, 'ν': {}, 'ο': {}, 'ρ': {},
+	'τ': {}, 'υ': {}, 'χ': {}, 'ω': {},
+}
+
+// Inspect applies the checks that need no list: dangerous schemes, IP
+// literal hosts, punycode lookalikes and nested shorteners. ownHosts are the
+// hosts this service answers short links on; they count as shorteners too.
+func Inspect(rawURL string, ownHosts []string) Verdict {
+	trimmed := strings.TrimSpace(rawURL)
+	scheme, _, hasScheme := strings.Cut(trimmed, ":")
+	if hasScheme {
+		if _, ok := blockedSchemes[strings.ToLower(strings.TrimSpace(scheme))]; ok {
+			return blocked(CategoryScheme, "destination uses the "+strings.ToLower(scheme)+": scheme")
+		}
+	}
+
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return blocked(CategoryMalformed, "destination URL cannot be parsed")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Verdict{}
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return blocked(CategoryMalformed, "destination URL has no host")
+	}
+	if net.ParseIP(host) != nil || isNumericHost(host) {
+		return blocked(CategoryIPHost, "destination host is an IP address")
+	}
+	if reason, lookalike := lookalikeHost(host); lookalike {
+		return blocked(CategoryPunycode, reason)
+	}
+	if isShortener(host, ownHosts) {
+		return blocked(CategoryShortener, "destination is another short link")
+	}
+	return Verdict{}
+}
+
+// isNumericHost catches the integer and hex forms browsers still resolve as
+// IPv4 addresses, such as http://3232235777 or http://0xC0A80101.
+func isNumericHost(host string) bool {
+	if strings.Contains(host, ".") {
+		for _, part := range strings.Split(host, ".") {
+			if part == "" || !isNumericPart(part) {
+				return false
+			}
+		}
+		return true
+	}
+	return isNumericPart(host)
+}
+
+func isNumericPart(part string) bool {
+	if rest, ok := strings.CutPrefix(part, "0x"); ok {
+		if rest == "" {
+			return false
+		}
+		for _, r := range rest {
+			if !unicode.Is(unicode.ASCII_Hex_Digit, r) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, r := range part {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return part != ""
+}
+
+// lookalikeHost reports labels that decode to a mix of Latin with Cyrillic or
+// Greek letters, or to a non-Latin word made only of Latin lookalikes. Labels
+// that do not decode at all are rejected as well.
+func lookalikeHost(host string) (string, bool) {
+	for _, label := range strings.Split(host, ".") {
+		decoded := label
+		if strings.HasPrefix(label, "xn--") {
+			unicodeLabel, err := idna.Punycode.ToUnicode(label)
+			if err != nil {
+				return "destination host has malformed punycode", true
+			}
+			decoded = unicodeLabel
+		}
+		if isLookalikeLabel(decoded) {
+			return "destination host imitates a Latin domain name", true
+		}
+	}
+	return "", false
+}
+
+func isLookalikeLabel(label string) bool {
+	var latin, other, confusable, letters int
+	for _, r := range label {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r), unicode.Is(unicode.Greek, r):
+			other++
+			if _, ok := confusables[unicode.ToLower(r)]; ok {
+				confusable++
+			}
+		}
+	}
+	if latin > 0 && other > 0 {
+		return true
+	}
+	return letters > 0 && other == letters && confusable == letters
+}
+
+func isShortener(host string, ownHosts []string) bool {
+	bare := strings.TrimPrefix(host, "www.")
+	if _, ok := knownShorteners[bare]; ok {
+		return true
+	}
+	for _, own := range ownHosts {
+		if own != "" && (host == own || strings.HasSuffix(host, "."+own)) {
+			return true
+		}
+	}
+	return false
+}
+
+func blocked(category, reason string) Verdict {
+	return Verdict{Blocked: true, Category: category, Reason: reason}
+}
@@ -0,0 +1,44 @@
+package urlsafety
+
+import "testing"
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	ownHosts := []string{"lihat.in"}
+	tests := []struct {
+		name     string
+		url      string
+		category string
+	}{
+		{"plain https", "https://example.com/page?q=1", ""},
+		{"internationalized domain", "https://münchen.de", ""},
+		{"punycode of a real IDN", "https://xn--mnchen-3ya.de", ""},
+		{"greek domain", "https://ελλάδα.gr", ""},
+		{"mailto is left alone", "mailto:team@example.com", ""},
+		{"javascript scheme", "javascript:alert(document.cookie)", CategoryScheme},
+		{"data scheme", "DATA:text/html;base64,PHNjcmlwdD4=", CategoryScheme},
+		{"ipv4 host", "http://203.0.113.7/login", CategoryIPHost},
+		{"ipv6 host", "http://[2001:db8::1]/", CategoryIPHost},
+		{"integer ipv4 host", "http://3405803783/", CategoryIPHost},
+		{"hex ipv4 host", "http://0xCB007107/", CategoryIPHost},
+		{"mixed script punycode", "https://xn--pple-43d.com", CategoryPunycode},
+		{"all cyrillic lookalike", "https://аррӏе.com", CategoryPunycode},
+		{"malformed punycode", "https://xn--zz.com", CategoryPunycode},
+		{"known shortener", "https://bit.ly/abc", CategoryShortener},
+		{"shortener with www", "https://www.tinyurl.com/abc", CategoryShortener},
+		{"own host", "https://go.lihat.in/abc", CategoryShortener},
+		{"no host", "https:///path", CategoryMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			verdict := Inspect(tt.url, ownHosts)
+			if verdict.Blocked != (tt.category != "") || verdict.Category != tt.category {
+				t.Errorf("Inspect(%q) = %+v, want category %q", tt.url, verdict, tt.category)
+			}
+		})
+	}
+}
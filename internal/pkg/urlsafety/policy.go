@@ -0,0 +1,423 @@
+// Package urlsafety decides whether a short link may point at a destination.
+// It combines heuristic checks, domain and URL blocklists refreshed from
+// remote feeds, and optional reputation providers.
+package urlsafety
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Feeds of currently active malware and phishing URLs, one per line
+	defaultSourceURLs          = "https://urlhaus.abuse.ch/downloads/text_online/,https://openphish.com/feed.txt"
+	cacheKeyLastKnownGoodLists = "url_safety_blocklists:last_known_good"
+	defaultHTTPTimeout         = 20 * time.Second
+	defaultProviderTimeout     = 5 * time.Second
+)
+
+// Verdict categories
+const (
+	CategoryScheme    = "scheme"
+	CategoryMalformed = "malformed"
+	CategoryIPHost    = "ip_host"
+	CategoryPunycode  = "punycode"
+	CategoryShortener = "nested_shortener"
+	CategoryBlocklist = "blocklist"
+)
+
+//go:embed data/url_blocklist.conf
+var seedFS embed.FS
+
+// Verdict is the outcome of checking one URL. Category and Reason are only
+// set when Blocked is true; Reason is safe to show to the link owner.
+type Verdict struct {
+	Blocked  bool
+	Category string
+	Reason   string
+	Source   string
+}
+
+// Provider is an external reputation service consulted after the local
+// checks pass, such as Google Safe Browsing.
+type Provider interface {
+	Name() string
+	Check(ctx context.Context, rawURL string) (Verdict, error)
+}
+
+// Policy holds the blocklists and providers used to vet destination URLs.
+type Policy struct {
+	mu         sync.RWMutex
+	domains    map[string]struct{}
+	urls       map[string]struct{}
+	lastSource string
+	providers  []Provider
+
+	redisClient *redis.Client
+	httpClient  *http.Client
+	sourceURLs  []string
+	ownHosts    []string
+}
+
+var (
+	globalPolicy   *Policy
+	globalPolicyMu sync.RWMutex
+)
+
+// NewPolicy creates a policy seeded with the embedded blocklist. ownHosts are
+// the hosts short links are served on.
+func NewPolicy(redisClient *redis.Client, sourceURLs []string, ownHosts []string, httpClient *http.Client) *Policy {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	domains, urls := loadSeedLists()
+	return &Policy{
+		domains:     domains,
+		urls:        urls,
+		lastSource:  "seed",
+		redisClient: redisClient,
+		httpClient:  httpClient,
+		sourceURLs:  sourceURLs,
+		ownHosts:    ownHosts,
+	}
+}
+
+// InitGlobal initializes the global policy instance. When URL_SAFETY_ENABLED
+// is false no policy is installed and every destination is allowed.
+func InitGlobal(redisClient *redis.Client) error {
+	if !config.GetEnvAsBool(config.EnvURLSafetyEnabled, true) {
+		logger.Logger.Info("URL safety scanning disabled")
+		return nil
+	}
+
+	var sources []string
+	for _, source := range strings.Split(config.GetEnvOrDefault(config.EnvURLSafetyBlockListURLs, defaultSourceURLs), ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+
+	policy := NewPolicy(redisClient, sources, OwnHosts(), nil)
+	if key := config.GetEnvOrDefault(config.EnvURLSafetySafeBrowsingKey, ""); key != "" {
+		policy.RegisterProvider(NewSafeBrowsingProvider(key, nil))
+	}
+	if err := policy.Bootstrap(context.Background()); err != nil {
+		return err
+	}
+
+	globalPolicyMu.Lock()
+	globalPolicy = policy
+	globalPolicyMu.Unlock()
+
+	return nil
+}
+
+// Global returns the initialized global policy, or nil when scanning is off.
+func Global() *Policy {
+	globalPolicyMu.RLock()
+	defer globalPolicyMu.RUnlock()
+	return globalPolicy
+}
+
+// OwnHosts returns the hosts this service answers short links on, taken from
+// BACKEND_URL.
+func OwnHosts() []string {
+	u, err := url.Parse(config.GetEnvOrDefault(config.EnvBackendURL, ""))
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+	return []string{strings.ToLower(u.Hostname())}
+}
+
+// RegisterProvider adds a provider consulted by Check.
+func (p *Policy) RegisterProvider(provider Provider) {
+	p.mu.Lock()
+	p.providers = append(p.providers, provider)
+	p.mu.Unlock()
+}
+
+// Bootstrap warms up the blocklists from cache, then from the sources.
+func (p *Policy) Bootstrap(ctx context.Context) error {
+	p.loadFromCache(ctx)
+
+	if err := p.RefreshFromSource(ctx); err != nil {
+		logger.Logger.Warn("URL blocklist refresh failed, using existing snapshot",
+			"error", err.Error(),
+			"source", p.GetLastSource(),
+		)
+	}
+
+	return nil
+}
+
+// Check runs the heuristics, the blocklists and then every provider, and
+// returns the first verdict that blocks rawURL. A failing provider is logged
+// and skipped so an outage cannot block every new link.
+func (p *Policy) Check(ctx context.Context, rawURL string) Verdict {
+	if verdict := Inspect(rawURL, p.ownHosts); verdict.Blocked {
+		verdict.Source = "heuristic"
+		return verdict
+	}
+	if verdict := p.checkLists(rawURL); verdict.Blocked {
+		return verdict
+	}
+
+	p.mu.RLock()
+	providers := p.providers
+	p.mu.RUnlock()
+
+	for _, provider := range providers {
+		providerCtx, cancel := context.WithTimeout(ctx, defaultProviderTimeout)
+		verdict, err := provider.Check(providerCtx, rawURL)
+		cancel()
+		if err != nil {
+			logger.Logger.Warn("URL safety provider failed",
+				"provider", provider.Name(),
+				"error", err.Error(),
+			)
+			continue
+		}
+		if verdict.Blocked {
+			verdict.Source = provider.Name()
+			return verdict
+		}
+	}
+	return Verdict{}
+}
+
+// checkLists matches the URL against the URL list and its host, or any parent
+// of it, against the domain list.
+func (p *Policy) checkLists(rawURL string) Verdict {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return Verdict{}
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, candidate := range urlCandidates(u) {
+		if _, listed := p.urls[candidate]; listed {
+			return Verdict{Blocked: true, Category: CategoryBlocklist, Reason: "destination URL is on a malware or phishing blocklist", Source: p.lastSource}
+		}
+	}
+
+	check := NormalizeDomain(u.Hostname())
+	for check != "" {
+		if _, listed := p.domains[check]; listed {
+			return Verdict{Blocked: true, Category: CategoryBlocklist, Reason: "destination domain is on a malware or phishing blocklist", Source: p.lastSource}
+		}
+		dot := strings.IndexByte(check, '.')
+		if dot < 0 {
+			break
+		}
+		check = check[dot+1:]
+	}
+	return Verdict{}
+}
+
+// RefreshFromSource refreshes the blocklists from every source. Sources that
+// fail are skipped; the refresh only fails when none of them answered.
+func (p *Policy) RefreshFromSource(ctx context.Context) error {
+	if len(p.sourceURLs) == 0 {
+		return nil
+	}
+
+	var combined strings.Builder
+	var fetched int
+	var lastErr error
+	for _, source := range p.sourceURLs {
+		raw, err := p.fetchList(ctx, source)
+		if err != nil {
+			logger.Logger.Warn("Failed to fetch URL blocklist", "source", source, "error", err.Error())
+			lastErr = err
+			continue
+		}
+		combined.WriteString(raw)
+		combined.WriteString("\n")
+		fetched++
+	}
+	if fetched == 0 {
+		return fmt.Errorf("no URL blocklist source answered: %w", lastErr)
+	}
+
+	raw := combined.String()
+	domains, urls := ParseLists(raw)
+	if len(domains) == 0 && len(urls) == 0 {
+		return errors.New("URL blocklists are empty after parsing")
+	}
+
+	p.setLists(domains, urls, "remote")
+
+	if p.redisClient != nil {
+		if cacheErr := p.redisClient.Set(ctx, cacheKeyLastKnownGoodLists, raw, 0).Err(); cacheErr != nil {
+			logger.Logger.Warn("Failed to persist URL blocklist cache", "error", cacheErr.Error())
+		}
+	}
+
+	return nil
+}
+
+// GetLastSource returns current list source marker (seed/cache/remote).
+func (p *Policy) GetLastSource() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.lastSource
+}
+
+func (p *Policy) loadFromCache(ctx context.Context) {
+	if p.redisClient == nil {
+		return
+	}
+
+	raw, err := p.redisClient.Get(ctx, cacheKeyLastKnownGoodLists).Result()
+	if err != nil {
+		return
+	}
+
+	domains, urls := ParseLists(raw)
+	if len(domains) == 0 && len(urls) == 0 {
+		return
+	}
+
+	p.setLists(domains, urls, "cache")
+}
+
+func (p *Policy) fetchList(ctx context.Context, source string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("source responded with status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// setLists merges the seed entries back in so a remote list can never drop
+// what ships with the binary.
+func (p *Policy) setLists(domains, urls map[string]struct{}, source string) {
+	seedDomains, seedURLs := loadSeedLists()
+	for domain := range seedDomains {
+		domains[domain] = struct{}{}
+	}
+	for u := range seedURLs {
+		urls[u] = struct{}{}
+	}
+
+	p.mu.Lock()
+	p.domains = domains
+	p.urls = urls
+	p.lastSource = source
+	p.mu.Unlock()
+}
+
+func loadSeedLists() (map[string]struct{}, map[string]struct{}) {
+	raw, err := seedFS.ReadFile("data/url_blocklist.conf")
+	if err != nil {
+		logger.Logger.Warn("Failed to load seed URL blocklist", "error", err.Error())
+		return map[string]struct{}{}, map[string]struct{}{}
+	}
+	return ParseLists(string(raw))
+}
+
+// ParseLists parses a newline-separated blocklist. Lines with a scheme are
+// URL entries matched exactly; any other line is a domain entry that also
+// covers its subdomains. Blank lines and # comments are skipped.
+func ParseLists(raw string) (domains map[string]struct{}, urls map[string]struct{}) {
+	domains = make(map[string]struct{})
+	urls = make(map[string]struct{})
+	for _, line := range strings.Split(raw, "\n") {
+		candidate := strings.TrimSpace(line)
+		if candidate == "" || strings.HasPrefix(candidate, "#") {
+			continue
+		}
+
+		if strings.Contains(candidate, "://") {
+			if normalized := NormalizeURL(candidate); normalized != "" {
+				urls[normalized] = struct{}{}
+			}
+			continue
+		}
+		if normalized := NormalizeDomain(candidate); normalized != "" {
+			domains[normalized] = struct{}{}
+		}
+	}
+	return domains, urls
+}
+
+// NormalizeDomain normalizes and validates domain representation.
+func NormalizeDomain(domain string) string {
+	d := strings.ToLower(strings.TrimSpace(domain))
+	d = strings.TrimSuffix(d, ".")
+	if d == "" || strings.Contains(d, " ") {
+		return ""
+	}
+	if strings.Count(d, ".") < 1 {
+		return ""
+	}
+	return d
+}
+
+// NormalizeURL reduces a URL to the form blocklist entries are compared in:
+// lowercased scheme and host, no default port, no fragment and no trailing
+// slash on the path.
+func NormalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return normalizedURL(u, true)
+}
+
+// urlCandidates returns the list forms of u: with and without its query.
+func urlCandidates(u *url.URL) []string {
+	if u.Host == "" {
+		return nil
+	}
+	full := normalizedURL(u, true)
+	if u.RawQuery == "" {
+		return []string{full}
+	}
+	return []string{full, normalizedURL(u, false)}
+}
+
+func normalizedURL(u *url.URL, withQuery bool) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if port := u.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+
+	normalized := scheme + "://" + host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if withQuery && u.RawQuery != "" {
+		normalized += "?" + u.RawQuery
+	}
+	return normalized
+}
@@ -0,0 +1,167 @@
+package urlsafety
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type stubProvider struct {
+	verdict Verdict
+	err     error
+	calls   int
+}
+
+func (s *stubProvider) Name() string { return "stub" }
+
+func (s *stubProvider) Check(context.Context, string) (Verdict, error) {
+	s.calls++
+	return s.verdict, s.err
+}
+
+func TestParseLists(t *testing.T) {
+	t.Parallel()
+
+	domains, urls := ParseLists(`
+	# comment
+	Evil.Example.
+
+	https://Host.example:443/Phish/?id=1
+	http://host.example/drop.exe
+	`)
+
+	if _, ok := domains["evil.example"]; !ok || len(domains) != 1 {
+		t.Fatalf("domains = %v, want only evil.example", domains)
+	}
+	for _, want := range []string{"https://host.example/Phish?id=1", "http://host.example/drop.exe"} {
+		if _, ok := urls[want]; !ok {
+			t.Errorf("urls = %v, missing %q", urls, want)
+		}
+	}
+}
+
+func TestCheckLists(t *testing.T) {
+	t.Parallel()
+
+	p := NewPolicy(nil, nil, nil, nil)
+	domains, urls := ParseLists("evil.example\nhttps://shared.example/phish\nhttps://shared.example/kit?id=7")
+	p.setLists(domains, urls, "remote")
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://evil.example", true},
+		{"https://login.evil.example/account", true},
+		{"https://notevil.example", false},
+		{"https://SHARED.example/phish/", true},
+		{"https://shared.example/phish?utm_source=mail", true},
+		{"https://shared.example/kit?id=7", true},
+		{"https://shared.example/kit?id=8", false},
+		{"https://shared.example/", false},
+		{"https://testsafebrowsing.appspot.com/s/phishing.html", true},
+	}
+	for _, tt := range tests {
+		verdict := p.Check(context.Background(), tt.url)
+		if verdict.Blocked != tt.blocked {
+			t.Errorf("Check(%q) blocked = %v, want %v", tt.url, verdict.Blocked, tt.blocked)
+		}
+		if verdict.Blocked && verdict.Category != CategoryBlocklist {
+			t.Errorf("Check(%q) category = %q, want %q", tt.url, verdict.Category, CategoryBlocklist)
+		}
+	}
+}
+
+func TestCheckProviders(t *testing.T) {
+	t.Parallel()
+
+	failing := &stubProvider{err: errors.New("provider down")}
+	flagging := &stubProvider{verdict: Verdict{Blocked: true, Category: "phishing", Reason: "flagged"}}
+	p := NewPolicy(nil, nil, nil, nil)
+	p.RegisterProvider(failing)
+	p.RegisterProvider(flagging)
+
+	verdict := p.Check(context.Background(), "https://example.com")
+	if !verdict.Blocked || verdict.Source != "stub" || verdict.Category != "phishing" {
+		t.Fatalf("Check = %+v, want the flagging provider's verdict", verdict)
+	}
+	if failing.calls != 1 {
+		t.Errorf("failing provider called %d times, want 1", failing.calls)
+	}
+
+	// Heuristics answer first, without a provider round trip
+	if verdict := p.Check(context.Background(), "javascript:alert(1)"); verdict.Category != CategoryScheme {
+		t.Errorf("Check(javascript:) = %+v, want the scheme heuristic", verdict)
+	}
+	if flagging.calls != 1 {
+		t.Errorf("flagging provider called %d times, want 1", flagging.calls)
+	}
+}
+
+func TestRefreshFallbackKeepsLastKnownGood(t *testing.T) {
+	t.Parallel()
+
+	transport := &stubRoundTripper{
+		statusCode: http.StatusOK,
+		body:       "# feed\nhttps://bad.example/login\n",
+	}
+	client := &http.Client{Transport: transport}
+
+	p := NewPolicy(nil, []string{"http://feed.test/a", "http://feed.test/b"}, nil, client)
+	if err := p.RefreshFromSource(context.Background()); err != nil {
+		t.Fatalf("expected refresh success, got error: %v", err)
+	}
+	if !p.Check(context.Background(), "https://bad.example/login").Blocked {
+		t.Fatalf("expected refreshed URL to be blocked")
+	}
+	if !p.Check(context.Background(), "https://malware.testing.google.test/testing/malware/").Blocked {
+		t.Fatalf("expected seed entries to survive a refresh")
+	}
+
+	transport.statusCode = http.StatusInternalServerError
+	if err := p.RefreshFromSource(context.Background()); err == nil {
+		t.Fatalf("expected refresh error when every source fails")
+	}
+	if !p.Check(context.Background(), "https://bad.example/login").Blocked {
+		t.Fatalf("expected last-known-good lists retained after failed refresh")
+	}
+}
+
+func TestSafeBrowsingProvider(t *testing.T) {
+	t.Parallel()
+
+	transport := &stubRoundTripper{
+		statusCode: http.StatusOK,
+		body:       `{"matches":[{"threatType":"SOCIAL_ENGINEERING"}]}`,
+	}
+	provider := NewSafeBrowsingProvider("key", &http.Client{Transport: transport})
+
+	verdict, err := provider.Check(context.Background(), "https://example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !verdict.Blocked || verdict.Category != "social_engineering" {
+		t.Fatalf("verdict = %+v, want a social_engineering block", verdict)
+	}
+
+	transport.body = `{}`
+	if verdict, err := provider.Check(context.Background(), "https://example.com"); err != nil || verdict.Blocked {
+		t.Fatalf("verdict = %+v, err = %v, want a clean result", verdict, err)
+	}
+}
+
+type stubRoundTripper struct {
+	statusCode int
+	body       string
+}
+
+func (s *stubRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: s.statusCode,
+		Body:       io.NopCloser(strings.NewReader(s.body)),
+		Header:     make(http.Header),
+	}, nil
+}
@@ -0,0 +1,102 @@
+package urlsafety
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const safeBrowsingEndpoint = "https://safebrowsing.googleapis.com/v4/threatMatches:find"
+
+// SafeBrowsingProvider checks URLs with the Google Safe Browsing Lookup API.
+type SafeBrowsingProvider struct {
+	apiKey     string
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewSafeBrowsingProvider creates a provider using apiKey.
+func NewSafeBrowsingProvider(apiKey string, httpClient *http.Client) *SafeBrowsingProvider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultProviderTimeout}
+	}
+	return &SafeBrowsingProvider{apiKey: apiKey, endpoint: safeBrowsingEndpoint, httpClient: httpClient}
+}
+
+// Name returns the provider name recorded on verdicts.
+func (s *SafeBrowsingProvider) Name() string {
+	return "safe-browsing"
+}
+
+type safeBrowsingRequest struct {
+	Client struct {
+		ClientID      string `json:"clientId"`
+		ClientVersion string `json:"clientVersion"`
+	} `json:"client"`
+	ThreatInfo struct {
+		ThreatTypes      []string `json:"threatTypes"`
+		PlatformTypes    []string `json:"platformTypes"`
+		ThreatEntryTypes []string `json:"threatEntryTypes"`
+		ThreatEntries    []struct {
+			URL string `json:"url"`
+		} `json:"threatEntries"`
+	} `json:"threatInfo"`
+}
+
+type safeBrowsingResponse struct {
+	Matches []struct {
+		ThreatType string `json:"threatType"`
+	} `json:"matches"`
+}
+
+// Check looks rawURL up and blocks it on any match.
+func (s *SafeBrowsingProvider) Check(ctx context.Context, rawURL string) (Verdict, error) {
+	var payload safeBrowsingRequest
+	payload.Client.ClientID = "lihatin-go"
+	payload.Client.ClientVersion = "1.0"
+	payload.ThreatInfo.ThreatTypes = []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"}
+	payload.ThreatInfo.PlatformTypes = []string{"ANY_PLATFORM"}
+	payload.ThreatInfo.ThreatEntryTypes = []string{"URL"}
+	payload.ThreatInfo.ThreatEntries = []struct {
+		URL string `json:"url"`
+	}{{URL: rawURL}}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"?key="+s.apiKey, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return Verdict{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return Verdict{}, fmt.Errorf("safe browsing responded with status %d", resp.StatusCode)
+	}
+
+	var result safeBrowsingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Verdict{}, err
+	}
+	if len(result.Matches) == 0 {
+		return Verdict{}, nil
+	}
+
+	threat := strings.ToLower(strings.ReplaceAll(result.Matches[0].ThreatType, "_", " "))
+	return Verdict{
+		Blocked:  true,
+		Category: strings.ToLower(result.Matches[0].ThreatType),
+		Reason:   "destination URL is flagged by Safe Browsing as " + threat,
+	}, nil
+}
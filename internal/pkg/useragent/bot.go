@@ -0,0 +1,88 @@
+package useragent
+
+import (
+	"bufio"
+	_ "embed"
+	"net/http"
+	"strings"
+)
+
+//go:embed data/bot_signatures.conf
+var botSignaturesConf string
+
+// Names used when a client is classified by heuristics rather than by a
+// signature.
+const (
+	BotNameUnknown  = "Unknown client"
+	BotNameHeadOnly = "HEAD request"
+)
+
+// BotSignals are the request properties the bot classifier looks at.
+type BotSignals struct {
+	UserAgent      string
+	Method         string
+	AcceptLanguage string
+}
+
+// BotMatch is the outcome of DetectBot. Name is set only for bots.
+type BotMatch struct {
+	IsBot bool
+	Name  string
+}
+
+type botSignature struct {
+	pattern string
+	name    string
+}
+
+var botSignatures = parseBotSignatures(botSignaturesConf)
+
+// DetectBotRequest classifies an incoming HTTP request.
+func DetectBotRequest(r *http.Request) BotMatch {
+	return DetectBot(BotSignals{
+		UserAgent:      r.UserAgent(),
+		Method:         r.Method,
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+	})
+}
+
+// DetectBot reports whether a request comes from a bot, crawler, link-preview
+// fetcher or scripted client. Known signatures are checked first so bots keep
+// their name; otherwise HEAD requests, empty user agents and requests without
+// Accept-Language (which every browser sends on navigation) count as bots.
+func DetectBot(signals BotSignals) BotMatch {
+	ua := strings.ToLower(strings.TrimSpace(signals.UserAgent))
+	if ua != "" {
+		for _, sig := range botSignatures {
+			if strings.Contains(ua, sig.pattern) {
+				return BotMatch{IsBot: true, Name: sig.name}
+			}
+		}
+	}
+
+	switch {
+	case strings.EqualFold(signals.Method, http.MethodHead):
+		return BotMatch{IsBot: true, Name: BotNameHeadOnly}
+	case ua == "", strings.TrimSpace(signals.AcceptLanguage) == "":
+		return BotMatch{IsBot: true, Name: BotNameUnknown}
+	}
+	return BotMatch{}
+}
+
+func parseBotSignatures(raw string) []botSignature {
+	var signatures []botSignature
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, name, ok := strings.Cut(line, "|")
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if !ok || pattern == "" {
+			continue
+		}
+		signatures = append(signatures, botSignature{pattern: pattern, name: strings.TrimSpace(name)})
+	}
+	return signatures
+}
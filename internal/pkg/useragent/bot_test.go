@@ -0,0 +1,43 @@
+package useragent
+
+import "testing"
+
+func TestDetectBot(t *testing.T) {
+	t.Parallel()
+
+	const chrome = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36"
+
+	tests := []struct {
+		name    string
+		signals BotSignals
+		want    BotMatch
+	}{
+		{"browser", BotSignals{UserAgent: chrome, Method: "GET", AcceptLanguage: "id-ID,id;q=0.9"}, BotMatch{}},
+		{"slack", BotSignals{UserAgent: "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", Method: "GET"}, BotMatch{IsBot: true, Name: "Slackbot"}},
+		{"whatsapp", BotSignals{UserAgent: "WhatsApp/2.23.20.0 A", Method: "GET"}, BotMatch{IsBot: true, Name: "WhatsApp"}},
+		{"facebook", BotSignals{UserAgent: "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", Method: "GET"}, BotMatch{IsBot: true, Name: "Facebook"}},
+		{"twitter", BotSignals{UserAgent: "Twitterbot/1.0", Method: "GET", AcceptLanguage: "en"}, BotMatch{IsBot: true, Name: "Twitterbot"}},
+		{"telegram", BotSignals{UserAgent: "TelegramBot (like TwitterBot)", Method: "GET"}, BotMatch{IsBot: true, Name: "Telegram"}},
+		{"generic", BotSignals{UserAgent: "Mozilla/5.0 (compatible; ExampleBot/2.1)", Method: "GET", AcceptLanguage: "en"}, BotMatch{IsBot: true, Name: "Other bot"}},
+		{"head", BotSignals{UserAgent: chrome, Method: "HEAD", AcceptLanguage: "en"}, BotMatch{IsBot: true, Name: BotNameHeadOnly}},
+		{"no accept-language", BotSignals{UserAgent: chrome, Method: "GET"}, BotMatch{IsBot: true, Name: BotNameUnknown}},
+		{"empty user agent", BotSignals{Method: "GET", AcceptLanguage: "en"}, BotMatch{IsBot: true, Name: BotNameUnknown}},
+	}
+	for _, tt := range tests {
+		if got := DetectBot(tt.signals); got != tt.want {
+			t.Errorf("%s: DetectBot() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseBotSignatures(t *testing.T) {
+	t.Parallel()
+
+	got := parseBotSignatures("# comment\n\nFooBot|Foo\nmissing-name\n|Empty\n")
+	if len(got) != 1 || got[0] != (botSignature{pattern: "foobot", name: "Foo"}) {
+		t.Fatalf("parseBotSignatures() = %+v", got)
+	}
+	if len(botSignatures) == 0 {
+		t.Fatal("embedded bot signatures are empty")
+	}
+}
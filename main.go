@@ -1,16 +1,31 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/adehusnim37/lihatin-go/internal/jobs"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/clicks"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/codepolicy"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/disposable"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/domains"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/interstitial"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/linkmeta"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/migrations"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/passcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/privacy"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/shortcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/urlsafety"
 	appvalidator "github.com/adehusnim37/lihatin-go/internal/pkg/validator"
 	"github.com/adehusnim37/lihatin-go/middleware"
 	"github.com/adehusnim37/lihatin-go/routes"
@@ -23,6 +38,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// shutdownTimeout bounds how long in-flight requests get to finish on SIGTERM
+const shutdownTimeout = 15 * time.Second
+
 func configureGinMode() {
 	// Allow explicit GIN_MODE override from environment.
 	if mode := strings.TrimSpace(os.Getenv("GIN_MODE")); mode != "" {
@@ -91,6 +109,58 @@ func main() {
 	}
 	log.Println("✅ Disposable email policy initialized")
 
+	log.Println("🛡️ Initializing URL safety policy...")
+	if err := urlsafety.InitGlobal(middleware.GetSessionManager().GetRedisClient()); err != nil {
+		log.Printf("Failed to initialize URL safety policy: %v", err)
+		panic(err)
+	}
+	log.Println("✅ URL safety policy initialized")
+
+	if err := linkmeta.InitGlobal(); err != nil {
+		log.Printf("Failed to initialize link metadata fetcher: %v", err)
+		panic(err)
+	}
+
+	if err := shortcode.InitGlobal(gormDB); err != nil {
+		log.Printf("Failed to initialize short code generator: %v", err)
+		panic(err)
+	}
+
+	if err := codepolicy.InitGlobal(gormDB); err != nil {
+		log.Printf("Failed to initialize short code policy: %v", err)
+		panic(err)
+	}
+
+	if err := interstitial.InitGlobal(gormDB); err != nil {
+		log.Printf("Failed to initialize interstitial policy: %v", err)
+		panic(err)
+	}
+
+	if err := passcode.InitGlobal(middleware.GetSessionManager().GetRedisClient()); err != nil {
+		log.Printf("Failed to initialize passcode guard: %v", err)
+		panic(err)
+	}
+
+	// The click tracker picks up the global hasher, so this must come first
+	if _, err := privacy.InitGlobal(gormDB); err != nil {
+		log.Printf("Failed to initialize visitor privacy: %v", err)
+		panic(err)
+	}
+
+	if _, err := domains.InitGlobal(gormDB); err != nil {
+		log.Printf("Failed to initialize custom domain resolver: %v", err)
+		panic(err)
+	}
+
+	log.Println("📊 Initializing click tracker...")
+	clickTracker, err := clicks.InitGlobal(gormDB)
+	if err != nil {
+		log.Printf("Failed to initialize click tracker: %v", err)
+		panic(err)
+	}
+	defer clickTracker.Stop()
+	log.Println("✅ Click tracker started")
+
 	log.Println("🔁 Initializing scheduler...")
 	scheduler, err := jobs.NewScheduler(gormDB)
 	if err != nil {
@@ -104,6 +174,13 @@ func main() {
 		jobs.NewRefreshDisposableDomainsJob(),
 		jobs.NewWeeklySummaryJob(gormDB),
 		jobs.NewPromotionalCampaignJob(gormDB),
+		jobs.NewRollupClicksJob(gormDB),
+		jobs.NewPruneRawClicksJob(gormDB),
+		jobs.NewVerifyCustomDomainsJob(gormDB),
+		jobs.NewRefreshURLBlocklistsJob(),
+		jobs.NewRescanLinkSafetyJob(gormDB, middleware.GetSessionManager().GetRedisClient()),
+		jobs.NewFetchLinkMetadataJob(gormDB, middleware.GetSessionManager().GetRedisClient()),
+		jobs.NewProcessLinkImportsJob(gormDB, middleware.GetSessionManager().GetRedisClient()),
 	); err != nil {
 		log.Printf("Failed to register scheduler jobs: %v", err)
 		panic(err)
@@ -129,6 +206,36 @@ func main() {
 	}
 
 	r := routes.SetupRouter(validate)
-	fmt.Println("Server running on port:", config.GetRequiredEnv(config.EnvAppPort))
-	r.Run(config.GetRequiredEnv(config.EnvAppPort))
+	port := config.GetRequiredEnv(config.EnvAppPort)
+	srv := &http.Server{
+		Addr:    port,
+		Handler: r,
+	}
+
+	// Jalankan server di goroutine supaya main bisa menunggu sinyal shutdown.
+	// Tanpa ini deferred Stop() (click tracker, scheduler) tidak pernah jalan saat SIGTERM.
+	serverErr := make(chan error, 1)
+	go func() {
+		fmt.Println("Server running on port:", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	quit, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	select {
+	case <-quit.Done():
+	case err := <-serverErr:
+		log.Printf("Server failed: %v", err)
+		return
+	}
+
+	log.Println("🛑 Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+	log.Println("✅ Server stopped, flushing background workers...")
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"mime"
+	"net/url"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -209,16 +210,25 @@ func sanitizeRequestBody(body []byte, contentType string) string {
 		bodyText = bodyText[:maxBodySize] + "... [truncated]"
 	}
 
+	// Form posts, such as the passcode form, are redacted field by field
+	if contentType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(bodyText); err == nil {
+			for key := range form {
+				if isSensitiveField(key) {
+					form.Set(key, "[REDACTED]")
+				}
+			}
+			return form.Encode()
+		}
+	}
+
 	// Try to parse as JSON and remove sensitive fields
 	var jsonData map[string]interface{}
 	if err := json.Unmarshal([]byte(bodyText), &jsonData); err == nil {
 		// Remove sensitive fields
-		sensitiveFields := []string{"password", "token", "secret", "key", "auth", "authorization"}
-		for _, field := range sensitiveFields {
-			for key := range jsonData {
-				if strings.Contains(strings.ToLower(key), field) {
-					jsonData[key] = "[REDACTED]"
-				}
+		for key := range jsonData {
+			if isSensitiveField(key) {
+				jsonData[key] = "[REDACTED]"
 			}
 		}
 
@@ -231,6 +241,19 @@ func sanitizeRequestBody(body []byte, contentType string) string {
 	return bodyText
 }
 
+// isSensitiveField reports whether a body field or query parameter must not
+// be logged
+func isSensitiveField(key string) bool {
+	sensitiveFields := []string{"password", "passcode", "token", "secret", "key", "auth", "authorization"}
+	key = strings.ToLower(key)
+	for _, field := range sensitiveFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
 func shouldOmitRequestBody(contentType string) bool {
 	if contentType == "" {
 		return false
@@ -290,7 +313,10 @@ func captureQueryParams(c *gin.Context) string {
 
 	queryMap := make(map[string]interface{})
 	for key, values := range c.Request.URL.Query() {
-		if len(values) == 1 {
+		// Legacy protected links carry ?passcode=
+		if strings.EqualFold(key, "passcode") {
+			queryMap[key] = "[REDACTED]"
+		} else if len(values) == 1 {
 			queryMap[key] = values[0]
 		} else {
 			queryMap[key] = values
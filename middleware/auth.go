@@ -333,49 +333,56 @@ func RateLimitMiddleware(limit int, duration ...int) gin.HandlerFunc {
 	}
 
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
 		route := c.FullPath()
 		if route == "" {
 			route = c.Request.URL.Path
 		}
-		key := fmt.Sprintf("rate_limit:%s:%s:%s", clientIP, c.Request.Method, route)
-
-		// Get Redis via SessionManager
-		redisClient := GetSessionManager().GetRedisClient()
-
-		// 1. Increment counter atomically
-		// INCR key
-		count, err := redisClient.Incr(c.Request.Context(), key).Result()
-		if err != nil {
-			// Fail-open strategy: If Redis is down, allow request but log error
-			// This prevents blocking users during cache outages
-			logger.Logger.Error("Rate limit Redis error", "error", err)
-			c.Next()
+		if !allowRequest(c, route, limit, windowDuration) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		// 2. Set expiration on first request (start of window)
-		// If count is 1, it means the key was just created or expired.
-		// We set the TTL based on configured duration (default 1 hour).
-		if count == 1 {
-			redisClient.Expire(c.Request.Context(), key, windowDuration)
-		}
+// allowRequest counts the request against the client's limit for route. Once
+// the limit is exceeded it answers 429, aborts and returns false.
+func allowRequest(c *gin.Context, route string, limit int, windowDuration time.Duration) bool {
+	clientIP := c.ClientIP()
+	key := fmt.Sprintf("rate_limit:%s:%s:%s", clientIP, c.Request.Method, route)
+
+	// Get Redis via SessionManager
+	redisClient := GetSessionManager().GetRedisClient()
+
+	// 1. Increment counter atomically
+	// INCR key
+	count, err := redisClient.Incr(c.Request.Context(), key).Result()
+	if err != nil {
+		// Fail-open strategy: If Redis is down, allow request but log error
+		// This prevents blocking users during cache outages
+		logger.Logger.Error("Rate limit Redis error", "error", err)
+		return true
+	}
 
-		// 3. Check limit
-		if count > int64(limit) {
-			logger.Logger.Warn("Rate limit exceeded", "ip", clientIP, "count", count)
-			c.JSON(http.StatusTooManyRequests, common.APIResponse{
-				Success: false,
-				Data:    nil,
-				Message: "Rate limit exceeded",
-				Error:   map[string]string{"rate_limit": "Too many requests, please try again later"},
-			})
-			c.Abort()
-			return
-		}
+	// 2. Set expiration on first request (start of window)
+	// If count is 1, it means the key was just created or expired.
+	// We set the TTL based on configured duration (default 1 hour).
+	if count == 1 {
+		redisClient.Expire(c.Request.Context(), key, windowDuration)
+	}
 
-		c.Next()
+	// 3. Check limit
+	if count > int64(limit) {
+		logger.Logger.Warn("Rate limit exceeded", "ip", clientIP, "count", count)
+		c.JSON(http.StatusTooManyRequests, common.APIResponse{
+			Success: false,
+			Data:    nil,
+			Message: "Rate limit exceeded",
+			Error:   map[string]string{"rate_limit": "Too many requests, please try again later"},
+		})
+		c.Abort()
+		return false
 	}
+	return true
 }
 
 // SecurityHeaders middleware adds security headers
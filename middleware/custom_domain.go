@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/deeplink"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/domains"
+	"github.com/adehusnim37/lihatin-go/models/common"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys set for requests arriving on a custom domain
+const (
+	CustomDomainKey         = "custom_domain"
+	CustomDomainFallbackKey = "custom_domain_fallback"
+)
+
+// The public short link routes, and redirects on custom domains, are limited
+// per client to PublicShortRateLimit requests every PublicShortRateMinutes.
+const (
+	PublicShortRateLimit   = 25
+	PublicShortRateMinutes = 30
+
+	customDomainRateRoute = "custom_domain/:code"
+)
+
+// CustomDomainRouting answers requests whose Host is a registered custom
+// domain, so https://go.customer.com/abc resolves code abc scoped to that
+// domain. Once the domain is verified, /:code is handed to redirect and
+// everything else goes to the domain's fallback URL. Requests for other hosts
+// continue down the chain.
+func CustomDomainRouting(resolver *domains.Resolver, redirect gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if resolver == nil {
+			c.Next()
+			return
+		}
+
+		domain, ok := resolver.Lookup(c.Request.Context(), c.Request.Host)
+		if !ok {
+			c.Next()
+			return
+		}
+		defer c.Abort()
+
+		// POST only carries the passcode form back to /:code
+		method := c.Request.Method
+		if method != http.MethodGet && method != http.MethodHead && method != http.MethodPost {
+			customDomainNotFound(c)
+			return
+		}
+
+		if domain.Status != shortlink.DomainStatusVerified {
+			customDomainNotFound(c)
+			return
+		}
+
+		path := c.Request.URL.Path
+		if (path == deeplink.AppleAppSiteAssociationPath || path == deeplink.AssetLinksPath) && method != http.MethodPost {
+			serveAppAssociation(c, domain)
+			return
+		}
+
+		code := strings.TrimPrefix(path, "/")
+		if code == "" || strings.Contains(code, "/") {
+			if domain.FallbackURL != "" {
+				c.Redirect(http.StatusFound, domain.FallbackURL)
+				return
+			}
+			customDomainNotFound(c)
+			return
+		}
+
+		// These requests never reach the /v1/short group and its rate limit
+		if !allowRequest(c, customDomainRateRoute, PublicShortRateLimit, PublicShortRateMinutes*time.Minute) {
+			return
+		}
+
+		c.Params = append(c.Params[:0], gin.Param{Key: "code", Value: code})
+		c.Set(CustomDomainKey, domain.Domain)
+		c.Set(CustomDomainFallbackKey, domain.FallbackURL)
+		redirect(c)
+	}
+}
+
+// serveAppAssociation answers the file iOS or Android fetch to let the
+// domain's apps open its links, or 404 when the domain has no such app.
+func serveAppAssociation(c *gin.Context, domain *shortlink.CustomDomain) {
+	var (
+		body []byte
+		err  error
+	)
+	switch c.Request.URL.Path {
+	case deeplink.AppleAppSiteAssociationPath:
+		appIDs := domain.IOSAppIDList()
+		if len(appIDs) == 0 {
+			customDomainNotFound(c)
+			return
+		}
+		body, err = deeplink.AppleAppSiteAssociation(appIDs)
+	default:
+		fingerprints := domain.AndroidCertFingerprintList()
+		if domain.AndroidPackage == "" || len(fingerprints) == 0 {
+			customDomainNotFound(c)
+			return
+		}
+		body, err = deeplink.AssetLinks(domain.AndroidPackage, fingerprints)
+	}
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+func customDomainNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, common.APIResponse{
+		Success: false,
+		Data:    nil,
+		Message: "Route not found",
+		Error:   map[string]string{"route": "resource not found"},
+	})
+}
@@ -44,3 +44,30 @@ func BlockSensitivePaths() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// StripPasscodeQuery drops the passcode query parameter legacy protected links
+// carried before the passcode form, so it never reaches the access log or a
+// handler. It must run before the logger.
+func StripPasscodeQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.RawQuery == "" {
+			c.Next()
+			return
+		}
+
+		pairs := strings.Split(c.Request.URL.RawQuery, "&")
+		kept := pairs[:0]
+		for _, pair := range pairs {
+			key, _, _ := strings.Cut(pair, "=")
+			if unescaped, err := url.QueryUnescape(key); err == nil {
+				key = unescaped
+			}
+			if !strings.EqualFold(key, "passcode") {
+				kept = append(kept, pair)
+			}
+		}
+		c.Request.URL.RawQuery = strings.Join(kept, "&")
+
+		c.Next()
+	}
+}
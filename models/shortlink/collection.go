@@ -0,0 +1,36 @@
+package shortlink
+
+import "time"
+
+// LinkTag is a label a user puts on their links to group them, such as a
+// campaign name. A link can carry any number of tags; names are unique per
+// user.
+type LinkTag struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"size:191;not null;uniqueIndex:idx_link_tags_user_name,priority:1"`
+	Name      string    `json:"name" gorm:"size:50;not null;uniqueIndex:idx_link_tags_user_name,priority:2"`
+	Color     string    `json:"color,omitempty" gorm:"size:7"` // #rrggbb shown next to the tag
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (LinkTag) TableName() string {
+	return "link_tags"
+}
+
+// Collection is a folder of a user's links. A link sits in at most one
+// collection (ShortLink.CollectionID); names are unique per user.
+type Collection struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"user_id" gorm:"size:191;not null;uniqueIndex:idx_collections_user_name,priority:1"`
+	Name        string    `json:"name" gorm:"size:100;not null;uniqueIndex:idx_collections_user_name,priority:2"`
+	Description string    `json:"description,omitempty" gorm:"size:500"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (Collection) TableName() string {
+	return "collections"
+}
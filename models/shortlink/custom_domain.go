@@ -0,0 +1,67 @@
+package shortlink
+
+import (
+	"strings"
+	"time"
+)
+
+// DomainVerificationDNS is the custom domain verification method. It is the
+// only one: an HTTP file would be served by this service once the domain
+// points at it.
+const DomainVerificationDNS = "dns"
+
+// Custom domain states. Only verified domains route short links.
+const (
+	DomainStatusPending  = "pending"
+	DomainStatusVerified = "verified"
+	DomainStatusFailed   = "failed"
+)
+
+// CustomDomain is a host a user has claimed for their short links, e.g.
+// go.customer.com. Ownership is proven with a DNS TXT record carrying
+// VerificationToken and re-checked periodically.
+type CustomDomain struct {
+	ID                 string     `json:"id" gorm:"primaryKey"`
+	UserID             string     `json:"user_id" gorm:"size:191;not null;index"`
+	Domain             string     `json:"domain" gorm:"size:191;not null;uniqueIndex"` // Lowercased host without port
+	VerificationMethod string     `json:"verification_method" gorm:"size:10;not null;default:'dns'"`
+	VerificationToken  string     `json:"-" gorm:"size:64;not null"`
+	Status             string     `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	VerifiedAt         *time.Time `json:"verified_at,omitempty"`
+	LastCheckedAt      *time.Time `json:"last_checked_at,omitempty" gorm:"index"`
+	LastError          string     `json:"last_error,omitempty" gorm:"size:255"`
+	FailedChecks       int        `json:"failed_checks" gorm:"not null;default:0"`
+	FallbackURL        string     `json:"fallback_url,omitempty" gorm:"type:text"` // Where unknown codes and the bare domain redirect to
+	CreatedAt          time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Apps allowed to open the domain's links, served from /.well-known
+	IOSAppIDs               string `json:"ios_app_ids,omitempty" gorm:"type:text"` // Comma separated TEAMID.bundle.id
+	AndroidPackage          string `json:"android_package,omitempty" gorm:"size:255"`
+	AndroidCertFingerprints string `json:"android_cert_fingerprints,omitempty" gorm:"type:text"` // Comma separated SHA-256 signing certificate fingerprints
+}
+
+// IOSAppIDList returns the iOS apps allowed to open the domain's links
+func (d CustomDomain) IOSAppIDList() []string {
+	return splitList(d.IOSAppIDs)
+}
+
+// AndroidCertFingerprintList returns the signing certificates the Android app
+// may carry
+func (d CustomDomain) AndroidCertFingerprintList() []string {
+	return splitList(d.AndroidCertFingerprints)
+}
+
+func splitList(joined string) []string {
+	var items []string
+	for item := range strings.SplitSeq(joined, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (CustomDomain) TableName() string {
+	return "custom_domains"
+}
@@ -1,6 +1,7 @@
 package shortlink
 
 import (
+	"net/url"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,27 +11,83 @@ import (
 type ShortLinkDetail struct {
 	ID                     string         `json:"id" gorm:"primaryKey"`                         // Changed to string for consistency
 	ShortLinkID            string         `json:"short_link_id" gorm:"size:191;not null;index"` // Foreign key, changed to string
-	Passcode               int            `json:"passcode,omitempty" validate:"max=6,numeric"`  // Removed gorm:"size:6"
+	Passcode               int            `json:"-" gorm:"-"`                                   // Plaintext passcode, only set on create so it can be emailed to the owner
+	PasscodeHash           string         `json:"-" gorm:"size:100"`                            // Salted bcrypt hash; empty when the link has no passcode
 	ClickLimit             int            `json:"click_limit" gorm:"default:0"`                 // 0 means unlimited
 	CurrentClicks          int            `json:"current_clicks" gorm:"default:0"`
+	UniqueClicks           int            `json:"unique_clicks" gorm:"default:0"`
+	ClickLimitMode         string         `json:"click_limit_mode" gorm:"size:10;default:'total'"` // Counter the click limit applies to: total or unique
+	DedupWindowMinutes     *int           `json:"dedup_window_minutes,omitempty"`                  // Unique-click window; nil inherits the global default, 0 counts every click
+	VariantMode            string         `json:"variant_mode,omitempty" gorm:"size:10"`           // How A/B variants stick to a visitor: cookie, ip or random; empty means cookie
+	InterstitialMode       string         `json:"interstitial_mode,omitempty" gorm:"size:10"`      // on or off; empty inherits the global default
 	EnableStats            bool           `json:"enable_stats" gorm:"default:true"`
 	IsBanned               bool           `json:"is_banned" gorm:"default:false"`
 	BannedReason           string         `json:"banned_reason,omitempty" gorm:"size:255"`
-	BannedBy               *string        `json:"banned_by,omitempty" gorm:"size:191"` // Admin user ID who banned the link
+	BannedBy               *string        `json:"banned_by,omitempty" gorm:"size:191"` // Admin user ID who banned the link; nil when banned by the safety scan
+	SafetyScannedAt        *time.Time     `json:"safety_scanned_at,omitempty" gorm:"index"` // Last periodic URL safety re-scan
+	MetadataFetchedAt      *time.Time     `json:"metadata_fetched_at,omitempty" gorm:"index"` // Destination metadata fetched or given up on; nil while a fetch is pending
+	MetadataAttempts       int            `json:"-" gorm:"default:0"`
+	IsFlagged              bool           `json:"is_flagged" gorm:"default:false"` // Admin forced the interstitial warning instead of a ban
+	FlaggedReason          string         `json:"flagged_reason,omitempty" gorm:"size:255"`
+	FlaggedBy              *string        `json:"flagged_by,omitempty" gorm:"size:191"`
 	CustomDomain           string         `json:"custom_domain,omitempty" gorm:"size:255"`
 	UTMSource              string         `json:"utm_source,omitempty" gorm:"size:100"`
 	UTMMedium              string         `json:"utm_medium,omitempty" gorm:"size:100"`
 	UTMCampaign            string         `json:"utm_campaign,omitempty" gorm:"size:100"`
 	UTMTerm                string         `json:"utm_term,omitempty" gorm:"size:100"`
 	UTMContent             string         `json:"utm_content,omitempty" gorm:"size:100"`
+	PrivacyMode            string         `json:"privacy_mode,omitempty" gorm:"size:20"`   // off, hash or truncate; empty inherits the global default
+	HonorDNT               *bool          `json:"honor_dnt,omitempty" gorm:"column:honor_dnt"` // nil inherits the global default
 	CreatedAt              time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt              time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt              gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
+	// Redirect behaviour: the status code and caching of the redirect, and
+	// how the UTM tags and the visitor's query string merge into the
+	// destination
+	RedirectStatus       int    `json:"redirect_status,omitempty" gorm:"default:0"`        // 301, 302, 307 or 308; 0 means 307
+	RedirectCacheSeconds int    `json:"redirect_cache_seconds,omitempty" gorm:"default:0"` // Cache-Control max-age; 0 means no-store
+	ForwardQuery         bool   `json:"forward_query" gorm:"default:false"`
+	QueryConflictMode    string `json:"query_conflict_mode,omitempty" gorm:"size:10"` // keep, override or append; empty means keep
+
 	// Relationships
 	ShortLink ShortLink `json:"short_link,omitempty" gorm:"foreignKey:ShortLinkID;references:ID"`
 }
 
+// Counters a click limit can apply to
+const (
+	ClickLimitModeTotal  = "total"
+	ClickLimitModeUnique = "unique"
+)
+
+// Interstitial modes a link can set; empty inherits the admin-global setting
+const (
+	InterstitialModeOn  = "on"
+	InterstitialModeOff = "off"
+)
+
+// UTMParams returns the stored UTM tags as query parameters.
+func (d ShortLinkDetail) UTMParams() url.Values {
+	params := url.Values{}
+	for key, value := range map[string]string{
+		"utm_source":   d.UTMSource,
+		"utm_medium":   d.UTMMedium,
+		"utm_campaign": d.UTMCampaign,
+		"utm_term":     d.UTMTerm,
+		"utm_content":  d.UTMContent,
+	} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	return params
+}
+
+// HasPasscode reports whether the link is passcode protected
+func (d ShortLinkDetail) HasPasscode() bool {
+	return d.PasscodeHash != ""
+}
+
 // TableName specifies the table name for GORM
 func (ShortLinkDetail) TableName() string {
 	return "short_link_details"
@@ -0,0 +1,64 @@
+package shortlink
+
+import "time"
+
+// Link import statuses
+const (
+	LinkImportStatusPending    = "pending"
+	LinkImportStatusProcessing = "processing"
+	LinkImportStatusCompleted  = "completed"
+)
+
+// Link import row statuses
+const (
+	LinkImportRowStatusPending = "pending"
+	LinkImportRowStatusCreated = "created"
+	LinkImportRowStatusFailed  = "failed"
+)
+
+// LinkImport is a file of links a user uploaded. Its rows are created in the
+// background; the counters follow the rows as they are processed. An import
+// left processing by a worker that stopped is picked up again once its
+// UpdatedAt heartbeat goes stale.
+type LinkImport struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	UserID        string     `json:"user_id" gorm:"size:191;not null;index"`
+	Format        string     `json:"format" gorm:"size:10;not null"`
+	FileName      string     `json:"file_name" gorm:"size:255"`
+	Status        string     `json:"status" gorm:"size:20;not null;default:pending;index"`
+	TotalRows     int        `json:"total_rows" gorm:"not null;default:0"`
+	ProcessedRows int        `json:"processed_rows" gorm:"not null;default:0"`
+	SucceededRows int        `json:"succeeded_rows" gorm:"not null;default:0"`
+	FailedRows    int        `json:"failed_rows" gorm:"not null;default:0"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"` // Heartbeat of the worker processing it
+}
+
+// TableName specifies the table name for GORM
+func (LinkImport) TableName() string {
+	return "link_imports"
+}
+
+// LinkImportRow is one row of an import. Payload holds the link to create
+// until the row is processed; rows that failed validation on upload are
+// stored already failed.
+type LinkImportRow struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	ImportID     string    `json:"import_id" gorm:"size:191;not null;index:idx_link_import_rows_import_line,priority:1"`
+	Line         int       `json:"line" gorm:"not null;index:idx_link_import_rows_import_line,priority:2"`
+	Payload      string    `json:"-" gorm:"type:text"`
+	OriginalURL  string    `json:"original_url" gorm:"type:text"`
+	Status       string    `json:"status" gorm:"size:20;not null;default:pending;index"`
+	ShortCode    string    `json:"short_code,omitempty" gorm:"size:100"`
+	ErrorCode    string    `json:"error_code,omitempty" gorm:"size:100"`
+	ErrorMessage string    `json:"error_message,omitempty" gorm:"size:1000"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (LinkImportRow) TableName() string {
+	return "link_import_rows"
+}
@@ -0,0 +1,82 @@
+package shortlink
+
+import "time"
+
+// Who made a revision (ShortLinkRevision.ActorType)
+const (
+	RevisionActorUser   = "user"
+	RevisionActorAPIKey = "api_key"
+	RevisionActorAdmin  = "admin"
+	// RevisionActorSystem is a change the service made on its own, such as a
+	// safety scan ban or fetched destination metadata.
+	RevisionActorSystem = "system"
+)
+
+// ShortLinkRevision is the state of a link after one change. Number counts a
+// link's revisions from 1, the first being the link as it was before its
+// first recorded change. State is the JSON of a LinkRevisionState so any two
+// revisions can be diffed and an old one restored. ShortLink.RevisionID
+// points at the revision in effect, which is what clicks record.
+type ShortLinkRevision struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	ShortLinkID   string    `json:"short_link_id" gorm:"size:191;not null;uniqueIndex:idx_link_revisions_number,priority:1"`
+	Number        int       `json:"number" gorm:"not null;uniqueIndex:idx_link_revisions_number,priority:2"`
+	State         string    `json:"-" gorm:"type:text;not null"`
+	ChangedFields string    `json:"changed_fields,omitempty" gorm:"size:1000"` // Comma separated LinkRevisionState field names
+	ActorType     string    `json:"actor_type" gorm:"size:10;not null"`
+	ActorID       *string   `json:"actor_id,omitempty" gorm:"size:191;index"` // User or admin who made the change; nil for the baseline of an anonymous link
+	APIKeyID      *string   `json:"api_key_id,omitempty" gorm:"size:191"`     // Set when the change came through an API key
+	RestoredFrom  *int      `json:"restored_from,omitempty"`                  // Number of the revision a rollback restored
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (ShortLinkRevision) TableName() string {
+	return "short_link_revisions"
+}
+
+// LinkRevisionState is what a revision records of a link and its detail:
+// everything UpdateShortLink can change. PasscodeHash is kept so a rollback
+// can restore the passcode, and never leaves the server. Deleted is only
+// recorded; a rollback never deletes or revives a link. Redirect rules and A/B
+// variants are kept in their own tables and are not part of a revision: only
+// promoting a variant, which rewrites OriginalURL, shows up here, and a
+// rollback leaves rules and variants as they are.
+type LinkRevisionState struct {
+	OriginalURL         string     `json:"original_url"`
+	Domain              string     `json:"domain"`
+	ShortCode           string     `json:"short_code"`
+	Title               string     `json:"title"`
+	Description         string     `json:"description"`
+	IsActive            bool       `json:"is_active"`
+	Deleted             bool       `json:"deleted"`
+	ExpiresAt           *time.Time `json:"expires_at"`
+	CollectionID        *string    `json:"collection_id"`
+	TagIDs              []string   `json:"tag_ids"`
+	OGTitle             string     `json:"og_title"`
+	OGDescription       string     `json:"og_description"`
+	OGImageURL          string     `json:"og_image_url"`
+	TwitterCard         string     `json:"twitter_card"`
+	IOSDeepLink         string     `json:"ios_deep_link"`
+	AndroidDeepLink     string     `json:"android_deep_link"`
+	DeepLinkFallbackURL string     `json:"deep_link_fallback_url"`
+
+	PasscodeHash         string `json:"passcode_hash"`
+	ClickLimit           int    `json:"click_limit"`
+	ClickLimitMode       string `json:"click_limit_mode"`
+	DedupWindowMinutes   *int   `json:"dedup_window_minutes"`
+	EnableStats          bool   `json:"enable_stats"`
+	VariantMode          string `json:"variant_mode"`
+	InterstitialMode     string `json:"interstitial_mode"`
+	PrivacyMode          string `json:"privacy_mode"`
+	HonorDNT             *bool  `json:"honor_dnt"`
+	UTMSource            string `json:"utm_source"`
+	UTMMedium            string `json:"utm_medium"`
+	UTMCampaign          string `json:"utm_campaign"`
+	UTMTerm              string `json:"utm_term"`
+	UTMContent           string `json:"utm_content"`
+	RedirectStatus       int    `json:"redirect_status"`
+	RedirectCacheSeconds int    `json:"redirect_cache_seconds"`
+	ForwardQuery         bool   `json:"forward_query"`
+	QueryConflictMode    string `json:"query_conflict_mode"`
+}
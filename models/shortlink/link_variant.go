@@ -0,0 +1,32 @@
+package shortlink
+
+import "time"
+
+// How A/B variants stick to a visitor (ShortLinkDetail.VariantMode)
+const (
+	VariantModeCookie = "cookie"
+	VariantModeIP     = "ip"
+	VariantModeRandom = "random"
+)
+
+// LinkVariant is one weighted destination of an A/B split. While a link has
+// active variants, clicks not claimed by a redirect rule are spread across
+// them in proportion to Weight instead of going to OriginalURL. Clicks and
+// UniqueClicks count human clicks served this variant.
+type LinkVariant struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	ShortLinkID    string    `json:"short_link_id" gorm:"size:191;not null;index"`
+	Name           string    `json:"name" gorm:"size:100"`
+	DestinationURL string    `json:"destination_url" gorm:"type:text;not null"`
+	Weight         int       `json:"weight" gorm:"not null;default:1"`
+	IsActive       bool      `json:"is_active" gorm:"default:true"`
+	Clicks         int64     `json:"clicks" gorm:"default:0"`
+	UniqueClicks   int64     `json:"unique_clicks" gorm:"default:0"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (LinkVariant) TableName() string {
+	return "link_variants"
+}
@@ -0,0 +1,16 @@
+package shortlink
+
+import "time"
+
+// ClickPrivacySalt is the random salt used to hash visitor IPs on one UTC day.
+// Salts are deleted once the day has passed, so stored hashes can no longer be
+// linked back to an address.
+type ClickPrivacySalt struct {
+	Day       string    `json:"day" gorm:"size:10;primaryKey"` // YYYY-MM-DD (UTC)
+	Salt      []byte    `json:"-" gorm:"type:varbinary(32);not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ClickPrivacySalt) TableName() string {
+	return "click_privacy_salts"
+}
@@ -0,0 +1,77 @@
+package shortlink
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// RuleConditions is the match part of a redirect rule. Every condition that
+// is set must match; a list matches when any of its entries does.
+type RuleConditions struct {
+	Countries     []string        `json:"countries,omitempty"`      // ISO 3166-1 alpha-2 codes from the geo lookup
+	Devices       []string        `json:"devices,omitempty"`        // Desktop, Mobile, iPad, Bot or API
+	OS            []string        `json:"os,omitempty"`             // Prefix of the detected OS, e.g. "Windows" or "iOS"
+	Languages     []string        `json:"languages,omitempty"`      // Visitor's preferred Accept-Language tag, e.g. "id" or "pt-BR"
+	ReferrerHosts []string        `json:"referrer_hosts,omitempty"` // Referrer host, subdomains included
+	Query         *RuleQuery      `json:"query,omitempty"`
+	TimeWindow    *RuleTimeWindow `json:"time_window,omitempty"`
+}
+
+// RuleQuery matches a query parameter of the short URL. An empty Value only
+// requires the parameter to be present.
+type RuleQuery struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+// RuleTimeWindow matches clicks within an absolute range and/or a recurring
+// daily window. StartTime and EndTime are HH:MM in Timezone (UTC when empty);
+// a window whose end is before its start runs past midnight. Days are
+// weekdays, 0 for Sunday through 6 for Saturday.
+type RuleTimeWindow struct {
+	From      *time.Time `json:"from,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+	Days      []int      `json:"days,omitempty"`
+	StartTime string     `json:"start_time,omitempty"`
+	EndTime   string     `json:"end_time,omitempty"`
+	Timezone  string     `json:"timezone,omitempty"`
+}
+
+// Value implements the driver.Valuer interface for database storage
+func (c RuleConditions) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (c *RuleConditions) Scan(value interface{}) error {
+	*c = RuleConditions{}
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return nil
+	}
+}
+
+// RedirectRule sends visitors matching Conditions to DestinationURL instead
+// of the link's OriginalURL. Rules are evaluated in Position order and the
+// first active match wins.
+type RedirectRule struct {
+	ID             string         `json:"id" gorm:"primaryKey"`
+	ShortLinkID    string         `json:"short_link_id" gorm:"size:191;not null;index"`
+	Position       int            `json:"position" gorm:"not null;default:0"`
+	Name           string         `json:"name" gorm:"size:100"`
+	Conditions     RuleConditions `json:"conditions" gorm:"type:json"`
+	DestinationURL string         `json:"destination_url" gorm:"type:text;not null"`
+	IsActive       bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (RedirectRule) TableName() string {
+	return "redirect_rules"
+}
@@ -0,0 +1,24 @@
+package shortlink
+
+import "time"
+
+// Kinds of reserved code entries
+const (
+	// ReservedCodeKindReserved blocks the word as a whole code
+	ReservedCodeKindReserved = "reserved"
+	// ReservedCodeKindBlocked blocks every code containing the word
+	ReservedCodeKindBlocked = "blocked"
+)
+
+// ReservedCode is a word short codes may not use, managed by admins.
+type ReservedCode struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Word      string    `json:"word" gorm:"size:100;not null;uniqueIndex:idx_reserved_codes_word_kind,priority:1"`
+	Kind      string    `json:"kind" gorm:"size:16;not null;uniqueIndex:idx_reserved_codes_word_kind,priority:2"`
+	CreatedBy *string   `json:"created_by,omitempty" gorm:"size:191"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ReservedCode) TableName() string {
+	return "reserved_codes"
+}
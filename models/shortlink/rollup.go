@@ -0,0 +1,97 @@
+package shortlink
+
+import "time"
+
+// Rollup dimensions stored in the click_rollup_dimensions_* tables
+const (
+	RollupDimensionCountry  = "country"
+	RollupDimensionReferrer = "referrer"
+	RollupDimensionDevice   = "device"
+	RollupDimensionBrowser  = "browser"
+	RollupDimensionOS       = "os"
+	// RollupDimensionBot counts bot clicks by bot name; every other rollup
+	// only covers human clicks.
+	RollupDimensionBot = "bot"
+	// RollupDimensionRule counts human clicks by the redirect rule that
+	// matched; the default destination is stored as "default".
+	RollupDimensionRule = "rule"
+	// RollupDimensionVariant counts human clicks by the A/B variant served.
+	RollupDimensionVariant = "variant"
+	// RollupDimensionRevision counts human clicks by the link revision in
+	// effect; clicks before the link's first change are stored as "initial".
+	RollupDimensionRevision = "revision"
+	// RollupDimensionSource counts human clicks by how the visitor reached
+	// the link; ordinary clicks are stored as "link".
+	RollupDimensionSource = "source"
+)
+
+// ClickRollup holds pre-aggregated click counts for one short link and one
+// time bucket. VisitorSketch is a HyperLogLog sketch of visitor IPs so unique
+// visitors can be merged across buckets without the raw rows.
+type ClickRollup struct {
+	ShortLinkID    string    `json:"short_link_id" gorm:"size:191;primaryKey"`
+	BucketStart    time.Time `json:"bucket_start" gorm:"primaryKey;index"`
+	Clicks         int64     `json:"clicks" gorm:"not null;default:0"`
+	UniqueVisitors int64     `json:"unique_visitors" gorm:"not null;default:0"`
+	VisitorSketch  []byte    `json:"-" gorm:"type:blob"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ClickRollupHourly is the per-hour rollup of view_link_details
+type ClickRollupHourly struct {
+	ClickRollup `gorm:"embedded"`
+}
+
+func (ClickRollupHourly) TableName() string {
+	return "click_rollups_hourly"
+}
+
+// ClickRollupDaily is the per-day rollup, rebuilt from the hourly rows
+type ClickRollupDaily struct {
+	ClickRollup `gorm:"embedded"`
+}
+
+func (ClickRollupDaily) TableName() string {
+	return "click_rollups_daily"
+}
+
+// ClickRollupDimension holds the click count of one dimension value
+// (country, referrer host, device, browser, os) within a bucket.
+type ClickRollupDimension struct {
+	ShortLinkID string    `json:"short_link_id" gorm:"size:191;primaryKey"`
+	BucketStart time.Time `json:"bucket_start" gorm:"primaryKey;index"`
+	Dimension   string    `json:"dimension" gorm:"size:20;primaryKey"`
+	Value       string    `json:"value" gorm:"size:191;primaryKey"`
+	Clicks      int64     `json:"clicks" gorm:"not null;default:0"`
+}
+
+type ClickRollupDimensionHourly struct {
+	ClickRollupDimension `gorm:"embedded"`
+}
+
+func (ClickRollupDimensionHourly) TableName() string {
+	return "click_rollup_dimensions_hourly"
+}
+
+type ClickRollupDimensionDaily struct {
+	ClickRollupDimension `gorm:"embedded"`
+}
+
+func (ClickRollupDimensionDaily) TableName() string {
+	return "click_rollup_dimensions_daily"
+}
+
+// ClickRollupState stores how far view_link_details has been rolled up.
+// Everything before RolledUntil is served from the rollup tables.
+// LateCheckedUntil is how far rows inserted after their hour was rolled up
+// have been looked for and rolled up again, by their created_at.
+type ClickRollupState struct {
+	Name             string    `json:"name" gorm:"size:50;primaryKey"`
+	RolledUntil      time.Time `json:"rolled_until"`
+	LateCheckedUntil time.Time `json:"late_checked_until"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (ClickRollupState) TableName() string {
+	return "click_rollup_states"
+}
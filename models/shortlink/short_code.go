@@ -0,0 +1,26 @@
+package shortlink
+
+import "time"
+
+// ShortCodeSequence is a counter the sqids code strategy encodes into codes.
+type ShortCodeSequence struct {
+	Name  string `json:"name" gorm:"size:64;primaryKey"`
+	Value uint64 `json:"value" gorm:"not null;default:0"`
+}
+
+func (ShortCodeSequence) TableName() string {
+	return "short_code_sequences"
+}
+
+// ShortCodeSettings holds a user's preferences for generated codes. Prefix
+// is put in front of every code generated for the user's links; custom
+// codes are left alone.
+type ShortCodeSettings struct {
+	UserID    string    `json:"user_id" gorm:"size:191;primaryKey"`
+	Prefix    string    `json:"prefix" gorm:"size:20;not null;default:''"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (ShortCodeSettings) TableName() string {
+	return "short_code_settings"
+}
@@ -6,24 +6,61 @@ import (
 	"gorm.io/gorm"
 )
 
-// ShortLink represents the main short link entity
+// ShortLink represents the main short link entity. Domain is the custom
+// domain the code lives on, empty for the main host; codes are unique per
+// domain.
 type ShortLink struct {
 	ID          string         `json:"id" gorm:"primaryKey"`                    // Changed to string for consistency
 	UserID      *string        `json:"user_id,omitempty" gorm:"size:191;index"` // Foreign key to users table (nullable for optional auth)
-	ShortCode   string         `json:"short_code" gorm:"uniqueIndex;size:100;not null"`
+	Domain      string         `json:"domain,omitempty" gorm:"size:191;not null;default:'';uniqueIndex:idx_short_links_domain_code,priority:1"`
+	ShortCode   string         `json:"short_code" gorm:"uniqueIndex:idx_short_links_domain_code,priority:2;index:idx_short_links_code;size:100;not null"`
 	OriginalURL string         `json:"original_url" gorm:"type:text;not null"`
 	Title       string         `json:"title,omitempty" gorm:"size:255"`
 	Description string         `json:"description,omitempty" gorm:"type:text"`
+	FaviconURL  string         `json:"favicon_url,omitempty" gorm:"type:text"` // Fetched from the destination page
 	IsActive    bool           `json:"is_active" gorm:"default:true;index"`
 	ExpiresAt   *time.Time     `json:"expires_at" gorm:"index"`
 	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
+	// Social card tags served to link preview crawlers instead of the redirect
+	OGTitle       string `json:"og_title,omitempty" gorm:"size:255"`
+	OGDescription string `json:"og_description,omitempty" gorm:"size:500"`
+	OGImageURL    string `json:"og_image_url,omitempty" gorm:"type:text"`
+	TwitterCard   string `json:"twitter_card,omitempty" gorm:"size:32"` // summary or summary_large_image; empty picks one from OGImageURL
+
+	// Deep links mobile visitors are sent to instead of OriginalURL: a
+	// universal/app link or a custom scheme URL per platform
+	IOSDeepLink         string `json:"ios_deep_link,omitempty" gorm:"type:text"`
+	AndroidDeepLink     string `json:"android_deep_link,omitempty" gorm:"type:text"`
+	DeepLinkFallbackURL string `json:"deep_link_fallback_url,omitempty" gorm:"type:text"` // Where a custom scheme falls back to without the app; empty uses the destination
+
+	// Collection the link is filed in; nil when it is in none
+	CollectionID *string `json:"collection_id,omitempty" gorm:"size:191;index"`
+
+	// Storage key of the logo drawn in the centre of the link's QR code
+	QRLogoKey string `json:"qr_logo_key,omitempty" gorm:"size:255"`
+
+	// Revision in effect, recorded on every click; empty until the link's
+	// first change is recorded
+	RevisionID string `json:"revision_id,omitempty" gorm:"size:191"`
+
 	// Relationships - Note: User tidak di-include untuk menghindari circular import
 	// Gunakan service layer untuk populate user data jika diperlukan
 	Detail *ShortLinkDetail `json:"detail,omitempty" gorm:"foreignKey:ShortLinkID;constraint:OnDelete:CASCADE"`
 	Views  []ViewLinkDetail `json:"views,omitempty" gorm:"foreignKey:ShortLinkID;constraint:OnDelete:CASCADE"`
+	Tags   []LinkTag        `json:"tags,omitempty" gorm:"many2many:short_link_tags;constraint:OnDelete:CASCADE"`
+}
+
+// HasSocialCard reports whether crawlers get the link's social card
+func (l ShortLink) HasSocialCard() bool {
+	return l.OGTitle != "" || l.OGDescription != "" || l.OGImageURL != ""
+}
+
+// HasDeepLinks reports whether the link opens an app on any platform
+func (l ShortLink) HasDeepLinks() bool {
+	return l.IOSDeepLink != "" || l.AndroidDeepLink != ""
 }
 
 // TableName specifies the table name for GORM
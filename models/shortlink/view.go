@@ -6,27 +6,49 @@ import (
 	"gorm.io/gorm"
 )
 
-// ViewLinkDetail tracks individual clicks/views of short links
+// ViewLinkDetail tracks individual clicks/views of short links. RuleID is the
+// redirect rule that picked the destination, empty for the default URL,
+// VariantID the A/B variant that was served and Source how the visitor reached
+// the link, empty for an ordinary click.
 type ViewLinkDetail struct {
-	ID          string         `json:"id" gorm:"primaryKey"`                         // Changed to string for consistency
-	ShortLinkID string         `json:"short_link_id" gorm:"size:191;not null;index"` // Foreign key, changed to string
-	IPAddress   string         `json:"ip_address" gorm:"size:45;index"`              // IPv4/IPv6
-	UserAgent   string         `json:"user_agent" gorm:"type:text"`
-	Referer     string         `json:"referer" gorm:"size:500"`
-	Country     string         `json:"country" gorm:"size:100"`
-	City        string         `json:"city" gorm:"size:100"`
-	Device      string         `json:"device" gorm:"size:100"`
-	Browser     string         `json:"browser" gorm:"size:100"`
-	OS          string         `json:"os" gorm:"size:100"`
-	ClickedAt   time.Time      `json:"clicked_at" gorm:"index"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID             string         `json:"id" gorm:"primaryKey"`                         // Changed to string for consistency
+	ShortLinkID    string         `json:"short_link_id" gorm:"size:191;not null;index"` // Foreign key, changed to string
+	IPAddress      string         `json:"ip_address" gorm:"size:45;index"`              // IPv4/IPv6, truncated or empty under privacy modes
+	VisitorHash    string         `json:"visitor_hash,omitempty" gorm:"size:64;index"`  // Salted daily hash under the "hash" privacy mode
+	UserAgent      string         `json:"user_agent" gorm:"type:text"`
+	Referer        string         `json:"referer" gorm:"size:500"`
+	Country        string         `json:"country" gorm:"size:100"`
+	CountryCode    string         `json:"country_code" gorm:"size:2"`
+	Region         string         `json:"region" gorm:"size:100"`
+	City           string         `json:"city" gorm:"size:100"`
+	Latitude       *float64       `json:"latitude,omitempty"`
+	Longitude      *float64       `json:"longitude,omitempty"`
+	ASN            uint           `json:"asn,omitempty" gorm:"column:asn;index"`
+	ASOrganization string         `json:"as_organization,omitempty" gorm:"size:255"`
+	Device         string         `json:"device" gorm:"size:100"`
+	Browser        string         `json:"browser" gorm:"size:100"`
+	OS             string         `json:"os" gorm:"size:100"`
+	IsBot          bool           `json:"is_bot" gorm:"default:false;index"`
+	BotName        string         `json:"bot_name,omitempty" gorm:"size:100"`
+	RuleID         string         `json:"rule_id,omitempty" gorm:"size:191;index"`
+	VariantID      string         `json:"variant_id,omitempty" gorm:"size:191;index"`
+	Source         string         `json:"source,omitempty" gorm:"size:20;index"`
+	ClickedAt      time.Time      `json:"clicked_at" gorm:"index"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"index"` // Insert time; later than clicked_at for queued or replayed clicks
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
 	// Relationships
 	ShortLink ShortLink `json:"short_link,omitempty" gorm:"foreignKey:ShortLinkID;references:ID"`
+
+	// Revision of the link in effect when the click was made; empty for
+	// clicks before its first change
+	RevisionID string `json:"revision_id,omitempty" gorm:"size:191;index"`
 }
 
+// ClickSourceQR is the Source of a click made by scanning the link's QR code
+const ClickSourceQR = "qr"
+
 // TableName specifies the table name for GORM
 func (ViewLinkDetail) TableName() string {
 	return "view_link_details"
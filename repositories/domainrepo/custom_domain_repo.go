@@ -0,0 +1,226 @@
+package domainrepo
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/deeplink"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/domains"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CustomDomainRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomDomainRepository(db *gorm.DB) *CustomDomainRepository {
+	return &CustomDomainRepository{db: db}
+}
+
+func customDomainNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperrors.ErrCustomDomainNotFound
+	}
+	return apperrors.ErrCustomDomainFindFailed.WithError(err)
+}
+
+// ValidFallbackURL reports whether raw is an absolute http(s) URL. An empty
+// value is valid and means "no fallback".
+func ValidFallbackURL(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// Create claims domain for userID. The claim stays pending until verified.
+func (r *CustomDomainRepository) Create(userID, rawDomain, method, fallbackURL string) (*shortlink.CustomDomain, error) {
+	name, err := domains.Normalize(rawDomain)
+	if err != nil {
+		return nil, apperrors.ErrCustomDomainInvalid
+	}
+	if domains.IsPrimaryHost(name, domains.PrimaryHosts()) {
+		return nil, apperrors.ErrCustomDomainReserved
+	}
+	if !ValidFallbackURL(fallbackURL) {
+		return nil, apperrors.ErrCustomDomainInvalidFallback
+	}
+	if method == "" {
+		method = shortlink.DomainVerificationDNS
+	}
+
+	var existing int64
+	if err := r.db.Model(&shortlink.CustomDomain{}).Where("domain = ?", name).Count(&existing).Error; err != nil {
+		return nil, apperrors.ErrCustomDomainFindFailed.WithError(err)
+	}
+	if existing > 0 {
+		return nil, apperrors.ErrCustomDomainExists
+	}
+
+	token, err := domains.NewToken()
+	if err != nil {
+		return nil, apperrors.ErrCustomDomainCreateFailed.WithError(err)
+	}
+
+	domain := shortlink.CustomDomain{
+		ID:                 uuid.New().String(),
+		UserID:             userID,
+		Domain:             name,
+		VerificationMethod: method,
+		VerificationToken:  token,
+		Status:             shortlink.DomainStatusPending,
+		FallbackURL:        fallbackURL,
+	}
+	if err := r.db.Create(&domain).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, apperrors.ErrCustomDomainExists
+		}
+		return nil, apperrors.ErrCustomDomainCreateFailed.WithError(err)
+	}
+
+	// The host may have been cached as unknown
+	r.invalidate(name)
+
+	logger.Logger.Info("Custom domain claimed",
+		"domain", name,
+		"user_id", userID,
+		"method", method,
+	)
+	return &domain, nil
+}
+
+// ListByUser returns the user's domains, newest first.
+func (r *CustomDomainRepository) ListByUser(userID string) ([]shortlink.CustomDomain, error) {
+	var list []shortlink.CustomDomain
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&list).Error; err != nil {
+		return nil, apperrors.ErrCustomDomainFindFailed.WithError(err)
+	}
+	return list, nil
+}
+
+// GetByID returns a domain owned by userID; admins may read any domain.
+func (r *CustomDomainRepository) GetByID(id, userID, userRole string) (*shortlink.CustomDomain, error) {
+	q := r.db.Where("id = ?", id)
+	if userRole != "admin" {
+		q = q.Where("user_id = ?", userID)
+	}
+
+	var domain shortlink.CustomDomain
+	if err := q.First(&domain).Error; err != nil {
+		return nil, customDomainNotFound(err)
+	}
+	return &domain, nil
+}
+
+// UpdateFallback sets or clears the URL unknown codes on the domain redirect to.
+func (r *CustomDomainRepository) UpdateFallback(id, userID, userRole, fallbackURL string) (*shortlink.CustomDomain, error) {
+	fallbackURL = strings.TrimSpace(fallbackURL)
+	if !ValidFallbackURL(fallbackURL) {
+		return nil, apperrors.ErrCustomDomainInvalidFallback
+	}
+
+	domain, err := r.GetByID(id, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Model(domain).Update("fallback_url", fallbackURL).Error; err != nil {
+		return nil, apperrors.ErrCustomDomainUpdateFailed.WithError(err)
+	}
+	domain.FallbackURL = fallbackURL
+
+	r.invalidate(domain.Domain)
+	return domain, nil
+}
+
+// UpdateAppLinks sets the iOS apps and the Android app allowed to open the
+// domain's links. An Android package needs at least one signing certificate
+// fingerprint.
+func (r *CustomDomainRepository) UpdateAppLinks(id, userID, userRole string, in *dto.UpdateDomainAppLinksRequest) (*shortlink.CustomDomain, error) {
+	appIDs := make([]string, 0, len(in.IOSAppIDs))
+	for _, appID := range in.IOSAppIDs {
+		appID = strings.TrimSpace(appID)
+		if !deeplink.ValidIOSAppID(appID) {
+			return nil, apperrors.ErrCustomDomainInvalidAppLinks
+		}
+		appIDs = append(appIDs, appID)
+	}
+
+	packageName := strings.TrimSpace(in.AndroidPackage)
+	fingerprints := make([]string, 0, len(in.AndroidCertFingerprints))
+	for _, fingerprint := range in.AndroidCertFingerprints {
+		fingerprint, ok := deeplink.NormalizeCertFingerprint(fingerprint)
+		if !ok {
+			return nil, apperrors.ErrCustomDomainInvalidAppLinks
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	if packageName != "" && (!deeplink.ValidAndroidPackage(packageName) || len(fingerprints) == 0) {
+		return nil, apperrors.ErrCustomDomainInvalidAppLinks
+	}
+	if packageName == "" {
+		fingerprints = fingerprints[:0]
+	}
+
+	domain, err := r.GetByID(id, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	domain.IOSAppIDs = strings.Join(appIDs, ",")
+	domain.AndroidPackage = packageName
+	domain.AndroidCertFingerprints = strings.Join(fingerprints, ",")
+	if err := r.db.Model(domain).Updates(map[string]any{
+		"ios_app_ids":               domain.IOSAppIDs,
+		"android_package":           domain.AndroidPackage,
+		"android_cert_fingerprints": domain.AndroidCertFingerprints,
+	}).Error; err != nil {
+		return nil, apperrors.ErrCustomDomainUpdateFailed.WithError(err)
+	}
+
+	r.invalidate(domain.Domain)
+	return domain, nil
+}
+
+// Delete releases a domain. Domains that still carry short links, including
+// soft-deleted ones, cannot be released so their codes can never be picked up
+// by whoever claims the domain next.
+func (r *CustomDomainRepository) Delete(id, userID, userRole string) error {
+	domain, err := r.GetByID(id, userID, userRole)
+	if err != nil {
+		return err
+	}
+
+	var links int64
+	if err := r.db.Unscoped().Model(&shortlink.ShortLink{}).Where("domain = ?", domain.Domain).Count(&links).Error; err != nil {
+		return apperrors.ErrCustomDomainFindFailed.WithError(err)
+	}
+	if links > 0 {
+		return apperrors.ErrCustomDomainInUse
+	}
+
+	if err := r.db.Delete(domain).Error; err != nil {
+		return apperrors.ErrCustomDomainDeleteFailed.WithError(err)
+	}
+
+	r.invalidate(domain.Domain)
+
+	logger.Logger.Info("Custom domain released",
+		"domain", domain.Domain,
+		"user_id", userID,
+	)
+	return nil
+}
+
+func (r *CustomDomainRepository) invalidate(domain string) {
+	if resolver := domains.Global(); resolver != nil {
+		resolver.Invalidate(domain)
+	}
+}
@@ -0,0 +1,430 @@
+package shortlink
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// byLinkFilter narrows a short_links query to the links carrying the
+// filter's tag and filed in its collection.
+func byLinkFilter(filter dto.ShortLinkFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.CollectionID != "" {
+			db = db.Where("short_links.collection_id = ?", filter.CollectionID)
+		}
+		if filter.TagID != "" {
+			tagged := db.Session(&gorm.Session{NewDB: true}).
+				Table("short_link_tags").
+				Select("short_link_id").
+				Where("link_tag_id = ?", filter.TagID)
+			db = db.Where("short_links.id IN (?)", tagged)
+		}
+		return db
+	}
+}
+
+// orderTagsByName preloads a link's tags alphabetically.
+func orderTagsByName(db *gorm.DB) *gorm.DB {
+	return db.Order("link_tags.name ASC")
+}
+
+// ownerTags returns the tags with ids, which must all belong to ownerID.
+func ownerTags(db *gorm.DB, ownerID string, ids []string) ([]shortlink.LinkTag, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if ownerID == "" {
+		return nil, apperrors.ErrLinkTagNotFound
+	}
+
+	var tags []shortlink.LinkTag
+	if err := db.Where("id IN ? AND user_id = ?", ids, ownerID).Order("name ASC").Find(&tags).Error; err != nil {
+		return nil, apperrors.ErrLinkTagFailed.WithError(err)
+	}
+	if len(tags) != len(ids) {
+		return nil, apperrors.ErrLinkTagNotFound
+	}
+	return tags, nil
+}
+
+// ownerCollectionID returns the collection_id a link of ownerID is filed
+// under: nil for an empty id, else id once it is known to be theirs.
+func ownerCollectionID(db *gorm.DB, ownerID, id string) (*string, error) {
+	if id == "" {
+		return nil, nil
+	}
+	if ownerID == "" {
+		return nil, apperrors.ErrCollectionNotFound
+	}
+
+	var count int64
+	if err := db.Model(&shortlink.Collection{}).Where("id = ? AND user_id = ?", id, ownerID).Count(&count).Error; err != nil {
+		return nil, apperrors.ErrCollectionFailed.WithError(err)
+	}
+	if count == 0 {
+		return nil, apperrors.ErrCollectionNotFound
+	}
+	return &id, nil
+}
+
+// ListLinkTags returns the user's tags by name with the number of links
+// carrying each.
+func (r *ShortLinkRepository) ListLinkTags(userID string) ([]dto.LinkTagResponse, error) {
+	var tags []shortlink.LinkTag
+	if err := r.db.Where("user_id = ?", userID).Order("name ASC").Find(&tags).Error; err != nil {
+		return nil, apperrors.ErrLinkTagFailed.WithError(err)
+	}
+
+	ids := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		ids = append(ids, tag.ID)
+	}
+	counts, err := r.tagLinkCounts(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.LinkTagResponse, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, toLinkTagResponse(tag, counts[tag.ID]))
+	}
+	return items, nil
+}
+
+// CreateLinkTag adds a tag for the user.
+func (r *ShortLinkRepository) CreateLinkTag(userID string, req *dto.LinkTagRequest) (*dto.LinkTagResponse, error) {
+	tag := shortlink.LinkTag{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		Name:   strings.TrimSpace(req.Name),
+		Color:  strings.ToLower(req.Color),
+	}
+	if err := r.checkLinkTagName(userID, tag.Name, ""); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Create(&tag).Error; err != nil {
+		if isDuplicateKey(err) {
+			return nil, apperrors.ErrLinkTagExists
+		}
+		logger.Logger.Error("Failed to create link tag",
+			"user_id", userID,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrLinkTagFailed.WithError(err)
+	}
+
+	response := toLinkTagResponse(tag, 0)
+	return &response, nil
+}
+
+// UpdateLinkTag renames a tag and sets its color. The links carrying it keep
+// it.
+func (r *ShortLinkRepository) UpdateLinkTag(tagID, userID string, req *dto.LinkTagRequest) (*dto.LinkTagResponse, error) {
+	var tag shortlink.LinkTag
+	if err := r.db.Where("id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrLinkTagNotFound
+		}
+		return nil, apperrors.ErrLinkTagFailed.WithError(err)
+	}
+
+	tag.Name = strings.TrimSpace(req.Name)
+	tag.Color = strings.ToLower(req.Color)
+	if err := r.checkLinkTagName(userID, tag.Name, tag.ID); err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&tag).Select("name", "color", "updated_at").Updates(&tag).Error; err != nil {
+		if isDuplicateKey(err) {
+			return nil, apperrors.ErrLinkTagExists
+		}
+		logger.Logger.Error("Failed to update link tag",
+			"tag_id", tagID,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrLinkTagFailed.WithError(err)
+	}
+
+	counts, err := r.tagLinkCounts([]string{tag.ID})
+	if err != nil {
+		return nil, err
+	}
+	response := toLinkTagResponse(tag, counts[tag.ID])
+	return &response, nil
+}
+
+// DeleteLinkTag removes a tag from the user's links, recording a revision of
+// each, and deletes it.
+func (r *ShortLinkRepository) DeleteLinkTag(tagID string, actor RevisionActor) error {
+	userID := actor.UserID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&shortlink.LinkTag{}).Where("id = ? AND user_id = ?", tagID, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return apperrors.ErrLinkTagNotFound
+		}
+
+		var linkIDs []string
+		if err := tx.Table("short_link_tags").Where("link_tag_id = ?", tagID).Pluck("short_link_id", &linkIDs).Error; err != nil {
+			return err
+		}
+		if err := recordLinkRevisions(tx, linkIDs, actor, func() error {
+			return tx.Exec("DELETE FROM short_link_tags WHERE link_tag_id = ?", tagID).Error
+		}); err != nil {
+			return err
+		}
+		return tx.Delete(&shortlink.LinkTag{}, "id = ?", tagID).Error
+	})
+	if errors.Is(err, apperrors.ErrLinkTagNotFound) {
+		return err
+	}
+	if err != nil {
+		logger.Logger.Error("Failed to delete link tag",
+			"tag_id", tagID,
+			"error", err.Error(),
+		)
+		return apperrors.ErrLinkTagFailed.WithError(err)
+	}
+
+	logger.Logger.Info("Link tag deleted",
+		"tag_id", tagID,
+		"user_id", userID,
+	)
+	return nil
+}
+
+func (r *ShortLinkRepository) checkLinkTagName(userID, name, exceptID string) error {
+	var count int64
+	if err := r.db.Model(&shortlink.LinkTag{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).
+		Count(&count).Error; err != nil {
+		return apperrors.ErrLinkTagFailed.WithError(err)
+	}
+	if count > 0 {
+		return apperrors.ErrLinkTagExists
+	}
+	return nil
+}
+
+// tagLinkCounts returns how many live links carry each of the tags.
+func (r *ShortLinkRepository) tagLinkCounts(tagIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(tagIDs))
+	if len(tagIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		LinkTagID string
+		Count     int64
+	}
+	if err := r.db.Table("short_link_tags").
+		Select("short_link_tags.link_tag_id, COUNT(*) AS count").
+		Joins("JOIN short_links ON short_links.id = short_link_tags.short_link_id AND short_links.deleted_at IS NULL").
+		Where("short_link_tags.link_tag_id IN ?", tagIDs).
+		Group("short_link_tags.link_tag_id").
+		Scan(&rows).Error; err != nil {
+		return nil, apperrors.ErrLinkTagFailed.WithError(err)
+	}
+	for _, row := range rows {
+		counts[row.LinkTagID] = row.Count
+	}
+	return counts, nil
+}
+
+// ListCollections returns the user's collections by name with the number of
+// links in each.
+func (r *ShortLinkRepository) ListCollections(userID string) ([]dto.CollectionResponse, error) {
+	var collections []shortlink.Collection
+	if err := r.db.Where("user_id = ?", userID).Order("name ASC").Find(&collections).Error; err != nil {
+		return nil, apperrors.ErrCollectionFailed.WithError(err)
+	}
+
+	ids := make([]string, 0, len(collections))
+	for _, collection := range collections {
+		ids = append(ids, collection.ID)
+	}
+	counts, err := r.collectionLinkCounts(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.CollectionResponse, 0, len(collections))
+	for _, collection := range collections {
+		items = append(items, toCollectionResponse(collection, counts[collection.ID]))
+	}
+	return items, nil
+}
+
+// CreateCollection adds an empty collection for the user.
+func (r *ShortLinkRepository) CreateCollection(userID string, req *dto.CollectionRequest) (*dto.CollectionResponse, error) {
+	collection := shortlink.Collection{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+	}
+	if err := r.checkCollectionName(userID, collection.Name, ""); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Create(&collection).Error; err != nil {
+		if isDuplicateKey(err) {
+			return nil, apperrors.ErrCollectionExists
+		}
+		logger.Logger.Error("Failed to create collection",
+			"user_id", userID,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrCollectionFailed.WithError(err)
+	}
+
+	response := toCollectionResponse(collection, 0)
+	return &response, nil
+}
+
+// UpdateCollection replaces a collection's name and description.
+func (r *ShortLinkRepository) UpdateCollection(collectionID, userID string, req *dto.CollectionRequest) (*dto.CollectionResponse, error) {
+	var collection shortlink.Collection
+	if err := r.db.Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrCollectionNotFound
+		}
+		return nil, apperrors.ErrCollectionFailed.WithError(err)
+	}
+
+	collection.Name = strings.TrimSpace(req.Name)
+	collection.Description = strings.TrimSpace(req.Description)
+	if err := r.checkCollectionName(userID, collection.Name, collection.ID); err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&collection).Select("name", "description", "updated_at").Updates(&collection).Error; err != nil {
+		if isDuplicateKey(err) {
+			return nil, apperrors.ErrCollectionExists
+		}
+		logger.Logger.Error("Failed to update collection",
+			"collection_id", collectionID,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrCollectionFailed.WithError(err)
+	}
+
+	counts, err := r.collectionLinkCounts([]string{collection.ID})
+	if err != nil {
+		return nil, err
+	}
+	response := toCollectionResponse(collection, counts[collection.ID])
+	return &response, nil
+}
+
+// DeleteCollection deletes a collection. Its links are kept and end up in no
+// collection, each with a revision recording it.
+func (r *ShortLinkRepository) DeleteCollection(collectionID string, actor RevisionActor) error {
+	userID := actor.UserID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", collectionID, userID).Delete(&shortlink.Collection{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrCollectionNotFound
+		}
+
+		// Deleted links too, so a revived link does not point at a missing collection
+		var linkIDs []string
+		if err := tx.Unscoped().Model(&shortlink.ShortLink{}).
+			Where("collection_id = ?", collectionID).
+			Pluck("id", &linkIDs).Error; err != nil {
+			return err
+		}
+		return recordLinkRevisions(tx, linkIDs, actor, func() error {
+			return tx.Unscoped().Model(&shortlink.ShortLink{}).
+				Where("collection_id = ?", collectionID).
+				Update("collection_id", nil).Error
+		})
+	})
+	if errors.Is(err, apperrors.ErrCollectionNotFound) {
+		return err
+	}
+	if err != nil {
+		logger.Logger.Error("Failed to delete collection",
+			"collection_id", collectionID,
+			"error", err.Error(),
+		)
+		return apperrors.ErrCollectionFailed.WithError(err)
+	}
+
+	logger.Logger.Info("Collection deleted",
+		"collection_id", collectionID,
+		"user_id", userID,
+	)
+	return nil
+}
+
+func (r *ShortLinkRepository) checkCollectionName(userID, name, exceptID string) error {
+	var count int64
+	if err := r.db.Model(&shortlink.Collection{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).
+		Count(&count).Error; err != nil {
+		return apperrors.ErrCollectionFailed.WithError(err)
+	}
+	if count > 0 {
+		return apperrors.ErrCollectionExists
+	}
+	return nil
+}
+
+// collectionLinkCounts returns how many live links are in each of the
+// collections.
+func (r *ShortLinkRepository) collectionLinkCounts(collectionIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(collectionIDs))
+	if len(collectionIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		CollectionID string
+		Count        int64
+	}
+	if err := r.db.Model(&shortlink.ShortLink{}).
+		Select("collection_id, COUNT(*) AS count").
+		Where("collection_id IN ?", collectionIDs).
+		Group("collection_id").
+		Scan(&rows).Error; err != nil {
+		return nil, apperrors.ErrCollectionFailed.WithError(err)
+	}
+	for _, row := range rows {
+		counts[row.CollectionID] = row.Count
+	}
+	return counts, nil
+}
+
+func toLinkTagResponse(tag shortlink.LinkTag, linkCount int64) dto.LinkTagResponse {
+	return dto.LinkTagResponse{
+		ID:        tag.ID,
+		Name:      tag.Name,
+		Color:     tag.Color,
+		LinkCount: &linkCount,
+		CreatedAt: tag.CreatedAt,
+		UpdatedAt: tag.UpdatedAt,
+	}
+}
+
+func toCollectionResponse(collection shortlink.Collection, linkCount int64) dto.CollectionResponse {
+	return dto.CollectionResponse{
+		ID:          collection.ID,
+		Name:        collection.Name,
+		Description: collection.Description,
+		LinkCount:   linkCount,
+		CreatedAt:   collection.CreatedAt,
+		UpdatedAt:   collection.UpdatedAt,
+	}
+}
@@ -0,0 +1,94 @@
+package shortlink
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// dryRunDB builds SQL without connecting to MySQL
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/lihatin",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return db
+}
+
+func TestByLinkFilter(t *testing.T) {
+	t.Parallel()
+
+	db := dryRunDB(t)
+	tests := []struct {
+		name   string
+		filter dto.ShortLinkFilter
+		want   []string
+		absent []string
+	}{
+		{
+			name:   "no filter",
+			absent: []string{"collection_id", "short_link_tags"},
+		},
+		{
+			name:   "collection",
+			filter: dto.ShortLinkFilter{CollectionID: "c1"},
+			want:   []string{"short_links.collection_id = ?"},
+			absent: []string{"short_link_tags"},
+		},
+		{
+			name:   "tag and collection",
+			filter: dto.ShortLinkFilter{TagID: "t1", CollectionID: "c1"},
+			want: []string{
+				"short_links.collection_id = ?",
+				"short_links.id IN (SELECT short_link_id FROM `short_link_tags` WHERE link_tag_id = ?)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var links []shortlink.ShortLink
+			stmt := db.Where("user_id = ?", "u1").Scopes(byLinkFilter(tt.filter)).Find(&links).Statement
+			sql := stmt.SQL.String()
+			for _, want := range tt.want {
+				if !strings.Contains(sql, want) {
+					t.Errorf("SQL %q does not contain %q", sql, want)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(sql, absent) {
+					t.Errorf("SQL %q contains %q", sql, absent)
+				}
+			}
+		})
+	}
+}
+
+func TestOwnerTagsAndCollectionWithoutOwner(t *testing.T) {
+	t.Parallel()
+
+	db := dryRunDB(t)
+	if tags, err := ownerTags(db, "", nil); tags != nil || err != nil {
+		t.Errorf("ownerTags(no ids) = %v, %v; want nil, nil", tags, err)
+	}
+	if _, err := ownerTags(db, "", []string{"t1"}); !errors.Is(err, apperrors.ErrLinkTagNotFound) {
+		t.Errorf("ownerTags(anonymous) error = %v, want ErrLinkTagNotFound", err)
+	}
+	if id, err := ownerCollectionID(db, "u1", ""); id != nil || err != nil {
+		t.Errorf("ownerCollectionID(empty) = %v, %v; want nil, nil", id, err)
+	}
+	if _, err := ownerCollectionID(db, "", "c1"); !errors.Is(err, apperrors.ErrCollectionNotFound) {
+		t.Errorf("ownerCollectionID(anonymous) error = %v, want ErrCollectionNotFound", err)
+	}
+}
@@ -0,0 +1,52 @@
+package shortlink
+
+import (
+	"context"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/deeplink"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+// checkDeepLinks rejects targets that cannot open an app and vets the web
+// ones, app links and the fallback, like any other destination.
+func checkDeepLinks(ctx context.Context, links *dto.DeepLinks) error {
+	if links == nil {
+		return nil
+	}
+
+	for _, target := range []string{links.IOS, links.Android} {
+		if !deeplink.ValidTarget(target) {
+			return apperrors.ErrInvalidDeepLink
+		}
+		if target != "" && deeplink.IsAppLink(target) {
+			if err := checkDestination(ctx, target); err != nil {
+				return err
+			}
+		}
+	}
+	if links.FallbackURL != "" {
+		return checkDestination(ctx, links.FallbackURL)
+	}
+	return nil
+}
+
+// applyDeepLinks copies links onto a new link; nil leaves it without any.
+func applyDeepLinks(link *shortlink.ShortLink, links *dto.DeepLinks) {
+	if links == nil {
+		return
+	}
+	link.IOSDeepLink = links.IOS
+	link.AndroidDeepLink = links.Android
+	link.DeepLinkFallbackURL = links.FallbackURL
+}
+
+// deepLinkColumns returns the short_links columns that store links.
+func deepLinkColumns(links *dto.DeepLinks) map[string]any {
+	return map[string]any{
+		"ios_deep_link":          links.IOS,
+		"android_deep_link":      links.Android,
+		"deep_link_fallback_url": links.FallbackURL,
+	}
+}
@@ -0,0 +1,71 @@
+package shortlink
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/domains"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+// codeDomainSeparator joins a short code and the domain qualifying it. Codes
+// can never contain it.
+const codeDomainSeparator = "@"
+
+// QualifiedCode returns code qualified with domain for the management
+// repository methods, which take the result wherever they take a code. An
+// empty domain leaves code unqualified.
+func QualifiedCode(code, domain string) string {
+	domain = strings.TrimSpace(domain)
+	if domain == "" {
+		return code
+	}
+	if name, err := domains.Normalize(domain); err == nil {
+		domain = name
+	}
+	return code + codeDomainSeparator + domain
+}
+
+// byShortCode matches a link by code for the management endpoints. Codes are
+// only unique per domain: a code qualified by QualifiedCode only matches the
+// link on that domain, and otherwise the main-host link wins when the same
+// code also exists on a custom domain.
+func byShortCode(code string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if code, domain, ok := strings.Cut(code, codeDomainSeparator); ok {
+			return db.Where("short_links.short_code = ? AND short_links.domain = ?", code, domain)
+		}
+		return db.Where("short_links.short_code = ?", code).
+			Order("short_links.domain = '' DESC")
+	}
+}
+
+// resolveLinkDomain normalizes the domain a link is created on or moved to.
+// An empty value is the main host; anything else must be a verified custom
+// domain owned by userID.
+func resolveLinkDomain(db *gorm.DB, raw, userID string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return "", nil
+	}
+
+	name, err := domains.Normalize(raw)
+	if err != nil {
+		return "", apperrors.ErrCustomDomainInvalid
+	}
+	if userID == "" {
+		return "", apperrors.ErrCustomDomainNotVerified
+	}
+
+	var domain shortlink.CustomDomain
+	err = db.Where("domain = ? AND user_id = ? AND status = ?", name, userID, shortlink.DomainStatusVerified).
+		First(&domain).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", apperrors.ErrCustomDomainNotVerified
+		}
+		return "", apperrors.ErrCustomDomainFindFailed.WithError(err)
+	}
+	return domain.Domain, nil
+}
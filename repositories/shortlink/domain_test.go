@@ -0,0 +1,62 @@
+package shortlink
+
+import (
+	"strings"
+	"testing"
+
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+func TestByShortCode(t *testing.T) {
+	t.Parallel()
+
+	db := dryRunDB(t)
+	tests := []struct {
+		name     string
+		code     string
+		want     string
+		wantVars []any
+	}{
+		{
+			name:     "unqualified prefers the main host",
+			code:     "promo",
+			want:     "ORDER BY short_links.domain = '' DESC",
+			wantVars: []any{"promo"},
+		},
+		{
+			name:     "qualified by a custom domain",
+			code:     QualifiedCode("promo", " Go.Customer.com "),
+			want:     "short_links.short_code = ? AND short_links.domain = ?",
+			wantVars: []any{"promo", "go.customer.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stmt := db.Scopes(byShortCode(tt.code)).Find(&[]shortlink.ShortLink{}).Statement
+			if sql := stmt.SQL.String(); !strings.Contains(sql, tt.want) {
+				t.Errorf("SQL = %q, want it to contain %q", sql, tt.want)
+			}
+			if len(stmt.Vars) != len(tt.wantVars) {
+				t.Fatalf("Vars = %v, want %v", stmt.Vars, tt.wantVars)
+			}
+			for i, v := range tt.wantVars {
+				if stmt.Vars[i] != v {
+					t.Errorf("Vars[%d] = %v, want %v", i, stmt.Vars[i], v)
+				}
+			}
+		})
+	}
+}
+
+func TestQualifiedCode(t *testing.T) {
+	t.Parallel()
+
+	if got := QualifiedCode("promo", ""); got != "promo" {
+		t.Errorf("QualifiedCode() without a domain = %q, want the code", got)
+	}
+	if got := QualifiedCode("promo", "go.customer.com"); got != "promo@go.customer.com" {
+		t.Errorf("QualifiedCode() = %q", got)
+	}
+}
@@ -0,0 +1,134 @@
+package shortlink
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/interstitial"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+// Interstitial returns the page to show before redirecting code on domain, or
+// nil when the visitor goes straight on. preview always asks for the page;
+// unavailable links then fail with the error a redirect would give, otherwise
+// they are left for RedirectByShortCode to reject. Visitors get past the page
+// with the continueToken it signed; bots, which never click through, skip it
+// unless the link is flagged or forces it.
+func (r *ShortLinkRepository) Interstitial(ctx context.Context, domain, code string, preview bool, continueToken string, isBot bool) (*interstitial.Page, error) {
+	policy := interstitial.Global()
+	snapshot, err := r.loadRedirectSnapshot(ctx, domain, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := snapshotAvailability(snapshot); err != nil {
+		if preview {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	globalEnabled := policy != nil && policy.Enabled()
+	if !interstitial.Required(snapshot.InterstitialMode, snapshot.IsFlagged, preview, globalEnabled) {
+		return nil, nil
+	}
+	if !preview {
+		if policy != nil && continueToken != "" && policy.VerifyContinue(continueToken, snapshot.LinkID) {
+			return nil, nil
+		}
+		if isBot && !snapshot.IsFlagged && snapshot.InterstitialMode != shortlink.InterstitialModeOn {
+			return nil, nil
+		}
+	}
+
+	page := &interstitial.Page{
+		ShortCode:         snapshot.ShortCode,
+		Title:             snapshot.Title,
+		Description:       snapshot.Description,
+		DestinationVaries: len(snapshot.Rules) > 0 || len(snapshot.Variants) > 0,
+		Preview:           preview,
+		Flagged:           snapshot.IsFlagged,
+		FlaggedReason:     snapshot.FlaggedReason,
+		NewLink:           policy != nil && policy.IsNewLink(snapshot.CreatedAt),
+	}
+	if policy != nil {
+		page.ContinueToken = policy.ContinueToken(snapshot.LinkID)
+	}
+	if destination, err := url.Parse(snapshot.OriginalURL); err == nil {
+		page.DestinationHost = destination.Hostname()
+	}
+	// Passcode protected links only reveal the host, as CheckShortCode does
+	if snapshot.PasscodeHash == "" {
+		page.Destination = snapshot.OriginalURL
+	}
+	return page, nil
+}
+
+// snapshotAvailability returns the error a redirect gives for a link that
+// cannot be followed, ignoring its passcode.
+func snapshotAvailability(snapshot *redirectSnapshot) error {
+	switch {
+	case snapshot.NotFound:
+		return apperrors.ErrShortLinkNotFound
+	case !snapshot.IsActive:
+		return apperrors.ErrShortLinkInactive
+	case snapshot.ExpiresAt != nil && snapshot.ExpiresAt.Before(time.Now()):
+		return apperrors.ErrShortLinkExpired
+	case snapshot.IsBanned:
+		return apperrors.ErrLinkIsBanned
+	}
+	return nil
+}
+
+// FlagShortByAdmin forces the interstitial warning on a suspicious link,
+// showing request.Reason to visitors, without banning it.
+func (r *ShortLinkRepository) FlagShortByAdmin(request *dto.FlagRequest, userID string, code *dto.CodeRequest) error {
+	return r.setFlag(code.Code, map[string]any{
+		"is_flagged":     true,
+		"flagged_reason": request.Reason,
+		"flagged_by":     userID,
+	})
+}
+
+// UnflagShortByAdmin lifts a flag set by FlagShortByAdmin.
+func (r *ShortLinkRepository) UnflagShortByAdmin(code string) error {
+	return r.setFlag(code, map[string]any{
+		"is_flagged":     false,
+		"flagged_reason": "",
+		"flagged_by":     nil,
+	})
+}
+
+func (r *ShortLinkRepository) setFlag(code string, updates map[string]any) error {
+	var link shortlink.ShortLink
+	if err := r.db.Scopes(byShortCode(code)).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrShortLinkNotFound
+		}
+
+		logger.Logger.Error("Database error while fetching short link",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return apperrors.ErrShortGetFailed.WithError(err)
+	}
+
+	if err := r.db.Model(&shortlink.ShortLinkDetail{}).
+		Where("short_link_id = ?", link.ID).
+		Updates(updates).Error; err != nil {
+		logger.Logger.Error("Failed to update short link flag",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return apperrors.ErrShortFlagFailed.WithError(err)
+	}
+
+	r.invalidateRedirect(link)
+	return nil
+}
@@ -0,0 +1,603 @@
+package shortlink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/linkfile"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// LinkImportInvalidRowCode is the error code of rows that failed
+	// validation on upload
+	LinkImportInvalidRowCode = "VALIDATION_ERROR"
+	// linkImportStaleAfter hands an import whose worker stopped sending
+	// heartbeats to the next run of the job
+	linkImportStaleAfter = 10 * time.Minute
+	// linkImportInsertBatch is how many rows go into one INSERT on upload
+	linkImportInsertBatch = 500
+	// linkImportListLimit is how many of a user's latest imports are listed
+	linkImportListLimit = 50
+	// linkImportMessageSize matches the error_message column
+	linkImportMessageSize = 1000
+)
+
+// LinkImportRowInput is a row of an uploaded file: the link to create, or
+// why the row cannot be imported.
+type LinkImportRowInput struct {
+	Line    int
+	Payload dto.LinkImportPayload
+	// Invalid is why the row failed validation; such rows are stored failed
+	Invalid string
+}
+
+// LinkImportResult summarizes one ProcessPendingImport run.
+type LinkImportResult struct {
+	ImportID string
+	Created  int
+	Failed   int
+}
+
+// importNames maps the lower-cased names of a user's tags and collections to
+// their IDs, so import rows can name them.
+type importNames struct {
+	tags        map[string]string
+	collections map[string]string
+}
+
+// byClaimableImport narrows link_imports to the imports a worker may take:
+// pending ones, and processing ones whose worker went quiet.
+func byClaimableImport(staleBefore time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? OR (status = ? AND updated_at < ?)",
+			shortlink.LinkImportStatusPending,
+			shortlink.LinkImportStatusProcessing,
+			staleBefore,
+		)
+	}
+}
+
+// CreateLinkImport queues the rows of an uploaded file for the import job.
+// A user may have at most maxOpen imports pending or processing at once. An
+// import whose rows all failed validation is completed straight away.
+func (r *ShortLinkRepository) CreateLinkImport(userID, format, fileName string, inputs []LinkImportRowInput, maxOpen int) (*dto.LinkImportResponse, error) {
+	linkImport := shortlink.LinkImport{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Format:    format,
+		FileName:  fileName,
+		Status:    shortlink.LinkImportStatusPending,
+		TotalRows: len(inputs),
+	}
+
+	rows := make([]shortlink.LinkImportRow, 0, len(inputs))
+	for _, input := range inputs {
+		row := shortlink.LinkImportRow{
+			ID:          uuid.New().String(),
+			ImportID:    linkImport.ID,
+			Line:        input.Line,
+			OriginalURL: input.Payload.Link.OriginalURL,
+			Status:      shortlink.LinkImportRowStatusPending,
+		}
+		if input.Invalid != "" {
+			row.Status = shortlink.LinkImportRowStatusFailed
+			row.ErrorCode = LinkImportInvalidRowCode
+			row.ErrorMessage = truncateMessage(input.Invalid)
+			linkImport.ProcessedRows++
+			linkImport.FailedRows++
+		} else {
+			payload, err := json.Marshal(input.Payload)
+			if err != nil {
+				return nil, apperrors.ErrLinkImportCreateFailed.WithError(err)
+			}
+			row.Payload = string(payload)
+		}
+		rows = append(rows, row)
+	}
+	if linkImport.ProcessedRows == linkImport.TotalRows {
+		now := time.Now()
+		linkImport.Status = shortlink.LinkImportStatusCompleted
+		linkImport.CompletedAt = &now
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var open int64
+		if err := tx.Model(&shortlink.LinkImport{}).
+			Where("user_id = ? AND status IN ?", userID, []string{shortlink.LinkImportStatusPending, shortlink.LinkImportStatusProcessing}).
+			Count(&open).Error; err != nil {
+			return apperrors.ErrLinkImportCreateFailed.WithError(err)
+		}
+		if open >= int64(maxOpen) {
+			return apperrors.ErrLinkImportLimitExceeded
+		}
+
+		if err := tx.Create(&linkImport).Error; err != nil {
+			return apperrors.ErrLinkImportCreateFailed.WithError(err)
+		}
+		if err := tx.CreateInBatches(rows, linkImportInsertBatch).Error; err != nil {
+			return apperrors.ErrLinkImportCreateFailed.WithError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to queue link import",
+			"user_id", userID,
+			"rows", len(inputs),
+			"error", err.Error(),
+		)
+		return nil, err
+	}
+
+	logger.Logger.Info("Link import queued",
+		"import_id", linkImport.ID,
+		"user_id", userID,
+		"rows", linkImport.TotalRows,
+		"invalid_rows", linkImport.FailedRows,
+	)
+
+	response := dto.NewLinkImportResponse(linkImport)
+	return &response, nil
+}
+
+// ListLinkImports returns the user's latest imports, newest first.
+func (r *ShortLinkRepository) ListLinkImports(userID string) ([]dto.LinkImportResponse, error) {
+	var imports []shortlink.LinkImport
+	if err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(linkImportListLimit).
+		Find(&imports).Error; err != nil {
+		return nil, apperrors.ErrLinkImportFindFailed.WithError(err)
+	}
+
+	responses := make([]dto.LinkImportResponse, 0, len(imports))
+	for _, linkImport := range imports {
+		responses = append(responses, dto.NewLinkImportResponse(linkImport))
+	}
+	return responses, nil
+}
+
+// GetLinkImport returns one of the user's imports.
+func (r *ShortLinkRepository) GetLinkImport(importID, userID string) (*dto.LinkImportResponse, error) {
+	linkImport, err := r.ownedLinkImport(importID, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := dto.NewLinkImportResponse(*linkImport)
+	return &response, nil
+}
+
+// ListLinkImportRows returns a page of an import's rows by line, narrowed to
+// one status when req asks for it.
+func (r *ShortLinkRepository) ListLinkImportRows(importID, userID string, req dto.LinkImportRowsRequest) (*dto.PaginatedLinkImportRowsResponse, error) {
+	if _, err := r.ownedLinkImport(importID, userID); err != nil {
+		return nil, err
+	}
+
+	page, limit := req.Page, req.Limit
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 50
+	}
+
+	query := r.db.Model(&shortlink.LinkImportRow{}).Where("import_id = ?", importID)
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, apperrors.ErrLinkImportFindFailed.WithError(err)
+	}
+
+	var rows []shortlink.LinkImportRow
+	if err := query.Order("line ASC").Offset((page - 1) * limit).Limit(limit).Find(&rows).Error; err != nil {
+		return nil, apperrors.ErrLinkImportFindFailed.WithError(err)
+	}
+
+	responses := make([]dto.LinkImportRowResponse, 0, len(rows))
+	for _, row := range rows {
+		responses = append(responses, toLinkImportRowResponse(row))
+	}
+
+	return &dto.PaginatedLinkImportRowsResponse{
+		Rows:       responses,
+		TotalCount: total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}, nil
+}
+
+// LinkImportFailures returns an import with every row of it that failed, by
+// line, for its error report.
+func (r *ShortLinkRepository) LinkImportFailures(importID, userID string) (*dto.LinkImportResponse, []linkfile.Failure, error) {
+	linkImport, err := r.ownedLinkImport(importID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []shortlink.LinkImportRow
+	if err := r.db.Where("import_id = ? AND status = ?", importID, shortlink.LinkImportRowStatusFailed).
+		Order("line ASC").
+		Find(&rows).Error; err != nil {
+		return nil, nil, apperrors.ErrLinkImportFindFailed.WithError(err)
+	}
+
+	failures := make([]linkfile.Failure, 0, len(rows))
+	for _, row := range rows {
+		failures = append(failures, linkfile.Failure{
+			Line:        row.Line,
+			OriginalURL: row.OriginalURL,
+			Code:        row.ErrorCode,
+			Message:     row.ErrorMessage,
+		})
+	}
+
+	response := dto.NewLinkImportResponse(*linkImport)
+	return &response, failures, nil
+}
+
+func (r *ShortLinkRepository) ownedLinkImport(importID, userID string) (*shortlink.LinkImport, error) {
+	var linkImport shortlink.LinkImport
+	if err := r.db.Where("id = ? AND user_id = ?", importID, userID).First(&linkImport).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrLinkImportNotFound
+		}
+		return nil, apperrors.ErrLinkImportFindFailed.WithError(err)
+	}
+	return &linkImport, nil
+}
+
+// ProcessPendingImport claims the oldest import waiting for a worker and
+// creates its pending rows batchSize at a time, updating the counters and the
+// heartbeat after each batch. It returns nil when no import is waiting. A row
+// whose link was created just before the worker stopped is created again by
+// the next worker, since the link and the row are written separately.
+func (r *ShortLinkRepository) ProcessPendingImport(ctx context.Context, batchSize int) (*LinkImportResult, error) {
+	linkImport, err := r.claimLinkImport(ctx)
+	if err != nil || linkImport == nil {
+		return nil, err
+	}
+
+	result := &LinkImportResult{ImportID: linkImport.ID}
+	names, err := r.loadImportNames(ctx, linkImport.UserID)
+	if err != nil {
+		return result, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var rows []shortlink.LinkImportRow
+		if err := r.db.WithContext(ctx).
+			Where("import_id = ? AND status = ?", linkImport.ID, shortlink.LinkImportRowStatusPending).
+			Order("line ASC").
+			Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return result, apperrors.ErrLinkImportProcessFailed.WithError(err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for i := range rows {
+			if err := r.processLinkImportRow(ctx, linkImport.UserID, &rows[i], names, result); err != nil {
+				return result, err
+			}
+		}
+		if err := r.updateLinkImportProgress(ctx, linkImport.ID, false); err != nil {
+			return result, err
+		}
+	}
+
+	if err := r.updateLinkImportProgress(ctx, linkImport.ID, true); err != nil {
+		return result, err
+	}
+
+	logger.Logger.Info("Link import completed",
+		"import_id", linkImport.ID,
+		"user_id", linkImport.UserID,
+		"created", result.Created,
+		"failed", result.Failed,
+	)
+	return result, nil
+}
+
+// claimLinkImport marks the oldest claimable import as processing. The
+// update repeats the claim conditions, so of two workers racing for an import
+// only one gets it; the other finds nothing this run.
+func (r *ShortLinkRepository) claimLinkImport(ctx context.Context) (*shortlink.LinkImport, error) {
+	claimable := byClaimableImport(time.Now().Add(-linkImportStaleAfter))
+
+	var linkImport shortlink.LinkImport
+	if err := r.db.WithContext(ctx).
+		Scopes(claimable).
+		Order("created_at ASC").
+		First(&linkImport).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, apperrors.ErrLinkImportProcessFailed.WithError(err)
+	}
+
+	now := time.Now()
+	claim := r.db.WithContext(ctx).Model(&shortlink.LinkImport{}).
+		Scopes(claimable).
+		Where("id = ?", linkImport.ID).
+		Updates(map[string]any{
+			"status":     shortlink.LinkImportStatusProcessing,
+			"started_at": gorm.Expr("COALESCE(started_at, ?)", now),
+		})
+	if claim.Error != nil {
+		return nil, apperrors.ErrLinkImportProcessFailed.WithError(claim.Error)
+	}
+	if claim.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	if linkImport.Status == shortlink.LinkImportStatusProcessing {
+		logger.Logger.Warn("Resuming stale link import", "import_id", linkImport.ID)
+	}
+	return &linkImport, nil
+}
+
+func (r *ShortLinkRepository) loadImportNames(ctx context.Context, userID string) (*importNames, error) {
+	var tags []shortlink.LinkTag
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&tags).Error; err != nil {
+		return nil, apperrors.ErrLinkImportProcessFailed.WithError(err)
+	}
+	var collections []shortlink.Collection
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&collections).Error; err != nil {
+		return nil, apperrors.ErrLinkImportProcessFailed.WithError(err)
+	}
+
+	names := &importNames{
+		tags:        make(map[string]string, len(tags)),
+		collections: make(map[string]string, len(collections)),
+	}
+	for _, tag := range tags {
+		names.tags[strings.ToLower(tag.Name)] = tag.ID
+	}
+	for _, collection := range collections {
+		names.collections[strings.ToLower(collection.Name)] = collection.ID
+	}
+	return names, nil
+}
+
+// resolve files link under the tags and collection payload names.
+func (n *importNames) resolve(payload *dto.LinkImportPayload) error {
+	payload.Link.TagIDs = nil
+	seen := make(map[string]struct{}, len(payload.TagNames))
+	for _, name := range payload.TagNames {
+		id, ok := n.tags[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return apperrors.ErrLinkImportUnknownTag.WithError(errors.New(name))
+		}
+		if _, dup := seen[id]; !dup {
+			seen[id] = struct{}{}
+			payload.Link.TagIDs = append(payload.Link.TagIDs, id)
+		}
+	}
+
+	payload.Link.CollectionID = ""
+	if name := strings.TrimSpace(payload.Collection); name != "" {
+		id, ok := n.collections[strings.ToLower(name)]
+		if !ok {
+			return apperrors.ErrLinkImportUnknownCollection.WithError(errors.New(name))
+		}
+		payload.Link.CollectionID = id
+	}
+	return nil
+}
+
+// processLinkImportRow creates a row's link and records the outcome. Only
+// failing to record it is returned; the link's own errors fail the row. The
+// payload is cleared once processed, since it can hold a passcode.
+func (r *ShortLinkRepository) processLinkImportRow(ctx context.Context, userID string, row *shortlink.LinkImportRow, names *importNames, result *LinkImportResult) error {
+	var payload dto.LinkImportPayload
+	err := json.Unmarshal([]byte(row.Payload), &payload)
+	if err != nil {
+		err = apperrors.ErrLinkImportProcessFailed.WithError(err)
+	} else if err = names.resolve(&payload); err == nil {
+		payload.Link.UserID = userID
+		var link *shortlink.ShortLink
+		if link, _, err = r.CreateShortLink(&payload.Link); err == nil {
+			row.ShortCode = link.ShortCode
+		}
+	}
+
+	updates := map[string]any{"payload": ""}
+	if err == nil {
+		row.Status = shortlink.LinkImportRowStatusCreated
+		updates["short_code"] = row.ShortCode
+		result.Created++
+	} else {
+		row.Status = shortlink.LinkImportRowStatusFailed
+		row.ErrorCode, row.ErrorMessage = importErrorCode(err)
+		updates["error_code"] = row.ErrorCode
+		updates["error_message"] = row.ErrorMessage
+		result.Failed++
+	}
+	updates["status"] = row.Status
+
+	if err := r.db.WithContext(ctx).Model(&shortlink.LinkImportRow{}).
+		Where("id = ?", row.ID).
+		Updates(updates).Error; err != nil {
+		logger.Logger.Error("Failed to record link import row",
+			"import_id", row.ImportID,
+			"line", row.Line,
+			"error", err.Error(),
+		)
+		return apperrors.ErrLinkImportProcessFailed.WithError(err)
+	}
+	return nil
+}
+
+// updateLinkImportProgress recounts an import's rows into its counters,
+// which also refreshes its heartbeat; done completes the import.
+func (r *ShortLinkRepository) updateLinkImportProgress(ctx context.Context, importID string, done bool) error {
+	var counts []struct {
+		Status string
+		Total  int
+	}
+	if err := r.db.WithContext(ctx).Model(&shortlink.LinkImportRow{}).
+		Select("status, COUNT(*) AS total").
+		Where("import_id = ?", importID).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return apperrors.ErrLinkImportProcessFailed.WithError(err)
+	}
+
+	var created, failed int
+	for _, count := range counts {
+		switch count.Status {
+		case shortlink.LinkImportRowStatusCreated:
+			created = count.Total
+		case shortlink.LinkImportRowStatusFailed:
+			failed = count.Total
+		}
+	}
+
+	updates := map[string]any{
+		"processed_rows": created + failed,
+		"succeeded_rows": created,
+		"failed_rows":    failed,
+	}
+	if done {
+		updates["status"] = shortlink.LinkImportStatusCompleted
+		updates["completed_at"] = time.Now()
+	}
+	if err := r.db.WithContext(ctx).Model(&shortlink.LinkImport{}).
+		Where("id = ?", importID).
+		Updates(updates).Error; err != nil {
+		return apperrors.ErrLinkImportProcessFailed.WithError(err)
+	}
+	return nil
+}
+
+// detailedRowErrors are the errors whose cause tells the user what is wrong
+// with a row: the unknown tag or collection, or why a URL is unsafe. Other
+// causes are internal.
+var detailedRowErrors = map[string]struct{}{
+	apperrors.ErrLinkImportUnknownTag.Code:        {},
+	apperrors.ErrLinkImportUnknownCollection.Code: {},
+	apperrors.ErrUnsafeDestinationURL.Code:        {},
+}
+
+// importErrorCode returns the code and message a failed row reports.
+func importErrorCode(err error) (code, message string) {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		return apperrors.ErrLinkImportProcessFailed.Code, truncateMessage(err.Error())
+	}
+	message = appErr.Message
+	if _, ok := detailedRowErrors[appErr.Code]; ok && appErr.Err != nil {
+		message += ": " + appErr.Err.Error()
+	}
+	return appErr.Code, truncateMessage(message)
+}
+
+func truncateMessage(message string) string {
+	if len(message) <= linkImportMessageSize {
+		return message
+	}
+	return strings.ToValidUTF8(message[:linkImportMessageSize], "")
+}
+
+// ExportShortLinks passes each of the user's links, narrowed by filter, to
+// write as a record; it stops at the first error write returns.
+func (r *ShortLinkRepository) ExportShortLinks(ctx context.Context, userID string, filter dto.ShortLinkFilter, batchSize int, write func(linkfile.Record) error) error {
+	var collections []shortlink.Collection
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&collections).Error; err != nil {
+		return apperrors.ErrLinkExportFailed.WithError(err)
+	}
+	collectionNames := make(map[string]string, len(collections))
+	for _, collection := range collections {
+		collectionNames[collection.ID] = collection.Name
+	}
+
+	var writeErr error
+	var links []shortlink.ShortLink
+	err := r.db.WithContext(ctx).
+		Scopes(byLinkFilter(filter)).
+		Where("short_links.user_id = ?", userID).
+		Preload("Detail").
+		Preload("Tags", orderTagsByName).
+		FindInBatches(&links, batchSize, func(tx *gorm.DB, batch int) error {
+			for _, link := range links {
+				if writeErr = write(exportRecord(link, collectionNames)); writeErr != nil {
+					return writeErr
+				}
+			}
+			return nil
+		}).Error
+	if writeErr != nil {
+		return writeErr
+	}
+	if err != nil {
+		logger.Logger.Error("Failed to export short links", "user_id", userID, "error", err.Error())
+		return apperrors.ErrLinkExportFailed.WithError(err)
+	}
+	return nil
+}
+
+// exportRecord turns a link into the record an import recreates it from,
+// plus its state and counters. Passcodes are only stored hashed and are left
+// out.
+func exportRecord(link shortlink.ShortLink, collectionNames map[string]string) linkfile.Record {
+	record := linkfile.Record{
+		OriginalURL: link.OriginalURL,
+		ShortCode:   link.ShortCode,
+		Domain:      link.Domain,
+		Title:       link.Title,
+		Description: link.Description,
+		ExpiresAt:   link.ExpiresAt,
+		IsActive:    &link.IsActive,
+		CreatedAt:   &link.CreatedAt,
+	}
+	if link.CollectionID != nil {
+		record.Collection = collectionNames[*link.CollectionID]
+	}
+	for _, tag := range link.Tags {
+		record.Tags = append(record.Tags, tag.Name)
+	}
+
+	if detail := link.Detail; detail != nil {
+		if detail.ClickLimit > 0 {
+			record.ClickLimit = &detail.ClickLimit
+		}
+		record.EnableStats = &detail.EnableStats
+		record.UTMSource = detail.UTMSource
+		record.UTMMedium = detail.UTMMedium
+		record.UTMCampaign = detail.UTMCampaign
+		record.UTMTerm = detail.UTMTerm
+		record.UTMContent = detail.UTMContent
+		record.CurrentClicks = &detail.CurrentClicks
+		record.UniqueClicks = &detail.UniqueClicks
+	}
+	return record
+}
+
+func toLinkImportRowResponse(row shortlink.LinkImportRow) dto.LinkImportRowResponse {
+	return dto.LinkImportRowResponse{
+		Line:         row.Line,
+		OriginalURL:  row.OriginalURL,
+		Status:       row.Status,
+		ShortCode:    row.ShortCode,
+		ErrorCode:    row.ErrorCode,
+		ErrorMessage: row.ErrorMessage,
+	}
+}
@@ -0,0 +1,191 @@
+package shortlink
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+func TestByClaimableImport(t *testing.T) {
+	t.Parallel()
+
+	// Writes outside a transaction, which would connect to MySQL
+	db := dryRunDB(t).Session(&gorm.Session{SkipDefaultTransaction: true})
+	stmt := db.Model(&shortlink.LinkImport{}).
+		Scopes(byClaimableImport(time.Now())).
+		Where("id = ?", "i1").
+		Updates(map[string]any{"status": shortlink.LinkImportStatusProcessing}).Statement
+
+	// The claim also refreshes the heartbeat
+	sql := stmt.SQL.String()
+	for _, want := range []string{"`updated_at`=?", "WHERE id = ? AND (status = ? OR (status = ? AND updated_at < ?))"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %q", sql, want)
+		}
+	}
+}
+
+func TestImportNamesResolve(t *testing.T) {
+	t.Parallel()
+
+	names := &importNames{
+		tags:        map[string]string{"news": "t1", "promo": "t2"},
+		collections: map[string]string{"spring sale": "c1"},
+	}
+
+	tests := []struct {
+		name           string
+		payload        dto.LinkImportPayload
+		wantTags       []string
+		wantCollection string
+		wantErr        *apperrors.AppError
+	}{
+		{
+			name:           "names ignore case and repeats",
+			payload:        dto.LinkImportPayload{TagNames: []string{"News", " promo", "news"}, Collection: "Spring Sale"},
+			wantTags:       []string{"t1", "t2"},
+			wantCollection: "c1",
+		},
+		{
+			name:    "unknown tag",
+			payload: dto.LinkImportPayload{TagNames: []string{"news", "other"}},
+			wantErr: apperrors.ErrLinkImportUnknownTag,
+		},
+		{
+			name:    "unknown collection",
+			payload: dto.LinkImportPayload{Collection: "Winter"},
+			wantErr: apperrors.ErrLinkImportUnknownCollection,
+		},
+		{
+			name: "nothing named",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			payload := tt.payload
+			err := names.resolve(&payload)
+			if tt.wantErr != nil {
+				var appErr *apperrors.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantErr.Code {
+					t.Fatalf("resolve() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve() error = %v", err)
+			}
+			if !reflect.DeepEqual(payload.Link.TagIDs, tt.wantTags) || payload.Link.CollectionID != tt.wantCollection {
+				t.Errorf("resolve() = tags %v collection %q, want %v %q", payload.Link.TagIDs, payload.Link.CollectionID, tt.wantTags, tt.wantCollection)
+			}
+		})
+	}
+}
+
+func TestImportErrorCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "client error keeps its cause",
+			err:         apperrors.ErrLinkImportUnknownTag.WithError(errors.New("promo")),
+			wantCode:    "LINK_IMPORT_UNKNOWN_TAG",
+			wantMessage: "The row names a tag you do not have: promo",
+		},
+		{
+			name:        "server error hides its cause",
+			err:         apperrors.ErrShortCreatedFailed.WithError(errors.New("connection refused")),
+			wantCode:    apperrors.ErrShortCreatedFailed.Code,
+			wantMessage: apperrors.ErrShortCreatedFailed.Message,
+		},
+		{
+			name:        "plain error",
+			err:         errors.New(strings.Repeat("x", 1200)),
+			wantCode:    "LINK_IMPORT_PROCESS_FAILED",
+			wantMessage: strings.Repeat("x", linkImportMessageSize),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			code, message := importErrorCode(tt.err)
+			if code != tt.wantCode || message != tt.wantMessage {
+				t.Errorf("importErrorCode() = %q, %q, want %q, %q", code, message, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestExportRecord(t *testing.T) {
+	t.Parallel()
+
+	collectionID := "c1"
+	link := shortlink.ShortLink{
+		OriginalURL:  "https://example.com",
+		ShortCode:    "promo",
+		IsActive:     true,
+		CollectionID: &collectionID,
+		Tags:         []shortlink.LinkTag{{Name: "news"}, {Name: "promo"}},
+		Detail: &shortlink.ShortLinkDetail{
+			EnableStats:   false,
+			UTMSource:     "mail",
+			CurrentClicks: 7,
+		},
+	}
+
+	record := exportRecord(link, map[string]string{"c1": "Spring"})
+	if record.Collection != "Spring" || !reflect.DeepEqual(record.Tags, []string{"news", "promo"}) {
+		t.Errorf("exportRecord() filed under %q %v", record.Collection, record.Tags)
+	}
+	if record.ClickLimit != nil {
+		t.Errorf("exportRecord() ClickLimit = %d, want unset for unlimited links", *record.ClickLimit)
+	}
+	if record.EnableStats == nil || *record.EnableStats || record.UTMSource != "mail" || *record.CurrentClicks != 7 {
+		t.Errorf("exportRecord() detail fields = %+v", record)
+	}
+	if record.Passcode != "" {
+		t.Errorf("exportRecord() Passcode = %q, want it left out", record.Passcode)
+	}
+}
+
+func TestCreateDetailWritesDisabledStats(t *testing.T) {
+	t.Parallel()
+
+	var statements []string
+	db := dryRunDB(t)
+	if err := db.Callback().Create().After("gorm:create").Register("test:record_create", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("test:record_update", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	detail := shortlink.ShortLinkDetail{ID: "d1", ShortLinkID: "l1", EnableStats: false}
+	if err := createDetail(db.Session(&gorm.Session{SkipDefaultTransaction: true}), &detail); err != nil {
+		t.Fatalf("createDetail() error = %v", err)
+	}
+	if len(statements) != 2 || !strings.Contains(statements[1], "SET `enable_stats`=?") {
+		t.Errorf("createDetail() ran %q, want an insert and an enable_stats update", statements)
+	}
+	if detail.EnableStats {
+		t.Error("createDetail() left EnableStats true")
+	}
+}
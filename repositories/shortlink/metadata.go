@@ -0,0 +1,157 @@
+package shortlink
+
+import (
+	"context"
+	"time"
+
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/linkmeta"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+const (
+	// metadataFetchBudget bounds the background fetch started for new links
+	metadataFetchBudget = 2 * time.Minute
+	// metadataRetryDelay keeps the retry job away from links whose first
+	// fetch may still be running
+	metadataRetryDelay = 5 * time.Minute
+)
+
+// MetadataFetchResult summarizes one FetchPendingMetadata run.
+type MetadataFetchResult struct {
+	Fetched int
+	Failed  int
+}
+
+// WithMetadataFetcher fills the empty title, description and favicon of new
+// links from their destination in the background
+func (r *ShortLinkRepository) WithMetadataFetcher(fetcher *linkmeta.Fetcher) *ShortLinkRepository {
+	r.metadataFetcher = fetcher
+	return r
+}
+
+// fetchMetadataAsync fetches the metadata of freshly created links without
+// holding up the request. Links it cannot fetch are retried by
+// FetchPendingMetadata.
+func (r *ShortLinkRepository) fetchMetadataAsync(links ...shortlink.ShortLink) {
+	if r.metadataFetcher == nil || len(links) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), metadataFetchBudget)
+		defer cancel()
+		for _, link := range links {
+			if ctx.Err() != nil {
+				return
+			}
+			_ = r.FetchLinkMetadata(ctx, r.metadataFetcher, link)
+		}
+	}()
+}
+
+// FetchPendingMetadata fetches the metadata of links whose earlier fetches
+// failed, up to maxAttempts tries per link. Newer links go first.
+func (r *ShortLinkRepository) FetchPendingMetadata(ctx context.Context, fetcher *linkmeta.Fetcher, batchSize, maxAttempts int) (MetadataFetchResult, error) {
+	var result MetadataFetchResult
+
+	var links []shortlink.ShortLink
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN short_link_details ON short_link_details.short_link_id = short_links.id AND short_link_details.deleted_at IS NULL").
+		Where("short_link_details.metadata_fetched_at IS NULL").
+		Where("short_link_details.metadata_attempts < ?", maxAttempts).
+		Where("short_link_details.is_banned = ?", false).
+		Where("short_links.created_at < ?", time.Now().Add(-metadataRetryDelay)).
+		Order("short_link_details.metadata_attempts ASC, short_links.created_at DESC").
+		Limit(batchSize).
+		Find(&links).Error; err != nil {
+		return result, apperrors.ErrLinkMetadataFailed.WithError(err)
+	}
+
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := r.FetchLinkMetadata(ctx, fetcher, link); err != nil {
+			result.Failed++
+			continue
+		}
+		result.Fetched++
+	}
+	return result, nil
+}
+
+// FetchLinkMetadata fetches link's destination and fills whichever of its
+// title, description and favicon are still empty, so anything the owner set
+// in the meantime wins. A failed fetch counts as an attempt; one that can never
+// succeed also ends the retries.
+func (r *ShortLinkRepository) FetchLinkMetadata(ctx context.Context, fetcher *linkmeta.Fetcher, link shortlink.ShortLink) error {
+	meta, fetchErr := fetcher.Fetch(ctx, link.OriginalURL)
+	if fetchErr != nil {
+		permanent := linkmeta.Permanent(fetchErr)
+		logger.Logger.Warn("Failed to fetch destination metadata",
+			"short_code", link.ShortCode,
+			"permanent", permanent,
+			"error", fetchErr.Error(),
+		)
+
+		progress := map[string]any{"metadata_attempts": gorm.Expr("metadata_attempts + 1")}
+		if permanent {
+			progress["metadata_fetched_at"] = time.Now()
+		}
+		if err := r.db.WithContext(ctx).Model(&shortlink.ShortLinkDetail{}).
+			Where("short_link_id = ?", link.ID).
+			Updates(progress).Error; err != nil {
+			logger.Logger.Error("Failed to record metadata fetch attempt",
+				"short_code", link.ShortCode,
+				"error", err.Error(),
+			)
+		}
+		return fetchErr
+	}
+
+	fill := make(map[string]any, 3)
+	for column, value := range map[string]string{
+		"title":       meta.Title,
+		"description": meta.Description,
+		"favicon_url": meta.FaviconURL,
+	} {
+		if value != "" {
+			fill[column] = gorm.Expr("CASE WHEN "+column+" IS NULL OR "+column+" = '' THEN ? ELSE "+column+" END", value)
+		}
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(fill) > 0 {
+			// A fetched title or description is part of the link's history
+			if err := recordLinkRevisions(tx, []string{link.ID}, systemActor, func() error {
+				return tx.Model(&shortlink.ShortLink{}).
+					Where("id = ?", link.ID).
+					Updates(fill).Error
+			}); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&shortlink.ShortLinkDetail{}).
+			Where("short_link_id = ?", link.ID).
+			Updates(map[string]any{
+				"metadata_attempts":   gorm.Expr("metadata_attempts + 1"),
+				"metadata_fetched_at": time.Now(),
+			}).Error
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to store destination metadata",
+			"short_code", link.ShortCode,
+			"error", err.Error(),
+		)
+		return apperrors.ErrLinkMetadataFailed.WithError(err)
+	}
+
+	// The interstitial and passcode pages show the title from the redirect cache
+	if len(fill) > 0 {
+		r.invalidateRedirect(link)
+	}
+	return nil
+}
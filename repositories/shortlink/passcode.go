@@ -0,0 +1,128 @@
+package shortlink
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	passcodeguard "github.com/adehusnim37/lihatin-go/internal/pkg/passcode"
+)
+
+// hashPasscode hashes a passcode from a create or update request; the empty
+// string removes the passcode.
+func hashPasscode(raw string) (string, error) {
+	code, ok := passcodeguard.ParseCode(raw)
+	if !ok {
+		return "", nil
+	}
+	hash, err := passcodeguard.Hash(code)
+	if err != nil {
+		return "", apperrors.ErrPasscodeProcessFailed.WithError(err)
+	}
+	return hash, nil
+}
+
+// PasscodeForm returns what the passcode form shows for code on domain.
+func (r *ShortLinkRepository) PasscodeForm(ctx context.Context, domain, code string) (*passcodeguard.FormPage, error) {
+	snapshot, err := r.loadRedirectSnapshot(ctx, domain, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := snapshotAvailability(snapshot); err != nil {
+		return nil, err
+	}
+
+	page := &passcodeguard.FormPage{ShortCode: snapshot.ShortCode, Title: snapshot.Title}
+	if destination, err := url.Parse(snapshot.OriginalURL); err == nil {
+		page.DestinationHost = destination.Hostname()
+	}
+	return page, nil
+}
+
+// UnlockPasscode checks a passcode submitted on the form and returns the grant
+// cookie value that lets the visitor through, empty when code on domain has
+// no passcode.
+func (r *ShortLinkRepository) UnlockPasscode(ctx context.Context, domain, code string, passcode int, ipAddress string) (string, error) {
+	snapshot, err := r.loadRedirectSnapshot(ctx, domain, code)
+	if err != nil {
+		return "", err
+	}
+	if err := snapshotAvailability(snapshot); err != nil {
+		return "", err
+	}
+	if snapshot.PasscodeHash == "" {
+		return "", nil
+	}
+
+	guard := passcodeguard.Global()
+	if guard == nil {
+		return "", apperrors.ErrPasscodeProcessFailed.WithError(errors.New("passcode guard not initialized"))
+	}
+	if err := verifyPasscode(ctx, guard, snapshot, passcode, ipAddress); err != nil {
+		return "", err
+	}
+	return guard.Grant(snapshot.LinkID, snapshot.PasscodeHash), nil
+}
+
+// checkPasscode lets a redirect through a protected link when grant unlocks
+// it. Passcodes are only taken by the form, through UnlockPasscode.
+func checkPasscode(snapshot *redirectSnapshot, grant, ipAddress string) error {
+	if snapshot.PasscodeHash == "" {
+		return nil
+	}
+
+	guard := passcodeguard.Global()
+	if guard != nil && grant != "" && guard.VerifyGrant(grant, snapshot.LinkID, snapshot.PasscodeHash) {
+		return nil
+	}
+
+	logger.Logger.Warn("Passcode required but not provided",
+		"short_code", snapshot.ShortCode,
+		"ip_address", ipAddress,
+	)
+	return apperrors.ErrPasscodeRequired
+}
+
+// verifyPasscode compares passcode with the link's hash, counting wrong
+// passcodes against the link and the IP. A nil guard or an unreachable Redis
+// skips the attempt limits.
+func verifyPasscode(ctx context.Context, guard *passcodeguard.Guard, snapshot *redirectSnapshot, passcode int, ipAddress string) error {
+	if guard != nil {
+		blocked, retryAfterSeconds, err := guard.Blocked(ctx, snapshot.LinkID, ipAddress)
+		if err != nil {
+			logger.Logger.Warn("Passcode attempt limiter unavailable",
+				"short_code", snapshot.ShortCode,
+				"ip_address", ipAddress,
+				"error", err.Error(),
+			)
+		} else if blocked {
+			logger.Logger.Warn("Passcode attempts rate limited",
+				"short_code", snapshot.ShortCode,
+				"ip_address", ipAddress,
+				"retry_after_seconds", retryAfterSeconds,
+			)
+			return apperrors.ErrPasscodeTooManyAttempts
+		}
+	}
+
+	if passcodeguard.Matches(snapshot.PasscodeHash, passcode) {
+		return nil
+	}
+
+	logger.Logger.Warn("Invalid passcode attempt",
+		"short_code", snapshot.ShortCode,
+		"ip_address", ipAddress,
+	)
+	if guard != nil {
+		if err := guard.RecordFailure(ctx, snapshot.LinkID, ipAddress); err != nil {
+			logger.Logger.Warn("Failed to record passcode attempt",
+				"short_code", snapshot.ShortCode,
+				"ip_address", ipAddress,
+				"error", err.Error(),
+			)
+		}
+	}
+	return apperrors.ErrPasscodeIncorrect
+}
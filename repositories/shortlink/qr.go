@@ -0,0 +1,24 @@
+package shortlink
+
+import (
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+// SetQRLogo stores the key of the logo drawn in link's QR code; an empty key
+// removes the logo. The redirect does not use it, so the cache is kept.
+func (r *ShortLinkRepository) SetQRLogo(link *shortlink.ShortLink, objectKey string) error {
+	if err := r.db.Model(&shortlink.ShortLink{}).
+		Where("id = ?", link.ID).
+		Update("qr_logo_key", objectKey).Error; err != nil {
+		logger.Logger.Error("Failed to update QR code logo",
+			"short_code", link.ShortCode,
+			"error", err.Error(),
+		)
+		return apperrors.ErrQRLogoUpdateFailed.WithError(err)
+	}
+
+	link.QRLogoKey = objectKey
+	return nil
+}
@@ -0,0 +1,317 @@
+package shortlink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/targeting"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redirectCacheKeyPrefix      = "shortlink:redirect:v4:" // v4: the revision in effect is cached
+	redirectClickCounterPrefix  = "shortlink:clicks:"
+	redirectClickDedupPrefix    = "shortlink:dedup:"
+	defaultRedirectCacheTTL     = 300
+	defaultClickDedupMinutes    = 30
+	redirectNegativeCacheTTL    = 30 * time.Second
+	redirectCacheOperationLimit = 200 * time.Millisecond
+)
+
+// reserveClickScript atomically reserves one click against a limit.
+// Returns -1 when the counter has not been seeded yet, 0 when the limit is
+// reached and 1 when the click was counted.
+var reserveClickScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return -1
+end
+if tonumber(current) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('INCR', KEYS[1])
+return 1
+`)
+
+// redirectSnapshot is the subset of short_links + short_link_details needed to
+// answer a redirect. current_clicks is deliberately absent: it changes on every
+// hit and is tracked by the Redis click counter instead.
+type redirectSnapshot struct {
+	NotFound           bool       `json:"not_found,omitempty"`
+	LinkID             string     `json:"link_id"`
+	UserID             *string    `json:"user_id,omitempty"`
+	Domain             string     `json:"domain,omitempty"`
+	ShortCode          string     `json:"short_code"`
+	OriginalURL        string     `json:"original_url"`
+	Title              string     `json:"title,omitempty"`
+	Description        string     `json:"description,omitempty"`
+	IsActive           bool       `json:"is_active"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+	DetailID           string     `json:"detail_id"`
+	PasscodeHash       string     `json:"passcode_hash,omitempty"`
+	ClickLimit         int        `json:"click_limit,omitempty"`
+	EnableStats        bool       `json:"enable_stats"`
+	IsBanned           bool       `json:"is_banned,omitempty"`
+	BannedReason       string     `json:"banned_reason,omitempty"`
+	PrivacyMode        string     `json:"privacy_mode,omitempty"`
+	HonorDNT           *bool      `json:"honor_dnt,omitempty"`
+	ClickLimitMode     string     `json:"click_limit_mode,omitempty"`
+	DedupWindowMinutes *int       `json:"dedup_window_minutes,omitempty"`
+
+	// Rules are the active redirect rules in evaluation order and Variants
+	// the active A/B variants
+	Rules       []targeting.Rule    `json:"rules,omitempty"`
+	Variants    []targeting.Variant `json:"variants,omitempty"`
+	VariantMode string              `json:"variant_mode,omitempty"`
+
+	// Interstitial page settings; CreatedAt dates the new link notice
+	InterstitialMode string    `json:"interstitial_mode,omitempty"`
+	IsFlagged        bool      `json:"is_flagged,omitempty"`
+	FlaggedReason    string    `json:"flagged_reason,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+
+	// Social card served to crawlers instead of the redirect
+	OGTitle       string `json:"og_title,omitempty"`
+	OGDescription string `json:"og_description,omitempty"`
+	OGImageURL    string `json:"og_image_url,omitempty"`
+	TwitterCard   string `json:"twitter_card,omitempty"`
+
+	// App targets mobile visitors are sent to
+	IOSDeepLink         string `json:"ios_deep_link,omitempty"`
+	AndroidDeepLink     string `json:"android_deep_link,omitempty"`
+	DeepLinkFallbackURL string `json:"deep_link_fallback_url,omitempty"`
+
+	// How the destination URL is built and the redirect answered
+	UTMSource            string `json:"utm_source,omitempty"`
+	UTMMedium            string `json:"utm_medium,omitempty"`
+	UTMCampaign          string `json:"utm_campaign,omitempty"`
+	UTMTerm              string `json:"utm_term,omitempty"`
+	UTMContent           string `json:"utm_content,omitempty"`
+	RedirectStatus       int    `json:"redirect_status,omitempty"`
+	RedirectCacheSeconds int    `json:"redirect_cache_seconds,omitempty"`
+	ForwardQuery         bool   `json:"forward_query,omitempty"`
+	QueryConflictMode    string `json:"query_conflict_mode,omitempty"`
+
+	// Revision of the link in effect, recorded on each click
+	RevisionID string `json:"revision_id,omitempty"`
+}
+
+// RedirectCache caches redirect snapshots and click-limit counters in Redis.
+// A nil cache or nil client disables caching; callers fall back to MySQL.
+type RedirectCache struct {
+	client      *redis.Client
+	ttl         time.Duration
+	dedupWindow time.Duration
+}
+
+// NewRedirectCache creates a redirect cache backed by the given Redis client.
+func NewRedirectCache(client *redis.Client) *RedirectCache {
+	ttlSeconds := config.GetEnvAsInt(config.EnvRedirectCacheTTLSeconds, defaultRedirectCacheTTL)
+	if ttlSeconds <= 0 {
+		ttlSeconds = defaultRedirectCacheTTL
+	}
+
+	dedupMinutes := config.GetEnvAsInt(config.EnvClickDedupWindowMinutes, defaultClickDedupMinutes)
+	if dedupMinutes < 0 {
+		dedupMinutes = defaultClickDedupMinutes
+	}
+
+	return &RedirectCache{
+		client:      client,
+		ttl:         time.Duration(ttlSeconds) * time.Second,
+		dedupWindow: time.Duration(dedupMinutes) * time.Minute,
+	}
+}
+
+// redirectKey identifies a code within its domain. Main-host codes keep the
+// bare code so existing cache entries stay valid.
+func redirectKey(domain, code string) string {
+	if domain == "" {
+		return code
+	}
+	return domain + "/" + code
+}
+
+func (c *RedirectCache) enabled() bool {
+	return c != nil && c.client != nil
+}
+
+func (c *RedirectCache) get(ctx context.Context, key string) (*redirectSnapshot, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redirectCacheOperationLimit)
+	defer cancel()
+
+	raw, err := c.client.Get(ctx, redirectCacheKeyPrefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Logger.Warn("Redirect cache read failed", "short_code", key, "error", err.Error())
+		}
+		return nil, false
+	}
+
+	var snapshot redirectSnapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		logger.Logger.Warn("Discarding malformed redirect cache entry", "short_code", key, "error", err.Error())
+		return nil, false
+	}
+	return &snapshot, true
+}
+
+func (c *RedirectCache) set(ctx context.Context, key string, snapshot *redirectSnapshot) {
+	if !c.enabled() {
+		return
+	}
+
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return
+	}
+
+	ttl := c.ttl
+	if snapshot.NotFound {
+		ttl = redirectNegativeCacheTTL
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redirectCacheOperationLimit)
+	defer cancel()
+
+	if err := c.client.Set(ctx, redirectCacheKeyPrefix+key, raw, ttl).Err(); err != nil {
+		logger.Logger.Warn("Redirect cache write failed", "short_code", key, "error", err.Error())
+	}
+}
+
+// Invalidate drops cached snapshots for the given redirect keys (see
+// redirectKey).
+func (c *RedirectCache) Invalidate(ctx context.Context, codes ...string) {
+	if !c.enabled() || len(codes) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if code == "" {
+			continue
+		}
+		keys = append(keys, redirectCacheKeyPrefix+code)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redirectCacheOperationLimit)
+	defer cancel()
+
+	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+		logger.Logger.Warn("Redirect cache invalidation failed", "short_codes", codes, "error", err.Error())
+	}
+}
+
+// clickCounterKey is the Redis counter a click limit is enforced on. The
+// total counter keeps its original key so existing counters stay valid.
+func clickCounterKey(detailID, mode string) string {
+	if mode == shortlink.ClickLimitModeUnique {
+		return redirectClickCounterPrefix + detailID + ":" + mode
+	}
+	return redirectClickCounterPrefix + detailID
+}
+
+// reserveClick atomically counts one click against limit on the counter of
+// mode. seed is called at most once, when the counter does not exist yet, to
+// load the persisted current_clicks or unique_clicks value. handled is false
+// when Redis is unavailable so the caller can enforce the limit in MySQL
+// instead.
+func (c *RedirectCache) reserveClick(ctx context.Context, detailID, mode string, limit int, seed func() (int, error)) (allowed bool, handled bool) {
+	if !c.enabled() {
+		return false, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redirectCacheOperationLimit)
+	defer cancel()
+
+	key := clickCounterKey(detailID, mode)
+	for attempt := 0; attempt < 2; attempt++ {
+		result, err := reserveClickScript.Run(ctx, c.client, []string{key}, limit).Int()
+		if err != nil {
+			logger.Logger.Warn("Click counter reservation failed", "detail_id", detailID, "error", err.Error())
+			return false, false
+		}
+
+		switch result {
+		case 1:
+			return true, true
+		case 0:
+			return false, true
+		}
+
+		current, err := seed()
+		if err != nil {
+			return false, false
+		}
+		if err := c.client.SetNX(ctx, key, current, 0).Err(); err != nil {
+			logger.Logger.Warn("Click counter seed failed", "detail_id", detailID, "error", err.Error())
+			return false, false
+		}
+	}
+
+	return false, false
+}
+
+// ResetClickCounters drops the click-limit counters of detailIDs so they are
+// reseeded from MySQL on the next limited click.
+func (c *RedirectCache) ResetClickCounters(ctx context.Context, detailIDs ...string) {
+	if !c.enabled() || len(detailIDs) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(detailIDs)*2)
+	for _, detailID := range detailIDs {
+		keys = append(keys,
+			clickCounterKey(detailID, shortlink.ClickLimitModeTotal),
+			clickCounterKey(detailID, shortlink.ClickLimitModeUnique),
+		)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redirectCacheOperationLimit)
+	defer cancel()
+
+	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+		logger.Logger.Warn("Click counter reset failed", "detail_ids", detailIDs, "error", err.Error())
+	}
+}
+
+// firstClick records a visitor fingerprint for the dedup window and reports
+// whether it was not seen within the window yet. windowMinutes overrides the
+// global window when set; a zero window counts every click as first. handled
+// is false when Redis is unavailable; the caller then counts the click as
+// unique.
+func (c *RedirectCache) firstClick(ctx context.Context, linkID, fingerprint string, windowMinutes *int) (first bool, handled bool) {
+	if !c.enabled() {
+		return false, false
+	}
+
+	window := c.dedupWindow
+	if windowMinutes != nil {
+		window = time.Duration(*windowMinutes) * time.Minute
+	}
+	if window <= 0 {
+		return true, true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redirectCacheOperationLimit)
+	defer cancel()
+
+	first, err := c.client.SetNX(ctx, redirectClickDedupPrefix+linkID+":"+fingerprint, 1, window).Result()
+	if err != nil {
+		logger.Logger.Warn("Click dedup check failed", "link_id", linkID, "error", err.Error())
+		return false, false
+	}
+	return first, true
+}
@@ -0,0 +1,420 @@
+package shortlink
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/clicks"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/ip"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/privacy"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/qrcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/targeting"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// loadRedirectSnapshot returns the cached snapshot for code on domain (empty
+// for the main host) or rebuilds it from MySQL. Unknown codes are cached
+// briefly as NotFound so scans for random codes do not reach the database on
+// every request.
+func (r *ShortLinkRepository) loadRedirectSnapshot(ctx context.Context, domain, code string) (*redirectSnapshot, error) {
+	key := redirectKey(domain, code)
+	if snapshot, ok := r.cache.get(ctx, key); ok {
+		return snapshot, nil
+	}
+
+	var link shortlink.ShortLink
+	if err := r.db.WithContext(ctx).Where("domain = ? AND short_code = ?", domain, code).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			notFound := &redirectSnapshot{NotFound: true, Domain: domain, ShortCode: code}
+			r.cache.set(ctx, key, notFound)
+			return notFound, nil
+		}
+
+		logger.Logger.Error("Database error while fetching short link",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrShortGetFailed.WithError(err)
+	}
+
+	var detail shortlink.ShortLinkDetail
+	if err := r.db.WithContext(ctx).Where("short_link_id = ?", link.ID).First(&detail).Error; err != nil {
+		logger.Logger.Error("Failed to fetch short link detail",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrShortDetailNotFound
+	}
+
+	var rules []shortlink.RedirectRule
+	if err := r.db.WithContext(ctx).Where("short_link_id = ? AND is_active = ?", link.ID, true).
+		Order("position ASC, created_at ASC").Find(&rules).Error; err != nil {
+		logger.Logger.Error("Failed to fetch redirect rules",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrRedirectRuleFindFailed.WithError(err)
+	}
+
+	var variants []shortlink.LinkVariant
+	if err := r.db.WithContext(ctx).Where("short_link_id = ? AND is_active = ?", link.ID, true).
+		Order("created_at ASC").Find(&variants).Error; err != nil {
+		logger.Logger.Error("Failed to fetch link variants",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrLinkVariantFindFailed.WithError(err)
+	}
+
+	snapshot := &redirectSnapshot{
+		LinkID:       link.ID,
+		UserID:       link.UserID,
+		Domain:       link.Domain,
+		ShortCode:    link.ShortCode,
+		OriginalURL:  link.OriginalURL,
+		Title:        link.Title,
+		Description:  link.Description,
+		IsActive:     link.IsActive,
+		ExpiresAt:    link.ExpiresAt,
+		DetailID:     detail.ID,
+		PasscodeHash: detail.PasscodeHash,
+		ClickLimit:   detail.ClickLimit,
+		EnableStats:  detail.EnableStats,
+		IsBanned:     detail.IsBanned,
+		BannedReason: detail.BannedReason,
+		PrivacyMode:  detail.PrivacyMode,
+		HonorDNT:     detail.HonorDNT,
+
+		ClickLimitMode:     detail.ClickLimitMode,
+		DedupWindowMinutes: detail.DedupWindowMinutes,
+
+		Rules:       targeting.FromModels(rules),
+		Variants:    targeting.VariantsFromModels(variants),
+		VariantMode: detail.VariantMode,
+
+		InterstitialMode: detail.InterstitialMode,
+		IsFlagged:        detail.IsFlagged,
+		FlaggedReason:    detail.FlaggedReason,
+		CreatedAt:        link.CreatedAt,
+
+		OGTitle:       link.OGTitle,
+		OGDescription: link.OGDescription,
+		OGImageURL:    link.OGImageURL,
+		TwitterCard:   link.TwitterCard,
+
+		IOSDeepLink:         link.IOSDeepLink,
+		AndroidDeepLink:     link.AndroidDeepLink,
+		DeepLinkFallbackURL: link.DeepLinkFallbackURL,
+
+		UTMSource:            detail.UTMSource,
+		UTMMedium:            detail.UTMMedium,
+		UTMCampaign:          detail.UTMCampaign,
+		UTMTerm:              detail.UTMTerm,
+		UTMContent:           detail.UTMContent,
+		RedirectStatus:       detail.RedirectStatus,
+		RedirectCacheSeconds: detail.RedirectCacheSeconds,
+		ForwardQuery:         detail.ForwardQuery,
+		QueryConflictMode:    detail.QueryConflictMode,
+
+		RevisionID: link.RevisionID,
+	}
+	r.cache.set(ctx, key, snapshot)
+
+	return snapshot, nil
+}
+
+// selectDestination applies the first matching redirect rule to link and
+// returns its ID, or "" when the visitor goes to the original URL. The
+// visitor is only geolocated with locate when a rule matches on country; the
+// location is returned so the click tracker does not look it up again.
+func selectDestination(snapshot *redirectSnapshot, link *shortlink.ShortLink, visitor targeting.Visitor, ipAddress string, locate func(string) ip.Location) (string, ip.Location) {
+	if len(snapshot.Rules) == 0 {
+		return "", ip.Location{}
+	}
+
+	var location ip.Location
+	if targeting.NeedsCountry(snapshot.Rules) {
+		location = locate(ipAddress)
+		visitor.CountryCode = location.CountryCode
+	}
+
+	rule, ok := targeting.Select(snapshot.Rules, visitor)
+	if !ok {
+		return "", location
+	}
+	link.OriginalURL = rule.Destination
+	return rule.ID, location
+}
+
+// selectVariant spreads clicks no rule claimed across the link's A/B variants
+// and points link at the one served. current is the variant remembered in
+// the visitor's cookie; stick is the variant the cookie should now hold, empty
+// when it needs no update.
+func selectVariant(snapshot *redirectSnapshot, link *shortlink.ShortLink, current, ipAddress string) (variantID, stick string) {
+	if len(snapshot.Variants) == 0 {
+		return "", ""
+	}
+
+	variant, ok := targeting.PickVariant(snapshot.Variants, snapshot.VariantMode, current, snapshot.LinkID+"|"+ipAddress)
+	if !ok {
+		return "", ""
+	}
+	link.OriginalURL = variant.Destination
+
+	mode := snapshot.VariantMode
+	if (mode == "" || mode == shortlink.VariantModeCookie) && variant.ID != current {
+		stick = variant.ID
+	}
+	return variant.ID, stick
+}
+
+// clickSource tells a scan of the link's QR code, whose URL carries the
+// qrcode.SourceParam marker, from an ordinary click.
+func clickSource(query url.Values) string {
+	if query.Has(qrcode.SourceParam) {
+		return shortlink.ClickSourceQR
+	}
+	return ""
+}
+
+// isUniqueClick reports whether this is the visitor's first click on the link
+// within its dedup window. Visitors are fingerprinted by hashed IP and user
+// agent; without Redis every click counts as unique.
+func (r *ShortLinkRepository) isUniqueClick(ctx context.Context, snapshot *redirectSnapshot, ipAddress, userAgent string) bool {
+	first, handled := r.cache.firstClick(ctx, snapshot.LinkID, clickFingerprint(ipAddress, userAgent), snapshot.DedupWindowMinutes)
+	return first || !handled
+}
+
+// clickFingerprint identifies a visitor for deduplication without keeping
+// the IP address in Redis.
+func clickFingerprint(ipAddress, userAgent string) string {
+	sum := sha256.Sum256([]byte(ipAddress + "|" + userAgent))
+	return hex.EncodeToString(sum[:16])
+}
+
+// reserveClick enforces the click limit before the redirect is answered.
+// The limit applies to current_clicks or, in unique mode, to unique_clicks;
+// repeat clicks never count against a unique limit. Limited links are
+// counted atomically in Redis; without Redis the limit is enforced with a
+// conditional UPDATE so concurrent requests can never push the counter past
+// click_limit. countClick and countUnique report whether the ingestion queue
+// still has to persist the current_clicks and unique_clicks increments.
+func (r *ShortLinkRepository) reserveClick(ctx context.Context, snapshot *redirectSnapshot, code, ipAddress string, unique bool) (countClick, countUnique bool, err error) {
+	if snapshot.ClickLimit <= 0 {
+		return true, unique, nil
+	}
+
+	column := "current_clicks"
+	mode := shortlink.ClickLimitModeTotal
+	if snapshot.ClickLimitMode == shortlink.ClickLimitModeUnique {
+		if !unique {
+			return true, false, nil
+		}
+		column = "unique_clicks"
+		mode = shortlink.ClickLimitModeUnique
+	}
+
+	seed := func() (int, error) {
+		var detail shortlink.ShortLinkDetail
+		if err := r.db.WithContext(ctx).Select(column).Where("id = ?", snapshot.DetailID).First(&detail).Error; err != nil {
+			return 0, err
+		}
+		if mode == shortlink.ClickLimitModeUnique {
+			return detail.UniqueClicks, nil
+		}
+		return detail.CurrentClicks, nil
+	}
+
+	allowed, handled := r.cache.reserveClick(ctx, snapshot.DetailID, mode, snapshot.ClickLimit, seed)
+	if handled {
+		if !allowed {
+			logger.Logger.Warn("Click limit reached",
+				"short_code", code,
+				"ip_address", ipAddress,
+			)
+			return false, false, apperrors.ErrClickLimitReached
+		}
+		return true, unique, nil
+	}
+
+	result := r.db.WithContext(ctx).Model(&shortlink.ShortLinkDetail{}).
+		Where("id = ? AND (click_limit = 0 OR "+column+" < click_limit)", snapshot.DetailID).
+		Updates(map[string]interface{}{
+			column:       gorm.Expr(column+" + ?", 1),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		logger.Logger.Error("Failed to update short link detail",
+			"short_code", code,
+			"ip_address", ipAddress,
+			"error", result.Error.Error(),
+		)
+		return false, false, apperrors.ErrShortDetailUpdateFailed.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		logger.Logger.Warn("Click limit reached",
+			"short_code", code,
+			"ip_address", ipAddress,
+		)
+		return false, false, apperrors.ErrClickLimitReached
+	}
+
+	// The limited counter was incremented above; the other one is queued
+	if mode == shortlink.ClickLimitModeUnique {
+		return true, false, nil
+	}
+	return false, unique, nil
+}
+
+// trackClick hands the click to the ingestion queue. When the queue drops the
+// event the counter increments are still applied so the counters stay exact.
+// Without a tracker the view row is written inline, without geolocation, so no
+// unbounded goroutines are spawned per click.
+func (r *ShortLinkRepository) trackClick(event clicks.Event) {
+	logIP := event.IPAddress
+	if event.DoNotTrack || event.PrivacyMode != privacy.ModeOff {
+		logIP = privacy.TruncateIP(event.IPAddress)
+	}
+
+	if r.clickTracker != nil {
+		if r.clickTracker.Track(event) {
+			return
+		}
+
+		logger.Logger.Warn("Click queue full, dropping view record",
+			"short_code", event.ShortCode,
+			"ip_address", logIP,
+		)
+		r.incrementCounters(event)
+		return
+	}
+
+	r.incrementCounters(event)
+
+	event = clicks.Anonymize(context.Background(), event, privacy.Global())
+	viewDetail := shortlink.ViewLinkDetail{
+		ID:          uuid.New().String(),
+		ShortLinkID: event.ShortLinkID,
+		IPAddress:   event.IPAddress,
+		VisitorHash: event.VisitorHash,
+		UserAgent:   event.UserAgent,
+		Referer:     event.Referer,
+		Device:      event.Device,
+		Browser:     event.Browser,
+		OS:          event.OS,
+		IsBot:       event.IsBot,
+		BotName:     event.BotName,
+		RuleID:      event.RuleID,
+		VariantID:   event.VariantID,
+		RevisionID:  event.RevisionID,
+		Source:      event.Source,
+		ClickedAt:   event.ClickedAt,
+	}
+	if err := r.db.Create(&viewDetail).Error; err != nil {
+		logger.Logger.Error("Failed to track click",
+			"short_code", event.ShortCode,
+			"ip_address", logIP,
+			"error", err.Error(),
+		)
+	}
+}
+
+// incrementCounters applies the current_clicks and unique_clicks increments
+// the event still carries, and those of the variant it was served.
+func (r *ShortLinkRepository) incrementCounters(event clicks.Event) {
+	if event.VariantID != "" && !event.IsBot {
+		unique := 0
+		if event.UniqueVisitor {
+			unique = 1
+		}
+		if err := r.db.Model(&shortlink.LinkVariant{}).
+			Where("id = ?", event.VariantID).
+			UpdateColumns(clicks.VariantCounterUpdates(1, unique)).Error; err != nil {
+			logger.Logger.Error("Failed to update link variant counters",
+				"short_code", event.ShortCode,
+				"error", err.Error(),
+			)
+		}
+	}
+
+	updates := map[string]interface{}{"updated_at": time.Now()}
+	if event.CountClick {
+		updates["current_clicks"] = gorm.Expr("current_clicks + ?", 1)
+	}
+	if event.CountUnique {
+		updates["unique_clicks"] = gorm.Expr("unique_clicks + ?", 1)
+	}
+	if len(updates) == 1 {
+		return
+	}
+
+	if err := r.db.Model(&shortlink.ShortLinkDetail{}).
+		Where("id = ?", event.DetailID).
+		Updates(updates).Error; err != nil {
+		logger.Logger.Error("Failed to update short link detail",
+			"short_code", event.ShortCode,
+			"error", err.Error(),
+		)
+	}
+}
+
+// invalidateRedirect drops the cached redirect snapshots of links after a
+// write.
+func (r *ShortLinkRepository) invalidateRedirect(links ...shortlink.ShortLink) {
+	keys := make([]string, 0, len(links))
+	for _, link := range links {
+		keys = append(keys, redirectKey(link.Domain, link.ShortCode))
+	}
+	r.cache.Invalidate(context.Background(), keys...)
+}
+
+func (s *redirectSnapshot) toShortLink() *shortlink.ShortLink {
+	return &shortlink.ShortLink{
+		ID:          s.LinkID,
+		UserID:      s.UserID,
+		Domain:      s.Domain,
+		ShortCode:   s.ShortCode,
+		OriginalURL: s.OriginalURL,
+		Title:       s.Title,
+		Description: s.Description,
+		IsActive:    s.IsActive,
+		ExpiresAt:   s.ExpiresAt,
+
+		OGTitle:       s.OGTitle,
+		OGDescription: s.OGDescription,
+		OGImageURL:    s.OGImageURL,
+		TwitterCard:   s.TwitterCard,
+
+		IOSDeepLink:         s.IOSDeepLink,
+		AndroidDeepLink:     s.AndroidDeepLink,
+		DeepLinkFallbackURL: s.DeepLinkFallbackURL,
+
+		Detail: &shortlink.ShortLinkDetail{
+			ID:           s.DetailID,
+			ShortLinkID:  s.LinkID,
+			PasscodeHash: s.PasscodeHash,
+			ClickLimit:   s.ClickLimit,
+			EnableStats:  s.EnableStats,
+			IsBanned:     s.IsBanned,
+			BannedReason: s.BannedReason,
+
+			UTMSource:            s.UTMSource,
+			UTMMedium:            s.UTMMedium,
+			UTMCampaign:          s.UTMCampaign,
+			UTMTerm:              s.UTMTerm,
+			UTMContent:           s.UTMContent,
+			RedirectStatus:       s.RedirectStatus,
+			RedirectCacheSeconds: s.RedirectCacheSeconds,
+			ForwardQuery:         s.ForwardQuery,
+			QueryConflictMode:    s.QueryConflictMode,
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package shortlink
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/helpers"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+// applyUTMTags copies tags onto a new link's detail; nil leaves it untagged.
+func applyUTMTags(detail *shortlink.ShortLinkDetail, tags *dto.Tags) {
+	if tags == nil {
+		return
+	}
+	detail.UTMSource = helpers.PtrToString(tags.UTMSource)
+	detail.UTMMedium = helpers.PtrToString(tags.UTMMedium)
+	detail.UTMCampaign = helpers.PtrToString(tags.UTMCampaign)
+	detail.UTMTerm = helpers.PtrToString(tags.UTMTerm)
+	detail.UTMContent = helpers.PtrToString(tags.UTMContent)
+}
+
+// applyRedirectBehavior copies behavior onto a new link's detail; nil keeps
+// the defaults.
+func applyRedirectBehavior(detail *shortlink.ShortLinkDetail, behavior *dto.RedirectBehavior) {
+	if behavior == nil {
+		return
+	}
+	detail.RedirectStatus = behavior.StatusCode
+	detail.RedirectCacheSeconds = behavior.CacheSeconds
+	detail.ForwardQuery = behavior.ForwardQuery
+	detail.QueryConflictMode = behavior.QueryConflict
+}
+
+// redirectBehaviorColumns returns the short_link_details columns that store
+// behavior.
+func redirectBehaviorColumns(behavior *dto.RedirectBehavior) map[string]any {
+	return map[string]any{
+		"redirect_status":        behavior.StatusCode,
+		"redirect_cache_seconds": behavior.CacheSeconds,
+		"forward_query":          behavior.ForwardQuery,
+		"query_conflict_mode":    behavior.QueryConflict,
+	}
+}
@@ -0,0 +1,383 @@
+package shortlink
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/analytics"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/targeting"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxRedirectRules caps the rules per link; every redirect evaluates them in
+// order.
+const maxRedirectRules = 20
+
+// ownedLink returns the link a rule or variant request targets; admins may
+// manage those of any link.
+func (r *ShortLinkRepository) ownedLink(code, userID, userRole string) (*shortlink.ShortLink, error) {
+	var link shortlink.ShortLink
+	query := r.db.Scopes(byShortCode(code))
+	if userRole != "admin" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrShortLinkNotFound
+		}
+		logger.Logger.Error("Database error while fetching short link",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrShortGetFailed.WithError(err)
+	}
+	return &link, nil
+}
+
+func (r *ShortLinkRepository) linkRules(linkID string) ([]shortlink.RedirectRule, error) {
+	var rules []shortlink.RedirectRule
+	if err := r.db.Where("short_link_id = ?", linkID).Order("position ASC, created_at ASC").Find(&rules).Error; err != nil {
+		return nil, apperrors.ErrRedirectRuleFindFailed.WithError(err)
+	}
+	return rules, nil
+}
+
+// ListRedirectRules returns the rules of a link in evaluation order.
+func (r *ShortLinkRepository) ListRedirectRules(code, userID, userRole string) ([]dto.RedirectRuleResponse, error) {
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := r.linkRules(link.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toRedirectRuleResponses(rules), nil
+}
+
+// CreateRedirectRule appends a rule after the link's existing rules.
+func (r *ShortLinkRepository) CreateRedirectRule(code, userID, userRole string, req *dto.RedirectRuleRequest) (*dto.RedirectRuleResponse, error) {
+	conditions, err := toRuleConditions(req.Conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDestination(context.Background(), req.DestinationURL); err != nil {
+		return nil, err
+	}
+
+	var stats struct {
+		Count       int64
+		MaxPosition *int
+	}
+	if err := r.db.Model(&shortlink.RedirectRule{}).
+		Select("COUNT(*) AS count, MAX(position) AS max_position").
+		Where("short_link_id = ?", link.ID).
+		Scan(&stats).Error; err != nil {
+		return nil, apperrors.ErrRedirectRuleFindFailed.WithError(err)
+	}
+	if stats.Count >= maxRedirectRules {
+		return nil, apperrors.ErrRedirectRuleLimitReached
+	}
+
+	rule := shortlink.RedirectRule{
+		ID:             uuid.New().String(),
+		ShortLinkID:    link.ID,
+		Name:           strings.TrimSpace(req.Name),
+		Conditions:     conditions,
+		DestinationURL: req.DestinationURL,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}
+	if stats.MaxPosition != nil {
+		rule.Position = *stats.MaxPosition + 1
+	}
+
+	// Select every column so an inactive rule is not replaced by the
+	// is_active column default
+	if err := r.db.Select("*").Create(&rule).Error; err != nil {
+		logger.Logger.Error("Failed to create redirect rule",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrRedirectRuleCreateFailed.WithError(err)
+	}
+
+	r.invalidateRedirect(*link)
+
+	logger.Logger.Info("Redirect rule created",
+		"short_code", code,
+		"rule_id", rule.ID,
+		"user_id", userID,
+	)
+	response := toRedirectRuleResponse(rule)
+	return &response, nil
+}
+
+// UpdateRedirectRule replaces a rule's name, destination, state and
+// conditions; its position is changed with ReorderRedirectRules.
+func (r *ShortLinkRepository) UpdateRedirectRule(code, ruleID, userID, userRole string, req *dto.RedirectRuleRequest) (*dto.RedirectRuleResponse, error) {
+	conditions, err := toRuleConditions(req.Conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDestination(context.Background(), req.DestinationURL); err != nil {
+		return nil, err
+	}
+
+	var rule shortlink.RedirectRule
+	if err := r.db.Where("id = ? AND short_link_id = ?", ruleID, link.ID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrRedirectRuleNotFound
+		}
+		return nil, apperrors.ErrRedirectRuleFindFailed.WithError(err)
+	}
+
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.Conditions = conditions
+	rule.DestinationURL = req.DestinationURL
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	if err := r.db.Model(&rule).Select("name", "conditions", "destination_url", "is_active", "updated_at").Updates(&rule).Error; err != nil {
+		logger.Logger.Error("Failed to update redirect rule",
+			"short_code", code,
+			"rule_id", ruleID,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrRedirectRuleUpdateFailed.WithError(err)
+	}
+
+	r.invalidateRedirect(*link)
+
+	response := toRedirectRuleResponse(rule)
+	return &response, nil
+}
+
+// DeleteRedirectRule removes a rule. Clicks it already routed keep its ID and
+// are reported as a deleted rule.
+func (r *ShortLinkRepository) DeleteRedirectRule(code, ruleID, userID, userRole string) error {
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.Where("id = ? AND short_link_id = ?", ruleID, link.ID).Delete(&shortlink.RedirectRule{})
+	if result.Error != nil {
+		logger.Logger.Error("Failed to delete redirect rule",
+			"short_code", code,
+			"rule_id", ruleID,
+			"error", result.Error.Error(),
+		)
+		return apperrors.ErrRedirectRuleDeleteFailed.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrRedirectRuleNotFound
+	}
+
+	r.invalidateRedirect(*link)
+
+	logger.Logger.Info("Redirect rule deleted",
+		"short_code", code,
+		"rule_id", ruleID,
+		"user_id", userID,
+	)
+	return nil
+}
+
+// ReorderRedirectRules sets the evaluation order. ruleIDs must list every rule
+// of the link exactly once.
+func (r *ShortLinkRepository) ReorderRedirectRules(code, userID, userRole string, req *dto.ReorderRedirectRulesRequest) ([]dto.RedirectRuleResponse, error) {
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := r.linkRules(link.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) != len(req.RuleIDs) {
+		return nil, apperrors.ErrRedirectRuleOrderMismatch
+	}
+
+	byID := make(map[string]*shortlink.RedirectRule, len(rules))
+	for i := range rules {
+		byID[rules[i].ID] = &rules[i]
+	}
+	ordered := make([]shortlink.RedirectRule, 0, len(rules))
+	for _, id := range req.RuleIDs {
+		rule, ok := byID[id]
+		if !ok {
+			return nil, apperrors.ErrRedirectRuleOrderMismatch
+		}
+		ordered = append(ordered, *rule)
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		for position := range ordered {
+			ordered[position].Position = position
+			if err := tx.Model(&shortlink.RedirectRule{}).
+				Where("id = ?", ordered[position].ID).
+				Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to reorder redirect rules",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrRedirectRuleUpdateFailed.WithError(err)
+	}
+
+	r.invalidateRedirect(*link)
+	return toRedirectRuleResponses(ordered), nil
+}
+
+// ruleHits breaks the link's human clicks down by the rule that routed them,
+// most clicked first. Clicks of deleted rules are folded into one entry.
+func (r *ShortLinkRepository) ruleHits(ctx context.Context, linkID string, to time.Time) ([]dto.RuleHit, error) {
+	counts, err := r.stats.Breakdown(ctx, []string{linkID}, shortlink.RollupDimensionRule, time.Time{}, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return []dto.RuleHit{}, nil
+	}
+
+	rules, err := r.linkRules(linkID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		names[rule.ID] = rule.Name
+	}
+
+	hits := make([]dto.RuleHit, 0, len(counts))
+	var deleted int
+	for value, count := range counts {
+		if value == analytics.DefaultRule {
+			hits = append(hits, dto.RuleHit{Name: "Default", Count: int(count)})
+		} else if name, ok := names[value]; ok {
+			hits = append(hits, dto.RuleHit{RuleID: value, Name: name, Count: int(count)})
+		} else {
+			deleted += int(count)
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Count != hits[j].Count {
+			return hits[i].Count > hits[j].Count
+		}
+		return hits[i].RuleID < hits[j].RuleID
+	})
+	if deleted > 0 {
+		hits = append(hits, dto.RuleHit{Name: "Deleted rules", Count: deleted})
+	}
+	return hits, nil
+}
+
+// toRuleConditions normalizes validated request conditions for storage.
+func toRuleConditions(req dto.RedirectRuleConditions) (shortlink.RuleConditions, error) {
+	conditions := shortlink.RuleConditions{
+		Countries:     mapStrings(req.Countries, strings.ToUpper),
+		Devices:       req.Devices,
+		OS:            req.OS,
+		Languages:     req.Languages,
+		ReferrerHosts: mapStrings(req.ReferrerHosts, strings.ToLower),
+	}
+	if req.Query != nil {
+		conditions.Query = &shortlink.RuleQuery{Key: req.Query.Key, Value: req.Query.Value}
+	}
+	if req.TimeWindow != nil {
+		conditions.TimeWindow = &shortlink.RuleTimeWindow{
+			From:      req.TimeWindow.From,
+			Until:     req.TimeWindow.Until,
+			Days:      req.TimeWindow.Days,
+			StartTime: req.TimeWindow.StartTime,
+			EndTime:   req.TimeWindow.EndTime,
+			Timezone:  req.TimeWindow.Timezone,
+		}
+	}
+
+	switch err := targeting.Validate(conditions); {
+	case errors.Is(err, targeting.ErrNoConditions):
+		return conditions, apperrors.ErrRedirectRuleNoConditions
+	case err != nil:
+		return conditions, apperrors.ErrRedirectRuleInvalidTimeWindow
+	}
+	return conditions, nil
+}
+
+func mapStrings(values []string, fn func(string) string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	mapped := make([]string, len(values))
+	for i, value := range values {
+		mapped[i] = fn(strings.TrimSpace(value))
+	}
+	return mapped
+}
+
+func toRedirectRuleResponses(rules []shortlink.RedirectRule) []dto.RedirectRuleResponse {
+	responses := make([]dto.RedirectRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		responses = append(responses, toRedirectRuleResponse(rule))
+	}
+	return responses
+}
+
+func toRedirectRuleResponse(rule shortlink.RedirectRule) dto.RedirectRuleResponse {
+	conditions := dto.RedirectRuleConditions{
+		Countries:     rule.Conditions.Countries,
+		Devices:       rule.Conditions.Devices,
+		OS:            rule.Conditions.OS,
+		Languages:     rule.Conditions.Languages,
+		ReferrerHosts: rule.Conditions.ReferrerHosts,
+	}
+	if query := rule.Conditions.Query; query != nil {
+		conditions.Query = &dto.RedirectRuleQuery{Key: query.Key, Value: query.Value}
+	}
+	if window := rule.Conditions.TimeWindow; window != nil {
+		conditions.TimeWindow = &dto.RedirectRuleTimeWindow{
+			From:      window.From,
+			Until:     window.Until,
+			Days:      window.Days,
+			StartTime: window.StartTime,
+			EndTime:   window.EndTime,
+			Timezone:  window.Timezone,
+		}
+	}
+
+	return dto.RedirectRuleResponse{
+		ID:             rule.ID,
+		Position:       rule.Position,
+		Name:           rule.Name,
+		DestinationURL: rule.DestinationURL,
+		IsActive:       rule.IsActive,
+		Conditions:     conditions,
+		CreatedAt:      rule.CreatedAt,
+		UpdatedAt:      rule.UpdatedAt,
+	}
+}
@@ -0,0 +1,143 @@
+package shortlink
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/adehusnim37/lihatin-go/internal/pkg/ip"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/targeting"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+func TestReserveClickCounters(t *testing.T) {
+	t.Parallel()
+
+	// None of these cases reach Redis or MySQL
+	repo := &ShortLinkRepository{}
+	tests := []struct {
+		name       string
+		snapshot   redirectSnapshot
+		unique     bool
+		wantClick  bool
+		wantUnique bool
+	}{
+		{"unlimited unique", redirectSnapshot{}, true, true, true},
+		{"unlimited repeat", redirectSnapshot{}, false, true, false},
+		{"unique limit repeat", redirectSnapshot{ClickLimit: 1, ClickLimitMode: shortlink.ClickLimitModeUnique}, false, true, false},
+	}
+	for _, tt := range tests {
+		countClick, countUnique, err := repo.reserveClick(context.Background(), &tt.snapshot, "abc", "", tt.unique)
+		if err != nil || countClick != tt.wantClick || countUnique != tt.wantUnique {
+			t.Errorf("%s: reserveClick() = %v, %v, %v; want %v, %v, nil", tt.name, countClick, countUnique, err, tt.wantClick, tt.wantUnique)
+		}
+	}
+}
+
+func TestClickDedupWithoutRedis(t *testing.T) {
+	t.Parallel()
+
+	repo := &ShortLinkRepository{}
+	if !repo.isUniqueClick(context.Background(), &redirectSnapshot{LinkID: "l1"}, "203.0.113.7", "Mozilla/5.0") {
+		t.Fatal("isUniqueClick() = false without Redis, want true")
+	}
+
+	a := clickFingerprint("203.0.113.7", "Mozilla/5.0")
+	if a != clickFingerprint("203.0.113.7", "Mozilla/5.0") || a == clickFingerprint("203.0.113.8", "Mozilla/5.0") {
+		t.Fatal("clickFingerprint() is not stable per visitor")
+	}
+	if clickCounterKey("d1", shortlink.ClickLimitModeTotal) == clickCounterKey("d1", shortlink.ClickLimitModeUnique) {
+		t.Fatal("total and unique limits share a Redis counter")
+	}
+}
+
+func TestSelectDestination(t *testing.T) {
+	t.Parallel()
+
+	snapshot := &redirectSnapshot{
+		OriginalURL: "https://example.com",
+		Rules: []targeting.Rule{
+			{ID: "us", Destination: "https://us.example.com", Conditions: shortlink.RuleConditions{Countries: []string{"US"}}},
+			{ID: "mobile", Destination: "https://m.example.com", Conditions: shortlink.RuleConditions{Devices: []string{"Mobile"}}},
+		},
+	}
+	located := 0
+	locate := func(string) ip.Location {
+		located++
+		return ip.Location{Country: "Indonesia", CountryCode: "ID"}
+	}
+
+	link := snapshot.toShortLink()
+	ruleID, location := selectDestination(snapshot, link, targeting.Visitor{Device: "Mobile"}, "203.0.113.7", locate)
+	if ruleID != "mobile" || link.OriginalURL != "https://m.example.com" {
+		t.Fatalf("selectDestination() = %q, %q; want mobile rule", ruleID, link.OriginalURL)
+	}
+	if located != 1 || location.CountryCode != "ID" {
+		t.Fatalf("country rule did not geolocate once: located=%d, location=%+v", located, location)
+	}
+
+	link = snapshot.toShortLink()
+	if ruleID, _ := selectDestination(snapshot, link, targeting.Visitor{Device: "Desktop"}, "203.0.113.7", locate); ruleID != "" || link.OriginalURL != "https://example.com" {
+		t.Fatalf("selectDestination() = %q, %q; want default URL", ruleID, link.OriginalURL)
+	}
+
+	snapshot.Rules = snapshot.Rules[1:]
+	located = 0
+	selectDestination(snapshot, snapshot.toShortLink(), targeting.Visitor{Device: "Mobile"}, "203.0.113.7", locate)
+	if located != 0 {
+		t.Fatal("visitor geolocated without a country rule")
+	}
+}
+
+func TestSelectVariantStickiness(t *testing.T) {
+	t.Parallel()
+
+	snapshot := &redirectSnapshot{
+		LinkID:      "l1",
+		OriginalURL: "https://example.com",
+		Variants: []targeting.Variant{
+			{ID: "a", Destination: "https://a.example.com", Weight: 1},
+			{ID: "b", Destination: "https://b.example.com", Weight: 1},
+		},
+	}
+
+	link := snapshot.toShortLink()
+	variantID, stick := selectVariant(snapshot, link, "b", "203.0.113.7")
+	if variantID != "b" || stick != "" || link.OriginalURL != "https://b.example.com" {
+		t.Fatalf("cookie variant not kept: got %q, stick %q, url %q", variantID, stick, link.OriginalURL)
+	}
+
+	variantID, stick = selectVariant(snapshot, snapshot.toShortLink(), "gone", "203.0.113.7")
+	if variantID == "" || stick != variantID {
+		t.Fatalf("new cookie variant not remembered: got %q, stick %q", variantID, stick)
+	}
+
+	snapshot.VariantMode = shortlink.VariantModeIP
+	if _, stick = selectVariant(snapshot, snapshot.toShortLink(), "", "203.0.113.7"); stick != "" {
+		t.Fatalf("ip mode set a cookie: %q", stick)
+	}
+}
+
+func TestClickSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: ""},
+		{query: "utm_source=qr", want: ""},
+		{query: "qr=1", want: shortlink.ClickSourceQR},
+		{query: "ref=poster&qr", want: shortlink.ClickSourceQR},
+	}
+
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("url.ParseQuery(%q) error = %v", tt.query, err)
+		}
+		if got := clickSource(query); got != tt.want {
+			t.Errorf("clickSource(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package shortlink
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/codepolicy"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+// checkShortCode rejects codes that are reserved or contain a blocked term.
+func checkShortCode(code string) error {
+	policy := codepolicy.Global()
+	if policy == nil {
+		return nil
+	}
+
+	switch err := policy.Check(code); {
+	case errors.Is(err, codepolicy.ErrReserved):
+		return apperrors.ErrReservedShortCode
+	case errors.Is(err, codepolicy.ErrBlocked):
+		logger.Logger.Warn("Rejected short code with blocked term", "short_code", code)
+		return apperrors.ErrBlockedShortCode
+	}
+	return nil
+}
+
+// ListReservedCodes returns the reserved words and blocked terms, optionally
+// only those of kind.
+func (r *ShortLinkRepository) ListReservedCodes(kind string) ([]dto.ReservedCodeResponse, error) {
+	query := r.db.Model(&shortlink.ReservedCode{}).Order("kind ASC, word ASC")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var entries []shortlink.ReservedCode
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, apperrors.ErrReservedCodeFailed.WithError(err)
+	}
+
+	items := make([]dto.ReservedCodeResponse, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, toReservedCodeResponse(entry))
+	}
+	return items, nil
+}
+
+// CreateReservedCode adds a word to the list. Existing links using it are
+// left alone.
+func (r *ShortLinkRepository) CreateReservedCode(req *dto.CreateReservedCodeRequest, adminID string) (*dto.ReservedCodeResponse, error) {
+	entry := shortlink.ReservedCode{
+		Word:      strings.ToLower(req.Word),
+		Kind:      req.Kind,
+		CreatedBy: &adminID,
+	}
+
+	var count int64
+	if err := r.db.Model(&shortlink.ReservedCode{}).
+		Where("word = ? AND kind = ?", entry.Word, entry.Kind).
+		Count(&count).Error; err != nil {
+		return nil, apperrors.ErrReservedCodeFailed.WithError(err)
+	}
+	if count > 0 {
+		return nil, apperrors.ErrReservedCodeExists
+	}
+
+	if err := r.db.Create(&entry).Error; err != nil {
+		if isDuplicateKey(err) {
+			return nil, apperrors.ErrReservedCodeExists
+		}
+		logger.Logger.Error("Failed to create reserved code",
+			"word", entry.Word,
+			"kind", entry.Kind,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrReservedCodeFailed.WithError(err)
+	}
+	invalidateCodePolicy()
+
+	logger.Logger.Info("Reserved code added",
+		"word", entry.Word,
+		"kind", entry.Kind,
+		"admin_id", adminID,
+	)
+	response := toReservedCodeResponse(entry)
+	return &response, nil
+}
+
+// DeleteReservedCode removes a word from the list.
+func (r *ShortLinkRepository) DeleteReservedCode(id uint, adminID string) error {
+	result := r.db.Delete(&shortlink.ReservedCode{}, id)
+	if result.Error != nil {
+		logger.Logger.Error("Failed to delete reserved code",
+			"id", id,
+			"error", result.Error.Error(),
+		)
+		return apperrors.ErrReservedCodeFailed.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrReservedCodeNotFound
+	}
+	invalidateCodePolicy()
+
+	logger.Logger.Info("Reserved code removed",
+		"id", id,
+		"admin_id", adminID,
+	)
+	return nil
+}
+
+func invalidateCodePolicy() {
+	if policy := codepolicy.Global(); policy != nil {
+		policy.Invalidate()
+	}
+}
+
+func toReservedCodeResponse(entry shortlink.ReservedCode) dto.ReservedCodeResponse {
+	return dto.ReservedCodeResponse{
+		ID:        entry.ID,
+		Word:      entry.Word,
+		Kind:      entry.Kind,
+		CreatedBy: entry.CreatedBy,
+		CreatedAt: entry.CreatedAt,
+	}
+}
@@ -0,0 +1,636 @@
+package shortlink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevisionActor is who changes a link: the signed-in user or admin, and the
+// API key the request was authenticated with, if any. System marks changes
+// the service makes on its own.
+type RevisionActor struct {
+	UserID   string
+	Role     string
+	APIKeyID string
+	System   bool
+}
+
+// systemActor records changes no user asked for, such as safety scan bans.
+var systemActor = RevisionActor{System: true}
+
+// actorType labels a change the actor makes to a link of owner. An admin
+// editing their own link acts as its user.
+func (a RevisionActor) actorType(owner string) string {
+	switch {
+	case a.System:
+		return shortlink.RevisionActorSystem
+	case a.APIKeyID != "":
+		return shortlink.RevisionActorAPIKey
+	case a.Role == "admin" && a.UserID != owner:
+		return shortlink.RevisionActorAdmin
+	default:
+		return shortlink.RevisionActorUser
+	}
+}
+
+// lockedLink fetches the link behind code that actor may edit and locks its
+// row until tx ends, so changes to one link are recorded one after another.
+func lockedLink(tx *gorm.DB, code string, actor RevisionActor) (*shortlink.ShortLink, error) {
+	var link shortlink.ShortLink
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(byShortCode(code))
+	if actor.Role != "admin" {
+		query = query.Where("user_id = ?", actor.UserID)
+	}
+	if err := query.First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrShortLinkNotFound
+		}
+		return nil, apperrors.ErrShortGetFailed.WithError(err)
+	}
+	return &link, nil
+}
+
+// revisionState takes what a revision records from a link, its detail and
+// the IDs of its tags.
+func revisionState(link shortlink.ShortLink, detail shortlink.ShortLinkDetail, tagIDs []string) shortlink.LinkRevisionState {
+	var expiresAt *time.Time
+	if link.ExpiresAt != nil {
+		at := link.ExpiresAt.UTC()
+		expiresAt = &at
+	}
+	tagIDs = slices.Clone(tagIDs)
+	slices.Sort(tagIDs)
+
+	return shortlink.LinkRevisionState{
+		OriginalURL:         link.OriginalURL,
+		Domain:              link.Domain,
+		ShortCode:           link.ShortCode,
+		Title:               link.Title,
+		Description:         link.Description,
+		IsActive:            link.IsActive,
+		Deleted:             link.DeletedAt.Valid,
+		ExpiresAt:           expiresAt,
+		CollectionID:        link.CollectionID,
+		TagIDs:              tagIDs,
+		OGTitle:             link.OGTitle,
+		OGDescription:       link.OGDescription,
+		OGImageURL:          link.OGImageURL,
+		TwitterCard:         link.TwitterCard,
+		IOSDeepLink:         link.IOSDeepLink,
+		AndroidDeepLink:     link.AndroidDeepLink,
+		DeepLinkFallbackURL: link.DeepLinkFallbackURL,
+
+		PasscodeHash:         detail.PasscodeHash,
+		ClickLimit:           detail.ClickLimit,
+		ClickLimitMode:       detail.ClickLimitMode,
+		DedupWindowMinutes:   detail.DedupWindowMinutes,
+		EnableStats:          detail.EnableStats,
+		VariantMode:          detail.VariantMode,
+		InterstitialMode:     detail.InterstitialMode,
+		PrivacyMode:          detail.PrivacyMode,
+		HonorDNT:             detail.HonorDNT,
+		UTMSource:            detail.UTMSource,
+		UTMMedium:            detail.UTMMedium,
+		UTMCampaign:          detail.UTMCampaign,
+		UTMTerm:              detail.UTMTerm,
+		UTMContent:           detail.UTMContent,
+		RedirectStatus:       detail.RedirectStatus,
+		RedirectCacheSeconds: detail.RedirectCacheSeconds,
+		ForwardQuery:         detail.ForwardQuery,
+		QueryConflictMode:    detail.QueryConflictMode,
+	}
+}
+
+// loadRevisionState reads the current state of a link, deleted or not.
+func loadRevisionState(db *gorm.DB, linkID string) (shortlink.LinkRevisionState, error) {
+	var link shortlink.ShortLink
+	if err := db.Unscoped().Where("id = ?", linkID).First(&link).Error; err != nil {
+		return shortlink.LinkRevisionState{}, err
+	}
+	var detail shortlink.ShortLinkDetail
+	if err := db.Unscoped().Where("short_link_id = ?", linkID).First(&detail).Error; err != nil {
+		return shortlink.LinkRevisionState{}, err
+	}
+	var tagIDs []string
+	if err := db.Table("short_link_tags").Where("short_link_id = ?", linkID).Pluck("link_tag_id", &tagIDs).Error; err != nil {
+		return shortlink.LinkRevisionState{}, err
+	}
+	return revisionState(link, detail, tagIDs), nil
+}
+
+// diffRevisionStates lists the fields that differ between two states, in
+// the order LinkRevisionState declares them. A passcode change is reported
+// as whether the link had one.
+func diffRevisionStates(from, to shortlink.LinkRevisionState) []dto.LinkRevisionChange {
+	changes := make([]dto.LinkRevisionChange, 0)
+	fromValue, toValue := reflect.ValueOf(from), reflect.ValueOf(to)
+	stateType := fromValue.Type()
+	for i := range stateType.NumField() {
+		before, after := fromValue.Field(i).Interface(), toValue.Field(i).Interface()
+		if sameRevisionValue(before, after) {
+			continue
+		}
+
+		field := stateType.Field(i).Tag.Get("json")
+		if field == "passcode_hash" {
+			changes = append(changes, dto.LinkRevisionChange{
+				Field: "passcode",
+				From:  from.PasscodeHash != "",
+				To:    to.PasscodeHash != "",
+			})
+			continue
+		}
+		changes = append(changes, dto.LinkRevisionChange{Field: field, From: before, To: after})
+	}
+	return changes
+}
+
+// sameRevisionValue compares two values of a LinkRevisionState field. Times
+// are compared as instants and tag lists ignore nil versus empty, since a
+// state read back from JSON differs from one read from the database there.
+func sameRevisionValue(a, b any) bool {
+	switch a := a.(type) {
+	case *time.Time:
+		b := b.(*time.Time)
+		if a == nil || b == nil {
+			return a == nil && b == nil
+		}
+		return a.Equal(*b)
+	case []string:
+		return slices.Equal(a, b.([]string))
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// recordRevision records a change made to link within tx: before is the
+// state read once the link was locked. Nothing is recorded when the state did
+// not change, and a nil revision is returned. A link's first change also
+// records before as its first revision. The link is pointed at the new
+// revision so clicks from now on record it.
+func recordRevision(tx *gorm.DB, link shortlink.ShortLink, before shortlink.LinkRevisionState, actor RevisionActor, restoredFrom *int) (*shortlink.ShortLinkRevision, error) {
+	after, err := loadRevisionState(tx, link.ID)
+	if err != nil {
+		return nil, apperrors.ErrLinkRevisionCreateFailed.WithError(err)
+	}
+	changes := diffRevisionStates(before, after)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	var latest int
+	if err := tx.Model(&shortlink.ShortLinkRevision{}).
+		Where("short_link_id = ?", link.ID).
+		Select("COALESCE(MAX(number), 0)").
+		Scan(&latest).Error; err != nil {
+		return nil, apperrors.ErrLinkRevisionCreateFailed.WithError(err)
+	}
+
+	if latest == 0 {
+		// The link as it was created, or as it was before history was kept
+		baseline, err := newRevision(link.ID, 1, before)
+		if err != nil {
+			return nil, apperrors.ErrLinkRevisionCreateFailed.WithError(err)
+		}
+		baseline.ActorType = shortlink.RevisionActorUser
+		baseline.ActorID = link.UserID
+		baseline.CreatedAt = link.CreatedAt
+		if err := tx.Create(baseline).Error; err != nil {
+			return nil, apperrors.ErrLinkRevisionCreateFailed.WithError(err)
+		}
+		latest = baseline.Number
+	}
+
+	revision, err := newRevision(link.ID, latest+1, after)
+	if err != nil {
+		return nil, apperrors.ErrLinkRevisionCreateFailed.WithError(err)
+	}
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	owner := ""
+	if link.UserID != nil {
+		owner = *link.UserID
+	}
+	revision.ChangedFields = strings.Join(fields, ",")
+	revision.ActorType = actor.actorType(owner)
+	if actor.UserID != "" {
+		revision.ActorID = &actor.UserID
+	}
+	if actor.APIKeyID != "" {
+		revision.APIKeyID = &actor.APIKeyID
+	}
+	revision.RestoredFrom = restoredFrom
+	if err := tx.Create(revision).Error; err != nil {
+		return nil, apperrors.ErrLinkRevisionCreateFailed.WithError(err)
+	}
+
+	if err := tx.Unscoped().Model(&shortlink.ShortLink{}).
+		Where("id = ?", link.ID).
+		UpdateColumn("revision_id", revision.ID).Error; err != nil {
+		return nil, apperrors.ErrLinkRevisionCreateFailed.WithError(err)
+	}
+	return revision, nil
+}
+
+// recordLinkRevisions runs change, which edits the links with linkIDs, and
+// records a revision of each link it changed. The links are locked until tx
+// ends, as lockedLink does for a single link.
+func recordLinkRevisions(tx *gorm.DB, linkIDs []string, actor RevisionActor, change func() error) error {
+	var links []shortlink.ShortLink
+	if len(linkIDs) > 0 {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", linkIDs).
+			Order("id").
+			Find(&links).Error; err != nil {
+			return err
+		}
+	}
+
+	before := make([]shortlink.LinkRevisionState, len(links))
+	for i, link := range links {
+		state, err := loadRevisionState(tx, link.ID)
+		if err != nil {
+			return err
+		}
+		before[i] = state
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	for i, link := range links {
+		if _, err := recordRevision(tx, link, before[i], actor, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newRevision(linkID string, number int, state shortlink.LinkRevisionState) (*shortlink.ShortLinkRevision, error) {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	return &shortlink.ShortLinkRevision{
+		ID:          uuid.New().String(),
+		ShortLinkID: linkID,
+		Number:      number,
+		State:       string(encoded),
+	}, nil
+}
+
+func decodeRevisionState(revision shortlink.ShortLinkRevision) (shortlink.LinkRevisionState, error) {
+	var state shortlink.LinkRevisionState
+	err := json.Unmarshal([]byte(revision.State), &state)
+	return state, err
+}
+
+func toLinkRevisionResponse(revision shortlink.ShortLinkRevision, currentID string) dto.LinkRevisionResponse {
+	fields := []string{}
+	if revision.ChangedFields != "" {
+		fields = strings.Split(revision.ChangedFields, ",")
+	}
+	return dto.LinkRevisionResponse{
+		ID:            revision.ID,
+		Number:        revision.Number,
+		ChangedFields: fields,
+		ActorType:     revision.ActorType,
+		ActorID:       revision.ActorID,
+		APIKeyID:      revision.APIKeyID,
+		RestoredFrom:  revision.RestoredFrom,
+		Current:       revision.ID == currentID,
+		CreatedAt:     revision.CreatedAt,
+	}
+}
+
+func (r *ShortLinkRepository) findLinkRevision(linkID, revisionID string) (*shortlink.ShortLinkRevision, error) {
+	var revision shortlink.ShortLinkRevision
+	if err := r.db.Where("id = ? AND short_link_id = ?", revisionID, linkID).First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrLinkRevisionNotFound
+		}
+		return nil, apperrors.ErrLinkRevisionFindFailed.WithError(err)
+	}
+	return &revision, nil
+}
+
+// ListLinkRevisions returns a page of a link's revisions, newest first. A
+// link that was never changed has none.
+func (r *ShortLinkRepository) ListLinkRevisions(code, userID, userRole string, req dto.LinkRevisionsRequest) (*dto.PaginatedLinkRevisionsResponse, error) {
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	page, limit := req.Page, req.Limit
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 20
+	}
+
+	query := r.db.Model(&shortlink.ShortLinkRevision{}).Where("short_link_id = ?", link.ID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, apperrors.ErrLinkRevisionFindFailed.WithError(err)
+	}
+
+	var revisions []shortlink.ShortLinkRevision
+	if err := query.Order("number DESC").Offset((page - 1) * limit).Limit(limit).Find(&revisions).Error; err != nil {
+		return nil, apperrors.ErrLinkRevisionFindFailed.WithError(err)
+	}
+
+	responses := make([]dto.LinkRevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		responses = append(responses, toLinkRevisionResponse(revision, link.RevisionID))
+	}
+
+	return &dto.PaginatedLinkRevisionsResponse{
+		Revisions:  responses,
+		TotalCount: total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}, nil
+}
+
+// GetLinkRevision returns a revision with the state it recorded and the
+// fields that differ from compareTo, or from the revision before it when
+// compareTo is empty.
+func (r *ShortLinkRepository) GetLinkRevision(code, revisionID, compareTo, userID, userRole string) (*dto.LinkRevisionDiffResponse, error) {
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := r.findLinkRevision(link.ID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	state, err := decodeRevisionState(*revision)
+	if err != nil {
+		return nil, apperrors.ErrLinkRevisionFindFailed.WithError(err)
+	}
+
+	var other *shortlink.ShortLinkRevision
+	if compareTo != "" {
+		if other, err = r.findLinkRevision(link.ID, compareTo); err != nil {
+			return nil, err
+		}
+	} else if revision.Number > 1 {
+		var previous shortlink.ShortLinkRevision
+		err := r.db.Where("short_link_id = ? AND number < ?", link.ID, revision.Number).
+			Order("number DESC").
+			First(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrLinkRevisionFindFailed.WithError(err)
+		}
+		if err == nil {
+			other = &previous
+		}
+	}
+
+	response := &dto.LinkRevisionDiffResponse{
+		Revision: toLinkRevisionResponse(*revision, link.RevisionID),
+		State:    dto.NewLinkRevisionStateResponse(state),
+		Changes:  []dto.LinkRevisionChange{},
+	}
+	if other != nil {
+		otherState, err := decodeRevisionState(*other)
+		if err != nil {
+			return nil, apperrors.ErrLinkRevisionFindFailed.WithError(err)
+		}
+		compared := toLinkRevisionResponse(*other, link.RevisionID)
+		response.ComparedTo = &compared
+		response.Changes = diffRevisionStates(otherState, state)
+	}
+	return response, nil
+}
+
+// RollbackShortLink puts a link back the way one of its revisions recorded
+// it, as a new revision. The restored destination, short code and domain are
+// vetted again like an update; tags and a collection deleted since are left
+// out. Redirect rules and variants are not versioned and stay as they are.
+func (r *ShortLinkRepository) RollbackShortLink(code, revisionID string, actor RevisionActor) (*dto.LinkRevisionResponse, error) {
+	var link *shortlink.ShortLink
+	var restored shortlink.ShortLink
+	var revision *shortlink.ShortLinkRevision
+	var resetCounters []string
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if link, err = lockedLink(tx, code, actor); err != nil {
+			return err
+		}
+
+		var target shortlink.ShortLinkRevision
+		if err := tx.Where("id = ? AND short_link_id = ?", revisionID, link.ID).First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperrors.ErrLinkRevisionNotFound
+			}
+			return apperrors.ErrLinkRevisionFindFailed.WithError(err)
+		}
+		state, err := decodeRevisionState(target)
+		if err != nil {
+			return apperrors.ErrLinkRevisionFindFailed.WithError(err)
+		}
+
+		before, err := loadRevisionState(tx, link.ID)
+		if err != nil {
+			return apperrors.ErrLinkRollbackFailed.WithError(err)
+		}
+		state.Deleted = before.Deleted
+		if len(diffRevisionStates(before, state)) == 0 {
+			return apperrors.ErrLinkRevisionAlreadyCurrent
+		}
+
+		if state, err = vetRestoredState(tx, *link, before, state); err != nil {
+			return err
+		}
+		if err := restoreRevisionState(tx, *link, state); err != nil {
+			return apperrors.ErrLinkRollbackFailed.WithError(err)
+		}
+
+		if revision, err = recordRevision(tx, *link, before, actor, &target.Number); err != nil {
+			return err
+		}
+		if revision == nil {
+			// Only deleted tags or a deleted collection told them apart
+			return apperrors.ErrLinkRevisionAlreadyCurrent
+		}
+
+		// The Redis click-limit counters only track the counter of the
+		// current mode and stop being kept while the link has no limit
+		if state.ClickLimitMode != before.ClickLimitMode || state.ClickLimit != before.ClickLimit {
+			if err := tx.Model(&shortlink.ShortLinkDetail{}).
+				Where("short_link_id = ?", link.ID).
+				Pluck("id", &resetCounters).Error; err != nil {
+				return apperrors.ErrLinkRollbackFailed.WithError(err)
+			}
+		}
+
+		restored = *link
+		restored.Domain, restored.ShortCode = state.Domain, state.ShortCode
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.ResetClickCounters(context.Background(), resetCounters...)
+	r.invalidateRedirect(*link, restored)
+
+	logger.Logger.Info("Short link rolled back",
+		"short_code", code,
+		"revision_id", revisionID,
+		"user_id", actor.UserID,
+	)
+	response := toLinkRevisionResponse(*revision, revision.ID)
+	return &response, nil
+}
+
+// vetRestoredState checks what a rollback would change like an update would,
+// and drops the tags and collection the owner no longer has.
+func vetRestoredState(tx *gorm.DB, link shortlink.ShortLink, current, state shortlink.LinkRevisionState) (shortlink.LinkRevisionState, error) {
+	ctx := context.Background()
+	owner := ""
+	if link.UserID != nil {
+		owner = *link.UserID
+	}
+
+	if state.OriginalURL != current.OriginalURL {
+		if err := checkDestination(ctx, state.OriginalURL); err != nil {
+			return state, err
+		}
+	}
+	if state.IOSDeepLink != current.IOSDeepLink || state.AndroidDeepLink != current.AndroidDeepLink || state.DeepLinkFallbackURL != current.DeepLinkFallbackURL {
+		if err := checkDeepLinks(ctx, &dto.DeepLinks{
+			IOS:         state.IOSDeepLink,
+			Android:     state.AndroidDeepLink,
+			FallbackURL: state.DeepLinkFallbackURL,
+		}); err != nil {
+			return state, err
+		}
+	}
+
+	if state.Domain != current.Domain {
+		domain, err := resolveLinkDomain(tx, state.Domain, owner)
+		if err != nil {
+			return state, err
+		}
+		state.Domain = domain
+	}
+	if state.ShortCode != current.ShortCode {
+		if err := checkShortCode(state.ShortCode); err != nil {
+			return state, err
+		}
+	}
+	if state.Domain != current.Domain || state.ShortCode != current.ShortCode {
+		var taken int64
+		if err := tx.Model(&shortlink.ShortLink{}).
+			Where("domain = ? AND short_code = ? AND id <> ?", state.Domain, state.ShortCode, link.ID).
+			Count(&taken).Error; err != nil {
+			return state, apperrors.ErrLinkRollbackFailed.WithError(err)
+		}
+		if taken > 0 {
+			return state, apperrors.ErrDuplicateShortCode
+		}
+	}
+
+	if state.CollectionID != nil {
+		collectionID, err := ownerCollectionID(tx, owner, *state.CollectionID)
+		if errors.Is(err, apperrors.ErrCollectionNotFound) {
+			collectionID, err = nil, nil
+		}
+		if err != nil {
+			return state, err
+		}
+		state.CollectionID = collectionID
+	}
+	if len(state.TagIDs) > 0 {
+		var tagIDs []string
+		if err := tx.Model(&shortlink.LinkTag{}).
+			Where("id IN ? AND user_id = ?", state.TagIDs, owner).
+			Pluck("id", &tagIDs).Error; err != nil {
+			return state, apperrors.ErrLinkTagFailed.WithError(err)
+		}
+		state.TagIDs = tagIDs
+	}
+	return state, nil
+}
+
+// restoreRevisionState writes state over the link, its detail and its tags.
+func restoreRevisionState(tx *gorm.DB, link shortlink.ShortLink, state shortlink.LinkRevisionState) error {
+	if err := tx.Model(&shortlink.ShortLink{}).Where("id = ?", link.ID).Updates(map[string]any{
+		"original_url":           state.OriginalURL,
+		"domain":                 state.Domain,
+		"short_code":             state.ShortCode,
+		"title":                  state.Title,
+		"description":            state.Description,
+		"is_active":              state.IsActive,
+		"expires_at":             state.ExpiresAt,
+		"collection_id":          state.CollectionID,
+		"og_title":               state.OGTitle,
+		"og_description":         state.OGDescription,
+		"og_image_url":           state.OGImageURL,
+		"twitter_card":           state.TwitterCard,
+		"ios_deep_link":          state.IOSDeepLink,
+		"android_deep_link":      state.AndroidDeepLink,
+		"deep_link_fallback_url": state.DeepLinkFallbackURL,
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&shortlink.ShortLinkDetail{}).Where("short_link_id = ?", link.ID).Updates(map[string]any{
+		"passcode_hash":          state.PasscodeHash,
+		"click_limit":            state.ClickLimit,
+		"click_limit_mode":       state.ClickLimitMode,
+		"dedup_window_minutes":   state.DedupWindowMinutes,
+		"enable_stats":           state.EnableStats,
+		"variant_mode":           state.VariantMode,
+		"interstitial_mode":      state.InterstitialMode,
+		"privacy_mode":           state.PrivacyMode,
+		"honor_dnt":              state.HonorDNT,
+		"custom_domain":          state.Domain,
+		"utm_source":             state.UTMSource,
+		"utm_medium":             state.UTMMedium,
+		"utm_campaign":           state.UTMCampaign,
+		"utm_term":               state.UTMTerm,
+		"utm_content":            state.UTMContent,
+		"redirect_status":        state.RedirectStatus,
+		"redirect_cache_seconds": state.RedirectCacheSeconds,
+		"forward_query":          state.ForwardQuery,
+		"query_conflict_mode":    state.QueryConflictMode,
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec("DELETE FROM short_link_tags WHERE short_link_id = ?", link.ID).Error; err != nil {
+		return err
+	}
+	if len(state.TagIDs) == 0 {
+		return nil
+	}
+	rows := make([]map[string]any, 0, len(state.TagIDs))
+	for _, tagID := range state.TagIDs {
+		rows = append(rows, map[string]any{"short_link_id": link.ID, "link_tag_id": tagID})
+	}
+	return tx.Table("short_link_tags").Create(&rows).Error
+}
@@ -0,0 +1,115 @@
+package shortlink
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+)
+
+func TestRevisionActorType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		actor RevisionActor
+		owner string
+		want  string
+	}{
+		{"owner", RevisionActor{UserID: "u1", Role: "user"}, "u1", shortlink.RevisionActorUser},
+		{"api key", RevisionActor{UserID: "u1", Role: "user", APIKeyID: "k1"}, "u1", shortlink.RevisionActorAPIKey},
+		{"admin on another user's link", RevisionActor{UserID: "a1", Role: "admin"}, "u1", shortlink.RevisionActorAdmin},
+		{"admin on their own link", RevisionActor{UserID: "a1", Role: "admin"}, "a1", shortlink.RevisionActorUser},
+		{"admin through an api key", RevisionActor{UserID: "a1", Role: "admin", APIKeyID: "k1"}, "u1", shortlink.RevisionActorAPIKey},
+		{"system", systemActor, "u1", shortlink.RevisionActorSystem},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.actor.actorType(tt.owner); got != tt.want {
+				t.Errorf("actorType(%q) = %q, want %q", tt.owner, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffRevisionStates(t *testing.T) {
+	t.Parallel()
+
+	wib := time.FixedZone("WIB", 7*60*60)
+	expiresAt := time.Date(2026, time.May, 1, 7, 0, 0, 0, wib)
+	collectionID := "c1"
+	from := revisionState(
+		shortlink.ShortLink{OriginalURL: "https://a.example", ShortCode: "promo", IsActive: true, ExpiresAt: &expiresAt},
+		shortlink.ShortLinkDetail{EnableStats: true, PasscodeHash: "hash"},
+		[]string{"t2", "t1"},
+	)
+	if !reflect.DeepEqual(from.TagIDs, []string{"t1", "t2"}) || from.ExpiresAt.Location() != time.UTC {
+		t.Fatalf("revisionState() = tags %v expires %v, want sorted tags and a UTC time", from.TagIDs, from.ExpiresAt)
+	}
+
+	to := from
+	to.OriginalURL = "https://b.example"
+	to.CollectionID = &collectionID
+	to.PasscodeHash = ""
+	to.TagIDs = nil
+
+	changes := diffRevisionStates(from, to)
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	if want := []string{"original_url", "collection_id", "tag_ids", "passcode"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("diffRevisionStates() fields = %v, want %v", fields, want)
+	}
+	passcode := changes[3]
+	if passcode.From != true || passcode.To != false {
+		t.Errorf("passcode change = %+v, want only whether the link had one", passcode)
+	}
+}
+
+func TestDiffRevisionStatesAfterJSON(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.Local)
+	state := revisionState(
+		shortlink.ShortLink{OriginalURL: "https://a.example", ExpiresAt: &expiresAt},
+		shortlink.ShortLinkDetail{},
+		nil,
+	)
+
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	decoded, err := decodeRevisionState(shortlink.ShortLinkRevision{State: string(encoded)})
+	if err != nil {
+		t.Fatalf("decodeRevisionState() error = %v", err)
+	}
+
+	// A stored state must not look changed next to the link it was read from
+	if changes := diffRevisionStates(state, decoded); len(changes) != 0 {
+		t.Errorf("diffRevisionStates() = %+v, want no changes", changes)
+	}
+}
+
+func TestToLinkRevisionResponse(t *testing.T) {
+	t.Parallel()
+
+	restored := 2
+	revision := shortlink.ShortLinkRevision{ID: "r3", Number: 3, ChangedFields: "original_url,passcode", RestoredFrom: &restored}
+
+	response := toLinkRevisionResponse(revision, "r3")
+	if !response.Current || !reflect.DeepEqual(response.ChangedFields, []string{"original_url", "passcode"}) || *response.RestoredFrom != 2 {
+		t.Errorf("toLinkRevisionResponse() = %+v", response)
+	}
+
+	// The baseline revision records no change
+	baseline := toLinkRevisionResponse(shortlink.ShortLinkRevision{ID: "r1", Number: 1}, "r3")
+	if baseline.Current || baseline.ChangedFields == nil || len(baseline.ChangedFields) != 0 {
+		t.Errorf("toLinkRevisionResponse() = %+v, want an empty field list", baseline)
+	}
+}
@@ -0,0 +1,164 @@
+package shortlink
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/urlsafety"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+// autoBanReasonPrefix marks bans recorded by the safety re-scan rather than
+// an admin.
+const autoBanReasonPrefix = "Automatic safety scan: "
+
+// SafetyScanResult summarizes one RescanLinkSafety run.
+type SafetyScanResult struct {
+	Scanned int
+	Banned  int
+}
+
+// checkDestination rejects a destination the URL safety policy blocks. It
+// allows everything when scanning is disabled.
+func checkDestination(ctx context.Context, rawURL string) error {
+	policy := urlsafety.Global()
+	if policy == nil {
+		return nil
+	}
+
+	verdict := policy.Check(ctx, rawURL)
+	if !verdict.Blocked {
+		return nil
+	}
+	logger.Logger.Warn("Rejected unsafe destination URL",
+		"url", rawURL,
+		"category", verdict.Category,
+		"source", verdict.Source,
+	)
+	return apperrors.ErrUnsafeDestinationURL.WithError(errors.New(verdict.Reason))
+}
+
+// RescanLinkSafety re-checks the least recently scanned links that are not
+// banned yet, including the destinations of their active redirect rules and
+// variants, and bans every link with an unsafe destination.
+func (r *ShortLinkRepository) RescanLinkSafety(ctx context.Context, policy *urlsafety.Policy, batchSize int) (SafetyScanResult, error) {
+	var result SafetyScanResult
+
+	var links []shortlink.ShortLink
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN short_link_details ON short_link_details.short_link_id = short_links.id AND short_link_details.deleted_at IS NULL").
+		Where("short_link_details.is_banned = ?", false).
+		Order("short_link_details.safety_scanned_at IS NOT NULL, short_link_details.safety_scanned_at ASC").
+		Limit(batchSize).
+		Find(&links).Error; err != nil {
+		return result, apperrors.ErrSafetyScanFailed.WithError(err)
+	}
+	if len(links) == 0 {
+		return result, nil
+	}
+
+	linkIDs := make([]string, 0, len(links))
+	destinations := make(map[string][]string, len(links))
+	for _, link := range links {
+		linkIDs = append(linkIDs, link.ID)
+		destinations[link.ID] = []string{link.OriginalURL}
+	}
+
+	var rules []shortlink.RedirectRule
+	if err := r.db.WithContext(ctx).Select("short_link_id", "destination_url").
+		Where("short_link_id IN ? AND is_active = ?", linkIDs, true).
+		Find(&rules).Error; err != nil {
+		return result, apperrors.ErrSafetyScanFailed.WithError(err)
+	}
+	for _, rule := range rules {
+		destinations[rule.ShortLinkID] = append(destinations[rule.ShortLinkID], rule.DestinationURL)
+	}
+
+	var variants []shortlink.LinkVariant
+	if err := r.db.WithContext(ctx).Select("short_link_id", "destination_url").
+		Where("short_link_id IN ? AND is_active = ?", linkIDs, true).
+		Find(&variants).Error; err != nil {
+		return result, apperrors.ErrSafetyScanFailed.WithError(err)
+	}
+	for _, variant := range variants {
+		destinations[variant.ShortLinkID] = append(destinations[variant.ShortLinkID], variant.DestinationURL)
+	}
+
+	scanned := make([]string, 0, len(links))
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			break
+		}
+		for _, destination := range destinations[link.ID] {
+			verdict := policy.Check(ctx, destination)
+			if !verdict.Blocked {
+				continue
+			}
+			if err := r.banUnsafeLink(ctx, link, destination, verdict); err != nil {
+				return result, err
+			}
+			result.Banned++
+			break
+		}
+		scanned = append(scanned, link.ID)
+		result.Scanned++
+	}
+
+	if len(scanned) > 0 {
+		if err := r.db.WithContext(ctx).Model(&shortlink.ShortLinkDetail{}).
+			Where("short_link_id IN ?", scanned).
+			Update("safety_scanned_at", time.Now()).Error; err != nil {
+			return result, apperrors.ErrSafetyScanFailed.WithError(err)
+		}
+	}
+	return result, ctx.Err()
+}
+
+// banUnsafeLink bans a link the way an admin ban does, recording the verdict
+// as the reason and no admin, and the ban as a system revision of the link.
+func (r *ShortLinkRepository) banUnsafeLink(ctx context.Context, link shortlink.ShortLink, destination string, verdict urlsafety.Verdict) error {
+	reason := autoBanReasonPrefix + verdict.Reason
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return recordLinkRevisions(tx, []string{link.ID}, systemActor, func() error {
+			if err := tx.Model(&shortlink.ShortLink{}).
+				Where("id = ?", link.ID).
+				Update("is_active", false).Error; err != nil {
+				return err
+			}
+			return tx.Model(&shortlink.ShortLinkDetail{}).
+				Where("short_link_id = ?", link.ID).
+				Updates(map[string]any{
+					"is_banned":     true,
+					"banned_reason": reason,
+					"banned_by":     nil,
+					"enable_stats":  false,
+				}).Error
+		})
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to ban unsafe short link",
+			"short_code", link.ShortCode,
+			"error", err.Error(),
+		)
+		return apperrors.ErrShortBanFailed.WithError(err)
+	}
+
+	r.invalidateRedirect(link)
+
+	logger.Logger.Warn("Banned short link with unsafe destination",
+		"short_code", link.ShortCode,
+		"domain", link.Domain,
+		"destination", destination,
+		"category", verdict.Category,
+		"source", verdict.Source,
+	)
+	return nil
+}
@@ -1,44 +1,102 @@
 package shortlink
 
 import (
-	"crypto/rand"
-	"encoding/base64"
+	"context"
 	"errors"
 	"fmt"
-	"math/big"
+	"maps"
 	"net/url"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/analytics"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/clicks"
 	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/helpers"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/ip"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/linkmeta"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	passcodeguard "github.com/adehusnim37/lihatin-go/internal/pkg/passcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/privacy"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/shortcode"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/targeting"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/useragent"
 	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type ShortLinkRepository struct {
-	db *gorm.DB
+	db           *gorm.DB
+	stats        *analytics.Store
+	cache        *RedirectCache
+	clickTracker *clicks.Tracker
+
+	metadataFetcher *linkmeta.Fetcher
+	codeAllocator   *shortcode.Allocator
 }
 
 func NewShortLinkRepository(db *gorm.DB) *ShortLinkRepository {
-	return &ShortLinkRepository{db: db}
+	return &ShortLinkRepository{db: db, stats: analytics.NewStore(db)}
+}
+
+// WithRedirectCache enables the Redis-backed redirect cache
+func (r *ShortLinkRepository) WithRedirectCache(cache *RedirectCache) *ShortLinkRepository {
+	r.cache = cache
+	return r
+}
+
+// WithClickTracker routes click writes through the asynchronous ingestion queue
+func (r *ShortLinkRepository) WithClickTracker(tracker *clicks.Tracker) *ShortLinkRepository {
+	r.clickTracker = tracker
+	return r
 }
 
 func (r *ShortLinkRepository) CreateShortLink(link *dto.CreateShortLinkRequest) (*shortlink.ShortLink, *shortlink.ShortLinkDetail, error) {
-	// Check for duplicate short code first
+	if err := checkDestination(context.Background(), link.OriginalURL); err != nil {
+		return nil, nil, err
+	}
+	if err := checkDeepLinks(context.Background(), link.DeepLinks); err != nil {
+		return nil, nil, err
+	}
+
+	domain, err := resolveLinkDomain(r.db, link.Domain, link.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	passcodeHash, err := hashPasscode(link.Passcode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Tags and the collection must be the owner's
+	tags, err := ownerTags(r.db, link.UserID, link.TagIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	collectionID, err := ownerCollectionID(r.db, link.UserID, link.CollectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Check for duplicate short code first; codes are unique per domain
 	if link.CustomCode != "" {
-		if err := r.db.Where("short_code = ?", link.CustomCode).First(&shortlink.ShortLink{}).Error; err == nil {
+		if err := checkShortCode(link.CustomCode); err != nil {
+			return nil, nil, err
+		}
+		if err := r.db.Where("domain = ? AND short_code = ?", domain, link.CustomCode).First(&shortlink.ShortLink{}).Error; err == nil {
 			return nil, nil, apperrors.ErrDuplicateShortCode
 		}
 	}
 
-	if link.CustomCode == "" {
-		link.CustomCode = r.generateCustomCode(link.OriginalURL)
+	generated := link.CustomCode == ""
+	if generated {
+		if link.CustomCode, err = r.generateShortCode(context.Background(), r.db, domain, link.UserID, nil); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Handle nullable UserID - convert string to *string for database
@@ -58,57 +116,70 @@ func (r *ShortLinkRepository) CreateShortLink(link *dto.CreateShortLinkRequest)
 	shortLink := shortlink.ShortLink{
 		ID:          uuid.New().String(),
 		UserID:      userIDPtr, // ✅ Use pointer for nullable field
+		Domain:      domain,
 		ShortCode:   link.CustomCode,
 		OriginalURL: link.OriginalURL,
 		Title:       link.Title,
 		Description: link.Description,
 		ExpiresAt:   link.ExpiresAt,
-	}
 
-	var utmSource, utmMedium, utmCampaign, utmTerm, utmContent string
-	if link.Tags != nil {
-		utmSource = helpers.PtrToString(link.Tags.UTMSource)
-		utmMedium = helpers.PtrToString(link.Tags.UTMMedium)
-		utmCampaign = helpers.PtrToString(link.Tags.UTMCampaign)
-		utmTerm = helpers.PtrToString(link.Tags.UTMTerm)
-		utmContent = helpers.PtrToString(link.Tags.UTMContent)
+		CollectionID: collectionID,
+		Tags:         tags,
 	}
+	applySocialCard(&shortLink, link.SocialCard)
+	applyDeepLinks(&shortLink, link.DeepLinks)
 
 	shortLinkDetail := shortlink.ShortLinkDetail{
-		ID:          uuid.New().String(),
-		ShortLinkID: shortLink.ID,
-		Passcode:    helpers.StringToInt(link.Passcode),
-		ClickLimit:  helpers.PtrToValue(link.Limit, 0),
-		EnableStats: helpers.PtrToValue(link.EnableStats, true),
-		UTMSource:   utmSource,
-		UTMMedium:   utmMedium,
-		UTMCampaign: utmCampaign,
-		UTMTerm:     utmTerm,
-		UTMContent:  utmContent,
-	}
+		ID:           uuid.New().String(),
+		ShortLinkID:  shortLink.ID,
+		Passcode:     helpers.StringToInt(link.Passcode),
+		PasscodeHash: passcodeHash,
+		ClickLimit:   helpers.PtrToValue(link.Limit, 0),
+		EnableStats:  helpers.PtrToValue(link.EnableStats, true),
+		CustomDomain: domain,
+		PrivacyMode:  link.PrivacyMode,
+		HonorDNT:     link.HonorDNT,
+
+		ClickLimitMode:     link.ClickLimitMode,
+		DedupWindowMinutes: link.DedupWindowMinutes,
+		InterstitialMode:   link.InterstitialMode,
+	}
+	applyUTMTags(&shortLinkDetail, link.Tags)
+	applyRedirectBehavior(&shortLinkDetail, link.RedirectBehavior)
 
 	// Use transaction to ensure both shortLink and shortLinkDetail are created atomically
-	err := r.db.Transaction(func(tx *gorm.DB) error {
+	create := func(tx *gorm.DB) error {
 		if err := tx.Create(&shortLink).Error; err != nil {
 			logger.Logger.Error("Failed to create short link", "error", err.Error())
-			if errors.Is(err, gorm.ErrDuplicatedKey) {
+			if isDuplicateKey(err) {
 				return apperrors.ErrDuplicateShortCode
 			}
 			return apperrors.ErrShortCreatedFailed.WithError(err)
 		}
 
-		if err := tx.Create(&shortLinkDetail).Error; err != nil {
+		if err := createDetail(tx, &shortLinkDetail); err != nil {
 			logger.Logger.Error("Failed to create short link detail", "error", err.Error())
 			return apperrors.ErrShortDetailCreatedFailed.WithError(err)
 		}
 
 		return nil
-	})
+	}
+	err = r.db.Transaction(create)
+	if generated && errors.Is(err, apperrors.ErrDuplicateShortCode) {
+		// Another request took the generated code between the check and the insert
+		if shortLink.ShortCode, err = r.generateShortCode(context.Background(), r.db, domain, link.UserID, nil); err == nil {
+			err = r.db.Transaction(create)
+		}
+	}
 
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// Drop any negative cache entry left by earlier lookups of this code
+	r.invalidateRedirect(shortLink)
+	r.fetchMetadataAsync(shortLink)
+
 	logger.Logger.Info("Short link created successfully",
 		"id", shortLink.ID,
 		"short_code", shortLink.ShortCode,
@@ -118,6 +189,19 @@ func (r *ShortLinkRepository) CreateShortLink(link *dto.CreateShortLinkRequest)
 	return &shortLink, &shortLinkDetail, nil
 }
 
+// createDetail inserts a link's detail. GORM writes the column default in
+// place of a false EnableStats, so turning stats off takes a second update.
+func createDetail(tx *gorm.DB, detail *shortlink.ShortLinkDetail) error {
+	enableStats := detail.EnableStats
+	if err := tx.Create(detail).Error; err != nil {
+		return err
+	}
+	if enableStats {
+		return nil
+	}
+	return tx.Model(detail).Update("enable_stats", false).Error
+}
+
 // CreateBulkShortLinks creates multiple short links in a single transaction
 func (r *ShortLinkRepository) CreateBulkShortLinks(links []dto.CreateShortLinkRequest) ([]shortlink.ShortLink, []shortlink.ShortLinkDetail, error) {
 	if len(links) == 0 {
@@ -131,26 +215,64 @@ func (r *ShortLinkRepository) CreateBulkShortLinks(links []dto.CreateShortLinkRe
 	var createdLinks []shortlink.ShortLink
 	var createdDetails []shortlink.ShortLinkDetail
 
+	// Vet every destination, resolve every link's domain and hash every
+	// passcode up front; codes are unique per domain
+	linkDomains := make([]string, len(links))
+	passcodeHashes := make([]string, len(links))
+	linkTags := make([][]shortlink.LinkTag, len(links))
+	linkCollections := make([]*string, len(links))
+	for i := range links {
+		if err := checkDestination(context.Background(), links[i].OriginalURL); err != nil {
+			return nil, nil, err
+		}
+		if err := checkDeepLinks(context.Background(), links[i].DeepLinks); err != nil {
+			return nil, nil, err
+		}
+		domain, err := resolveLinkDomain(r.db, links[i].Domain, links[i].UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		linkDomains[i] = domain
+		if passcodeHashes[i], err = hashPasscode(links[i].Passcode); err != nil {
+			return nil, nil, err
+		}
+		if linkTags[i], err = ownerTags(r.db, links[i].UserID, links[i].TagIDs); err != nil {
+			return nil, nil, err
+		}
+		if linkCollections[i], err = ownerCollectionID(r.db, links[i].UserID, links[i].CollectionID); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Single transaction for all operations
 	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Codes taken by earlier links of the batch, keyed by domain and code
+		batchCodes := make(map[[2]string]struct{}, len(links))
 		for i, linkReq := range links {
-			// Generate short code if not provided
 			if linkReq.CustomCode == "" {
-				linkReq.CustomCode = r.generateCustomCode(linkReq.OriginalURL)
-			}
+				// Generated codes are checked against the batch and the database
+				code, err := r.generateShortCode(context.Background(), tx, linkDomains[i], linkReq.UserID, batchCodes)
+				if err != nil {
+					return err
+				}
+				linkReq.CustomCode = code
+			} else {
+				if err := checkShortCode(linkReq.CustomCode); err != nil {
+					return err
+				}
 
-			// Check for duplicate codes within batch
-			for j := range i {
-				if links[j].CustomCode == linkReq.CustomCode {
+				// Check for duplicate codes within batch
+				if _, taken := batchCodes[[2]string{linkDomains[i], linkReq.CustomCode}]; taken {
 					return apperrors.ErrDuplicateShortCodeInBatch
 				}
-			}
 
-			// Check existing codes in database
-			var existingLink shortlink.ShortLink
-			if err := tx.Where("short_code = ?", linkReq.CustomCode).First(&existingLink).Error; err == nil {
-				return apperrors.ErrDuplicateShortCode
+				// Check existing codes in database
+				var existingLink shortlink.ShortLink
+				if err := tx.Where("domain = ? AND short_code = ?", linkDomains[i], linkReq.CustomCode).First(&existingLink).Error; err == nil {
+					return apperrors.ErrDuplicateShortCode
+				}
 			}
+			batchCodes[[2]string{linkDomains[i], linkReq.CustomCode}] = struct{}{}
 
 			// Handle nullable UserID
 			var userIDPtr *string
@@ -162,12 +284,18 @@ func (r *ShortLinkRepository) CreateBulkShortLinks(links []dto.CreateShortLinkRe
 			shortLink := shortlink.ShortLink{
 				ID:          uuid.New().String(),
 				UserID:      userIDPtr,
+				Domain:      linkDomains[i],
 				ShortCode:   linkReq.CustomCode,
 				OriginalURL: linkReq.OriginalURL,
 				Title:       linkReq.Title,
 				Description: linkReq.Description,
 				ExpiresAt:   linkReq.ExpiresAt,
+
+				CollectionID: linkCollections[i],
+				Tags:         linkTags[i],
 			}
+			applySocialCard(&shortLink, linkReq.SocialCard)
+			applyDeepLinks(&shortLink, linkReq.DeepLinks)
 
 			if err := tx.Create(&shortLink).Error; err != nil {
 				return apperrors.ErrShortCreatedFailed
@@ -176,12 +304,24 @@ func (r *ShortLinkRepository) CreateBulkShortLinks(links []dto.CreateShortLinkRe
 
 			// Create ShortLinkDetail
 			shortLinkDetail := shortlink.ShortLinkDetail{
-				ID:          uuid.New().String(),
-				ShortLinkID: shortLink.ID,
-				Passcode:    helpers.StringToInt(linkReq.Passcode),
+				ID:           uuid.New().String(),
+				ShortLinkID:  shortLink.ID,
+				Passcode:     helpers.StringToInt(linkReq.Passcode),
+				PasscodeHash: passcodeHashes[i],
+				ClickLimit:   helpers.PtrToValue(linkReq.Limit, 0),
+				EnableStats:  helpers.PtrToValue(linkReq.EnableStats, true),
+				CustomDomain: linkDomains[i],
+				PrivacyMode:  linkReq.PrivacyMode,
+				HonorDNT:     linkReq.HonorDNT,
+
+				ClickLimitMode:     linkReq.ClickLimitMode,
+				DedupWindowMinutes: linkReq.DedupWindowMinutes,
+				InterstitialMode:   linkReq.InterstitialMode,
 			}
+			applyUTMTags(&shortLinkDetail, linkReq.Tags)
+			applyRedirectBehavior(&shortLinkDetail, linkReq.RedirectBehavior)
 
-			if err := tx.Create(&shortLinkDetail).Error; err != nil {
+			if err := createDetail(tx, &shortLinkDetail); err != nil {
 				return apperrors.ErrShortDetailCreatedFailed
 			}
 			createdDetails = append(createdDetails, shortLinkDetail)
@@ -195,6 +335,9 @@ func (r *ShortLinkRepository) CreateBulkShortLinks(links []dto.CreateShortLinkRe
 		return nil, nil, apperrors.ErrShortBulkCreateFailed.WithError(err)
 	}
 
+	r.invalidateRedirect(createdLinks...)
+	r.fetchMetadataAsync(createdLinks...)
+
 	logger.Logger.Info("Bulk short links created successfully",
 		"count", len(createdLinks),
 		"user_id", links[0].UserID,
@@ -203,33 +346,13 @@ func (r *ShortLinkRepository) CreateBulkShortLinks(links []dto.CreateShortLinkRe
 	return createdLinks, createdDetails, nil
 }
 
-func (r *ShortLinkRepository) generateCustomCode(url string) string {
-	// Encode the URL to base64 and take 4 random characters from the encoded string
-	encodedString := base64.RawURLEncoding.EncodeToString([]byte(url))
-	if encodedString == "" {
-		encodedString = "shortlink"
-	}
-
-	code := make([]byte, 4)
-	max := big.NewInt(int64(len(encodedString))) // Use the length of the encoded string for random index generation
-	for i := range code {                        // Generate 4 random characters
-		idx, err := rand.Int(rand.Reader, max)
-		if err != nil { // Fallback to deterministic character if random generation fails
-			logger.Logger.Error("Failed to generate random index for short code", "error", err)
-			code[i] = encodedString[i%len(encodedString)]
-			continue
-		}
-		code[i] = encodedString[idx.Int64()]
-	}
-	return string(code)
-}
-
-// GetShortsByUserIDWithPagination gets short links with pagination and sorting
-func (r *ShortLinkRepository) GetShortsByUserIDWithPagination(userID string, page, limit int, sort, orderBy, search string) (*dto.PaginatedShortLinksResponse, error) {
+// GetShortsByUserIDWithPagination gets short links with pagination and sorting,
+// optionally only those carrying a tag or filed in a collection
+func (r *ShortLinkRepository) GetShortsByUserIDWithPagination(userID string, page, limit int, sort, orderBy, search string, filter dto.ShortLinkFilter) (*dto.PaginatedShortLinksResponse, error) {
 	var links []shortlink.ShortLink
 	var totalCount int64
 
-	baseQuery := r.db.Model(&shortlink.ShortLink{}).Where("user_id = ?", userID)
+	baseQuery := r.db.Model(&shortlink.ShortLink{}).Where("user_id = ?", userID).Scopes(byLinkFilter(filter))
 	if strings.TrimSpace(search) != "" {
 		keyword := "%" + strings.ToLower(strings.TrimSpace(search)) + "%"
 		baseQuery = baseQuery.Where(
@@ -250,7 +373,7 @@ func (r *ShortLinkRepository) GetShortsByUserIDWithPagination(userID string, pag
 	orderClause := fmt.Sprintf("%s %s", sort, orderBy)
 
 	// Get paginated results with sorting
-	findQuery := r.db.Where("user_id = ?", userID)
+	findQuery := r.db.Where("user_id = ?", userID).Scopes(byLinkFilter(filter))
 	if strings.TrimSpace(search) != "" {
 		keyword := "%" + strings.ToLower(strings.TrimSpace(search)) + "%"
 		findQuery = findQuery.Where(
@@ -260,6 +383,7 @@ func (r *ShortLinkRepository) GetShortsByUserIDWithPagination(userID string, pag
 	}
 
 	if err := findQuery.
+		Preload("Tags", orderTagsByName).
 		Order(orderClause).
 		Offset(offset).
 		Limit(limit).
@@ -288,15 +412,20 @@ func (r *ShortLinkRepository) GetShortsByUserIDWithPagination(userID string, pag
 		shortLinkResponses = append(shortLinkResponses, dto.ShortsLinkResponse{
 			ID:          link.ID, // Now both are strings - consistent!
 			UserID:      link.UserID,
+			Domain:      link.Domain,
 			ShortCode:   link.ShortCode,
 			OriginalURL: link.OriginalURL,
 			Title:       link.Title,
 			Description: link.Description,
+			FaviconURL:  link.FaviconURL,
 			IsActive:    link.IsActive,
 			ExpiresAt:   link.ExpiresAt,
 			CreatedAt:   link.CreatedAt,
 			UpdatedAt:   link.UpdatedAt,
 			ClickCount:  int(clickCount), // Real click count from Views
+
+			CollectionID: link.CollectionID,
+			Tags:         dto.NewLinkTagResponses(link.Tags),
 		})
 	}
 
@@ -316,149 +445,111 @@ func (r *ShortLinkRepository) GetShortsByUserIDWithPagination(userID string, pag
 	return response, nil
 }
 
-func (r *ShortLinkRepository) RedirectByShortCode(code string, ipAddress, userAgent, referer, device, browser, os string, passcode int) (*shortlink.ShortLink, error) {
-	var link shortlink.ShortLink
-	// Find the short link by code with proper validation
-	err := r.db.Where("short_code = ?", code).First(&link).Error
+// RedirectByShortCode resolves code on domain (empty for the main host) for a
+// redirect and records the click. doNotTrack reports a DNT or Sec-GPC opt-out; it is applied only when the
+// link's privacy settings honour it. Bot clicks are recorded but never count
+// against the click limit or current_clicks. The returned link points at the
+// destination chosen by redirect rules and A/B variants; variantCookie is the
+// visitor's sticky variant and the returned string the variant the cookie
+// should now hold, empty when it needs no update. passcodeGrant is the cookie
+// set by the passcode form.
+func (r *ShortLinkRepository) RedirectByShortCode(ctx context.Context, domain, code string, ipAddress, userAgent, referer, device, browser, os string, passcodeGrant string, doNotTrack bool, bot useragent.BotMatch, acceptLanguage string, query url.Values, variantCookie string) (*shortlink.ShortLink, string, error) {
+	// Resolve link metadata from the redirect cache, falling back to MySQL
+	snapshot, err := r.loadRedirectSnapshot(ctx, domain, code)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			logger.Logger.Warn("Short link not found",
-				"short_code", code,
-				"ip_address", ipAddress,
-			)
-			return nil, apperrors.ErrShortLinkNotFound
-		}
-
-		logger.Logger.Error("Database error while fetching short link",
-			"short_code", code,
-			"error", err.Error(),
-		)
-		return nil, apperrors.ErrShortGetFailed.WithError(err)
+		return nil, "", err
 	}
 
-	if link.DeletedAt.Valid {
-		logger.Logger.Warn("Deleted short link accessed",
+	if snapshot.NotFound {
+		logger.Logger.Warn("Short link not found",
+			"domain", domain,
 			"short_code", code,
 			"ip_address", ipAddress,
 		)
-		return nil, apperrors.ErrShortLinkAlreadyDeleted
+		return nil, "", apperrors.ErrShortLinkNotFound
 	}
 
 	// Check if short link is active
-	if !link.IsActive {
+	if !snapshot.IsActive {
 		logger.Logger.Warn("Inactive short link accessed",
 			"short_code", code,
 			"ip_address", ipAddress,
 		)
-		return nil, apperrors.ErrShortLinkInactive
+		return nil, "", apperrors.ErrShortLinkInactive
 	}
 
 	// Check if short link is expired
-	if link.ExpiresAt != nil && link.ExpiresAt.Before(time.Now()) {
+	if snapshot.ExpiresAt != nil && snapshot.ExpiresAt.Before(time.Now()) {
 		logger.Logger.Warn("Expired short link accessed",
 			"short_code", code,
-			"expires_at", *link.ExpiresAt,
+			"expires_at", *snapshot.ExpiresAt,
 			"ip_address", ipAddress,
 		)
-		return nil, apperrors.ErrShortLinkExpired
+		return nil, "", apperrors.ErrShortLinkExpired
 	}
 
-	// Check passcode if provided
-	var detail shortlink.ShortLinkDetail
-	if err := r.db.Where("short_link_id = ?", link.ID).First(&detail).Error; err != nil {
-		logger.Logger.Error("Failed to fetch short link detail",
-			"short_code", code,
-			"error", err.Error(),
-		)
-		return nil, apperrors.ErrShortDetailNotFound
+	// Passcode checks; a grant from the passcode form stands in for the passcode
+	if err := checkPasscode(snapshot, passcodeGrant, ipAddress); err != nil {
+		return nil, "", err
 	}
 
-	// Passcode checks
-	if detail.Passcode != 0 && passcode == 0 {
-		logger.Logger.Warn("Passcode required but not provided",
-			"short_code", code,
-			"ip_address", ipAddress,
-		)
-		return nil, apperrors.ErrPasscodeRequired
-	}
-
-	if detail.Passcode != 0 && passcode != detail.Passcode {
-		logger.Logger.Warn("Invalid passcode attempt",
-			"short_code", code,
-			"ip_address", ipAddress,
-		)
-		return nil, apperrors.ErrPasscodeIncorrect
-	}
-
-	if detail.IsBanned {
+	if snapshot.IsBanned {
 		logger.Logger.Warn("Banned short link accessed",
 			"short_code", code,
 			"ip_address", ipAddress,
-			"banned_reason", detail.BannedReason,
+			"banned_reason", snapshot.BannedReason,
 		)
 
-		return nil, apperrors.ErrLinkIsBanned
+		return nil, "", apperrors.ErrLinkIsBanned
 	}
 
-	if detail.ClickLimit > 0 && detail.CurrentClicks >= detail.ClickLimit {
-		logger.Logger.Warn("Click limit reached",
-			"short_code", code,
-			"ip_address", ipAddress,
-		)
-		return nil, apperrors.ErrClickLimitReached
+	// Redirect rules may send this visitor somewhere other than OriginalURL
+	link := snapshot.toShortLink()
+	visitor := targeting.NewVisitor(device, os, acceptLanguage, referer, query, time.Now())
+	ruleID, location := selectDestination(snapshot, link, visitor, ipAddress, ip.Locate)
+	var variantID, stickVariant string
+	if ruleID == "" {
+		variantID, stickVariant = selectVariant(snapshot, link, variantCookie, ipAddress)
 	}
 
-	// Atomic increment for click count using GORM expression
-	if err := r.db.Model(&shortlink.ShortLinkDetail{}).
-		Where("id = ?", detail.ID).
-		Updates(map[string]interface{}{
-			"current_clicks": gorm.Expr("current_clicks + ?", 1),
-			"updated_at":     time.Now(),
-		}).Error; err != nil {
-		logger.Logger.Error("Failed to update short link detail",
-			"short_code", code,
-			"ip_address", ipAddress,
-			"error", err.Error(),
-		)
-		return nil, apperrors.ErrShortDetailUpdateFailed.WithError(err)
-	}
-
-	link.Detail = &detail // Attach detail to link so it's fresh if needed
-
-	// Track the click with basic info in background
-	go func() {
-		// Use GetLocation once to avoid double API calls and potential blocking
-		country, city := ip.GetLocation(ipAddress)
-
-		viewDetail := shortlink.ViewLinkDetail{
-			ID:          uuid.New().String(),
-			ShortLinkID: link.ID,
-			IPAddress:   ipAddress,
-			UserAgent:   userAgent,
-			Referer:     referer,
-			Country:     country,
-			City:        city,
-			Device:      device,
-			Browser:     browser,
-			OS:          os,
-			ClickedAt:   time.Now(),
-		}
-
-		if err := r.db.Create(&viewDetail).Error; err != nil {
-			logger.Logger.Error("Failed to track click",
-				"short_code", code,
-				"ip_address", ipAddress,
-				"error", err.Error(),
-			)
+	var countClick, countUnique, unique bool
+	if !bot.IsBot {
+		unique = r.isUniqueClick(ctx, snapshot, ipAddress, userAgent)
+		if countClick, countUnique, err = r.reserveClick(ctx, snapshot, code, ipAddress, unique); err != nil {
+			return nil, "", err
 		}
+	}
 
-		logger.Logger.Info("Click tracked successfully",
-			"short_code", code,
-			"ip_address", ipAddress,
-		)
-	}()
+	settings := privacy.Defaults().ForLink(snapshot.PrivacyMode, snapshot.HonorDNT)
+
+	// Hand the click to the ingestion queue; the view row and counter
+	// increment are written in batches off the request path.
+	r.trackClick(clicks.Event{
+		ShortLinkID:   snapshot.LinkID,
+		DetailID:      snapshot.DetailID,
+		ShortCode:     code,
+		IPAddress:     ipAddress,
+		UserAgent:     userAgent,
+		Referer:       referer,
+		Device:        device,
+		Browser:       browser,
+		OS:            os,
+		ClickedAt:     time.Now(),
+		CountClick:    countClick,
+		CountUnique:   countUnique,
+		UniqueVisitor: unique,
+		IsBot:         bot.IsBot,
+		BotName:       bot.Name,
+		RuleID:        ruleID,
+		VariantID:     variantID,
+		RevisionID:    snapshot.RevisionID,
+		Source:        clickSource(query),
+		PrivacyMode:   settings.Mode,
+		DoNotTrack:    doNotTrack && settings.HonorDNT,
+		Location:      location,
+	})
 
-	return &link, nil
+	return link, stickVariant, nil
 }
 
 func (r *ShortLinkRepository) GetShortLink(code string, userID string, userRole string) (*dto.ShortLinkResponse, error) {
@@ -469,7 +560,7 @@ func (r *ShortLinkRepository) GetShortLink(code string, userID string, userRole
 	// Fetch short link based on role
 	var err error
 	if userRole != "admin" {
-		err = r.db.Where("short_code = ? AND user_id = ?", code, userID).First(&link).Error
+		err = r.db.Scopes(byShortCode(code)).Where("user_id = ?", userID).Preload("Tags", orderTagsByName).First(&link).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, apperrors.ErrShortLinkNotFound
@@ -490,7 +581,7 @@ func (r *ShortLinkRepository) GetShortLink(code string, userID string, userRole
 			return nil, apperrors.ErrShortLinkUnauthorized
 		}
 	} else {
-		err = r.db.Where("short_code = ?", code).First(&link).Error
+		err = r.db.Scopes(byShortCode(code)).Preload("Tags", orderTagsByName).First(&link).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, apperrors.ErrShortLinkNotFound
@@ -539,9 +630,10 @@ func (r *ShortLinkRepository) GetShortLink(code string, userID string, userRole
 	// Build detail response
 	detailResponse := &dto.ShortLinkDetailsResponse{
 		ID:            detail.ID,
-		Passcode:      detail.Passcode,
+		HasPasscode:   detail.HasPasscode(),
 		ClickLimit:    detail.ClickLimit,
 		CurrentClicks: detail.CurrentClicks,
+		UniqueClicks:  detail.UniqueClicks,
 		EnableStats:   detail.EnableStats,
 		CustomDomain:  detail.CustomDomain,
 		UTMSource:     detail.UTMSource,
@@ -552,20 +644,36 @@ func (r *ShortLinkRepository) GetShortLink(code string, userID string, userRole
 		IsBanned:      detail.IsBanned,
 		BannedReason:  detail.BannedReason,
 		BannedBy:      detail.BannedBy,
+		PrivacyMode:   detail.PrivacyMode,
+		HonorDNT:      detail.HonorDNT,
+
+		ClickLimitMode:     detail.ClickLimitMode,
+		DedupWindowMinutes: detail.DedupWindowMinutes,
+		InterstitialMode:   detail.InterstitialMode,
+		IsFlagged:          detail.IsFlagged,
+		FlaggedReason:      detail.FlaggedReason,
+
+		RedirectBehavior: dto.NewRedirectBehaviorResponse(&detail),
 	}
 
 	// Build main response
 	shortLinkResponse := &dto.ShortLinkResponse{
 		ID:              link.ID,
 		UserID:          link.UserID,
+		Domain:          link.Domain,
 		ShortCode:       link.ShortCode,
 		OriginalURL:     link.OriginalURL,
 		Title:           link.Title,
 		Description:     link.Description,
+		FaviconURL:      link.FaviconURL,
 		IsActive:        link.IsActive,
 		ExpiresAt:       link.ExpiresAt,
 		CreatedAt:       link.CreatedAt,
 		UpdatedAt:       link.UpdatedAt,
+		SocialCard:      dto.NewSocialCardResponse(&link),
+		DeepLinks:       dto.NewDeepLinksResponse(&link),
+		CollectionID:    link.CollectionID,
+		Tags:            dto.NewLinkTagResponses(link.Tags),
 		ShortLinkDetail: detailResponse,
 	}
 
@@ -574,17 +682,9 @@ func (r *ShortLinkRepository) GetShortLink(code string, userID string, userRole
 
 func (r *ShortLinkRepository) GetStatsShortLink(code string, userId string, userRole string) (*dto.ShortLinkWithStatsResponse, error) {
 	var link shortlink.ShortLink
-	var totalCount int64
-	var uniqueVisitors int64
-	var countries []dto.Country
-	var devices []dto.TopDevice
-	var referrers []dto.TopReferrer
-	var last24hCount int64
-	var last7dCount int64
-	var last30dCount int64
 
 	if userRole != "admin" {
-		err := r.db.Where("short_code = ? AND user_id = ?", code, userId).First(&link).Error
+		err := r.db.Scopes(byShortCode(code)).Where("user_id = ?", userId).First(&link).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, apperrors.ErrShortLinkNotFound
@@ -596,7 +696,7 @@ func (r *ShortLinkRepository) GetStatsShortLink(code string, userId string, user
 			return nil, apperrors.ErrShortGetFailed.WithError(err)
 		}
 	} else {
-		err := r.db.Where("short_code = ?", code).First(&link).Error
+		err := r.db.Scopes(byShortCode(code)).First(&link).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, apperrors.ErrShortLinkNotFound
@@ -609,212 +709,94 @@ func (r *ShortLinkRepository) GetStatsShortLink(code string, userId string, user
 		}
 	}
 
-	// Get total views
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).Where("short_link_id = ?", link.ID).Count(&totalCount).Error; err != nil {
-		return nil, apperrors.ErrShortViewTrackFailed.WithError(err)
-	}
+	// Stats are served from the click rollups; only the current partial hour
+	// is read from view_link_details.
+	ctx := context.Background()
+	linkIDs := []string{link.ID}
+	now := time.Now()
 
-	// Get unique visitors based on distinct IP addresses
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).Where("short_link_id = ?", link.ID).Distinct("ip_address").Count(&uniqueVisitors).Error; err != nil {
+	totals, err := r.stats.Totals(ctx, linkIDs, time.Time{}, now)
+	if err != nil {
 		return nil, apperrors.ErrShortViewTrackFailed.WithError(err)
 	}
 
-	// Get top Countries by views
-	// This is a simplified example; in a real scenario, you might want to limit the number of results
-	// This is a simplified example; in a real scenario, you might want to limit the number of results
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Select("country, COUNT(*) as count").
-		Where("short_link_id = ?", link.ID).
-		Group("country").
-		Scan(&countries).Error; err != nil {
-		return nil, apperrors.ErrShortStatsFailed.WithError(err)
-	}
-
-	// Sort countries DESC
-	sort.Slice(countries, func(i, j int) bool {
-		return countries[i].Count > countries[j].Count
-	})
-
-	// Add "Other" if > 5
-	if len(countries) > 5 {
-		var otherCount int
-		for i := 5; i < len(countries); i++ {
-			otherCount += countries[i].Count
-		}
-		countries = countries[:5]
-		if otherCount > 0 {
-			countries = append(countries, dto.Country{Country: "Other", Count: otherCount})
-		}
-	}
-
-	// Parse and Aggregate Referrers (Host-based)
-	var rawReferrers []struct {
-		Referer string
-		Count   int
-	}
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Select("referer, COUNT(*) as count").
-		Where("short_link_id = ?", link.ID).
-		Group("referer").
-		Scan(&rawReferrers).Error; err != nil {
+	countries, err := r.topBreakdown(ctx, linkIDs, shortlink.RollupDimensionCountry, time.Time{}, now, 5, true)
+	if err != nil {
 		return nil, apperrors.ErrShortStatsFailed.WithError(err)
 	}
-
-	referrerMap := make(map[string]int)
-	for _, ref := range rawReferrers {
-		host := "Direct / None"
-		if ref.Referer != "" {
-			if u, err := url.Parse(ref.Referer); err == nil && u.Host != "" {
-				host = u.Host
-			} else {
-				host = ref.Referer // Fallback
-			}
-		}
-		referrerMap[host] += ref.Count
-	}
-
-	for host, count := range referrerMap {
-		referrers = append(referrers, dto.TopReferrer{Host: host, Count: count})
-	}
-	// Sort referrers DESC
-	sort.Slice(referrers, func(i, j int) bool {
-		return referrers[i].Count > referrers[j].Count
-	})
-	if len(referrers) > 5 {
-		var otherCount int
-		for i := 5; i < len(referrers); i++ {
-			otherCount += referrers[i].Count
-		}
-		referrers = referrers[:5]
-		if otherCount > 0 {
-			referrers = append(referrers, dto.TopReferrer{Host: "Other", Count: otherCount})
-		}
-	}
-
-	// Parse and Aggregate Devices (UserAgent-based)
-	var rawDevices []struct {
-		UserAgent string
-		Count     int
-	}
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Select("user_agent, COUNT(*) as count").
-		Where("short_link_id = ?", link.ID).
-		Group("user_agent").
-		Scan(&rawDevices).Error; err != nil {
+	referrers, err := r.topBreakdown(ctx, linkIDs, shortlink.RollupDimensionReferrer, time.Time{}, now, 5, true)
+	if err != nil {
 		return nil, apperrors.ErrShortStatsFailed.WithError(err)
 	}
-
-	deviceMap := make(map[string]int)
-	for _, raw := range rawDevices {
-		device := "Unknown"
-		uaLower := strings.ToLower(raw.UserAgent)
-
-		// Simple parsing logic (replicating basic middleware logic)
-		if strings.Contains(uaLower, "mobile") || strings.Contains(uaLower, "android") || strings.Contains(uaLower, "iphone") {
-			device = "Mobile"
-		} else if strings.Contains(uaLower, "tablet") || strings.Contains(uaLower, "ipad") {
-			device = "Tablet"
-		} else if strings.Contains(uaLower, "bot") || strings.Contains(uaLower, "crawler") {
-			device = "Bot"
-		} else if strings.Contains(uaLower, "postman") || strings.Contains(uaLower, "axios") || strings.Contains(uaLower, "curl") {
-			device = "API"
-		} else {
-			device = "Desktop"
-		}
-
-		deviceMap[device] += raw.Count
-	}
-
-	for dev, count := range deviceMap {
-		devices = append(devices, dto.TopDevice{Device: dev, Count: count})
-	}
-	// Sort devices DESC
-	sort.Slice(devices, func(i, j int) bool {
-		return devices[i].Count > devices[j].Count
-	})
-	if len(devices) > 5 {
-		var otherCount int
-		for i := 5; i < len(devices); i++ {
-			otherCount += devices[i].Count
-		}
-		devices = devices[:5]
-		if otherCount > 0 {
-			devices = append(devices, dto.TopDevice{Device: "Other", Count: otherCount})
-		}
-	}
-
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Where("short_link_id = ? AND clicked_at >= ?", link.ID, time.Now().Add(-24*time.Hour)).
-		Count(&last24hCount).Error; err != nil {
+	devices, err := r.topBreakdown(ctx, linkIDs, shortlink.RollupDimensionDevice, time.Time{}, now, 5, true)
+	if err != nil {
 		return nil, apperrors.ErrShortStatsFailed.WithError(err)
 	}
-
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Where("short_link_id = ? AND clicked_at >= ?", link.ID, time.Now().Add(-7*24*time.Hour)).
-		Count(&last7dCount).Error; err != nil {
+	bots, err := r.topBreakdown(ctx, linkIDs, shortlink.RollupDimensionBot, time.Time{}, now, 5, true)
+	if err != nil {
 		return nil, apperrors.ErrShortStatsFailed.WithError(err)
 	}
 
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Where("short_link_id = ? AND clicked_at >= ?", link.ID, time.Now().Add(-30*24*time.Hour)).
-		Count(&last30dCount).Error; err != nil {
+	windows, err := r.clicksInWindows(ctx, linkIDs, now)
+	if err != nil {
 		return nil, apperrors.ErrShortStatsFailed.WithError(err)
 	}
 
-	var last60dCount int64
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Where("short_link_id = ? AND clicked_at >= ?", link.ID, time.Now().Add(-60*24*time.Hour)).
-		Count(&last60dCount).Error; err != nil {
+	ruleHits, err := r.ruleHits(ctx, link.ID, now)
+	if err != nil {
 		return nil, apperrors.ErrShortStatsFailed.WithError(err)
 	}
 
-	var last90dCount int64
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Where("short_link_id = ? AND clicked_at >= ?", link.ID, time.Now().Add(-90*24*time.Hour)).
-		Count(&last90dCount).Error; err != nil {
-		return nil, apperrors.ErrShortStatsFailed.WithError(err)
+	variants, err := r.linkVariants(link.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get Click History (Daily for last 90 days)
-	var history []dto.ClickHistoryItem
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Select("DATE_FORMAT(clicked_at, '%Y-%m-%d') as date, COUNT(*) as count").
-		Where("short_link_id = ? AND clicked_at >= ?", link.ID, time.Now().Add(-90*24*time.Hour)).
-		Group("DATE_FORMAT(clicked_at, '%Y-%m-%d')").
-		Order("date ASC").
-		Scan(&history).Error; err != nil {
+	history, err := r.clickHistory(ctx, linkIDs, now.Add(-90*24*time.Hour), now, analytics.GranularityDay)
+	if err != nil {
 		return nil, apperrors.ErrShortStatsFailed.WithError(err)
 	}
 
 	// Get Click History Hourly (Last 24 Hours)
-	var historyHourly []dto.ClickHistoryItem
-	if err := r.db.Model(&shortlink.ViewLinkDetail{}).
-		Select("DATE_FORMAT(clicked_at, '%Y-%m-%d %H:00') as date, COUNT(*) as count").
-		Where("short_link_id = ? AND clicked_at >= ?", link.ID, time.Now().Add(-24*time.Hour)).
-		Group("DATE_FORMAT(clicked_at, '%Y-%m-%d %H:00')").
-		Order("date ASC").
-		Scan(&historyHourly).Error; err != nil {
+	historyHourly, err := r.clickHistory(ctx, linkIDs, now.Add(-24*time.Hour), now, analytics.GranularityHour)
+	if err != nil {
 		return nil, apperrors.ErrShortStatsFailed.WithError(err)
 	}
 
-	return &dto.ShortLinkWithStatsResponse{
+	response := &dto.ShortLinkWithStatsResponse{
 		ShortCode:          link.ShortCode,
-		TotalClicks:        int(totalCount),
-		UniqueVisitors:     int(uniqueVisitors),
-		Last24h:            int(last24hCount),
-		Last7d:             int(last7dCount),
-		Last30d:            int(last30dCount),
-		Last60d:            int(last60dCount),
-		Last90d:            int(last90dCount),
-		TopReferrers:       referrers,
-		TopDevices:         devices,
-		TopCountries:       countries,
+		TotalClicks:        int(totals.Clicks),
+		UniqueVisitors:     int(totals.UniqueVisitors),
+		Last24h:            int(windows[0]),
+		Last7d:             int(windows[1]),
+		Last30d:            int(windows[2]),
+		Last60d:            int(windows[3]),
+		Last90d:            int(windows[4]),
 		ClickHistory:       history,
 		ClickHistoryHourly: historyHourly,
-	}, nil
+		RuleHits:           ruleHits,
+	}
+	if len(variants.Variants) > 0 {
+		response.Variants = variants
+	}
+	for _, entry := range countries {
+		response.TopCountries = append(response.TopCountries, dto.Country{Country: entry.label, Count: entry.count})
+	}
+	for _, entry := range referrers {
+		response.TopReferrers = append(response.TopReferrers, dto.TopReferrer{Host: entry.label, Count: entry.count})
+	}
+	for _, entry := range devices {
+		response.TopDevices = append(response.TopDevices, dto.TopDevice{Device: entry.label, Count: entry.count})
+	}
+	response.TopBots, response.BotClicks = toTopBots(bots)
+	return response, nil
 }
 
-func (r *ShortLinkRepository) GetDashboardStats(userId string, userRole string, startDate, endDate string) (*dto.DashboardStatsResponse, error) {
+// GetDashboardStats aggregates the stats of the user's links, or every link
+// for an admin. filter narrows them to a tag or collection for campaign-level
+// totals.
+func (r *ShortLinkRepository) GetDashboardStats(userId string, userRole string, startDate, endDate string, filter dto.ShortLinkFilter) (*dto.DashboardStatsResponse, error) {
 	// Build base query condition
 	var userCondition string
 	var userArgs []interface{}
@@ -825,7 +807,7 @@ func (r *ShortLinkRepository) GetDashboardStats(userId string, userRole string,
 
 	// Get all link IDs for this user (for aggregate stats)
 	var allLinkIDs []string
-	linkQuery := r.db.Model(&shortlink.ShortLink{}).Select("id")
+	linkQuery := r.db.Model(&shortlink.ShortLink{}).Select("id").Scopes(byLinkFilter(filter))
 	if userCondition != "" {
 		linkQuery = linkQuery.Where(userCondition, userArgs...)
 	}
@@ -837,13 +819,13 @@ func (r *ShortLinkRepository) GetDashboardStats(userId string, userRole string,
 	// Total links count
 	var totalLinks int64
 	var activeLinks int64
-	countQuery := r.db.Model(&shortlink.ShortLink{})
+	countQuery := r.db.Model(&shortlink.ShortLink{}).Scopes(byLinkFilter(filter))
 	if userCondition != "" {
 		countQuery = countQuery.Where(userCondition, userArgs...)
 	}
 	countQuery.Count(&totalLinks)
 
-	activeQuery := r.db.Model(&shortlink.ShortLink{}).Where("is_active = ?", true)
+	activeQuery := r.db.Model(&shortlink.ShortLink{}).Where("is_active = ?", true).Scopes(byLinkFilter(filter))
 	if userCondition != "" {
 		activeQuery = activeQuery.Where(userCondition, userArgs...)
 	}
@@ -858,118 +840,85 @@ func (r *ShortLinkRepository) GetDashboardStats(userId string, userRole string,
 
 	// Aggregate clicks across all user's links
 	if len(allLinkIDs) > 0 {
-		// Filtered base query for stats
-		statsQuery := r.db.Model(&shortlink.ViewLinkDetail{}).Where("short_link_id IN ?", allLinkIDs)
+		ctx := context.Background()
+		now := time.Now()
+
+		// Filtered range for stats: whole local days from start_date to end_date
+		var from time.Time
+		to := now
 		if useDateFilter {
-			// Filtering by provided date range
-			statsQuery = statsQuery.Where("clicked_at >= ? AND clicked_at <= ?", startDate+" 00:00:00", endDate+" 23:59:59")
+			start, startErr := time.ParseInLocation("2006-01-02", startDate, time.Local)
+			end, endErr := time.ParseInLocation("2006-01-02", endDate, time.Local)
+			if startErr != nil || endErr != nil || end.Before(start) {
+				return nil, apperrors.ErrShortStatsInvalidRange
+			}
+			from, to = start, end.AddDate(0, 0, 1)
 		}
 
-		statsQuery.Count(&summary.TotalClicks)
-		statsQuery.Distinct("ip_address").Count(&summary.TotalUniqueVisitors)
+		totals, err := r.stats.Totals(ctx, allLinkIDs, from, to)
+		if err != nil {
+			return nil, apperrors.ErrShortStatsFailed.WithError(err)
+		}
+		summary.TotalClicks = totals.Clicks
+		summary.TotalUniqueVisitors = totals.UniqueVisitors
 
 		// Clicks by date ranges
-		r.db.Model(&shortlink.ViewLinkDetail{}).
-			Where("short_link_id IN ? AND clicked_at >= ?", allLinkIDs, time.Now().Add(-24*time.Hour)).
-			Count(&summary.ClicksLast24h)
-
-		r.db.Model(&shortlink.ViewLinkDetail{}).
-			Where("short_link_id IN ? AND clicked_at >= ?", allLinkIDs, time.Now().Add(-7*24*time.Hour)).
-			Count(&summary.ClicksLast7d)
-
-		r.db.Model(&shortlink.ViewLinkDetail{}).
-			Where("short_link_id IN ? AND clicked_at >= ?", allLinkIDs, time.Now().Add(-30*24*time.Hour)).
-			Count(&summary.ClicksLast30d)
-
-		r.db.Model(&shortlink.ViewLinkDetail{}).
-			Where("short_link_id IN ? AND clicked_at >= ?", allLinkIDs, time.Now().Add(-60*24*time.Hour)).
-			Count(&summary.ClicksLast60d)
-
-		r.db.Model(&shortlink.ViewLinkDetail{}).
-			Where("short_link_id IN ? AND clicked_at >= ?", allLinkIDs, time.Now().Add(-90*24*time.Hour)).
-			Count(&summary.ClicksLast90d)
+		windows, err := r.clicksInWindows(ctx, allLinkIDs, now)
+		if err != nil {
+			return nil, apperrors.ErrShortStatsFailed.WithError(err)
+		}
+		summary.ClicksLast24h = windows[0]
+		summary.ClicksLast7d = windows[1]
+		summary.ClicksLast30d = windows[2]
+		summary.ClicksLast60d = windows[3]
+		summary.ClicksLast90d = windows[4]
 
 		// Aggregate top countries
-		var aggCountries []dto.Country
-		countryQuery := r.db.Model(&shortlink.ViewLinkDetail{}).
-			Select("COALESCE(NULLIF(country, ''), 'Unknown') as country, COUNT(*) as count").
-			Where("short_link_id IN ?", allLinkIDs)
-		if useDateFilter {
-			countryQuery = countryQuery.Where("clicked_at >= ? AND clicked_at <= ?", startDate+" 00:00:00", endDate+" 23:59:59")
-		}
-		countryQuery.Group("country").
-			Order("count DESC").
-			Limit(5).
-			Scan(&aggCountries)
-		summary.TopCountries = aggCountries
-
-		// Aggregate top devices (handle empty)
-		var aggDevices []dto.TopDevice
-		deviceQuery := r.db.Model(&shortlink.ViewLinkDetail{}).
-			Select("COALESCE(NULLIF(device, ''), 'Unknown') as device, COUNT(*) as count").
-			Where("short_link_id IN ?", allLinkIDs)
-		if useDateFilter {
-			deviceQuery = deviceQuery.Where("clicked_at >= ? AND clicked_at <= ?", startDate+" 00:00:00", endDate+" 23:59:59")
-		}
-		deviceQuery.Group("device").
-			Order("count DESC").
-			Limit(5).
-			Scan(&aggDevices)
-		summary.TopDevices = aggDevices
-
-		// Aggregate top referrers (parse host)
-		var rawRefs []struct {
-			Referer string
-			Count   int
-		}
-		refQuery := r.db.Model(&shortlink.ViewLinkDetail{}).
-			Select("referer, COUNT(*) as count").
-			Where("short_link_id IN ?", allLinkIDs)
-		if useDateFilter {
-			refQuery = refQuery.Where("clicked_at >= ? AND clicked_at <= ?", startDate+" 00:00:00", endDate+" 23:59:59")
-		}
-		refQuery.Group("referer").
-			Scan(&rawRefs)
-
-		refMap := make(map[string]int)
-		for _, ref := range rawRefs {
-			host := "Direct / None"
-			if ref.Referer != "" {
-				if u, err := url.Parse(ref.Referer); err == nil && u.Host != "" {
-					host = u.Host
-				} else {
-					host = ref.Referer
-				}
-			}
-			refMap[host] += ref.Count
+		countries, err := r.topBreakdown(ctx, allLinkIDs, shortlink.RollupDimensionCountry, from, to, 5, false)
+		if err != nil {
+			return nil, apperrors.ErrShortStatsFailed.WithError(err)
 		}
-		aggReferrers := make([]dto.TopReferrer, 0, len(refMap))
-		for host, count := range refMap {
-			aggReferrers = append(aggReferrers, dto.TopReferrer{Host: host, Count: count})
+		for _, entry := range countries {
+			summary.TopCountries = append(summary.TopCountries, dto.Country{Country: entry.label, Count: entry.count})
 		}
-		sort.Slice(aggReferrers, func(i, j int) bool {
-			return aggReferrers[i].Count > aggReferrers[j].Count
-		})
-		if len(aggReferrers) > 5 {
-			aggReferrers = aggReferrers[:5]
+
+		// Aggregate top devices
+		devices, err := r.topBreakdown(ctx, allLinkIDs, shortlink.RollupDimensionDevice, from, to, 5, false)
+		if err != nil {
+			return nil, apperrors.ErrShortStatsFailed.WithError(err)
+		}
+		for _, entry := range devices {
+			summary.TopDevices = append(summary.TopDevices, dto.TopDevice{Device: entry.label, Count: entry.count})
 		}
-		summary.TopReferrers = aggReferrers
 
-		// Aggregate click history (Filtered or Default 90d)
-		var clickHistory []dto.ClickHistoryItem
-		historyQuery := r.db.Model(&shortlink.ViewLinkDetail{}).
-			Select("DATE_FORMAT(clicked_at, '%Y-%m-%d') as date, COUNT(*) as count").
-			Where("short_link_id IN ?", allLinkIDs)
+		// Aggregate top referrers (by host)
+		referrers, err := r.topBreakdown(ctx, allLinkIDs, shortlink.RollupDimensionReferrer, from, to, 5, false)
+		if err != nil {
+			return nil, apperrors.ErrShortStatsFailed.WithError(err)
+		}
+		summary.TopReferrers = make([]dto.TopReferrer, 0, len(referrers))
+		for _, entry := range referrers {
+			summary.TopReferrers = append(summary.TopReferrers, dto.TopReferrer{Host: entry.label, Count: entry.count})
+		}
 
-		if useDateFilter {
-			historyQuery = historyQuery.Where("clicked_at >= ? AND clicked_at <= ?", startDate+" 00:00:00", endDate+" 23:59:59")
-		} else {
-			historyQuery = historyQuery.Where("clicked_at >= ?", time.Now().Add(-90*24*time.Hour))
+		// Bot traffic, reported apart from the human stats above
+		bots, err := r.topBreakdown(ctx, allLinkIDs, shortlink.RollupDimensionBot, from, to, 5, true)
+		if err != nil {
+			return nil, apperrors.ErrShortStatsFailed.WithError(err)
 		}
+		topBots, botClicks := toTopBots(bots)
+		summary.TopBots = topBots
+		summary.BotClicks = int64(botClicks)
 
-		historyQuery.Group("DATE_FORMAT(clicked_at, '%Y-%m-%d')").
-			Order("date ASC").
-			Scan(&clickHistory)
+		// Aggregate click history (Filtered or Default 90d)
+		historyFrom := from
+		if !useDateFilter {
+			historyFrom = now.Add(-90 * 24 * time.Hour)
+		}
+		clickHistory, err := r.clickHistory(ctx, allLinkIDs, historyFrom, to, analytics.GranularityDay)
+		if err != nil {
+			return nil, apperrors.ErrShortStatsFailed.WithError(err)
+		}
 		summary.ClickHistory = clickHistory
 	}
 
@@ -978,6 +927,89 @@ func (r *ShortLinkRepository) GetDashboardStats(userId string, userRole string,
 	}, nil
 }
 
+type rankedCount struct {
+	label string
+	count int
+}
+
+// topBreakdown returns the n values of a rollup dimension with the most
+// clicks, folding the remainder into "Other" when withOther is set.
+func (r *ShortLinkRepository) topBreakdown(ctx context.Context, linkIDs []string, dimension string, from, to time.Time, n int, withOther bool) ([]rankedCount, error) {
+	values, err := r.stats.Breakdown(ctx, linkIDs, dimension, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]rankedCount, 0, len(values))
+	for label, count := range values {
+		ranked = append(ranked, rankedCount{label: label, count: int(count)})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].count != ranked[j].count {
+			return ranked[i].count > ranked[j].count
+		}
+		return ranked[i].label < ranked[j].label
+	})
+
+	if len(ranked) > n {
+		var otherCount int
+		for _, entry := range ranked[n:] {
+			otherCount += entry.count
+		}
+		ranked = ranked[:n]
+		if withOther && otherCount > 0 {
+			ranked = append(ranked, rankedCount{label: "Other", count: otherCount})
+		}
+	}
+	return ranked, nil
+}
+
+// toTopBots converts a bot breakdown that includes its "Other" entry and
+// returns the total bot clicks alongside it.
+func toTopBots(entries []rankedCount) ([]dto.TopBot, int) {
+	bots := make([]dto.TopBot, 0, len(entries))
+	total := 0
+	for _, entry := range entries {
+		bots = append(bots, dto.TopBot{Name: entry.label, Count: entry.count})
+		total += entry.count
+	}
+	return bots, total
+}
+
+// clicksInWindows counts clicks over the last 24h, 7d, 30d, 60d and 90d.
+// Window starts are aligned down to the hour of the rollups.
+func (r *ShortLinkRepository) clicksInWindows(ctx context.Context, linkIDs []string, now time.Time) ([5]int64, error) {
+	var counts [5]int64
+	for i, days := range []int{1, 7, 30, 60, 90} {
+		count, err := r.stats.Clicks(ctx, linkIDs, now.Add(-time.Duration(days)*24*time.Hour), now)
+		if err != nil {
+			return counts, err
+		}
+		counts[i] = count
+	}
+	return counts, nil
+}
+
+func (r *ShortLinkRepository) clickHistory(ctx context.Context, linkIDs []string, from, to time.Time, granularity analytics.Granularity) ([]dto.ClickHistoryItem, error) {
+	points, err := r.stats.History(ctx, linkIDs, from, to, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := "2006-01-02"
+	if granularity == analytics.GranularityHour {
+		layout = "2006-01-02 15:00"
+	}
+	history := make([]dto.ClickHistoryItem, 0, len(points))
+	for _, point := range points {
+		history = append(history, dto.ClickHistoryItem{
+			Date:  point.Start.In(time.Local).Format(layout),
+			Count: int(point.Clicks),
+		})
+	}
+	return history, nil
+}
+
 // GetShortLinkViewsPaginated gets paginated views for a specific short link
 func (r *ShortLinkRepository) GetShortLinkViewsPaginated(code string, userID string, page, limit int, sort, orderBy string, userRole string) (*dto.PaginatedShortLinkDetailWithStatsResponse, error) {
 	var link shortlink.ShortLink
@@ -987,7 +1019,7 @@ func (r *ShortLinkRepository) GetShortLinkViewsPaginated(code string, userID str
 
 	// Validate the short link exists and user has access
 	if userRole != "admin" {
-		err := r.db.Where("short_code = ? AND user_id = ?", code, userID).First(&link).Error
+		err := r.db.Scopes(byShortCode(code)).Where("user_id = ?", userID).First(&link).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, apperrors.ErrShortLinkNotFound
@@ -999,7 +1031,7 @@ func (r *ShortLinkRepository) GetShortLinkViewsPaginated(code string, userID str
 			return nil, apperrors.ErrShortGetFailed.WithError(err)
 		}
 	} else {
-		err := r.db.Where("short_code = ?", code).First(&link).Error
+		err := r.db.Scopes(byShortCode(code)).First(&link).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, apperrors.ErrShortLinkNotFound
@@ -1060,21 +1092,35 @@ func (r *ShortLinkRepository) GetShortLinkViewsPaginated(code string, userID str
 		return nil, apperrors.ErrShortGetFailed.WithError(err)
 	}
 
-	// Convert views to response format
+	// Convert views to response format. Rows are masked with the link's current
+	// privacy mode, so switching a link to hash or truncate also hides clicks
+	// recorded before the switch.
+	settings := privacy.Defaults().ForLink(detail.PrivacyMode, detail.HonorDNT)
 	viewsResponse := make([]dto.ViewLinkDetailResponse, 0, len(viewDetails))
 	for _, view := range viewDetails {
-		viewsResponse = append(viewsResponse, dto.ViewLinkDetailResponse{
-			ID:        view.ID,
-			IPAddress: view.IPAddress,
-			UserAgent: view.UserAgent,
-			Referer:   view.Referer,
-			Country:   view.Country,
-			City:      view.City,
-			Device:    view.Device,
-			Browser:   view.Browser,
-			OS:        view.OS,
-			ClickedAt: view.ClickedAt,
-		})
+		viewsResponse = append(viewsResponse, maskView(settings.Mode, dto.ViewLinkDetailResponse{
+			ID:         view.ID,
+			IPAddress:  view.IPAddress,
+			UserAgent:  view.UserAgent,
+			Referer:    view.Referer,
+			Country:    view.Country,
+			Region:     view.Region,
+			City:       view.City,
+			Latitude:   view.Latitude,
+			Longitude:  view.Longitude,
+			ASN:        view.ASN,
+			ASOrg:      view.ASOrganization,
+			Device:     view.Device,
+			Browser:    view.Browser,
+			OS:         view.OS,
+			IsBot:      view.IsBot,
+			BotName:    view.BotName,
+			RuleID:     view.RuleID,
+			VariantID:  view.VariantID,
+			RevisionID: view.RevisionID,
+			Source:     view.Source,
+			ClickedAt:  view.ClickedAt,
+		}))
 	}
 
 	// Calculate total pages
@@ -1082,13 +1128,14 @@ func (r *ShortLinkRepository) GetShortLinkViewsPaginated(code string, userID str
 
 	// Build the paginated views response
 	paginatedViews := dto.PaginatedViewLinkDetailResponse{
-		Views:      viewsResponse,
-		TotalCount: totalCount,
-		Page:       page,
-		Limit:      limit,
-		TotalPages: totalPages,
-		Sort:       sort,
-		OrderBy:    orderBy,
+		Views:       viewsResponse,
+		TotalCount:  totalCount,
+		Page:        page,
+		Limit:       limit,
+		TotalPages:  totalPages,
+		Sort:        sort,
+		OrderBy:     orderBy,
+		PrivacyMode: string(settings.Mode),
 	}
 
 	// Build the complete response
@@ -1097,11 +1144,27 @@ func (r *ShortLinkRepository) GetShortLinkViewsPaginated(code string, userID str
 	}, nil
 }
 
+// maskView hides the identifying fields of a view under a privacy mode.
+func maskView(mode privacy.Mode, view dto.ViewLinkDetailResponse) dto.ViewLinkDetailResponse {
+	switch mode {
+	case privacy.ModeHash:
+		view.IPAddress = ""
+	case privacy.ModeTruncate:
+		view.IPAddress = privacy.TruncateIP(view.IPAddress)
+	default:
+		return view
+	}
+	view.UserAgent = ""
+	view.Latitude = nil
+	view.Longitude = nil
+	return view
+}
+
 func (r *ShortLinkRepository) CheckShortCode(code *dto.CodeRequest) (*dto.ShortLinkPreviewResponse, error) {
 	var link shortlink.ShortLink
 	var detail shortlink.ShortLinkDetail
 
-	err := r.db.Where("short_code = ?", code.Code).First(&link).Error
+	err := r.db.Scopes(byShortCode(code.Code)).First(&link).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // Code does not exist
@@ -1113,15 +1176,10 @@ func (r *ShortLinkRepository) CheckShortCode(code *dto.CodeRequest) (*dto.ShortL
 		return nil, apperrors.ErrShortCheckFailed.WithError(err)
 	}
 
-	q := r.db.Where("short_link_id = ?", link.ID)
-	if code.Passcode != "" {
-		passcodeInt := helpers.StringToInt(code.Passcode)
-		q = q.Where("passcode = ?", passcodeInt)
-	}
-	err = q.First(&detail).Error
+	err = r.db.Where("short_link_id = ?", link.ID).First(&detail).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil // Code or supplied passcode does not exist
+			return nil, nil // Code does not exist
 		}
 		logger.Logger.Error("Database error while checking short code",
 			"short_code", code.Code,
@@ -1150,11 +1208,13 @@ func (r *ShortLinkRepository) CheckShortCode(code *dto.CodeRequest) (*dto.ShortL
 		DestinationScheme: destinationScheme,
 		Title:             link.Title,
 		Description:       link.Description,
-		RequiresPasscode:  detail.Passcode != 0,
+		RequiresPasscode:  detail.HasPasscode(),
 	}, nil
 }
 
-func (r *ShortLinkRepository) UpdateShortLink(code, userID, userRole string, in *dto.UpdateShortLinkRequest) error {
+// UpdateShortLink applies the fields set in in and records the change as a
+// revision of the link in the same transaction.
+func (r *ShortLinkRepository) UpdateShortLink(code string, actor RevisionActor, in *dto.UpdateShortLinkRequest) error {
 	tx := r.db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -1162,37 +1222,97 @@ func (r *ShortLinkRepository) UpdateShortLink(code, userID, userRole string, in
 		}
 	}()
 
-	// 1) Ambil link sekali
-	var link shortlink.ShortLink
-	q := tx.Where("short_code = ?", code)
-	if userRole != "admin" {
-		q = q.Where("user_id = ?", userID)
+	// 1) Ambil link sekali, dikunci sampai transaksi selesai
+	lockedRow, err := lockedLink(tx, code, actor)
+	if err != nil {
+		tx.Rollback()
+		return err
 	}
-	if err := q.First(&link).Error; err != nil {
+	link := *lockedRow
+	before, err := loadRevisionState(tx, link.ID)
+	if err != nil {
 		tx.Rollback()
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return apperrors.ErrShortLinkNotFound
-		}
 		return apperrors.ErrShortUpdateFailed.WithError(err)
 	}
 
 	// 2) Bangun map updates agar hanya kolom yang berubah yang di-update
 	linkUpd := map[string]any{}
+	if in.OriginalURL != nil && *in.OriginalURL != link.OriginalURL {
+		if err := checkDestination(context.Background(), *in.OriginalURL); err != nil {
+			tx.Rollback()
+			return err
+		}
+		linkUpd["original_url"] = *in.OriginalURL
+	}
 	if in.Title != nil {
 		linkUpd["title"] = *in.Title
 	}
 	if in.Description != nil {
 		linkUpd["description"] = *in.Description
 	}
+	if in.SocialCard != nil {
+		maps.Copy(linkUpd, socialCardColumns(in.SocialCard))
+	}
+	if in.DeepLinks != nil {
+		if err := checkDeepLinks(context.Background(), in.DeepLinks); err != nil {
+			tx.Rollback()
+			return err
+		}
+		maps.Copy(linkUpd, deepLinkColumns(in.DeepLinks))
+	}
 	if in.IsActive != nil {
 		linkUpd["is_active"] = *in.IsActive
 	}
 	if in.ExpiresAtSet {
 		linkUpd["expires_at"] = in.ExpiresAt
 	}
-	if in.ShortCode != nil {
+	if in.ShortCode != nil && *in.ShortCode != link.ShortCode {
+		if err := checkShortCode(*in.ShortCode); err != nil {
+			tx.Rollback()
+			return err
+		}
 		linkUpd["short_code"] = *in.ShortCode
 	}
+	owner := ""
+	if link.UserID != nil {
+		owner = *link.UserID
+	}
+	if in.CollectionID != nil {
+		collectionID, err := ownerCollectionID(tx, owner, *in.CollectionID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		linkUpd["collection_id"] = collectionID
+	}
+	// CustomDomain moves the link to one of the owner's verified domains, or
+	// back to the main host when empty
+	updated := link
+	if in.CustomDomain != nil {
+		domain, err := resolveLinkDomain(tx, *in.CustomDomain, owner)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		linkUpd["domain"] = domain
+		updated.Domain = domain
+	}
+	if in.ShortCode != nil {
+		updated.ShortCode = *in.ShortCode
+	}
+	if updated.Domain != link.Domain || updated.ShortCode != link.ShortCode {
+		var taken int64
+		if err := tx.Model(&shortlink.ShortLink{}).
+			Where("domain = ? AND short_code = ? AND id <> ?", updated.Domain, updated.ShortCode, link.ID).
+			Count(&taken).Error; err != nil {
+			tx.Rollback()
+			return apperrors.ErrShortUpdateFailed.WithError(err)
+		}
+		if taken > 0 {
+			tx.Rollback()
+			return apperrors.ErrDuplicateShortCode
+		}
+	}
 
 	if len(linkUpd) > 0 {
 		if err := tx.Model(&shortlink.ShortLink{}).
@@ -1203,9 +1323,32 @@ func (r *ShortLinkRepository) UpdateShortLink(code, userID, userRole string, in
 		}
 	}
 
+	if in.TagIDs != nil {
+		tags, err := ownerTags(tx, owner, *in.TagIDs)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		tagsOf := tx.Model(&link).Association("Tags")
+		if len(tags) == 0 {
+			err = tagsOf.Clear()
+		} else {
+			err = tagsOf.Replace(tags)
+		}
+		if err != nil {
+			tx.Rollback()
+			return apperrors.ErrLinkTagFailed.WithError(err)
+		}
+	}
+
 	detailUpd := map[string]any{}
 	if in.Passcode != nil {
-		detailUpd["passcode"] = helpers.StringToInt(*in.Passcode)
+		passcodeHash, err := hashPasscode(*in.Passcode)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		detailUpd["passcode_hash"] = passcodeHash
 	}
 	if in.ClickLimit != nil {
 		detailUpd["click_limit"] = *in.ClickLimit
@@ -1214,7 +1357,7 @@ func (r *ShortLinkRepository) UpdateShortLink(code, userID, userRole string, in
 		detailUpd["enable_stats"] = *in.EnableStats
 	}
 	if in.CustomDomain != nil {
-		detailUpd["custom_domain"] = *in.CustomDomain
+		detailUpd["custom_domain"] = updated.Domain
 	}
 	if in.UTMSource != nil {
 		detailUpd["utm_source"] = *in.UTMSource
@@ -1231,6 +1374,35 @@ func (r *ShortLinkRepository) UpdateShortLink(code, userID, userRole string, in
 	if in.UTMContent != nil {
 		detailUpd["utm_content"] = *in.UTMContent
 	}
+	if in.PrivacyMode != nil {
+		mode := *in.PrivacyMode
+		if mode == "inherit" {
+			mode = ""
+		}
+		detailUpd["privacy_mode"] = mode
+	}
+	if in.HonorDNT != nil {
+		detailUpd["honor_dnt"] = *in.HonorDNT
+	}
+	if in.ClickLimitMode != nil {
+		detailUpd["click_limit_mode"] = *in.ClickLimitMode
+	}
+	if in.DedupWindowMinutes != nil {
+		detailUpd["dedup_window_minutes"] = *in.DedupWindowMinutes
+	}
+	if in.VariantMode != nil {
+		detailUpd["variant_mode"] = *in.VariantMode
+	}
+	if in.InterstitialMode != nil {
+		mode := *in.InterstitialMode
+		if mode == "inherit" {
+			mode = ""
+		}
+		detailUpd["interstitial_mode"] = mode
+	}
+	if in.RedirectBehavior != nil {
+		maps.Copy(detailUpd, redirectBehaviorColumns(in.RedirectBehavior))
+	}
 
 	if len(detailUpd) > 0 {
 		if err := tx.Model(&shortlink.ShortLinkDetail{}).
@@ -1241,51 +1413,80 @@ func (r *ShortLinkRepository) UpdateShortLink(code, userID, userRole string, in
 		}
 	}
 
+	// The Redis click-limit counters only track the counter of the current
+	// mode and stop being kept while the link has no limit, so reseed them
+	// from MySQL after either changes
+	var resetCounters []string
+	if in.ClickLimitMode != nil || in.ClickLimit != nil {
+		if err := tx.Model(&shortlink.ShortLinkDetail{}).
+			Where("short_link_id = ?", link.ID).
+			Pluck("id", &resetCounters).Error; err != nil {
+			tx.Rollback()
+			return apperrors.ErrShortDetailUpdateFailed.WithError(err)
+		}
+	}
+
+	if _, err := recordRevision(tx, link, before, actor, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return apperrors.ErrShortUpdateFailed.WithError(err)
 	}
+	r.cache.ResetClickCounters(context.Background(), resetCounters...)
+
+	r.invalidateRedirect(link, updated)
 	return nil
 }
 
-func (r *ShortLinkRepository) ToggleActiveInActiveShort(code string, userID string, roleUser string) error {
+func (r *ShortLinkRepository) ToggleActiveInActiveShort(code string, actor RevisionActor) error {
 	var link shortlink.ShortLink
 
-	q := r.db.Where("short_code = ?", code)
-	if roleUser != "admin" {
-		q = q.Where("user_id = ?", userID)
-	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		lockedRow, err := lockedLink(tx, code, actor)
+		if err != nil {
+			return err
+		}
+		link = *lockedRow
+		before, err := loadRevisionState(tx, link.ID)
+		if err != nil {
+			return apperrors.ErrShortUpdateFailed.WithError(err)
+		}
 
-	if err := q.First(&link).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return apperrors.ErrShortLinkNotFound
+		if link.IsActive {
+			link.IsActive = false
+		} else {
+			link.IsActive = true
 		}
-		return apperrors.ErrShortGetFailed.WithError(err)
-	}
 
-	if link.IsActive {
-		link.IsActive = false
-	} else {
-		link.IsActive = true
-	}
+		if link.ExpiresAt != nil {
+			link.ExpiresAt = nil
+		}
 
-	if link.ExpiresAt != nil {
-		link.ExpiresAt = nil
-	}
+		if err := tx.Save(&link).Error; err != nil {
+			return apperrors.ErrShortUpdateFailed.WithError(err)
+		}
 
-	if err := r.db.Save(&link).Error; err != nil {
-		return apperrors.ErrShortUpdateFailed.WithError(err)
+		_, err = recordRevision(tx, link, before, actor, nil)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
+	r.invalidateRedirect(link)
 	return nil
 }
 
-func (r *ShortLinkRepository) DeleteShortLink(code string, userID string, passcode int, roleUser string) error {
+// DeleteShortLink deletes the link behind code, recording the deletion as a
+// revision of the link.
+func (r *ShortLinkRepository) DeleteShortLink(code string, actor RevisionActor, passcode int) error {
 	var link shortlink.ShortLink
 
-	if roleUser != "admin" {
-		err := r.db.Where("short_links.short_code = ? AND short_links.user_id = ?", code, userID).
-			Joins("LEFT JOIN short_link_details ON short_links.id = short_link_details.short_link_id").
-			Select("short_links.*, short_link_details.passcode").
+	if actor.Role != "admin" {
+		err := r.db.Scopes(byShortCode(code)).Where("short_links.user_id = ?", actor.UserID).
+			Preload("Detail").
 			First(&link).Error
 
 		if err != nil {
@@ -1301,11 +1502,11 @@ func (r *ShortLinkRepository) DeleteShortLink(code string, userID string, passco
 		}
 
 		// Validate passcode if set
-		if link.Detail.Passcode != 0 && link.Detail.Passcode != passcode {
+		if link.Detail != nil && link.Detail.HasPasscode() && !passcodeguard.Matches(link.Detail.PasscodeHash, passcode) {
 			return apperrors.ErrPasscodeIncorrect
 		}
 	} else {
-		err := r.db.Where("short_code = ?", code).
+		err := r.db.Scopes(byShortCode(code)).
 			First(&link).Error
 
 		if err != nil {
@@ -1325,7 +1526,12 @@ func (r *ShortLinkRepository) DeleteShortLink(code string, userID string, passco
 		return apperrors.ErrShortLinkAlreadyDeleted
 	}
 
-	if err := r.db.Where("short_code = ?", link.ShortCode).Delete(&link).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return recordLinkRevisions(tx, []string{link.ID}, actor, func() error {
+			return tx.Where("id = ?", link.ID).Delete(&shortlink.ShortLink{}).Error
+		})
+	})
+	if err != nil {
 		logger.Logger.Error("Failed to delete short link",
 			"short_code", link.ShortCode,
 			"error", err.Error(),
@@ -1333,10 +1539,13 @@ func (r *ShortLinkRepository) DeleteShortLink(code string, userID string, passco
 		return apperrors.ErrShortDeleteFailed.WithError(err)
 	}
 
+	r.invalidateRedirect(link)
 	return nil
 }
 
-func (r *ShortLinkRepository) DeleteShortsLink(req *dto.BulkDeleteRequest) error {
+// DeleteShortsLink deletes the links behind req.Codes, recording each deletion
+// as a revision of the link.
+func (r *ShortLinkRepository) DeleteShortsLink(req *dto.BulkDeleteRequest, actor RevisionActor) error {
 	var links []shortlink.ShortLink
 
 	if len(req.Codes) == 0 {
@@ -1344,7 +1553,7 @@ func (r *ShortLinkRepository) DeleteShortsLink(req *dto.BulkDeleteRequest) error
 	}
 
 	// perform a check to see if all codes exist
-	if err := r.db.Where("short_code IN ?", req.Codes).Find(&links).Error; err != nil {
+	if err := r.db.Where("short_code IN ?", req.Codes).Order("domain = '' DESC").Find(&links).Error; err != nil {
 		logger.Logger.Error("Failed to fetch short links for bulk delete",
 			"short_codes", req.Codes,
 			"error", err.Error(),
@@ -1352,11 +1561,30 @@ func (r *ShortLinkRepository) DeleteShortsLink(req *dto.BulkDeleteRequest) error
 		return apperrors.ErrShortGetFailed.WithError(err)
 	}
 
-	if len(req.Codes) != len(links) {
+	// Like the single-link endpoints, a code shared with a custom domain
+	// targets the main-host link
+	seen := make(map[string]bool, len(links))
+	targets := make([]shortlink.ShortLink, 0, len(links))
+	ids := make([]string, 0, len(links))
+	for _, link := range links {
+		if seen[link.ShortCode] {
+			continue
+		}
+		seen[link.ShortCode] = true
+		targets = append(targets, link)
+		ids = append(ids, link.ID)
+	}
+
+	if len(req.Codes) != len(targets) {
 		return apperrors.ErrSomeShortLinksNotFound
 	}
 	// Perform bulk delete
-	if err := r.db.Where("short_code IN ?", req.Codes).Delete(&shortlink.ShortLink{}).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return recordLinkRevisions(tx, ids, actor, func() error {
+			return tx.Where("id IN ?", ids).Delete(&shortlink.ShortLink{}).Error
+		})
+	})
+	if err != nil {
 		logger.Logger.Error("Failed to delete short links",
 			"short_codes", req.Codes,
 			"error", err.Error(),
@@ -1364,10 +1592,14 @@ func (r *ShortLinkRepository) DeleteShortsLink(req *dto.BulkDeleteRequest) error
 		return apperrors.ErrShortDeleteFailed.WithError(err)
 	}
 
+	r.invalidateRedirect(targets...)
 	return nil
 }
 
-func (r *ShortLinkRepository) ListAllShortLinks(userID string, page, limit int, sort, orderBy, search string) (*dto.PaginatedShortLinksAdminResponse, error) {
+// ListAllShortLinks lists every user's short links with their details, or only
+// userID's when set, optionally only those carrying a tag or filed in a
+// collection
+func (r *ShortLinkRepository) ListAllShortLinks(userID string, page, limit int, sort, orderBy, search string, filter dto.ShortLinkFilter) (*dto.PaginatedShortLinksAdminResponse, error) {
 	var shortLinks []shortlink.ShortLink
 	var totalCount int64
 
@@ -1378,7 +1610,7 @@ func (r *ShortLinkRepository) ListAllShortLinks(userID string, page, limit int,
 		"order_by", orderBy,
 	)
 
-	queryCount := r.db.Model(&shortlink.ShortLink{})
+	queryCount := r.db.Model(&shortlink.ShortLink{}).Scopes(byLinkFilter(filter))
 	if userID != "" {
 		queryCount = queryCount.Where("user_id = ?", userID)
 	}
@@ -1404,6 +1636,8 @@ func (r *ShortLinkRepository) ListAllShortLinks(userID string, page, limit int,
 	queryFind := r.db.
 		Preload("Detail"). // Load detail relationship
 		Preload("Views").  // Load views relationship for click counts
+		Preload("Tags", orderTagsByName).
+		Scopes(byLinkFilter(filter)).
 		Order(orderClause).
 		Limit(limit).
 		Offset(offset)
@@ -1438,9 +1672,10 @@ func (r *ShortLinkRepository) ListAllShortLinks(userID string, page, limit int,
 		if link.Detail != nil {
 			detailResponse = &dto.ShortLinkDetailsResponse{
 				ID:            link.Detail.ID,
-				Passcode:      link.Detail.Passcode,
+				HasPasscode:   link.Detail.HasPasscode(),
 				ClickLimit:    link.Detail.ClickLimit,
 				CurrentClicks: link.Detail.CurrentClicks,
+				UniqueClicks:  link.Detail.UniqueClicks,
 				EnableStats:   link.Detail.EnableStats,
 				CustomDomain:  link.Detail.CustomDomain,
 				UTMSource:     link.Detail.UTMSource,
@@ -1448,6 +1683,16 @@ func (r *ShortLinkRepository) ListAllShortLinks(userID string, page, limit int,
 				UTMCampaign:   link.Detail.UTMCampaign,
 				UTMTerm:       link.Detail.UTMTerm,
 				UTMContent:    link.Detail.UTMContent,
+				PrivacyMode:   link.Detail.PrivacyMode,
+				HonorDNT:      link.Detail.HonorDNT,
+
+				ClickLimitMode:     link.Detail.ClickLimitMode,
+				DedupWindowMinutes: link.Detail.DedupWindowMinutes,
+				InterstitialMode:   link.Detail.InterstitialMode,
+				IsFlagged:          link.Detail.IsFlagged,
+				FlaggedReason:      link.Detail.FlaggedReason,
+
+				RedirectBehavior: dto.NewRedirectBehaviorResponse(link.Detail),
 			}
 		}
 
@@ -1455,14 +1700,20 @@ func (r *ShortLinkRepository) ListAllShortLinks(userID string, page, limit int,
 		shortLinkResponse := dto.ShortLinkResponse{
 			ID:              link.ID,
 			UserID:          link.UserID,
+			Domain:          link.Domain,
 			ShortCode:       link.ShortCode,
 			OriginalURL:     link.OriginalURL,
 			Title:           link.Title,
 			Description:     link.Description,
+			FaviconURL:      link.FaviconURL,
 			IsActive:        link.IsActive,
 			ExpiresAt:       link.ExpiresAt,
 			CreatedAt:       link.CreatedAt,
 			UpdatedAt:       link.UpdatedAt,
+			SocialCard:      dto.NewSocialCardResponse(&link),
+			DeepLinks:       dto.NewDeepLinksResponse(&link),
+			CollectionID:    link.CollectionID,
+			Tags:            dto.NewLinkTagResponses(link.Tags),
 			ShortLinkDetail: detailResponse,
 		}
 
@@ -1489,122 +1740,76 @@ func (r *ShortLinkRepository) ListAllShortLinks(userID string, page, limit int,
 	}, nil
 }
 
-func (r *ShortLinkRepository) BannedShortByAdmin(request *dto.BannedRequest, userID string, code *dto.CodeRequest) error {
-	var link shortlink.ShortLink
-	var detail shortlink.ShortLinkDetail
-
-	err := r.db.Where("short_code = ?", code.Code).First(&link).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return apperrors.ErrShortLinkNotFound
-		}
-
-		logger.Logger.Error("Database error while fetching short link",
-			"short_code", code.Code,
-			"error", err.Error(),
-		)
-		return apperrors.ErrShortGetFailed.WithError(err)
-	}
-
-	link.IsActive = false
-
-	err = r.db.Where("short_link_id = ?", link.ID).First(&detail).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return apperrors.ErrShortLinkNotFound
-		}
-
-		logger.Logger.Error("Database error while fetching short link detail",
-			"short_link_id", link.ID,
-			"error", err.Error(),
-		)
-		return apperrors.ErrShortDetailFindFailed.WithError(err)
-	}
-
-	detail.EnableStats = false
-	detail.IsBanned = true
-	detail.BannedBy = &userID
-	detail.BannedReason = request.Reason
-
-	if err := r.db.Save(&link).Error; err != nil {
-		logger.Logger.Error("Failed to update short link",
-			"short_code", code,
-			"error", err.Error(),
-		)
-		return apperrors.ErrShortBanFailed.WithError(err)
-	}
-
-	if err := r.db.Save(&detail).Error; err != nil {
-		logger.Logger.Error("Failed to update short link detail",
-			"short_link_id", link.ID,
-			"error", err.Error(),
-		)
-		return apperrors.ErrShortBanFailed.WithError(err)
-	}
+// BannedShortByAdmin deactivates and bans a link, recording the change as a
+// revision of the link.
+func (r *ShortLinkRepository) BannedShortByAdmin(request *dto.BannedRequest, actor RevisionActor, code *dto.CodeRequest) error {
+	return r.setBanned(code.Code, actor, false, map[string]any{
+		"enable_stats":  false,
+		"is_banned":     true,
+		"banned_by":     actor.UserID,
+		"banned_reason": request.Reason,
+	}, apperrors.ErrShortBanFailed)
+}
 
-	return nil
+// RestoreShortByAdmin lifts a ban set by BannedShortByAdmin and reactivates
+// the link, recording the change as a revision of the link.
+func (r *ShortLinkRepository) RestoreShortByAdmin(code string, actor RevisionActor) error {
+	return r.setBanned(code, actor, true, map[string]any{
+		"is_banned":     false,
+		"banned_by":     nil,
+		"banned_reason": "",
+	}, apperrors.ErrShortRestoreFailed)
 }
 
-func (r *ShortLinkRepository) RestoreShortByAdmin(code string) error {
+func (r *ShortLinkRepository) setBanned(code string, actor RevisionActor, isActive bool, detailUpdates map[string]any, failed *apperrors.AppError) error {
 	var link shortlink.ShortLink
-	var detail shortlink.ShortLinkDetail
 
-	err := r.db.Where("short_code = ?", code).First(&link).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return apperrors.ErrShortLinkNotFound
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		lockedRow, err := lockedLink(tx, code, actor)
+		if err != nil {
+			return err
+		}
+		link = *lockedRow
+		before, err := loadRevisionState(tx, link.ID)
+		if err != nil {
+			return failed.WithError(err)
 		}
 
-		logger.Logger.Error("Database error while fetching short link",
-			"short_code", code,
-			"error", err.Error(),
-		)
-		return apperrors.ErrShortGetFailed.WithError(err)
-	}
-
-	link.IsActive = true
-
-	err = r.db.Where("short_link_id = ?", link.ID).First(&detail).Error
-
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return apperrors.ErrShortLinkNotFound
+		if err := tx.Model(&shortlink.ShortLink{}).
+			Where("id = ?", link.ID).
+			Update("is_active", isActive).Error; err != nil {
+			return failed.WithError(err)
+		}
+		if err := tx.Model(&shortlink.ShortLinkDetail{}).
+			Where("short_link_id = ?", link.ID).
+			Updates(detailUpdates).Error; err != nil {
+			return failed.WithError(err)
 		}
 
-		logger.Logger.Error("Database error while fetching short link detail",
-			"short_link_id", link.ID,
-			"error", err.Error(),
-		)
-		return apperrors.ErrShortDetailFindFailed.WithError(err)
+		_, err = recordRevision(tx, link, before, actor, nil)
+		return err
+	})
+	if errors.Is(err, apperrors.ErrShortLinkNotFound) {
+		return err
 	}
-
-	detail.IsBanned = false
-	detail.BannedBy = nil
-	detail.BannedReason = ""
-
-	if err := r.db.Save(&link).Error; err != nil {
-		logger.Logger.Error("Failed to update short link",
+	if err != nil {
+		logger.Logger.Error("Failed to update short link ban",
 			"short_code", code,
 			"error", err.Error(),
 		)
-		return apperrors.ErrShortRestoreFailed.WithError(err)
-	}
-
-	if err := r.db.Save(&detail).Error; err != nil {
-		logger.Logger.Error("Failed to update short link detail",
-			"short_link_id", link.ID,
-			"error", err.Error(),
-		)
-		return apperrors.ErrShortRestoreFailed.WithError(err)
+		return err
 	}
 
+	r.invalidateRedirect(link)
 	return nil
 }
 
-func (r *ShortLinkRepository) RestoreDeletedShortByAdmin(code string) error {
+// RestoreDeletedShortByAdmin revives a deleted link, recording it as a
+// revision of the link.
+func (r *ShortLinkRepository) RestoreDeletedShortByAdmin(code string, actor RevisionActor) error {
 	var link shortlink.ShortLink
 
-	err := r.db.Unscoped().Where("short_code = ?", code).First(&link).Error
+	err := r.db.Unscoped().Scopes(byShortCode(code)).First(&link).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return apperrors.ErrShortLinkNotFound
@@ -1623,7 +1828,14 @@ func (r *ShortLinkRepository) RestoreDeletedShortByAdmin(code string) error {
 
 	link.DeletedAt = gorm.DeletedAt{Valid: false}
 
-	if err := r.db.Unscoped().Save(&link).Error; err != nil {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		return recordLinkRevisions(tx, []string{link.ID}, actor, func() error {
+			return tx.Unscoped().Model(&shortlink.ShortLink{}).
+				Where("id = ?", link.ID).
+				Update("deleted_at", nil).Error
+		})
+	})
+	if err != nil {
 		logger.Logger.Error("Failed to restore deleted short link",
 			"short_code", code,
 			"error", err.Error(),
@@ -1631,6 +1843,7 @@ func (r *ShortLinkRepository) RestoreDeletedShortByAdmin(code string) error {
 		return apperrors.ErrShortRestoreFailed.WithError(err)
 	}
 
+	r.invalidateRedirect(link)
 	return nil
 }
 
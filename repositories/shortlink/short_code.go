@@ -0,0 +1,137 @@
+package shortlink
+
+import (
+	"context"
+	"errors"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/shortcode"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultCodeAllocator serves repositories built without WithCodeAllocator
+var defaultCodeAllocator = shortcode.NewAllocator(shortcode.RandomGenerator{}, shortcode.DefaultLength, shortcode.DefaultMaxAttempts)
+
+// WithCodeAllocator generates codes for links created without a custom one;
+// nil keeps random base62 codes
+func (r *ShortLinkRepository) WithCodeAllocator(allocator *shortcode.Allocator) *ShortLinkRepository {
+	r.codeAllocator = allocator
+	return r
+}
+
+func (r *ShortLinkRepository) allocator() *shortcode.Allocator {
+	if r.codeAllocator != nil {
+		return r.codeAllocator
+	}
+	return defaultCodeAllocator
+}
+
+// generateShortCode returns a code free on domain, with the prefix userID
+// picked for generated codes. batchCodes holds codes already taken by
+// earlier links of the same batch, keyed by domain and code; nil for a
+// single link.
+func (r *ShortLinkRepository) generateShortCode(ctx context.Context, tx *gorm.DB, domain, userID string, batchCodes map[[2]string]struct{}) (string, error) {
+	prefix, err := codePrefix(tx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := r.allocator().Allocate(ctx, prefix, func(ctx context.Context, code string) (bool, error) {
+		if _, taken := batchCodes[[2]string{domain, code}]; taken {
+			return true, nil
+		}
+		// A code the policy refuses counts as taken, so another is tried
+		if checkShortCode(code) != nil {
+			return true, nil
+		}
+		var count int64
+		err := tx.WithContext(ctx).Unscoped().Model(&shortlink.ShortLink{}).
+			Where("domain = ? AND short_code = ?", domain, code).
+			Count(&count).Error
+		return count > 0, err
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to generate short code",
+			"domain", domain,
+			"strategy", r.allocator().Strategy(),
+			"error", err.Error(),
+		)
+		return "", apperrors.ErrShortCodeGenerationFailed.WithError(err)
+	}
+	return code, nil
+}
+
+// isDuplicateKey reports whether err is a unique index violation, as
+// translated by GORM or straight from MySQL.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.Is(err, gorm.ErrDuplicatedKey) ||
+		(errors.As(err, &mysqlErr) && mysqlErr.Number == 1062)
+}
+
+// codePrefix returns the prefix userID set for generated codes, "" for
+// anonymous links and users without one.
+func codePrefix(db *gorm.DB, userID string) (string, error) {
+	if userID == "" {
+		return "", nil
+	}
+	var settings shortlink.ShortCodeSettings
+	err := db.Where("user_id = ?", userID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", apperrors.ErrShortCodeGenerationFailed.WithError(err)
+	}
+	return settings.Prefix, nil
+}
+
+// GetShortCodeSettings returns how codes are generated for userID's links.
+func (r *ShortLinkRepository) GetShortCodeSettings(userID string) (*dto.ShortCodeSettingsResponse, error) {
+	prefix, err := codePrefix(r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return r.shortCodeSettingsResponse(prefix), nil
+}
+
+// UpdateShortCodeSettings sets the prefix of userID's generated codes. Links
+// created before keep their codes.
+func (r *ShortLinkRepository) UpdateShortCodeSettings(userID string, req *dto.UpdateShortCodeSettingsRequest) (*dto.ShortCodeSettingsResponse, error) {
+	if req.Prefix != "" {
+		if err := checkShortCode(req.Prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	settings := shortlink.ShortCodeSettings{UserID: userID, Prefix: req.Prefix}
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"prefix", "updated_at"}),
+	}).Create(&settings).Error; err != nil {
+		logger.Logger.Error("Failed to update short code settings",
+			"user_id", userID,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrShortCodeSettingsFailed.WithError(err)
+	}
+
+	logger.Logger.Info("Short code settings updated",
+		"user_id", userID,
+		"prefix", req.Prefix,
+	)
+	return r.shortCodeSettingsResponse(req.Prefix), nil
+}
+
+func (r *ShortLinkRepository) shortCodeSettingsResponse(prefix string) *dto.ShortCodeSettingsResponse {
+	return &dto.ShortCodeSettingsResponse{
+		Prefix:   prefix,
+		Strategy: r.allocator().Strategy(),
+		Length:   r.allocator().Length(),
+	}
+}
@@ -0,0 +1,58 @@
+package shortlink
+
+import (
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+// applySocialCard copies card onto a new link; nil leaves it without one.
+func applySocialCard(link *shortlink.ShortLink, card *dto.SocialCard) {
+	if card == nil {
+		return
+	}
+	link.OGTitle = card.OGTitle
+	link.OGDescription = card.OGDescription
+	link.OGImageURL = card.OGImageURL
+	link.TwitterCard = card.TwitterCard
+}
+
+// socialCardColumns returns the short_links columns that store card.
+func socialCardColumns(card *dto.SocialCard) map[string]any {
+	return map[string]any{
+		"og_title":       card.OGTitle,
+		"og_description": card.OGDescription,
+		"og_image_url":   card.OGImageURL,
+		"twitter_card":   card.TwitterCard,
+	}
+}
+
+// OwnedShortLink returns the link behind code when userID may edit it.
+func (r *ShortLinkRepository) OwnedShortLink(code, userID, userRole string) (*shortlink.ShortLink, error) {
+	return r.ownedLink(code, userID, userRole)
+}
+
+// SetSocialCardImage points link's og:image at an uploaded image and records
+// the change as a revision of the link.
+func (r *ShortLinkRepository) SetSocialCardImage(link *shortlink.ShortLink, imageURL string, actor RevisionActor) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return recordLinkRevisions(tx, []string{link.ID}, actor, func() error {
+			return tx.Model(&shortlink.ShortLink{}).
+				Where("id = ?", link.ID).
+				Update("og_image_url", imageURL).Error
+		})
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to update social card image",
+			"short_code", link.ShortCode,
+			"error", err.Error(),
+		)
+		return apperrors.ErrShortUpdateFailed.WithError(err)
+	}
+
+	link.OGImageURL = imageURL
+	r.invalidateRedirect(*link)
+	return nil
+}
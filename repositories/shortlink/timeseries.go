@@ -0,0 +1,184 @@
+package shortlink
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/analytics"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTimeseriesPoints   = 1500
+	timeseriesTopValues   = 5
+	timeseriesOtherValue  = "Other"
+	timeseriesDateLayout  = "2006-01-02"
+	defaultTimeseriesGran = analytics.GranularityDay
+)
+
+// defaultTimeseriesSpan is the range used when from is omitted
+var defaultTimeseriesSpan = map[analytics.Granularity]func(to time.Time) time.Time{
+	analytics.GranularityMinute: func(to time.Time) time.Time { return to.Add(-time.Hour) },
+	analytics.GranularityHour:   func(to time.Time) time.Time { return to.Add(-24 * time.Hour) },
+	analytics.GranularityDay:    func(to time.Time) time.Time { return to.AddDate(0, 0, -30) },
+	analytics.GranularityWeek:   func(to time.Time) time.Time { return to.AddDate(0, 0, -7*12) },
+	analytics.GranularityMonth:  func(to time.Time) time.Time { return to.AddDate(-1, 0, 0) },
+}
+
+// GetShortLinkTimeseries returns zero-filled click series for one short link,
+// optionally split by breakdown dimensions (top values plus "Other").
+func (r *ShortLinkRepository) GetShortLinkTimeseries(ctx context.Context, code, userID, userRole string, req *dto.TimeseriesRequest) (*dto.ShortLinkTimeseriesResponse, error) {
+	var link shortlink.ShortLink
+	query := r.db.WithContext(ctx).Scopes(byShortCode(code))
+	if userRole != "admin" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrShortLinkNotFound
+		}
+		logger.Logger.Error("Database error while fetching short link",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrShortGetFailed.WithError(err)
+	}
+
+	granularity := defaultTimeseriesGran
+	if req.Granularity != "" {
+		var ok bool
+		if granularity, ok = analytics.ParseGranularity(req.Granularity); !ok {
+			return nil, apperrors.ErrTimeseriesInvalidRange
+		}
+	}
+
+	loc := time.Local
+	if tz := strings.TrimSpace(req.TZ); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, apperrors.ErrTimeseriesInvalidTimezone
+		}
+	}
+
+	to := time.Now()
+	if req.To != "" {
+		parsed, dateOnly, err := parseTimeseriesBound(req.To, loc)
+		if err != nil {
+			return nil, apperrors.ErrTimeseriesInvalidRange
+		}
+		to = parsed
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	from := defaultTimeseriesSpan[granularity](to)
+	if req.From != "" {
+		parsed, _, err := parseTimeseriesBound(req.From, loc)
+		if err != nil {
+			return nil, apperrors.ErrTimeseriesInvalidRange
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return nil, apperrors.ErrTimeseriesInvalidRange
+	}
+
+	buckets, ok := analytics.Buckets(from, to, granularity, loc, maxTimeseriesPoints)
+	if !ok {
+		return nil, apperrors.ErrTimeseriesTooManyPoints
+	}
+
+	linkIDs := []string{link.ID}
+
+	series, err := r.stats.Series(ctx, linkIDs, from, to, granularity, loc, "")
+	if err != nil {
+		return nil, apperrors.ErrShortStatsFailed.WithError(err)
+	}
+	points, total := fillTimeseries(buckets, series[""])
+
+	response := &dto.ShortLinkTimeseriesResponse{
+		ShortCode:   link.ShortCode,
+		From:        from.In(loc),
+		To:          to.In(loc),
+		Granularity: string(granularity),
+		Timezone:    loc.String(),
+		Total:       total,
+		Points:      points,
+	}
+
+	for _, dimension := range req.Breakdown {
+		byValue, err := r.stats.Series(ctx, linkIDs, from, to, granularity, loc, dimension)
+		if err != nil {
+			return nil, apperrors.ErrShortStatsFailed.WithError(err)
+		}
+		response.Breakdowns = append(response.Breakdowns, dto.TimeseriesBreakdown{
+			Dimension: dimension,
+			Series:    breakdownTimeseries(buckets, byValue),
+		})
+	}
+
+	return response, nil
+}
+
+// parseTimeseriesBound accepts RFC3339 or a YYYY-MM-DD date in loc.
+func parseTimeseriesBound(raw string, loc *time.Location) (time.Time, bool, error) {
+	raw = strings.TrimSpace(raw)
+	if t, err := time.ParseInLocation(timeseriesDateLayout, raw, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t, false, err
+}
+
+// fillTimeseries lays counts over every bucket, using zero for empty ones.
+func fillTimeseries(buckets []time.Time, counts map[time.Time]int64) ([]dto.TimeseriesPoint, int64) {
+	points := make([]dto.TimeseriesPoint, len(buckets))
+	var total int64
+	for i, start := range buckets {
+		clicks := counts[start]
+		points[i] = dto.TimeseriesPoint{Timestamp: start, Clicks: clicks}
+		total += clicks
+	}
+	return points, total
+}
+
+// breakdownTimeseries keeps the values with the most clicks and folds the rest
+// into a single "Other" series.
+func breakdownTimeseries(buckets []time.Time, byValue map[string]map[time.Time]int64) []dto.TimeseriesSeries {
+	all := make([]dto.TimeseriesSeries, 0, len(byValue))
+	for value, counts := range byValue {
+		points, total := fillTimeseries(buckets, counts)
+		if total == 0 {
+			continue
+		}
+		all = append(all, dto.TimeseriesSeries{Value: value, Total: total, Points: points})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Total != all[j].Total {
+			return all[i].Total > all[j].Total
+		}
+		return all[i].Value < all[j].Value
+	})
+	if len(all) <= timeseriesTopValues {
+		return all
+	}
+
+	other := dto.TimeseriesSeries{Value: timeseriesOtherValue, Points: make([]dto.TimeseriesPoint, len(buckets))}
+	for i, start := range buckets {
+		other.Points[i].Timestamp = start
+	}
+	for _, series := range all[timeseriesTopValues:] {
+		other.Total += series.Total
+		for i, point := range series.Points {
+			other.Points[i].Clicks += point.Clicks
+		}
+	}
+	return append(all[:timeseriesTopValues], other)
+}
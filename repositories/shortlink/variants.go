@@ -0,0 +1,261 @@
+package shortlink
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/adehusnim37/lihatin-go/dto"
+	apperrors "github.com/adehusnim37/lihatin-go/internal/pkg/errors"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/logger"
+	shortlink "github.com/adehusnim37/lihatin-go/models/shortlink"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxLinkVariants caps the A/B variants per link.
+const maxLinkVariants = 10
+
+// linkVariants returns the variants of a link and its variant mode, oldest
+// variant first.
+func (r *ShortLinkRepository) linkVariants(linkID string) (*dto.LinkVariantsResponse, error) {
+	var variants []shortlink.LinkVariant
+	if err := r.db.Where("short_link_id = ?", linkID).Order("created_at ASC").Find(&variants).Error; err != nil {
+		return nil, apperrors.ErrLinkVariantFindFailed.WithError(err)
+	}
+
+	var detail shortlink.ShortLinkDetail
+	if err := r.db.Select("variant_mode").Where("short_link_id = ?", linkID).First(&detail).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrShortDetailNotFound
+		}
+		return nil, apperrors.ErrLinkVariantFindFailed.WithError(err)
+	}
+
+	mode := detail.VariantMode
+	if mode == "" {
+		mode = shortlink.VariantModeCookie
+	}
+	return &dto.LinkVariantsResponse{
+		VariantMode: mode,
+		Variants:    toLinkVariantResponses(variants),
+	}, nil
+}
+
+func (r *ShortLinkRepository) findLinkVariant(linkID, variantID string) (*shortlink.LinkVariant, error) {
+	var variant shortlink.LinkVariant
+	if err := r.db.Where("id = ? AND short_link_id = ?", variantID, linkID).First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrLinkVariantNotFound
+		}
+		return nil, apperrors.ErrLinkVariantFindFailed.WithError(err)
+	}
+	return &variant, nil
+}
+
+// ListLinkVariants returns a link's A/B variants with their click counts.
+func (r *ShortLinkRepository) ListLinkVariants(code, userID, userRole string) (*dto.LinkVariantsResponse, error) {
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	return r.linkVariants(link.ID)
+}
+
+// CreateLinkVariant adds a destination to the link's A/B split.
+func (r *ShortLinkRepository) CreateLinkVariant(code, userID, userRole string, req *dto.LinkVariantRequest) (*dto.LinkVariantsResponse, error) {
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDestination(context.Background(), req.DestinationURL); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := r.db.Model(&shortlink.LinkVariant{}).Where("short_link_id = ?", link.ID).Count(&count).Error; err != nil {
+		return nil, apperrors.ErrLinkVariantFindFailed.WithError(err)
+	}
+	if count >= maxLinkVariants {
+		return nil, apperrors.ErrLinkVariantLimitReached
+	}
+
+	variant := shortlink.LinkVariant{
+		ID:             uuid.New().String(),
+		ShortLinkID:    link.ID,
+		Name:           strings.TrimSpace(req.Name),
+		DestinationURL: req.DestinationURL,
+		Weight:         req.Weight,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}
+	// Select every column so an inactive variant is not replaced by the
+	// is_active column default
+	if err := r.db.Select("*").Create(&variant).Error; err != nil {
+		logger.Logger.Error("Failed to create link variant",
+			"short_code", code,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrLinkVariantCreateFailed.WithError(err)
+	}
+
+	r.invalidateRedirect(*link)
+
+	logger.Logger.Info("Link variant created",
+		"short_code", code,
+		"variant_id", variant.ID,
+		"user_id", userID,
+	)
+	return r.linkVariants(link.ID)
+}
+
+// UpdateLinkVariant replaces a variant's name, destination, weight and state.
+// Its click counts are kept.
+func (r *ShortLinkRepository) UpdateLinkVariant(code, variantID, userID, userRole string, req *dto.LinkVariantRequest) (*dto.LinkVariantsResponse, error) {
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDestination(context.Background(), req.DestinationURL); err != nil {
+		return nil, err
+	}
+
+	variant, err := r.findLinkVariant(link.ID, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant.Name = strings.TrimSpace(req.Name)
+	variant.DestinationURL = req.DestinationURL
+	variant.Weight = req.Weight
+	if req.IsActive != nil {
+		variant.IsActive = *req.IsActive
+	}
+	if err := r.db.Model(variant).Select("name", "destination_url", "weight", "is_active", "updated_at").Updates(variant).Error; err != nil {
+		logger.Logger.Error("Failed to update link variant",
+			"short_code", code,
+			"variant_id", variantID,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrLinkVariantUpdateFailed.WithError(err)
+	}
+
+	r.invalidateRedirect(*link)
+	return r.linkVariants(link.ID)
+}
+
+// DeleteLinkVariant removes a variant. Visitors whose cookie still names it are
+// assigned a new one on their next click.
+func (r *ShortLinkRepository) DeleteLinkVariant(code, variantID, userID, userRole string) error {
+	link, err := r.ownedLink(code, userID, userRole)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.Where("id = ? AND short_link_id = ?", variantID, link.ID).Delete(&shortlink.LinkVariant{})
+	if result.Error != nil {
+		logger.Logger.Error("Failed to delete link variant",
+			"short_code", code,
+			"variant_id", variantID,
+			"error", result.Error.Error(),
+		)
+		return apperrors.ErrLinkVariantDeleteFailed.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrLinkVariantNotFound
+	}
+
+	r.invalidateRedirect(*link)
+
+	logger.Logger.Info("Link variant deleted",
+		"short_code", code,
+		"variant_id", variantID,
+		"user_id", userID,
+	)
+	return nil
+}
+
+// PromoteLinkVariant ends the A/B split: the winning variant's destination
+// becomes the link's OriginalURL and every variant is deactivated, keeping
+// their stats. The new destination is recorded as a revision of the link.
+func (r *ShortLinkRepository) PromoteLinkVariant(code, variantID string, actor RevisionActor) (*dto.LinkVariantsResponse, error) {
+	link, err := r.ownedLink(code, actor.UserID, actor.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := r.findLinkVariant(link.ID, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var locked shortlink.ShortLink
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", link.ID).First(&locked).Error; err != nil {
+			return err
+		}
+		before, err := loadRevisionState(tx, locked.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&shortlink.ShortLink{}).
+			Where("id = ?", locked.ID).
+			Update("original_url", variant.DestinationURL).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&shortlink.LinkVariant{}).
+			Where("short_link_id = ?", locked.ID).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+		_, err = recordRevision(tx, locked, before, actor, nil)
+		return err
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to promote link variant",
+			"short_code", code,
+			"variant_id", variantID,
+			"error", err.Error(),
+		)
+		return nil, apperrors.ErrLinkVariantUpdateFailed.WithError(err)
+	}
+
+	r.invalidateRedirect(*link)
+
+	logger.Logger.Info("Link variant promoted",
+		"short_code", code,
+		"variant_id", variantID,
+		"user_id", actor.UserID,
+	)
+	return r.linkVariants(link.ID)
+}
+
+func toLinkVariantResponses(variants []shortlink.LinkVariant) []dto.LinkVariantResponse {
+	activeWeight := 0
+	for _, variant := range variants {
+		if variant.IsActive {
+			activeWeight += variant.Weight
+		}
+	}
+
+	responses := make([]dto.LinkVariantResponse, 0, len(variants))
+	for _, variant := range variants {
+		var share float64
+		if variant.IsActive && activeWeight > 0 {
+			share = float64(variant.Weight) * 100 / float64(activeWeight)
+		}
+		responses = append(responses, dto.LinkVariantResponse{
+			ID:             variant.ID,
+			Name:           variant.Name,
+			DestinationURL: variant.DestinationURL,
+			Weight:         variant.Weight,
+			Share:          share,
+			IsActive:       variant.IsActive,
+			Clicks:         variant.Clicks,
+			UniqueVisitors: variant.UniqueClicks,
+			CreatedAt:      variant.CreatedAt,
+			UpdatedAt:      variant.UpdatedAt,
+		})
+	}
+	return responses
+}
@@ -14,6 +14,9 @@ import (
 const (
 	// SettingDisposableEmailCheckEnabled controls disposable email enforcement.
 	SettingDisposableEmailCheckEnabled = "disposable_email_check_enabled"
+	// SettingRedirectInterstitialEnabled shows the interstitial page before
+	// every redirect whose link does not choose otherwise.
+	SettingRedirectInterstitialEnabled = "redirect_interstitial_enabled"
 )
 
 type SystemSettingRepository interface {
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/adehusnim37/lihatin-go/controllers"
+	domaincontroller "github.com/adehusnim37/lihatin-go/controllers/domain"
+	"github.com/adehusnim37/lihatin-go/middleware"
+	"github.com/adehusnim37/lihatin-go/repositories/authrepo"
+	"github.com/adehusnim37/lihatin-go/repositories/userrepo"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDomainRoutes(rg *gin.RouterGroup, userRepo userrepo.UserRepository, userAuthRepo *authrepo.UserAuthRepository, baseController *controllers.BaseController) {
+	domainController := domaincontroller.NewController(baseController)
+
+	protectedDomain := rg.Group("users/me/domains")
+	protectedDomain.Use(middleware.AuthMiddleware(userRepo, userAuthRepo), middleware.RequireEmailVerification())
+	{
+		protectedDomain.POST("", domainController.Create)
+		protectedDomain.GET("", domainController.List)
+		protectedDomain.GET("/:id", domainController.Get)
+		protectedDomain.PUT("/:id", domainController.Update)
+		protectedDomain.PUT("/:id/app-links", domainController.UpdateAppLinks)
+		protectedDomain.DELETE("/:id", domainController.Delete)
+		// Verification does DNS and HTTP lookups, so it is rate limited
+		protectedDomain.POST("/:id/verify", middleware.RateLimitMiddleware(20, 0, 10), domainController.Verify)
+	}
+}
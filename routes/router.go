@@ -12,6 +12,7 @@ import (
 	pkgauth "github.com/adehusnim37/lihatin-go/internal/pkg/auth"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/config"
 	"github.com/adehusnim37/lihatin-go/internal/pkg/csrf"
+	"github.com/adehusnim37/lihatin-go/internal/pkg/domains"
 	"github.com/adehusnim37/lihatin-go/middleware"
 	"github.com/adehusnim37/lihatin-go/models/common"
 	"github.com/adehusnim37/lihatin-go/repositories/authrepo"
@@ -58,13 +59,31 @@ func csrfTokenSkipRules() []csrf.SkipRule {
 		{Method: http.MethodPost, Path: "/v1/api/short", SkipOriginCheck: true},
 		{Method: http.MethodPut, Path: "/v1/api/short/:code", SkipOriginCheck: true},
 		{Method: http.MethodDelete, Path: "/v1/api/short/:code", SkipOriginCheck: true},
+		{Method: http.MethodPost, Path: "/v1/api/short/:code/rules", SkipOriginCheck: true},
+		{Method: http.MethodPut, Path: "/v1/api/short/:code/rules/order", SkipOriginCheck: true},
+		{Method: http.MethodPut, Path: "/v1/api/short/:code/rules/:ruleID", SkipOriginCheck: true},
+		{Method: http.MethodDelete, Path: "/v1/api/short/:code/rules/:ruleID", SkipOriginCheck: true},
+		{Method: http.MethodPost, Path: "/v1/api/short/:code/variants", SkipOriginCheck: true},
+		{Method: http.MethodPut, Path: "/v1/api/short/:code/variants/:variantID", SkipOriginCheck: true},
+		{Method: http.MethodDelete, Path: "/v1/api/short/:code/variants/:variantID", SkipOriginCheck: true},
+		{Method: http.MethodPost, Path: "/v1/api/short/:code/variants/:variantID/promote", SkipOriginCheck: true},
+		{Method: http.MethodPost, Path: "/v1/api/short/:code/revisions/:revisionID/rollback", SkipOriginCheck: true},
+		{Method: http.MethodPost, Path: "/v1/api/short/tags", SkipOriginCheck: true},
+		{Method: http.MethodPut, Path: "/v1/api/short/tags/:tagID", SkipOriginCheck: true},
+		{Method: http.MethodDelete, Path: "/v1/api/short/tags/:tagID", SkipOriginCheck: true},
+		{Method: http.MethodPost, Path: "/v1/api/short/collections", SkipOriginCheck: true},
+		{Method: http.MethodPut, Path: "/v1/api/short/collections/:collectionID", SkipOriginCheck: true},
+		{Method: http.MethodDelete, Path: "/v1/api/short/collections/:collectionID", SkipOriginCheck: true},
+		{Method: http.MethodPost, Path: "/v1/api/short/imports", SkipOriginCheck: true},
+		{Method: http.MethodPost, Path: "/v1/api/short/:code/qr/logo", SkipOriginCheck: true},
+		{Method: http.MethodDelete, Path: "/v1/api/short/:code/qr/logo", SkipOriginCheck: true},
 	}
 }
 
 func SetupRouter(validate *validator.Validate) *gin.Engine {
 	// Use gin.New and attach middleware once to avoid duplicate default middleware warnings.
 	r := gin.New()
-	r.Use(gin.Logger(), gin.Recovery())
+	r.Use(middleware.StripPasscodeQuery(), gin.Logger(), gin.Recovery())
 	r.Use(middleware.SecurityHeaders())
 	r.Use(middleware.BlockSensitivePaths())
 
@@ -104,6 +123,9 @@ func SetupRouter(validate *validator.Validate) *gin.Engine {
 	// Apply global middleware for activity logging
 	r.Use(middleware.ActivityLogger(loggerRepo))
 
+	// Verified custom domains answer short codes at the root of their host
+	r.Use(middleware.CustomDomainRouting(domains.Global(), shortController.Redirect))
+
 	// Definisikan route untuk user, auth, dan logger
 	v1 := r.Group("/v1")
 
@@ -115,6 +137,7 @@ func SetupRouter(validate *validator.Validate) *gin.Engine {
 	RegisterLoggerRoutes(v1, userRepo, userAuthRepo, loggerController)
 	RegisterShortRoutes(v1, shortController, userRepo, userAuthRepo, authRepo)
 	RegisterNotificationRoutes(v1, baseController, userRepo, userAuthRepo)
+	RegisterDomainRoutes(v1, userRepo, userAuthRepo, baseController)
 
 	// Route health check
 	v1.GET("/health", func(c *gin.Context) {
@@ -35,6 +35,15 @@ func TestAPIKeyMutationRoutesSkipOnlyTokenValidation(t *testing.T) {
 		{method: http.MethodPost, path: "/v1/api/short", full: "/v1/api/short"},
 		{method: http.MethodPut, path: "/v1/api/short/code", full: "/v1/api/short/:code"},
 		{method: http.MethodDelete, path: "/v1/api/short/code", full: "/v1/api/short/:code"},
+		{method: http.MethodPost, path: "/v1/api/short/code/rules", full: "/v1/api/short/:code/rules"},
+		{method: http.MethodPut, path: "/v1/api/short/code/rules/id", full: "/v1/api/short/:code/rules/:ruleID"},
+		{method: http.MethodPost, path: "/v1/api/short/code/variants/id/promote", full: "/v1/api/short/:code/variants/:variantID/promote"},
+		{method: http.MethodPost, path: "/v1/api/short/code/revisions/id/rollback", full: "/v1/api/short/:code/revisions/:revisionID/rollback"},
+		{method: http.MethodPost, path: "/v1/api/short/tags", full: "/v1/api/short/tags"},
+		{method: http.MethodDelete, path: "/v1/api/short/tags/id", full: "/v1/api/short/tags/:tagID"},
+		{method: http.MethodPut, path: "/v1/api/short/collections/id", full: "/v1/api/short/collections/:collectionID"},
+		{method: http.MethodPost, path: "/v1/api/short/imports", full: "/v1/api/short/imports"},
+		{method: http.MethodPost, path: "/v1/api/short/code/qr/logo", full: "/v1/api/short/:code/qr/logo"},
 	}
 
 	for _, tt := range tests {
@@ -65,3 +74,11 @@ func TestOneClickUnsubscribeAllowsProviderPOST(t *testing.T) {
 	}
 	t.Fatal("missing one-click unsubscribe CSRF skip rule")
 }
+
+func TestPasscodeFormKeepsCSRFTokenCheck(t *testing.T) {
+	for _, rule := range csrfTokenSkipRules() {
+		if rule.Method == http.MethodPost && rule.Path == "/v1/short/:code" {
+			t.Fatal("the passcode form posts a CSRF token and must not skip its check")
+		}
+	}
+}
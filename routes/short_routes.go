@@ -11,12 +11,13 @@ import (
 func RegisterShortRoutes(rg *gin.RouterGroup, shortController *shortlink.Controller, userRepo userrepo.UserRepository, userAuthRepo *authrepo.UserAuthRepository, authRepo *authrepo.AuthRepository) {
 	shortGroup := rg.Group("/short")
 	{
-		shortGroup.Use(middleware.RateLimitMiddleware(25, 00, 30)) // Limit to 25 requests per minute for public access
+		shortGroup.Use(middleware.RateLimitMiddleware(middleware.PublicShortRateLimit, 0, middleware.PublicShortRateMinutes)) // Limit to 25 requests per 30 minutes for public access
 		shortGroup.Use(middleware.OptionalAuth(userRepo))
 		shortGroup.POST("", shortController.Create)
 		shortGroup.GET("/:code", shortController.Redirect)
+		shortGroup.HEAD("/:code", shortController.Redirect)
+		shortGroup.POST("/:code", shortController.Redirect) // Passcode form submission
 		shortGroup.GET("check/:code", shortController.CheckShortLink)
-		shortGroup.GET("check/:code/:passcode", shortController.CheckShortLink)
 	}
 
 	// ✅ API ROUTES: Accessible by API key authentication (service-to-service)
@@ -30,9 +31,40 @@ func RegisterShortRoutes(rg *gin.RouterGroup, shortController *shortlink.Control
 		apiShort.PUT("/:code", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.UpdateShortLink)
 		apiShort.GET("", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.ListShortLinks)
 		apiShort.GET("/:code/stats", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.GetShortLinkStats)
+		apiShort.GET("/:code/timeseries", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.GetShortLinkTimeseries)
 		apiShort.GET("/:code/views", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.GetShortLinkViewsPaginated)
 		apiShort.DELETE("/:code", middleware.CheckPermissionAPIKey(authRepo, []string{"delete"}, false), shortController.DeleteShortLink)
+		apiShort.GET("/:code/rules", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.ListRedirectRules)
+		apiShort.POST("/:code/rules", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.CreateRedirectRule)
+		apiShort.PUT("/:code/rules/order", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.ReorderRedirectRules)
+		apiShort.PUT("/:code/rules/:ruleID", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.UpdateRedirectRule)
+		apiShort.DELETE("/:code/rules/:ruleID", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.DeleteRedirectRule)
+		apiShort.GET("/:code/variants", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.ListLinkVariants)
+		apiShort.POST("/:code/variants", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.CreateLinkVariant)
+		apiShort.PUT("/:code/variants/:variantID", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.UpdateLinkVariant)
+		apiShort.DELETE("/:code/variants/:variantID", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.DeleteLinkVariant)
+		apiShort.POST("/:code/variants/:variantID/promote", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.PromoteLinkVariant)
+		apiShort.GET("/:code/revisions", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.ListLinkRevisions)
+		apiShort.GET("/:code/revisions/:revisionID", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.GetLinkRevision)
+		apiShort.POST("/:code/revisions/:revisionID/rollback", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.RollbackShortLink)
+		apiShort.GET("/:code/qr", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.GetShortLinkQR)
+		apiShort.POST("/:code/qr/logo", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.UploadQRLogo)
+		apiShort.DELETE("/:code/qr/logo", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.DeleteQRLogo)
 		apiShort.GET("/stats", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.GetAllStatsShorts)
+		apiShort.GET("/tags", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.ListLinkTags)
+		apiShort.POST("/tags", middleware.CheckPermissionAPIKey(authRepo, []string{"create"}, false), shortController.CreateLinkTag)
+		apiShort.PUT("/tags/:tagID", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.UpdateLinkTag)
+		apiShort.DELETE("/tags/:tagID", middleware.CheckPermissionAPIKey(authRepo, []string{"delete"}, false), shortController.DeleteLinkTag)
+		apiShort.GET("/collections", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.ListCollections)
+		apiShort.POST("/collections", middleware.CheckPermissionAPIKey(authRepo, []string{"create"}, false), shortController.CreateCollection)
+		apiShort.PUT("/collections/:collectionID", middleware.CheckPermissionAPIKey(authRepo, []string{"update"}, false), shortController.UpdateCollection)
+		apiShort.DELETE("/collections/:collectionID", middleware.CheckPermissionAPIKey(authRepo, []string{"delete"}, false), shortController.DeleteCollection)
+		apiShort.POST("/imports", middleware.CheckPermissionAPIKey(authRepo, []string{"create"}, false), shortController.ImportLinks)
+		apiShort.GET("/imports", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.ListLinkImports)
+		apiShort.GET("/imports/:importID", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.GetLinkImport)
+		apiShort.GET("/imports/:importID/rows", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.ListLinkImportRows)
+		apiShort.GET("/imports/:importID/errors", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.GetLinkImportErrors)
+		apiShort.GET("/export", middleware.CheckPermissionAPIKey(authRepo, []string{"read"}, false), shortController.ExportLinks)
 	}
 
 	// ✅ PROTECTED ROUTES: Accessible by authenticated users (user or admin)
@@ -44,13 +76,47 @@ func RegisterShortRoutes(rg *gin.RouterGroup, shortController *shortlink.Control
 		protectedShort.POST("", shortController.Create)
 		protectedShort.GET("", shortController.ListShortLinks) // ✅ UNIVERSAL: Auto-detects role and filters accordingly
 		protectedShort.GET("/stats", shortController.GetAllStatsShorts)
+		protectedShort.GET("/code-settings", shortController.GetShortCodeSettings)
+		protectedShort.PUT("/code-settings", shortController.UpdateShortCodeSettings)
+		protectedShort.GET("/tags", shortController.ListLinkTags)
+		protectedShort.POST("/tags", shortController.CreateLinkTag)
+		protectedShort.PUT("/tags/:tagID", shortController.UpdateLinkTag)
+		protectedShort.DELETE("/tags/:tagID", shortController.DeleteLinkTag)
+		protectedShort.GET("/collections", shortController.ListCollections)
+		protectedShort.POST("/collections", shortController.CreateCollection)
+		protectedShort.PUT("/collections/:collectionID", shortController.UpdateCollection)
+		protectedShort.DELETE("/collections/:collectionID", shortController.DeleteCollection)
+		protectedShort.POST("/imports", shortController.ImportLinks)
+		protectedShort.GET("/imports", shortController.ListLinkImports)
+		protectedShort.GET("/imports/:importID", shortController.GetLinkImport)
+		protectedShort.GET("/imports/:importID/rows", shortController.ListLinkImportRows)
+		protectedShort.GET("/imports/:importID/errors", shortController.GetLinkImportErrors)
+		protectedShort.GET("/export", shortController.ExportLinks)
 		protectedShort.GET("/:code/stats", shortController.GetShortLinkStats)
+		protectedShort.GET("/:code/timeseries", shortController.GetShortLinkTimeseries)
 		protectedShort.GET("/:code", shortController.GetShortLink)
 		protectedShort.PUT("/:code", shortController.UpdateShortLink)
 		protectedShort.DELETE("/:code", shortController.DeleteShortLink)
 		protectedShort.GET("/:code/views", shortController.GetShortLinkViewsPaginated) // New route for paginated views
 		protectedShort.POST("/:code/toggle-active-inactive", shortController.SwitchActiveInActiveShort)
 		protectedShort.DELETE("/:code/passcode", shortController.RemovePasscode)
+		protectedShort.POST("/:code/og-image", shortController.UploadSocialCardImage)
+		protectedShort.GET("/:code/qr", shortController.GetShortLinkQR)
+		protectedShort.POST("/:code/qr/logo", shortController.UploadQRLogo)
+		protectedShort.DELETE("/:code/qr/logo", shortController.DeleteQRLogo)
+		protectedShort.GET("/:code/rules", shortController.ListRedirectRules)
+		protectedShort.POST("/:code/rules", shortController.CreateRedirectRule)
+		protectedShort.PUT("/:code/rules/order", shortController.ReorderRedirectRules)
+		protectedShort.PUT("/:code/rules/:ruleID", shortController.UpdateRedirectRule)
+		protectedShort.DELETE("/:code/rules/:ruleID", shortController.DeleteRedirectRule)
+		protectedShort.GET("/:code/variants", shortController.ListLinkVariants)
+		protectedShort.POST("/:code/variants", shortController.CreateLinkVariant)
+		protectedShort.PUT("/:code/variants/:variantID", shortController.UpdateLinkVariant)
+		protectedShort.DELETE("/:code/variants/:variantID", shortController.DeleteLinkVariant)
+		protectedShort.POST("/:code/variants/:variantID/promote", shortController.PromoteLinkVariant)
+		protectedShort.GET("/:code/revisions", shortController.ListLinkRevisions)
+		protectedShort.GET("/:code/revisions/:revisionID", shortController.GetLinkRevision)
+		protectedShort.POST("/:code/revisions/:revisionID/rollback", shortController.RollbackShortLink)
 
 	}
 
@@ -63,12 +129,24 @@ func RegisterShortRoutes(rg *gin.RouterGroup, shortController *shortlink.Control
 		protectedAdminShort.GET("", shortController.ListShortLinks) // Will return all short links for admin
 		protectedAdminShort.GET("users/:userID", shortController.ListShortLinks) // Get list of short links for specific user
 		protectedAdminShort.GET("/:code/views", shortController.GetShortLinkViewsPaginated)
+		protectedAdminShort.GET("/:code/timeseries", shortController.GetShortLinkTimeseries)
 		protectedAdminShort.DELETE("/:code", shortController.DeleteShortLink)
 		protectedAdminShort.DELETE("/bulk-delete", shortController.AdminBulkDeleteShortLinks)
 		protectedAdminShort.PUT("/:code/banned", shortController.AdminBannedShortLink)
 		protectedAdminShort.PUT("/:code/unban", shortController.AdminUnbanShortLink)
+		protectedAdminShort.PUT("/:code/flag", shortController.AdminFlagShortLink)
+		protectedAdminShort.PUT("/:code/unflag", shortController.AdminUnflagShortLink)
+		protectedAdminShort.GET("/interstitial", shortController.GetInterstitialPolicy)
+		protectedAdminShort.PUT("/interstitial", shortController.UpdateInterstitialPolicy)
+		protectedAdminShort.GET("/reserved-codes", shortController.ListReservedCodes)
+		protectedAdminShort.POST("/reserved-codes", shortController.CreateReservedCode)
+		protectedAdminShort.DELETE("/reserved-codes/:id", shortController.DeleteReservedCode)
 		protectedAdminShort.PUT("/:code", shortController.UpdateShortLink)            // Admin update any short link
+		protectedAdminShort.GET("/:code/revisions", shortController.ListLinkRevisions)
+		protectedAdminShort.GET("/:code/revisions/:revisionID", shortController.GetLinkRevision)
+		protectedAdminShort.POST("/:code/revisions/:revisionID/rollback", shortController.RollbackShortLink)
 		protectedAdminShort.POST("/:code/edotensei", shortController.ReviveShortLink) // Revive deleted short link
 		protectedAdminShort.GET("/short/stats", shortController.GetAllStatsShorts)
+		protectedAdminShort.GET("/click-tracker/metrics", shortController.GetClickTrackerMetrics)
 	}
 }